@@ -1,9 +1,6 @@
 package cmd
 
 import (
-	"archive/tar"
-	"bytes"
-	"compress/gzip"
 	"context"
 	"errors"
 	"fmt"
@@ -11,58 +8,109 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
-	"strings"
 
+	"github.com/boring-registry/boring-registry/pkg/core"
 	"github.com/boring-registry/boring-registry/pkg/module"
 
 	"github.com/hashicorp/go-version"
 )
 
-const (
-	moduleSpecFileName = "boring-registry.hcl"
-)
+// moduleUploader is implemented by the storage backends and by module.Client for publishing through the HTTP API of the registry
+type moduleUploader interface {
+	GetModule(ctx context.Context, namespace, name, provider, version string) (core.Module, error)
+	UploadModule(ctx context.Context, namespace, name, provider, version string, body io.Reader) (core.Module, error)
+}
 
-func archiveModules(root string, storage module.Storage) error {
+// archiveModules uploads the module of every spec file below root, or only of root if -recursive is disabled.
+// The spec files are collected first, so that the modules can be uploaded by a pool of workers.
+func archiveModules(ctx context.Context, root string, storage moduleUploader) error {
+	var paths []string
 	if flagRecursive {
 		err := filepath.Walk(root, func(path string, fi os.FileInfo, _ error) error {
 			// FYI we conciously ignore all walk-related errors
 
-			if fi.Name() != moduleSpecFileName {
+			if fi == nil || fi.Name() != module.SpecFileName {
 				return nil
 			}
-			if processErr := processModule(path, storage); processErr != nil {
-				return fmt.Errorf("failed to process module at %s:\n%w", path, processErr)
-			}
-
+			paths = append(paths, path)
 			return nil
 		})
-		return err
+		if err != nil {
+			return err
+		}
+	} else {
+		paths = append(paths, filepath.Join(root, module.SpecFileName))
 	}
 
-	path := filepath.Join(root, moduleSpecFileName)
-	if processErr := processModule(path, storage); processErr != nil {
-		return fmt.Errorf("failed to process module at %s:\n%w", path, processErr)
+	jobs := make([]uploadJob, 0, len(paths))
+	for _, path := range paths {
+		jobs = append(jobs, uploadJob{
+			path: path,
+			upload: func(ctx context.Context) moduleResult {
+				return processModule(ctx, path, "", storage)
+			},
+		})
 	}
-	return nil
+	return runUploads(ctx, jobs, flagConcurrency)
 }
 
-func processModule(path string, storage module.Storage) error {
-	spec, err := module.ParseFile(path)
+// processModule archives and uploads the module of the spec file at path.
+// The version of the spec file is replaced with the version if it isn't empty, e.g. with the version of a git tag.
+func processModule(ctx context.Context, path, version string, storage moduleUploader) moduleResult {
+	res := moduleResult{Path: path}
+
+	spec, err := module.ParseFileWithVersion(path, version)
 	if err != nil {
-		return err
+		return res.failed(err)
 	}
+	res.Module = spec.Name()
 
 	slog.Debug("parsed module spec", slog.String("path", path), slog.String("name", spec.Name()))
 
+	if reason, err := skipModule(ctx, spec, storage); err != nil {
+		return res.failed(err)
+	} else if reason != "" {
+		return res.skipped(reason)
+	}
+
+	archive, err := archiveModule(filepath.Dir(path))
+	if err != nil {
+		return res.failed(err)
+	}
+	defer os.Remove(archive.Name())
+	defer archive.Close()
+
+	if err := validateArchive(ctx, archive, spec); err != nil {
+		return res.failed(err)
+	}
+
+	if _, err := archive.Seek(0, io.SeekStart); err != nil {
+		return res.failed(err)
+	}
+	progress := newProgressReader(archive, "uploading module", slog.String("name", spec.Name()))
+	m, err := storage.UploadModule(ctx, spec.Metadata.Namespace, spec.Metadata.Name, spec.Metadata.Provider, spec.Metadata.Version, progress)
+	if err != nil {
+		return res.failed(err)
+	}
+
+	slog.Info("module successfully uploaded", slog.String("download_url", m.DownloadURL), slog.Int64("bytes", progress.total()))
+
+	return res.uploaded(m.DownloadURL)
+}
+
+// skipModule returns why the module is skipped, which is empty if it's uploaded.
+// Modules are skipped if they don't meet the version constraints or exist already,
+// unless -ignore-existing is disabled, in which case existing modules are an error.
+func skipModule(ctx context.Context, spec *module.Spec, storage moduleUploader) (string, error) {
 	// Check if the module meets version constraints
 	if versionConstraintsSemver != nil {
 		ok, err := meetsSemverConstraints(spec)
 		if err != nil {
-			return err
+			return "", err
 		} else if !ok {
 			// Skip the module, as it didn't pass the version constraints
 			slog.Info("module doesn't meet semver version constraints, skipped", slog.String("name", spec.Name()))
-			return nil
+			return "module doesn't meet semver version constraints", nil
 		}
 	}
 
@@ -70,93 +118,76 @@ func processModule(path string, storage module.Storage) error {
 		if !meetsRegexConstraints(spec) {
 			// Skip the module, as it didn't pass the regex version constraints
 			slog.Info("module doesn't meet regex version constraints, skipped", slog.String("name", spec.Name()))
-			return nil
+			return "module doesn't meet regex version constraints", nil
 		}
 	}
 
-	ctx := context.Background()
 	if res, err := storage.GetModule(ctx, spec.Metadata.Namespace, spec.Metadata.Name, spec.Metadata.Provider, spec.Metadata.Version); err == nil {
 		if flagIgnoreExistingModule {
 			slog.Info("module already exists", slog.String("download_url", res.DownloadURL))
-			return nil
+			return "module already exists", nil
 		} else {
 			slog.Error("module already exists", slog.String("download_url", res.DownloadURL))
-			return errors.New("module already exists")
+			return "", errors.New("module already exists")
 		}
 	}
+	return "", nil
+}
 
-	moduleRoot := filepath.Dir(path)
-
-	buf, err := archiveModule(moduleRoot)
+// validateArchive validates and scans the gzipped tarball of the module for secrets
+func validateArchive(ctx context.Context, archive io.Reader, spec *module.Spec) error {
+	secretScan, err := module.ParseSecretScanMode(flagSecretScan)
 	if err != nil {
 		return err
 	}
 
-	res, err := storage.UploadModule(ctx, spec.Metadata.Namespace, spec.Metadata.Name, spec.Metadata.Provider, spec.Metadata.Version, buf)
-	if err != nil {
-		return err
+	m := core.Module{
+		Namespace: spec.Metadata.Namespace,
+		Name:      spec.Metadata.Name,
+		Provider:  spec.Metadata.Provider,
+		Version:   spec.Metadata.Version,
+	}
+	err = module.ValidateArchive(archive, m,
+		module.WithMaxArchiveSize(flagUploadMaxSize),
+		module.WithMaxArchiveFiles(flagUploadMaxFiles),
+		module.WithContentValidation(!module.SkipValidationFromContext(ctx)),
+		module.WithSecretScan(secretScan),
+	)
+	if errors.Is(err, module.ErrInvalidModule) {
+		return fmt.Errorf("module %s failed validation, skip it with -skip-validation: %w", spec.Name(), err)
+	} else if err != nil {
+		return fmt.Errorf("module %s failed validation: %w", spec.Name(), err)
 	}
-
-	slog.Info("module successfully uploaded", slog.String("download_url", res.DownloadURL))
-
 	return nil
-
 }
 
-func archiveModule(root string) (io.Reader, error) {
-	buf := new(bytes.Buffer)
+// archiveModule writes the module directory as gzipped tarball to a temporary file in $TMPDIR, which is rewound afterward.
+// The archive is built only once, as the same file is validated and uploaded, and the file bounds the memory used for large modules.
+// The caller has to close and remove the file.
+func archiveModule(root string) (*os.File, error) {
 	// ensure the src actually exists before trying to tar it
 	if _, err := os.Stat(root); err != nil {
-		return buf, fmt.Errorf("unable to tar files - %v", err.Error())
+		return nil, fmt.Errorf("unable to tar files - %v", err.Error())
 	}
 
-	gw := gzip.NewWriter(buf)
-	defer gw.Close()
-
-	tw := tar.NewWriter(gw)
-	defer tw.Close()
-
-	err := filepath.Walk(root, func(path string, fi os.FileInfo, err error) error {
-		// return on any error
-		if err != nil {
-			return err
-		}
-
-		// return on non-regular files
-		if !fi.Mode().IsRegular() {
-			return nil
-		}
-
-		// create a new dir/file header
-		header, err := tar.FileInfoHeader(fi, fi.Name())
-		if err != nil {
-			return err
-		}
-
-		// update the name to correctly reflect the desired destination when untaring
-		header.Name = strings.TrimPrefix(strings.Replace(path, root, "", -1), string(filepath.Separator))
-
-		if err := tw.WriteHeader(header); err != nil {
-			return err
-		}
-
-		data, err := os.Open(path)
-		if err != nil {
-			return err
-		}
-
-		if _, err := io.Copy(tw, data); err != nil {
-			return err
+	tmp, err := os.CreateTemp("", "boring-registry-module-*.tar.gz")
+	if err != nil {
+		return nil, err
+	}
+	if err := module.WriteArchive(root, tmp, module.WithDereferenceSymlinks(flagDereferenceSymlinks)); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		if errors.Is(err, module.ErrSymlinkOutsideModule) {
+			return nil, fmt.Errorf("%w, archive the content of the file instead with -dereference-symlinks", err)
 		}
-
-		// manually close here after each file operation; deferring would cause each file close
-		// to wait until all operations have completed.
-		data.Close()
-
-		return nil
-	})
-
-	return buf, err
+		return nil, err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return nil, err
+	}
+	return tmp, nil
 }
 
 // meetsSemverConstraints checks whether a module version matches the semver version constraints.
@@ -0,0 +1,197 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const reportFormatJSON = "json"
+
+var (
+	flagConcurrency   int
+	flagContinueOnErr bool
+	flagModuleTimeout time.Duration
+	flagReport        string
+)
+
+func init() {
+	uploadCmd.PersistentFlags().IntVar(&flagConcurrency, "concurrency", 1, `Number of modules that are archived and uploaded in parallel.
+Modules from git tags are always uploaded one after another`)
+	uploadCmd.PersistentFlags().BoolVar(&flagContinueOnErr, "continue-on-error", false, `Continue uploading the remaining modules if a module fails, instead of stopping at the first failure.
+The command still fails if any module failed`)
+	uploadCmd.PersistentFlags().DurationVar(&flagModuleTimeout, "module-timeout", 0, "Timeout for validating and uploading a single module, disabled if 0")
+	uploadCmd.PersistentFlags().StringVar(&flagReport, "report", "", `Print a report of the result of every module to stdout once all modules were processed.
+The only format is json, which lists every module as uploaded, skipped or failed together with the reason`)
+}
+
+// moduleStatus is the result of uploading a module
+type moduleStatus string
+
+const (
+	moduleUploaded moduleStatus = "uploaded"
+
+	// moduleSkipped is the status of modules that exist already, don't meet the version constraints,
+	// or weren't processed as an earlier module failed
+	moduleSkipped moduleStatus = "skipped"
+	moduleFailed  moduleStatus = "failed"
+)
+
+// moduleResult is the entry of a module in the upload report
+type moduleResult struct {
+	// Path is the spec file or archive of the module, or the directory within the repository for git tags
+	Path        string       `json:"path"`
+	Tag         string       `json:"tag,omitempty"`
+	Module      string       `json:"module,omitempty"`
+	Status      moduleStatus `json:"status"`
+	Reason      string       `json:"reason,omitempty"`
+	DownloadURL string       `json:"download_url,omitempty"`
+	Duration    float64      `json:"duration_seconds"`
+
+	err error
+}
+
+func (r moduleResult) uploaded(downloadURL string) moduleResult {
+	r.Status = moduleUploaded
+	r.DownloadURL = downloadURL
+	return r
+}
+
+func (r moduleResult) skipped(reason string) moduleResult {
+	r.Status = moduleSkipped
+	r.Reason = reason
+	return r
+}
+
+func (r moduleResult) failed(err error) moduleResult {
+	r.Status = moduleFailed
+	r.Reason = err.Error()
+	r.err = err
+	return r
+}
+
+// error returns the error of a failed module in the format of the log output
+func (r moduleResult) error() error {
+	if r.Tag != "" {
+		return fmt.Errorf("failed to upload tag %s:\n%w", r.Tag, r.err)
+	}
+	return fmt.Errorf("failed to process module at %s:\n%w", r.Path, r.err)
+}
+
+// uploadReport lists the results of all modules of an upload for consumption in CI
+type uploadReport struct {
+	Uploaded int            `json:"uploaded"`
+	Skipped  int            `json:"skipped"`
+	Failed   int            `json:"failed"`
+	Modules  []moduleResult `json:"modules"`
+}
+
+// uploadJob uploads a single module. The path and tag identify the module in the report if it's never uploaded.
+type uploadJob struct {
+	path   string
+	tag    string
+	upload func(ctx context.Context) moduleResult
+}
+
+func (j uploadJob) notProcessed() moduleResult {
+	return moduleResult{Path: j.path, Tag: j.tag}.skipped("not processed, as an earlier module failed or the upload was canceled")
+}
+
+// validateBatchFlags checks the flags of the worker pool before anything is uploaded
+func validateBatchFlags() error {
+	if flagConcurrency < 1 {
+		return fmt.Errorf("invalid concurrency %d, has to be at least 1", flagConcurrency)
+	}
+	if flagReport != "" && flagReport != reportFormatJSON {
+		return fmt.Errorf("invalid report format %s, has to be json", flagReport)
+	}
+	return nil
+}
+
+// runUploads runs the jobs with a pool of workers and prints the report.
+// Unless -continue-on-error is set, no further jobs are started after a module failed, while the running jobs still complete.
+// The results are reported in the order of the jobs, regardless of the order in which they completed.
+func runUploads(ctx context.Context, jobs []uploadJob, concurrency int) error {
+	results := make([]moduleResult, len(jobs))
+	var stopped atomic.Bool
+
+	indices := make(chan int)
+	wg := sync.WaitGroup{}
+	for w := 0; w < min(concurrency, len(jobs)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				if stopped.Load() || ctx.Err() != nil {
+					results[i] = jobs[i].notProcessed()
+					continue
+				}
+				results[i] = runUpload(ctx, jobs[i])
+				if results[i].Status == moduleFailed && !flagContinueOnErr {
+					stopped.Store(true)
+				}
+			}
+		}()
+	}
+
+	for i := range jobs {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+
+	report := uploadReport{Modules: results}
+	var errs []error
+	for _, res := range results {
+		switch res.Status {
+		case moduleUploaded:
+			report.Uploaded++
+		case moduleSkipped:
+			report.Skipped++
+		case moduleFailed:
+			report.Failed++
+			errs = append(errs, res.error())
+		}
+	}
+
+	slog.Info("finished uploading modules", slog.Int("uploaded", report.Uploaded), slog.Int("skipped", report.Skipped), slog.Int("failed", report.Failed))
+
+	if flagReport == reportFormatJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(report); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	// Without -continue-on-error, only the first failure is returned like before there was a worker pool
+	if len(errs) > 0 && !flagContinueOnErr {
+		return errs[0]
+	}
+	return errors.Join(errs...)
+}
+
+// runUpload runs the job with the timeout of a single module
+func runUpload(ctx context.Context, job uploadJob) moduleResult {
+	if flagModuleTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, flagModuleTimeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	res := job.upload(ctx)
+	res.Duration = time.Since(start).Seconds()
+
+	if res.Status == moduleFailed && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		res = res.failed(fmt.Errorf("timed out after %s: %w", flagModuleTimeout, res.err))
+	}
+	return res
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	flagDownloadOutput string
+)
+
+func init() {
+	rootCmd.AddCommand(downloadCmd)
+	downloadCmd.AddCommand(downloadModuleCmd)
+
+	downloadModuleCmd.Flags().StringVarP(&flagDownloadOutput, "output", "o", "", `File path to write the module archive to. Defaults to <namespace>-<name>-<provider>-<version>.tar.gz in the working directory.
+Use - to write to stdout`)
+}
+
+var downloadCmd = &cobra.Command{
+	Use:   "download",
+	Short: "Download modules from the storage backend",
+}
+
+var downloadModuleCmd = &cobra.Command{
+	Use:          "module NAMESPACE/NAME/PROVIDER VERSION",
+	Short:        "Download a module archive",
+	Args:         cobra.ExactArgs(2),
+	SilenceUsage: true,
+	RunE:         downloadModule,
+}
+
+func downloadModule(cmd *cobra.Command, args []string) error {
+	parts := strings.Split(args[0], "/")
+	if len(parts) != 3 {
+		return fmt.Errorf("module has to be formatted as NAMESPACE/NAME/PROVIDER, but was %s", args[0])
+	}
+	namespace, name, provider, version := parts[0], parts[1], parts[2], args[1]
+
+	ctx := context.Background()
+	storageBackend, err := setupStorage(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to set up storage: %w", err)
+	}
+
+	archive, err := storageBackend.DownloadModule(ctx, namespace, name, provider, version)
+	if err != nil {
+		return fmt.Errorf("failed to download module %s %s: %w", args[0], version, err)
+	}
+	defer archive.Close()
+
+	output := flagDownloadOutput
+	if output == "" {
+		output = fmt.Sprintf("%s-%s-%s-%s.tar.gz", namespace, name, provider, version)
+	}
+
+	var w io.Writer = os.Stdout
+	if output != "-" {
+		f, err := os.Create(output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	progress := newProgressReader(archive, "downloading module", slog.String("module", args[0]), slog.String("version", version))
+	if _, err := io.Copy(w, progress); err != nil {
+		return fmt.Errorf("failed to download module: %w", err)
+	}
+
+	slog.Info("module successfully downloaded", slog.String("output", output), slog.Int64("bytes", progress.total()))
+	return nil
+}
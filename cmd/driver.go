@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/boring-registry/boring-registry/pkg/storage/driver"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	flagDriverListenAddr     string
+	flagDriverToken          string
+	flagDriverFilesystemRoot string
+)
+
+func init() {
+	rootCmd.AddCommand(driverCmd)
+	driverCmd.AddCommand(driverFilesystemCmd)
+
+	driverCmd.PersistentFlags().StringVar(&flagDriverListenAddr, "listen-address", driver.DefaultListenAddress, "Address to listen on, if the driver isn't launched by the registry")
+	driverCmd.PersistentFlags().StringVar(&flagDriverToken, "token", "", `Token clients have to authenticate with, if the driver isn't launched by the registry.
+Required unless the driver listens on a loopback address`)
+	driverFilesystemCmd.Flags().StringVar(&flagDriverFilesystemRoot, "root", "", "Directory to store the registry objects in")
+	if err := driverFilesystemCmd.MarkFlagRequired("root"); err != nil {
+		panic(err)
+	}
+}
+
+var driverCmd = &cobra.Command{
+	Use:   "driver",
+	Short: "Runs a storage driver for the external storage driver protocol",
+}
+
+var driverFilesystemCmd = &cobra.Command{
+	Use:          "filesystem",
+	Short:        "Runs the reference storage driver backed by the local filesystem",
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		d, err := driver.NewFilesystemDriver(flagDriverFilesystemRoot)
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer cancel()
+
+		return driver.Serve(ctx, d,
+			driver.WithListenAddress(flagDriverListenAddr),
+			driver.WithToken(flagDriverToken),
+		)
+	},
+}
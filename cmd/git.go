@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/module"
+
+	"github.com/spf13/cobra"
+)
+
+// defaultGitTagPattern matches tags like modules/vpc/v1.4.0 of monorepos and v1.4.0 of repositories with a single module
+const defaultGitTagPattern = `^(?:(?P<path>.+)/)?v(?P<version>[0-9]+\.[0-9]+\.[0-9]+(?:-[0-9A-Za-z.-]+)?)$`
+
+var (
+	flagGitRepository string
+	flagGitTagPattern string
+)
+
+func init() {
+	for _, c := range []*cobra.Command{uploadCmd, uploadModuleCmd} {
+		c.Flags().StringVar(&flagGitRepository, "git", "", `Upload modules from the tags of the git repository at this URL or path instead of a directory.
+The tree of every tag matching -git-tag-pattern is checked out, and the version of its module is derived from the tag.
+The version of the boring-registry.hcl file is ignored and can be omitted`)
+		c.Flags().StringVar(&flagGitTagPattern, "git-tag-pattern", defaultGitTagPattern, `Regex selecting the tags to upload with -git. The named group "version" is the version of the module,
+and the optional named group "path" is the directory of the module within the repository`)
+	}
+}
+
+// gitTag is a tag of a module version
+type gitTag struct {
+	name   string
+	commit string
+
+	// path is the directory of the module within the repository, which is empty for the root of the repository
+	path    string
+	version string
+}
+
+// uploadModulesFromGit uploads the module of every tag of the repository matching the tag pattern.
+// The modules are looked up in dir within the repository, which is joined with the path of the tag.
+// Versions that exist already are handled by processModule like for uploads of directories.
+func uploadModulesFromGit(ctx context.Context, repository, dir string, storage moduleUploader) error {
+	pattern, err := regexp.Compile(flagGitTagPattern)
+	if err != nil {
+		return fmt.Errorf("invalid tag pattern: %w", err)
+	}
+	if pattern.SubexpIndex("version") < 0 {
+		return errors.New(`tag pattern has to contain the named group "version"`)
+	}
+
+	tmp, err := os.MkdirTemp("", "boring-registry-git-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	// A bare clone contains all tags without checking out any tree, which is done for every tag in a worktree
+	bare := filepath.Join(tmp, "repository.git")
+	if _, err := git("", "clone", "--bare", "--quiet", repository, bare); err != nil {
+		return fmt.Errorf("failed to clone %s: %w", repository, err)
+	}
+
+	tags, err := listGitTags(bare, pattern)
+	if err != nil {
+		return err
+	}
+	slog.Info("found tags matching the tag pattern", slog.String("repository", repository), slog.Int("tags", len(tags)))
+
+	// The tags share a worktree, so they are uploaded one after another
+	jobs := make([]uploadJob, 0, len(tags))
+	for _, tag := range tags {
+		jobs = append(jobs, uploadJob{
+			path: path.Join(".", dir, tag.path),
+			tag:  tag.name,
+			upload: func(ctx context.Context) moduleResult {
+				return uploadGitTag(ctx, bare, filepath.Join(tmp, "worktree"), dir, tag, storage)
+			},
+		})
+	}
+	return runUploads(ctx, jobs, 1)
+}
+
+// listGitTags returns the tags matching the pattern in the order of their versions
+func listGitTags(repository string, pattern *regexp.Regexp) ([]gitTag, error) {
+	// Annotated tags point to a tag object, so the commit is read from the dereferenced object
+	out, err := git(repository, "for-each-ref", "--sort=version:refname", "--format=%(refname:strip=2) %(objectname) %(*objectname)", "refs/tags")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+
+	var tags []gitTag
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+
+		m := pattern.FindStringSubmatch(fields[0])
+		if m == nil {
+			slog.Debug("tag doesn't match the tag pattern, skipped", slog.String("tag", fields[0]))
+			continue
+		}
+
+		tag := gitTag{
+			name:    fields[0],
+			commit:  fields[len(fields)-1],
+			version: m[pattern.SubexpIndex("version")],
+		}
+		if i := pattern.SubexpIndex("path"); i >= 0 {
+			tag.path = m[i]
+		}
+		tags = append(tags, tag)
+	}
+	return tags, nil
+}
+
+// uploadGitTag checks out the module directory of the tag and uploads it with the version of the tag.
+// The tag is recorded in the provenance, unless the commit or ref were set explicitly.
+func uploadGitTag(ctx context.Context, repository, worktree, dir string, tag gitTag, storage moduleUploader) moduleResult {
+	moduleDir := path.Join(".", dir, tag.path)
+	res := moduleResult{Path: moduleDir, Tag: tag.name}
+	if strings.HasPrefix(moduleDir, "../") || moduleDir == ".." {
+		return res.failed(fmt.Errorf("module directory %s is outside of the repository", moduleDir))
+	}
+
+	if _, err := git(repository, "worktree", "add", "--detach", "--no-checkout", worktree, tag.commit); err != nil {
+		return res.failed(fmt.Errorf("failed to create worktree: %w", err))
+	}
+	defer func() {
+		if _, err := git(repository, "worktree", "remove", "--force", worktree); err != nil {
+			slog.Warn("failed to remove worktree", slog.String("path", worktree), slog.String("err", err.Error()))
+		}
+	}()
+
+	// Only the module directory is checked out, which keeps large monorepos fast
+	if _, err := git(worktree, "checkout", tag.commit, "--", moduleDir); err != nil {
+		return res.failed(fmt.Errorf("failed to check out %s: %w", moduleDir, err))
+	}
+
+	p := core.ProvenanceFromContext(ctx)
+	if flagProvenanceGitCommit == "" {
+		p.GitCommit = tag.commit
+	}
+	if flagProvenanceGitRef == "" {
+		p.GitRef = "refs/tags/" + tag.name
+	}
+	ctx = core.WithProvenance(ctx, p)
+
+	slog.Info("checked out tag", slog.String("tag", tag.name), slog.String("commit", tag.commit), slog.String("path", moduleDir))
+	result := processModule(ctx, filepath.Join(worktree, filepath.FromSlash(moduleDir), module.SpecFileName), tag.version, storage)
+
+	// The worktree is removed afterwards, so the module is reported with its directory within the repository
+	result.Path, result.Tag = res.Path, res.Tag
+	return result
+}
+
+// git runs git in the directory, which is the working directory if it's empty, and returns its output
+func git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+
+	stderr := new(bytes.Buffer)
+	cmd.Stderr = stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/boring-registry/boring-registry/pkg/graph"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	flagGraphFormat               string
+	flagGraphNamespace            string
+	flagGraphAllVersions          bool
+	flagGraphDependentsModule     string
+	flagGraphDependentsProvider   string
+	flagGraphDependentsConstraint string
+)
+
+func init() {
+	rootCmd.AddCommand(graphCmd)
+
+	graphCmd.Flags().StringVar(&flagGraphFormat, "format", "json", "Output format of the graph, either json or dot")
+	graphCmd.Flags().StringVar(&flagGraphNamespace, "namespace", "", "Only include the dependencies of the modules in this namespace")
+	graphCmd.Flags().BoolVar(&flagGraphAllVersions, "all-versions", false, "Include the dependencies of all module versions instead of only the latest version of every module")
+	graphCmd.Flags().StringVar(&flagGraphDependentsModule, "dependents-of-module", "", "Only include the modules depending on the module at this address, e.g. registry.example.com/network/vpc/aws")
+	graphCmd.Flags().StringVar(&flagGraphDependentsProvider, "dependents-of-provider", "", "Only include the modules depending on the provider at this address, e.g. hashicorp/aws")
+	graphCmd.Flags().StringVar(&flagGraphDependentsConstraint, "version", "", `Only include the dependents whose version constraint allows a published version matching this constraint, e.g. ">= 3.0"`)
+	graphCmd.MarkFlagsMutuallyExclusive("dependents-of-module", "dependents-of-provider")
+}
+
+var graphCmd = &cobra.Command{
+	Use:          "graph",
+	Short:        "Print the dependency graph of the published modules",
+	Long:         "Print the dependencies of the published modules on other modules and providers as JSON or in the DOT language of Graphviz",
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE:         printGraph,
+}
+
+func printGraph(cmd *cobra.Command, args []string) error {
+	if flagGraphFormat != "json" && flagGraphFormat != "dot" {
+		return fmt.Errorf("unsupported format %s, has to be json or dot", flagGraphFormat)
+	}
+
+	ctx := context.Background()
+	storageBackend, err := setupStorage(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to set up storage: %w", err)
+	}
+
+	svc := graph.NewService(storageBackend)
+	filter := graph.Filter{
+		Namespace:   flagGraphNamespace,
+		AllVersions: flagGraphAllVersions,
+	}
+
+	var edges []graph.Edge
+	switch {
+	case flagGraphDependentsModule != "":
+		edges, err = svc.GetDependents(ctx, graph.Query{Filter: filter, Kind: graph.KindModule, Address: flagGraphDependentsModule, Constraint: flagGraphDependentsConstraint})
+	case flagGraphDependentsProvider != "":
+		edges, err = svc.GetDependents(ctx, graph.Query{Filter: filter, Kind: graph.KindProvider, Address: flagGraphDependentsProvider, Constraint: flagGraphDependentsConstraint})
+	default:
+		edges, err = svc.GetGraph(ctx, filter)
+	}
+	if err != nil {
+		return err
+	}
+
+	if flagGraphFormat == "dot" {
+		return graph.WriteDOT(os.Stdout, edges)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		Edges []graph.Edge `json:"edges"`
+	}{edges})
+}
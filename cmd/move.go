@@ -0,0 +1,192 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/boring-registry/boring-registry/pkg/alias"
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/module"
+	"github.com/boring-registry/boring-registry/pkg/provider"
+	"github.com/boring-registry/boring-registry/pkg/storage"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	flagMoveDeprecation string
+)
+
+func init() {
+	rootCmd.AddCommand(moveCmd)
+	moveCmd.AddCommand(moveModuleCmd)
+	moveCmd.AddCommand(moveProviderCmd)
+	moveCmd.AddCommand(moveNamespaceCmd)
+
+	moveCmd.PersistentFlags().StringVar(&flagMoveDeprecation, "deprecation", "", "Deprecation warning returned to clients using the old address. The old address isn't deprecated if empty")
+}
+
+var moveCmd = &cobra.Command{
+	Use:   "move",
+	Short: "Move modules, providers or whole namespaces to a new address",
+	Long: `Move renames a module, a provider or a namespace, and keeps the old address working with an alias.
+All versions are copied to the new address first, then the alias is created, and the versions at the old address are deleted last.
+A move that was interrupted can be run again, as versions that exist at the new address already are skipped.
+Provider releases are only moved if their SHA256SUMS file is signed by one of the signing keys of the new namespace.`,
+}
+
+var moveModuleCmd = &cobra.Command{
+	Use:          "module OLD_NAMESPACE/NAME/PROVIDER NEW_NAMESPACE/NAME/PROVIDER",
+	Short:        "Move all versions of a module to a new address",
+	Args:         cobra.ExactArgs(2),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return move(alias.Alias{Type: alias.TypeModule, From: args[0], To: args[1], Deprecation: flagMoveDeprecation})
+	},
+}
+
+var moveProviderCmd = &cobra.Command{
+	Use:          "provider OLD_NAMESPACE/NAME NEW_NAMESPACE/NAME",
+	Short:        "Move all versions of a provider to another namespace",
+	Args:         cobra.ExactArgs(2),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return move(alias.Alias{Type: alias.TypeProvider, From: args[0], To: args[1], Deprecation: flagMoveDeprecation})
+	},
+}
+
+var moveNamespaceCmd = &cobra.Command{
+	Use:          "namespace OLD_NAMESPACE NEW_NAMESPACE",
+	Short:        "Move all modules and providers of a namespace to a new namespace",
+	Args:         cobra.ExactArgs(2),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return move(alias.Alias{Type: alias.TypeNamespace, From: args[0], To: args[1], Deprecation: flagMoveDeprecation})
+	},
+}
+
+// move copies everything the alias redirects to the new address, adds the alias and deletes the old versions
+func move(a alias.Alias) error {
+	if err := a.Validate(); err != nil {
+		return err
+	}
+
+	ctx := provenanceContext(context.Background())
+	s, err := setupPublishStorage(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to set up storage: %w", err)
+	}
+
+	modules, providers, err := moveSources(ctx, s, a)
+	if err != nil {
+		return err
+	} else if len(modules) == 0 && len(providers) == 0 {
+		return fmt.Errorf("%s %s has no versions to move", a.Type, a.From)
+	}
+
+	// The old versions are deleted after copying them, so we make sure that's allowed before copying anything
+	for _, m := range modules {
+		if err := s.Check(ctx, m.Namespace, m.Name); err != nil {
+			return fmt.Errorf("can't move module %s: %w", m.ID(true), err)
+		}
+	}
+	for _, p := range providers {
+		if err := s.Check(ctx, p.Namespace, p.Name); err != nil {
+			return fmt.Errorf("can't move provider %s/%s %s: %w", p.Namespace, p.Name, p.Version, err)
+		}
+	}
+
+	aliases := alias.Aliases{a}
+	providerService := provider.NewService(s, core.NewProxyUrlService(false, ""), provider.WithPublishEnabled(true))
+	for _, m := range modules {
+		dst, _ := aliases.ResolveModule(m)
+		dst.Version = m.Version
+		if _, err := s.CopyModule(ctx, m, dst); errors.Is(err, module.ErrModuleAlreadyExists) {
+			slog.Info("module version exists already", slog.String("module", dst.ID(true)))
+		} else if err != nil {
+			return fmt.Errorf("failed to copy module %s: %w", m.ID(true), err)
+		}
+	}
+	for _, p := range providers {
+		namespace, _, _ := aliases.ResolveProvider(p.Namespace, p.Name)
+		_, err := providerService.PromoteProvider(ctx, p.Namespace, p.Name, p.Version, namespace)
+		if errors.Is(err, provider.ErrProviderAlreadyExists) {
+			slog.Info("provider version exists already", slog.String("provider", fmt.Sprintf("%s/%s", namespace, p.Name)), slog.String("version", p.Version))
+		} else if err != nil {
+			return fmt.Errorf("failed to copy provider %s/%s %s: %w", p.Namespace, p.Name, p.Version, err)
+		}
+	}
+
+	if err := addMoveAlias(ctx, s, a); err != nil {
+		return err
+	}
+
+	for _, m := range modules {
+		if err := s.DeleteModule(ctx, m.Namespace, m.Name, m.Provider, m.Version); err != nil {
+			return fmt.Errorf("failed to delete module %s: %w", m.ID(true), err)
+		}
+	}
+	for _, p := range providers {
+		if err := s.DeleteProviderRelease(ctx, p.Namespace, p.Name, p.Version); err != nil {
+			return fmt.Errorf("failed to delete provider %s/%s %s: %w", p.Namespace, p.Name, p.Version, err)
+		}
+	}
+
+	slog.Info("successfully moved", slog.String("type", a.Type), slog.String("from", a.From), slog.String("to", a.To),
+		slog.Int("module_versions", len(modules)), slog.Int("provider_versions", len(providers)))
+	return nil
+}
+
+// moveSources returns the module and provider versions at the old address of the alias
+func moveSources(ctx context.Context, s storage.Storage, a alias.Alias) ([]core.Module, []core.ProviderVersion, error) {
+	switch a.Type {
+	case alias.TypeModule:
+		parts := strings.Split(a.From, "/")
+		modules, err := s.ListModuleVersions(ctx, parts[0], parts[1], parts[2])
+		return modules, nil, err
+	case alias.TypeProvider:
+		namespace, name, _ := strings.Cut(a.From, "/")
+		providers, err := s.ListProviders(ctx, namespace)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var versions []core.ProviderVersion
+		for _, p := range providers {
+			if p.Name == name {
+				versions = append(versions, p)
+			}
+		}
+		return nil, versions, nil
+	default:
+		modules, err := s.ListModules(ctx, a.From)
+		if err != nil {
+			return nil, nil, err
+		}
+		providers, err := s.ListProviders(ctx, a.From)
+		return modules, providers, err
+	}
+}
+
+// addMoveAlias adds the alias, unless it exists already from a previous run of the same move
+func addMoveAlias(ctx context.Context, s storage.Storage, a alias.Alias) error {
+	_, err := alias.NewService(s, alias.WithManageEnabled(true)).AddAlias(ctx, a)
+	if !errors.Is(err, alias.ErrAliasAlreadyExists) {
+		return err
+	}
+
+	existing, err := s.ListAliases(ctx)
+	if err != nil {
+		return err
+	}
+	for _, e := range existing {
+		if e.ID() == a.ID() && e.To == a.To {
+			slog.Info("alias exists already", slog.String("type", a.Type), slog.String("from", a.From))
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s redirects to another address", alias.ErrAliasAlreadyExists, a.ID())
+}
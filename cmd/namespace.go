@@ -0,0 +1,221 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/boring-registry/boring-registry/pkg/namespace"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	flagNamespaceDescription  string
+	flagNamespaceOwners       []string
+	flagNamespaceNamePatterns []string
+	flagNamespaceFrozen       bool
+)
+
+func init() {
+	rootCmd.AddCommand(namespaceCmd)
+	namespaceCmd.AddCommand(namespaceListCmd)
+	namespaceCmd.AddCommand(namespaceGetCmd)
+	namespaceCmd.AddCommand(namespaceCreateCmd)
+	namespaceCmd.AddCommand(namespaceUpdateCmd)
+	namespaceCmd.AddCommand(namespaceFreezeCmd)
+	namespaceCmd.AddCommand(namespaceUnfreezeCmd)
+	namespaceCmd.AddCommand(namespaceDeleteCmd)
+
+	for _, c := range []*cobra.Command{namespaceCreateCmd, namespaceUpdateCmd} {
+		c.Flags().StringVar(&flagNamespaceDescription, "description", "", "Description of the namespace")
+		c.Flags().StringSliceVar(&flagNamespaceOwners, "owner", nil, "Owner of the namespace, e.g. a team or an email address. Can be repeated")
+		c.Flags().StringSliceVar(&flagNamespaceNamePatterns, "name-pattern", nil, `Regular expression the names of modules and providers in the namespace have to match in full. Can be repeated.
+All names are allowed if empty`)
+		c.Flags().BoolVar(&flagNamespaceFrozen, "frozen", false, "Freeze the namespace, so that nothing can be published to it")
+	}
+}
+
+var namespaceCmd = &cobra.Command{
+	Use:   "namespace",
+	Short: "Manage the registered namespaces",
+	Long: `Namespace manages the registered namespaces with their description, owners, naming rules and whether they are frozen.
+Publishing to frozen namespaces or with names that don't match the name patterns of the namespace is rejected.
+Once any namespace is registered, publishing to namespaces that aren't registered is rejected as well.`,
+}
+
+var namespaceListCmd = &cobra.Command{
+	Use:          "list",
+	Short:        "List the registered namespaces",
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		svc, err := setupNamespaceService(context.Background())
+		if err != nil {
+			return err
+		}
+
+		namespaces, err := svc.ListNamespaces(context.Background())
+		if err != nil {
+			return err
+		}
+		if namespaces == nil {
+			namespaces = []namespace.Namespace{}
+		}
+		return printNamespaces(namespaces)
+	},
+}
+
+var namespaceGetCmd = &cobra.Command{
+	Use:          "get NAMESPACE",
+	Short:        "Print a registered namespace",
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		svc, err := setupNamespaceService(context.Background())
+		if err != nil {
+			return err
+		}
+
+		n, err := svc.GetNamespace(context.Background(), args[0])
+		if err != nil {
+			return err
+		}
+		return printNamespaces(n)
+	},
+}
+
+var namespaceCreateCmd = &cobra.Command{
+	Use:          "create NAMESPACE",
+	Short:        "Register a namespace",
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		svc, err := setupNamespaceService(ctx)
+		if err != nil {
+			return err
+		}
+
+		n, err := svc.AddNamespace(ctx, namespace.Namespace{
+			Name:         args[0],
+			Description:  flagNamespaceDescription,
+			Owners:       flagNamespaceOwners,
+			NamePatterns: flagNamespaceNamePatterns,
+			Frozen:       flagNamespaceFrozen,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create namespace %s: %w", args[0], err)
+		}
+
+		slog.Info("namespace successfully created", slog.String("namespace", n.Name))
+		return nil
+	},
+}
+
+var namespaceUpdateCmd = &cobra.Command{
+	Use:          "update NAMESPACE",
+	Short:        "Update a registered namespace",
+	Long:         "Update changes the attributes of the registered namespace whose flags are set, and keeps the other attributes",
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return updateNamespace(args[0], func(n *namespace.Namespace) {
+			if cmd.Flags().Changed("description") {
+				n.Description = flagNamespaceDescription
+			}
+			if cmd.Flags().Changed("owner") {
+				n.Owners = flagNamespaceOwners
+			}
+			if cmd.Flags().Changed("name-pattern") {
+				n.NamePatterns = flagNamespaceNamePatterns
+			}
+			if cmd.Flags().Changed("frozen") {
+				n.Frozen = flagNamespaceFrozen
+			}
+		})
+	},
+}
+
+var namespaceFreezeCmd = &cobra.Command{
+	Use:          "freeze NAMESPACE",
+	Short:        "Freeze a registered namespace, so that nothing can be published to it",
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return updateNamespace(args[0], func(n *namespace.Namespace) {
+			n.Frozen = true
+		})
+	},
+}
+
+var namespaceUnfreezeCmd = &cobra.Command{
+	Use:          "unfreeze NAMESPACE",
+	Short:        "Unfreeze a registered namespace, so that modules and providers can be published to it again",
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return updateNamespace(args[0], func(n *namespace.Namespace) {
+			n.Frozen = false
+		})
+	},
+}
+
+var namespaceDeleteCmd = &cobra.Command{
+	Use:          "delete NAMESPACE",
+	Short:        "Remove a namespace from the registry, whereas its modules and providers are kept",
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		svc, err := setupNamespaceService(ctx)
+		if err != nil {
+			return err
+		}
+
+		if err := svc.DeleteNamespace(ctx, args[0]); err != nil {
+			return fmt.Errorf("failed to delete namespace %s: %w", args[0], err)
+		}
+
+		slog.Info("namespace successfully deleted", slog.String("namespace", args[0]))
+		return nil
+	},
+}
+
+func setupNamespaceService(ctx context.Context) (namespace.Service, error) {
+	s, err := setupStorage(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up storage: %w", err)
+	}
+	return namespace.NewService(s, namespace.WithManageEnabled(true)), nil
+}
+
+// updateNamespace applies the changes to the registered namespace and stores it
+func updateNamespace(name string, change func(n *namespace.Namespace)) error {
+	ctx := context.Background()
+	svc, err := setupNamespaceService(ctx)
+	if err != nil {
+		return err
+	}
+
+	n, err := svc.GetNamespace(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	change(&n)
+	if _, err := svc.UpdateNamespace(ctx, n); err != nil {
+		return fmt.Errorf("failed to update namespace %s: %w", name, err)
+	}
+
+	slog.Info("namespace successfully updated", slog.String("namespace", n.Name), slog.Bool("frozen", n.Frozen))
+	return nil
+}
+
+func printNamespaces(v any) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/boring-registry/boring-registry/pkg/module"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	flagModuleArchive   string
+	flagModuleNamespace string
+	flagModuleName      string
+	flagModuleProvider  string
+	flagModuleVersion   string
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zipMagic  = []byte("PK\x03\x04")
+)
+
+func init() {
+	for _, c := range []*cobra.Command{uploadCmd, uploadModuleCmd} {
+		c.Flags().StringVar(&flagModuleArchive, "archive", "", `Upload a prebuilt module archive instead of a directory, either a gzipped tarball or a zip archive.
+Zip archives are converted to gzipped tarballs, while tarballs are uploaded as-is.
+The module is described by the boring-registry.hcl file in the root of the archive, if there is one, and by -namespace, -name, -provider and -version.
+The flags complete the spec file, e.g. with a version, but the upload fails if they contradict it`)
+		c.Flags().StringVar(&flagModuleNamespace, "namespace", "", "Namespace of the module uploaded with -archive")
+		c.Flags().StringVar(&flagModuleName, "name", "", "Name of the module uploaded with -archive")
+		c.Flags().StringVar(&flagModuleProvider, "provider", "", "Provider of the module uploaded with -archive")
+		c.Flags().StringVar(&flagModuleVersion, "version", "", "Version of the module uploaded with -archive")
+	}
+}
+
+// uploadModuleArchive validates and uploads the prebuilt module archive at path.
+// The version constraints and existing versions are handled like for uploads of directories.
+func uploadModuleArchive(ctx context.Context, path string, storage moduleUploader) error {
+	return runUploads(ctx, []uploadJob{{
+		path: path,
+		upload: func(ctx context.Context) moduleResult {
+			return processModuleArchive(ctx, path, storage)
+		},
+	}}, 1)
+}
+
+func processModuleArchive(ctx context.Context, path string, storage moduleUploader) moduleResult {
+	res := moduleResult{Path: path}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return res.failed(err)
+	}
+	defer f.Close()
+
+	archive, err := gzippedArchive(f)
+	if err != nil {
+		return res.failed(fmt.Errorf("failed to read archive: %w", err))
+	}
+	if archive != f {
+		defer os.Remove(archive.Name())
+		defer archive.Close()
+	}
+
+	spec, err := archiveModuleSpec(archive)
+	if err != nil {
+		return res.failed(fmt.Errorf("failed to read archive: %w", err))
+	}
+	res.Module = spec.Name()
+
+	slog.Debug("read module spec of archive", slog.String("path", path), slog.String("name", spec.Name()))
+
+	if reason, err := skipModule(ctx, spec, storage); err != nil {
+		return res.failed(err)
+	} else if reason != "" {
+		return res.skipped(reason)
+	}
+
+	if _, err := archive.Seek(0, io.SeekStart); err != nil {
+		return res.failed(err)
+	}
+	if err := validateArchive(ctx, archive, spec); err != nil {
+		return res.failed(err)
+	}
+
+	if _, err := archive.Seek(0, io.SeekStart); err != nil {
+		return res.failed(err)
+	}
+	progress := newProgressReader(archive, "uploading module", slog.String("name", spec.Name()))
+	m, err := storage.UploadModule(ctx, spec.Metadata.Namespace, spec.Metadata.Name, spec.Metadata.Provider, spec.Metadata.Version, progress)
+	if err != nil {
+		return res.failed(err)
+	}
+
+	slog.Info("module successfully uploaded", slog.String("download_url", m.DownloadURL), slog.Int64("bytes", progress.total()))
+
+	return res.uploaded(m.DownloadURL)
+}
+
+// gzippedArchive returns f if it's a gzipped tarball, or a temporary file with the converted tarball if it's a zip archive.
+// The format is detected by the content of the file rather than its extension, as build systems name their artifacts differently.
+func gzippedArchive(f *os.File) (*os.File, error) {
+	magic := make([]byte, len(zipMagic))
+	n, err := io.ReadFull(f, magic)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	magic = magic[:n]
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		return f, nil
+	case bytes.HasPrefix(magic, zipMagic):
+	default:
+		return nil, fmt.Errorf("%w: has to be a gzipped tarball or a zip archive", module.ErrInvalidArchive)
+	}
+
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	tmp, err := os.CreateTemp("", "boring-registry-module-*.tar.gz")
+	if err != nil {
+		return nil, err
+	}
+	if err := module.ConvertZipArchive(f, fi.Size(), tmp); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return nil, err
+	}
+	return tmp, nil
+}
+
+// archiveModuleSpec returns the spec of the module archive.
+// The spec file of the archive is optional, and the flags that are set take precedence over its metadata.
+// Contradicting metadata is rejected later on by the validation of the archive, as the spec file is part of the uploaded module.
+func archiveModuleSpec(archive io.ReadSeeker) (*module.Spec, error) {
+	if _, err := archive.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	spec, err := module.ArchiveSpec(archive)
+	if err != nil {
+		return nil, err
+	} else if spec == nil {
+		spec = &module.Spec{}
+	}
+
+	for _, override := range []struct {
+		flag  string
+		field *string
+	}{
+		{flagModuleNamespace, &spec.Metadata.Namespace},
+		{flagModuleName, &spec.Metadata.Name},
+		{flagModuleProvider, &spec.Metadata.Provider},
+		{flagModuleVersion, &spec.Metadata.Version},
+	} {
+		if override.flag != "" {
+			*override.field = override.flag
+		}
+	}
+
+	if err := spec.Validate(); err != nil {
+		return nil, fmt.Errorf("the module has to be described by the %s file of the archive or by -namespace, -name, -provider and -version: %w", module.SpecFileName, err)
+	}
+	return spec, nil
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"io"
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+// progressInterval is the interval in which the progress of long-running transfers is logged
+const progressInterval = 5 * time.Second
+
+// progressReader logs the number of bytes read through it periodically
+type progressReader struct {
+	reader  io.Reader
+	message string
+	attrs   []any
+	read    atomic.Int64
+	last    time.Time
+}
+
+func newProgressReader(r io.Reader, message string, attrs ...any) *progressReader {
+	return &progressReader{
+		reader:  r,
+		message: message,
+		attrs:   attrs,
+		last:    time.Now(),
+	}
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.reader.Read(b)
+	read := p.read.Add(int64(n))
+
+	if time.Since(p.last) >= progressInterval {
+		p.last = time.Now()
+		slog.Info(p.message, append(p.attrs, slog.Int64("bytes", read))...)
+	}
+	return n, err
+}
+
+// total returns the number of bytes read so far
+func (p *progressReader) total() int64 {
+	return p.read.Load()
+}
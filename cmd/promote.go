@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/module"
+	"github.com/boring-registry/boring-registry/pkg/provider"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	flagPromoteTo string
+)
+
+func init() {
+	rootCmd.AddCommand(promoteCmd)
+	promoteCmd.AddCommand(promoteModuleCmd)
+	promoteCmd.AddCommand(promoteProviderCmd)
+
+	promoteCmd.PersistentFlags().StringVar(&flagPromoteTo, "to", "", "Namespace the module version or provider release is promoted to")
+}
+
+var promoteCmd = &cobra.Command{
+	Use:   "promote",
+	Short: "Promote modules and providers to another namespace",
+	Long: `Promote copies a module version or a provider release to another namespace, e.g. from staging to prod.
+The artifacts are copied as-is without rebuilding or re-signing them, within the storage backend if it supports it.
+The SHA256SUMS file of a provider release has to be signed with one of the signing keys of the target namespace.`,
+}
+
+var promoteModuleCmd = &cobra.Command{
+	Use:          "module NAMESPACE/NAME/PROVIDER VERSION",
+	Short:        "Promote a module version to another namespace",
+	Args:         cobra.ExactArgs(2),
+	SilenceUsage: true,
+	RunE:         promoteModule,
+}
+
+var promoteProviderCmd = &cobra.Command{
+	Use:          "provider NAMESPACE/NAME VERSION",
+	Short:        "Promote a provider release with all of its platforms to another namespace",
+	Args:         cobra.ExactArgs(2),
+	SilenceUsage: true,
+	RunE:         promoteProvider,
+}
+
+func promoteModule(cmd *cobra.Command, args []string) error {
+	parts := strings.Split(args[0], "/")
+	if len(parts) != 3 {
+		return fmt.Errorf("module has to be formatted as NAMESPACE/NAME/PROVIDER, but was %s", args[0])
+	}
+	namespace, name, providerName, version := parts[0], parts[1], parts[2], args[1]
+	if flagPromoteTo == "" {
+		return errors.New("the target namespace has to be set with -to")
+	}
+
+	ctx := provenanceContext(context.Background())
+	storageBackend, err := setupPublishStorage(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to set up storage: %w", err)
+	}
+
+	svc := module.NewService(storageBackend, core.NewProxyUrlService(false, ""), module.WithPublishEnabled(true))
+	m, err := svc.PromoteModule(ctx, namespace, name, providerName, version, flagPromoteTo)
+	if err != nil {
+		return fmt.Errorf("failed to promote module %s %s: %w", args[0], version, err)
+	}
+
+	slog.Info("module successfully promoted", slog.String("module", m.ID(true)), slog.String("download_url", m.DownloadURL))
+	return nil
+}
+
+func promoteProvider(cmd *cobra.Command, args []string) error {
+	parts := strings.Split(args[0], "/")
+	if len(parts) != 2 {
+		return fmt.Errorf("provider has to be formatted as NAMESPACE/NAME, but was %s", args[0])
+	}
+	namespace, name, version := parts[0], parts[1], args[1]
+	if flagPromoteTo == "" {
+		return errors.New("the target namespace has to be set with -to")
+	}
+
+	ctx := provenanceContext(context.Background())
+	storageBackend, err := setupPublishStorage(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to set up storage: %w", err)
+	}
+
+	svc := provider.NewService(storageBackend, core.NewProxyUrlService(false, ""), provider.WithPublishEnabled(true))
+	p, err := svc.PromoteProvider(ctx, namespace, name, version, flagPromoteTo)
+	if err != nil {
+		return fmt.Errorf("failed to promote provider %s %s: %w", args[0], version, err)
+	}
+
+	slog.Info("provider successfully promoted", slog.String("provider", fmt.Sprintf("%s/%s", p.Namespace, p.Name)), slog.String("version", p.Version), slog.Int("platforms", len(p.Platforms)))
+	return nil
+}
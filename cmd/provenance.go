@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"os/user"
+	"strings"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/metadata"
+	"github.com/boring-registry/boring-registry/version"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	flagProvenanceGitCommit string
+	flagProvenanceGitRef    string
+	flagProvenanceCIRunURL  string
+	flagProvenanceUploader  string
+)
+
+func init() {
+	for _, c := range []*cobra.Command{uploadCmd, promoteCmd, moveCmd} {
+		c.PersistentFlags().StringVar(&flagProvenanceGitCommit, "git-commit", "", "Git commit recorded in the provenance of the uploaded artifacts. Detected from the CI environment or the git repository by default")
+		c.PersistentFlags().StringVar(&flagProvenanceGitRef, "git-ref", "", "Git branch or tag recorded in the provenance of the uploaded artifacts. Detected from the CI environment or the git repository by default")
+		c.PersistentFlags().StringVar(&flagProvenanceCIRunURL, "ci-run-url", "", "URL of the CI run recorded in the provenance of the uploaded artifacts. Detected from the CI environment by default")
+		c.PersistentFlags().StringVar(&flagProvenanceUploader, "uploader", "", `Identity recorded as uploader when accessing the storage backend directly. Detected from the CI environment or the OS user by default.
+Uploads through the HTTP API always record the subject of the token instead`)
+	}
+}
+
+// provenanceContext returns a context carrying the provenance of the uploads of this invocation.
+// Flags take precedence over the environment of the common CI systems, which takes precedence over the local git repository.
+func provenanceContext(ctx context.Context) context.Context {
+	p := core.Provenance{
+		ClientVersion: version.Version,
+		GitCommit:     firstNonEmpty(flagProvenanceGitCommit, os.Getenv("GITHUB_SHA"), os.Getenv("CI_COMMIT_SHA"), os.Getenv("GIT_COMMIT")),
+		GitRef:        firstNonEmpty(flagProvenanceGitRef, os.Getenv("GITHUB_REF"), os.Getenv("CI_COMMIT_REF_NAME"), os.Getenv("GIT_BRANCH")),
+		CIRunURL:      firstNonEmpty(flagProvenanceCIRunURL, githubRunURL(), os.Getenv("CI_JOB_URL"), os.Getenv("BUILD_URL")),
+	}
+	if p.GitCommit == "" {
+		p.GitCommit = gitOutput("rev-parse", "HEAD")
+	}
+	if p.GitRef == "" {
+		p.GitRef = gitOutput("rev-parse", "--abbrev-ref", "HEAD")
+	}
+
+	uploader := firstNonEmpty(flagProvenanceUploader, os.Getenv("GITHUB_ACTOR"), os.Getenv("GITLAB_USER_LOGIN"))
+	if uploader == "" {
+		if u, err := user.Current(); err == nil {
+			uploader = u.Username
+		}
+	}
+
+	return metadata.WithUploader(core.WithProvenance(ctx, p), uploader)
+}
+
+// githubRunURL builds the URL of the GitHub Actions run, which isn't provided as a single variable
+func githubRunURL() string {
+	server, repository, run := os.Getenv("GITHUB_SERVER_URL"), os.Getenv("GITHUB_REPOSITORY"), os.Getenv("GITHUB_RUN_ID")
+	if server == "" || repository == "" || run == "" {
+		return ""
+	}
+	return server + "/" + repository + "/actions/runs/" + run
+}
+
+// gitOutput runs git in the working directory and returns an empty string if it fails, e.g. outside a repository
+func gitOutput(args ...string) string {
+	out, err := exec.Command("git", args...).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
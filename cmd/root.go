@@ -40,6 +40,28 @@ var (
 	flagAzureStorageContainer       string
 	flagAzureStoragePrefix          string
 	flagAzureStorageSignedURLExpiry time.Duration
+
+	// WebDAV options.
+	flagWebDAVURL               string
+	flagWebDAVPrefix            string
+	flagWebDAVUsername          string
+	flagWebDAVPassword          string
+	flagWebDAVToken             string
+	flagWebDAVCreateCollections bool
+	flagWebDAVSigningKey        string
+	flagWebDAVSignedURLExpiry   time.Duration
+
+	// Plugin options.
+	flagPluginCommand         string
+	flagPluginArgs            []string
+	flagPluginURL             string
+	flagPluginToken           string
+	flagPluginPrefix          string
+	flagPluginSigningKey      string
+	flagPluginSignedURLExpiry time.Duration
+
+	// Namespace options.
+	flagRequireNamespaces bool
 )
 
 var rootCmd = &cobra.Command{
@@ -86,6 +108,25 @@ For GCS presigned URLs this SA needs the iam.serviceAccountTokenCreator role.`)
 	rootCmd.PersistentFlags().StringVar(&flagAzureStorageContainer, "storage-azure-container", "", "Azure Storage Container to use for the registry")
 	rootCmd.PersistentFlags().StringVar(&flagAzureStoragePrefix, "storage-azure-prefix", "", "Azure Storage prefix to use for the registry")
 	rootCmd.PersistentFlags().DurationVar(&flagAzureStorageSignedURLExpiry, "storage-azure-signedurl-expiry", 5*time.Minute, "Generate Azure Storage signed URL valid for X seconds.")
+	rootCmd.PersistentFlags().StringVar(&flagWebDAVURL, "storage-webdav-url", "", "Base URL of the HTTP/WebDAV server to use for the registry, e.g. an Artifactory generic or Nexus raw repository")
+	rootCmd.PersistentFlags().StringVar(&flagWebDAVPrefix, "storage-webdav-prefix", "", "Prefix to use below the WebDAV base URL")
+	rootCmd.PersistentFlags().StringVar(&flagWebDAVUsername, "storage-webdav-username", "", "Username for basic authentication against the WebDAV server")
+	rootCmd.PersistentFlags().StringVar(&flagWebDAVPassword, "storage-webdav-password", "", "Password for basic authentication against the WebDAV server")
+	rootCmd.PersistentFlags().StringVar(&flagWebDAVToken, "storage-webdav-token", "", "Token for bearer authentication against the WebDAV server. Takes precedence over basic authentication")
+	rootCmd.PersistentFlags().BoolVar(&flagWebDAVCreateCollections, "storage-webdav-create-collections", false, "Create parent collections with MKCOL before uploading (required for plain WebDAV servers)")
+	rootCmd.PersistentFlags().StringVar(&flagWebDAVSigningKey, "storage-webdav-signing-key", "", `Key to sign download URLs with, which are verified by the download proxy.
+Has to be shared by all replicas. A random key is generated if empty`)
+	rootCmd.PersistentFlags().DurationVar(&flagWebDAVSignedURLExpiry, "storage-webdav-signedurl-expiry", 5*time.Minute, "Generate WebDAV signed URL valid for X seconds.")
+	rootCmd.PersistentFlags().StringVar(&flagPluginCommand, "storage-plugin-command", "", "Path to an external storage driver executable, which is launched by the registry")
+	rootCmd.PersistentFlags().StringSliceVar(&flagPluginArgs, "storage-plugin-args", []string{}, "Arguments passed to the external storage driver executable")
+	rootCmd.PersistentFlags().StringVar(&flagPluginURL, "storage-plugin-url", "", "URL of an already running external storage driver. Ignored if -storage-plugin-command is set")
+	rootCmd.PersistentFlags().StringVar(&flagPluginToken, "storage-plugin-token", "", "Token to authenticate against the external storage driver configured with -storage-plugin-url")
+	rootCmd.PersistentFlags().StringVar(&flagPluginPrefix, "storage-plugin-prefix", "", "Prefix to use for object keys in the external storage driver")
+	rootCmd.PersistentFlags().StringVar(&flagPluginSigningKey, "storage-plugin-signing-key", "", `Key to sign download URLs with, which are verified by the download proxy.
+Has to be shared by all replicas. A random key is generated if empty`)
+	rootCmd.PersistentFlags().DurationVar(&flagPluginSignedURLExpiry, "storage-plugin-signedurl-expiry", 5*time.Minute, "Generate external storage driver signed URL valid for X seconds.")
+	rootCmd.PersistentFlags().BoolVar(&flagRequireNamespaces, "require-namespaces", false, `Reject publishing modules and providers to namespaces that aren't registered, even if no namespace is registered yet.
+Unregistered namespaces are always rejected once any namespace is registered`)
 }
 
 func initializeConfig(cmd *cobra.Command) error {
@@ -13,15 +13,22 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/boring-registry/boring-registry/pkg/alias"
 	"github.com/boring-registry/boring-registry/pkg/auth"
+	"github.com/boring-registry/boring-registry/pkg/catalog"
 	"github.com/boring-registry/boring-registry/pkg/core"
 	"github.com/boring-registry/boring-registry/pkg/discovery"
+	"github.com/boring-registry/boring-registry/pkg/graph"
+	"github.com/boring-registry/boring-registry/pkg/metadata"
 	"github.com/boring-registry/boring-registry/pkg/mirror"
 	"github.com/boring-registry/boring-registry/pkg/module"
+	"github.com/boring-registry/boring-registry/pkg/namespace"
 	o11y "github.com/boring-registry/boring-registry/pkg/observability"
 	"github.com/boring-registry/boring-registry/pkg/provider"
 	"github.com/boring-registry/boring-registry/pkg/proxy"
 	"github.com/boring-registry/boring-registry/pkg/storage"
+	"github.com/boring-registry/boring-registry/pkg/storage/driver"
+	"github.com/boring-registry/boring-registry/pkg/ui"
 
 	"github.com/go-kit/kit/endpoint"
 	httptransport "github.com/go-kit/kit/transport/http"
@@ -41,6 +48,11 @@ var (
 	prefixProviders = fmt.Sprintf("%s/providers", prefix)
 	prefixMirror    = fmt.Sprintf("%s/mirror", prefix)
 	prefixProxy     = fmt.Sprintf("%s/proxy", prefix)
+	prefixMetadata  = fmt.Sprintf("%s/metadata", prefix)
+	prefixCatalog   = fmt.Sprintf("%s/namespaces", prefix)
+	prefixGraph     = fmt.Sprintf("%s/graph", prefix)
+	prefixAliases   = fmt.Sprintf("%s/aliases", prefix)
+	prefixUI        = "/ui"
 )
 
 var (
@@ -73,6 +85,32 @@ var (
 	// Provider Network Mirror
 	flagProviderNetworkMirrorEnabled            bool
 	flagProviderNetworkMirrorPullThroughEnabled bool
+
+	// Metadata store options.
+	flagMetadataStorePath       string
+	flagMetadataRefreshInterval time.Duration
+
+	// Git module options.
+	flagModuleGitConfig     string
+	flagModuleGitTagsExpiry time.Duration
+
+	// Alias options.
+	flagAliasConfig string
+	flagAliasExpiry time.Duration
+
+	// Namespace options.
+	flagNamespaceExpiry time.Duration
+
+	// Publishing options.
+	flagPublishEnabled   bool
+	flagModuleMaxSize    int64
+	flagModuleMaxFiles   int
+	flagProviderMaxSize  int64
+	flagModuleSecretScan string
+	flagModuleSkipValid  bool
+
+	// Web UI options.
+	flagUIEnabled bool
 )
 
 var serverCmd = &cobra.Command{
@@ -207,6 +245,39 @@ func init() {
 	// Provider Network Mirror options
 	serverCmd.Flags().BoolVar(&flagProviderNetworkMirrorEnabled, "network-mirror", true, "Enable the provider network mirror")
 	serverCmd.Flags().BoolVar(&flagProviderNetworkMirrorPullThroughEnabled, "network-mirror-pull-through", false, "Enable the pull-through provider network mirror. This setting takes no effect if network-mirror is disabled")
+
+	// Metadata store options
+	serverCmd.Flags().StringVar(&flagMetadataStorePath, "metadata-store-path", "", `Path to the file of the embedded metadata store, which records all uploads. Changes are appended to a journal next to it.
+The metadata store is disabled if empty`)
+	serverCmd.Flags().DurationVar(&flagMetadataRefreshInterval, "metadata-refresh-interval", 15*time.Minute, `Interval in which the metadata store is rebuilt from the storage backend to pick up uploads that didn't go through the server.
+Set to 0 to only rebuild it on startup`)
+
+	// Git module options
+	serverCmd.Flags().StringVar(&flagModuleGitConfig, "module-git-config", "", `Path to an HCL file mapping modules to directories of git repositories, whose semver tags are served as module versions without publishing them.
+Disabled if empty`)
+	serverCmd.Flags().DurationVar(&flagModuleGitTagsExpiry, "module-git-tags-expiry", time.Minute, "Duration for which the tags of the git repositories are cached before they are listed again")
+
+	// Alias options
+	serverCmd.Flags().StringVar(&flagAliasConfig, "alias-config", "", `Path to an HCL file with aliases, which redirect the old addresses of renamed namespaces, modules and providers to their new addresses.
+Aliases can be managed through the API as well`)
+	serverCmd.Flags().DurationVar(&flagAliasExpiry, "alias-expiry", time.Minute, "Duration for which the aliases managed through the API are cached before they are read from the storage backend again")
+
+	// Namespace options
+	serverCmd.Flags().DurationVar(&flagNamespaceExpiry, "namespace-expiry", time.Minute, "Duration for which the registered namespaces are cached before they are read from the storage backend again")
+
+	// Publishing options
+	serverCmd.Flags().BoolVar(&flagPublishEnabled, "publish-enabled", false, `Enable publishing modules and provider releases through the HTTP API of the registry.
+Requires authentication to be configured`)
+	serverCmd.Flags().Int64Var(&flagModuleMaxSize, "module-max-size", module.DefaultMaxArchiveSize, "Maximum size in bytes of all files of a published module archive once it's extracted")
+	serverCmd.Flags().IntVar(&flagModuleMaxFiles, "module-max-files", module.DefaultMaxArchiveFiles, "Maximum number of files of a published module archive")
+	serverCmd.Flags().Int64Var(&flagProviderMaxSize, "provider-max-size", provider.DefaultMaxReleaseSize, "Maximum size in bytes of all files of a published provider release")
+	serverCmd.Flags().StringVar(&flagModuleSecretScan, "module-secret-scan", string(module.SecretScanBlock), `Scan published modules for secrets like AWS keys, private keys, state and .tfvars files.
+Either block the upload, only warn, or turn the scan off`)
+	serverCmd.Flags().BoolVar(&flagModuleSkipValid, "module-allow-skip-validation", false, `Allow publishers to skip validating the contents of a module with the query parameter skip_validation=true.
+The archive and its limits are validated regardless`)
+
+	// Web UI options
+	serverCmd.Flags().BoolVar(&flagUIEnabled, "ui-enabled", false, "Enable the web UI for browsing the registry, which is served at /ui")
 }
 
 // TODO(oliviermichaelis): move to root, as the storage flags are defined in root?
@@ -236,11 +307,51 @@ func setupStorage(ctx context.Context) (storage.Storage, error) {
 			storage.WithAzureStorageArchiveFormat(flagModuleArchiveFormat),
 			storage.WithAzureStorageSignedUrlExpiry(flagAzureStorageSignedURLExpiry),
 		)
+	case flagWebDAVURL != "":
+		return storage.NewWebDAVStorage(flagWebDAVURL,
+			storage.WithWebDAVStoragePrefix(flagWebDAVPrefix),
+			storage.WithWebDAVStorageBasicAuth(flagWebDAVUsername, flagWebDAVPassword),
+			storage.WithWebDAVStorageBearerToken(flagWebDAVToken),
+			storage.WithWebDAVStorageCreateCollections(flagWebDAVCreateCollections),
+			storage.WithWebDAVStorageArchiveFormat(flagModuleArchiveFormat),
+			storage.WithWebDAVStorageSigningKey(flagWebDAVSigningKey),
+			storage.WithWebDAVStorageSignedUrlExpiry(flagWebDAVSignedURLExpiry),
+		)
+	case flagPluginCommand != "" || flagPluginURL != "":
+		var client *driver.Client
+		if flagPluginCommand != "" {
+			plugin, err := driver.Launch(ctx, flagPluginCommand, flagPluginArgs...)
+			if err != nil {
+				return nil, err
+			}
+			client = plugin.Client
+		} else {
+			var err error
+			client, err = driver.NewClient(flagPluginURL, driver.WithClientToken(flagPluginToken))
+			if err != nil {
+				return nil, err
+			}
+		}
+		return storage.NewPluginStorage(client,
+			storage.WithPluginStoragePrefix(flagPluginPrefix),
+			storage.WithPluginStorageArchiveFormat(flagModuleArchiveFormat),
+			storage.WithPluginStorageSigningKey(flagPluginSigningKey),
+			storage.WithPluginStorageSignedUrlExpiry(flagPluginSignedURLExpiry),
+		)
 	default:
 		return nil, errors.New("storage provider is not specified")
 	}
 }
 
+// setupPublishStorage returns the storage backend for commands publishing to it, which checks publishing against the registered namespaces
+func setupPublishStorage(ctx context.Context) (*storage.NamespaceStorage, error) {
+	s, err := setupStorage(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return storage.NewNamespaceStorage(s, storage.WithNamespacesRequired(flagRequireNamespaces)), nil
+}
+
 func serveMux(ctx context.Context) (*http.ServeMux, error) {
 	mux := http.NewServeMux()
 
@@ -297,8 +408,67 @@ func serveMux(ctx context.Context) (*http.ServeMux, error) {
 		return nil, err
 	}
 
+	if _, ok := s.(*storage.PluginStorage); ok && !flagProxy {
+		return nil, errors.New("the external storage driver is only reachable through the download proxy, enable it with -download-proxy")
+	}
+
+	if flagPublishEnabled && flagAuthStaticTokens == nil && flagAuthOktaIssuer == "" {
+		return nil, errors.New("publishing requires authentication, configure -auth-static-token or -auth-okta-issuer")
+	}
+
+	if flagMetadataStorePath != "" {
+		store, err := metadata.NewFileStore(flagMetadataStorePath)
+		if err != nil {
+			return nil, err
+		}
+
+		metadataStorage, err := storage.NewMetadataStorage(s, store)
+		if err != nil {
+			return nil, err
+		}
+		go metadataStorage.Sync(ctx, flagMetadataRefreshInterval)
+		s = metadataStorage
+
+		if err := registerMetadata(mux, store, instrumentation); err != nil {
+			return nil, err
+		}
+	}
+
+	if flagModuleGitConfig != "" {
+		modules, err := storage.ParseGitModules(flagModuleGitConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse git module config: %w", err)
+		}
+
+		s, err = storage.NewGitStorage(s, modules, storage.WithGitStorageTagsExpiry(flagModuleGitTagsExpiry))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	s = storage.NewNamespaceStorage(s,
+		storage.WithNamespacesRequired(flagRequireNamespaces),
+		storage.WithNamespaceStorageExpiry(flagNamespaceExpiry),
+	)
+
+	var aliases []alias.Alias
+	if flagAliasConfig != "" {
+		aliases, err = storage.ParseAliases(flagAliasConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse alias config: %w", err)
+		}
+	}
+	s, err = storage.NewAliasStorage(s, aliases, storage.WithAliasStorageExpiry(flagAliasExpiry))
+	if err != nil {
+		return nil, err
+	}
+
 	proxyUrlService := core.NewProxyUrlService(flagProxy, prefixProxy)
 
+	if err := registerAlias(mux, s, instrumentation); err != nil {
+		return nil, err
+	}
+
 	if err := registerModule(mux, s, metrics.Module, instrumentation, proxyUrlService); err != nil {
 		return nil, err
 	}
@@ -307,6 +477,20 @@ func serveMux(ctx context.Context) (*http.ServeMux, error) {
 		return nil, err
 	}
 
+	if err := registerCatalog(mux, s, instrumentation); err != nil {
+		return nil, err
+	}
+
+	if err := registerGraph(mux, s, instrumentation); err != nil {
+		return nil, err
+	}
+
+	if flagUIEnabled {
+		if err := registerUI(mux, s, instrumentation, proxyUrlService); err != nil {
+			return nil, err
+		}
+	}
+
 	if flagProxy {
 		if err := registerProxy(mux, s, metrics.Proxy, instrumentation); err != nil {
 			return nil, err
@@ -345,7 +529,20 @@ func registerMetrics(mux *http.ServeMux) {
 }
 
 func registerModule(mux *http.ServeMux, s storage.Storage, metrics *o11y.ModuleMetrics, instrumentation o11y.Middleware, proxyUrlService core.ProxyUrlService) error {
-	service := module.NewService(s, proxyUrlService)
+	secretScan, err := module.ParseSecretScanMode(flagModuleSecretScan)
+	if err != nil {
+		return err
+	}
+
+	service := module.NewService(s, proxyUrlService,
+		module.WithPublishEnabled(flagPublishEnabled),
+		module.WithSkipValidationAllowed(flagModuleSkipValid),
+		module.WithValidation(
+			module.WithMaxArchiveSize(flagModuleMaxSize),
+			module.WithMaxArchiveFiles(flagModuleMaxFiles),
+			module.WithSecretScan(secretScan),
+		),
+	)
 	{
 		service = module.LoggingMiddleware()(service)
 	}
@@ -389,7 +586,10 @@ func authMiddleware() endpoint.Middleware {
 }
 
 func registerProvider(mux *http.ServeMux, s storage.Storage, metrics *o11y.ProviderMetrics, instrumentation o11y.Middleware, proxyUrlService core.ProxyUrlService) error {
-	service := provider.NewService(s, proxyUrlService)
+	service := provider.NewService(s, proxyUrlService,
+		provider.WithPublishEnabled(flagPublishEnabled),
+		provider.WithMaxReleaseSize(flagProviderMaxSize),
+	)
 	{
 		service = provider.LoggingMiddleware()(service)
 	}
@@ -445,6 +645,150 @@ func registerMirror(mux *http.ServeMux, s storage.Storage, svc mirror.Service, m
 	return nil
 }
 
+func registerMetadata(mux *http.ServeMux, store metadata.Store, instrumentation o11y.Middleware) error {
+	opts := []httptransport.ServerOption{
+		httptransport.ServerErrorEncoder(metadata.ErrorEncoder),
+		httptransport.ServerBefore(
+			httptransport.PopulateRequestContext,
+		),
+	}
+
+	mux.Handle(
+		fmt.Sprintf(`%s/`, prefixMetadata),
+		http.StripPrefix(
+			prefixMetadata,
+			metadata.MakeHandler(
+				metadata.NewService(store),
+				authMiddleware(),
+				instrumentation,
+				opts...,
+			),
+		),
+	)
+
+	return nil
+}
+
+func registerCatalog(mux *http.ServeMux, s storage.Storage, instrumentation o11y.Middleware) error {
+	opts := []httptransport.ServerOption{
+		httptransport.ServerErrorEncoder(catalog.ErrorEncoder),
+		httptransport.ServerBefore(
+			httptransport.PopulateRequestContext,
+		),
+	}
+
+	catalogHandler := catalog.MakeHandler(
+		catalog.NewService(s),
+		authMiddleware(),
+		instrumentation,
+		opts...,
+	)
+	namespaceHandler := namespace.MakeHandler(
+		namespace.NewService(s, namespace.WithManageEnabled(flagPublishEnabled)),
+		authMiddleware(),
+		instrumentation,
+		httptransport.ServerErrorEncoder(namespace.ErrorEncoder),
+		httptransport.ServerBefore(
+			httptransport.PopulateRequestContext,
+		),
+	)
+
+	// The catalog lists the namespaces, whereas single namespaces are managed through the namespace registry
+	mux.Handle(
+		fmt.Sprintf(`%s/`, prefixCatalog),
+		http.StripPrefix(
+			prefixCatalog,
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodGet && r.URL.Path == "/" {
+					catalogHandler.ServeHTTP(w, r)
+					return
+				}
+				namespaceHandler.ServeHTTP(w, r)
+			}),
+		),
+	)
+
+	return nil
+}
+
+func registerGraph(mux *http.ServeMux, s storage.Storage, instrumentation o11y.Middleware) error {
+	opts := []httptransport.ServerOption{
+		httptransport.ServerErrorEncoder(graph.ErrorEncoder),
+		httptransport.ServerBefore(
+			httptransport.PopulateRequestContext,
+		),
+	}
+
+	mux.Handle(
+		fmt.Sprintf(`%s/`, prefixGraph),
+		http.StripPrefix(
+			prefixGraph,
+			graph.MakeHandler(
+				graph.NewService(s),
+				authMiddleware(),
+				instrumentation,
+				opts...,
+			),
+		),
+	)
+
+	return nil
+}
+
+func registerAlias(mux *http.ServeMux, s storage.Storage, instrumentation o11y.Middleware) error {
+	opts := []httptransport.ServerOption{
+		httptransport.ServerErrorEncoder(alias.ErrorEncoder),
+		httptransport.ServerBefore(
+			httptransport.PopulateRequestContext,
+		),
+	}
+
+	mux.Handle(
+		fmt.Sprintf(`%s/`, prefixAliases),
+		http.StripPrefix(
+			prefixAliases,
+			alias.MakeHandler(
+				alias.NewService(s, alias.WithManageEnabled(flagPublishEnabled)),
+				authMiddleware(),
+				instrumentation,
+				opts...,
+			),
+		),
+	)
+
+	return nil
+}
+
+func registerUI(mux *http.ServeMux, s storage.Storage, instrumentation o11y.Middleware, proxyUrlService core.ProxyUrlService) error {
+	service := ui.NewService(
+		module.LoggingMiddleware()(module.NewService(s, proxyUrlService)),
+		provider.LoggingMiddleware()(provider.NewService(s, proxyUrlService)),
+		catalog.NewService(s),
+	)
+
+	opts := []httptransport.ServerOption{
+		httptransport.ServerErrorEncoder(ui.ErrorEncoder),
+		httptransport.ServerBefore(
+			httptransport.PopulateRequestContext,
+		),
+	}
+
+	mux.Handle(
+		fmt.Sprintf(`%s/`, prefixUI),
+		http.StripPrefix(
+			prefixUI,
+			ui.MakeHandler(
+				service,
+				authMiddleware(),
+				instrumentation,
+				opts...,
+			),
+		),
+	)
+
+	return nil
+}
+
 func registerProxy(mux *http.ServeMux, storage storage.Storage, metrics *o11y.ProxyMetrics, instrumentation o11y.Middleware) error {
 	opts := []httptransport.ServerOption{
 		httptransport.ServerErrorEncoder(proxy.ErrorEncoder),
@@ -1,17 +1,19 @@
 package cmd
 
 import (
-	"bytes"
 	"context"
 	"errors"
 	"fmt"
-	"log/slog"
+	"io"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"regexp"
+	"syscall"
 	"time"
 
 	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/module"
 	"github.com/boring-registry/boring-registry/pkg/provider"
 
 	"github.com/hashicorp/go-version"
@@ -28,6 +30,13 @@ var (
 	flagIgnoreExistingModule     bool
 	flagVersionConstraintsRegex  string
 	flagVersionConstraintsSemver string
+	flagRegistryURL              string
+	flagRegistryToken            string
+	flagSkipValidation           bool
+	flagSecretScan               string
+	flagUploadMaxSize            int64
+	flagUploadMaxFiles           int
+	flagDereferenceSymlinks      bool
 
 	// upload provider flags
 	flagFileSha256Sums       string
@@ -60,6 +69,22 @@ Can be combined with the -version-constraints-semver flag`)
 	uploadCmd.PersistentFlags().StringVar(&flagVersionConstraintsSemver, "version-constraints-semver", "", `Limit the module versions that are eligible for upload with version constraints.
 The version string has to be formatted as a string literal containing one or more conditions, which are separated by commas.
 Can be combined with the -version-constrained-regex flag`)
+	for _, c := range []*cobra.Command{uploadCmd, uploadModuleCmd} {
+		c.Flags().StringVar(&flagRegistryURL, "registry-url", "", `Publish modules through the HTTP API of the boring-registry at this URL instead of accessing the storage backend.
+Publishing has to be enabled on the server with -publish-enabled`)
+		c.Flags().StringVar(&flagRegistryToken, "registry-token", "", "Token to authenticate against the boring-registry configured with -registry-url")
+		c.Flags().BoolVar(&flagSkipValidation, "skip-validation", false, `Skip validating the Terraform files of the modules before uploading them.
+With -registry-url, the registry is asked to skip the validation as well, which requires -module-allow-skip-validation on the server.
+The registry still validates the archive and its limits`)
+		c.Flags().StringVar(&flagSecretScan, "secret-scan", string(module.SecretScanBlock), `Scan the modules for secrets like AWS keys, private keys, state and .tfvars files before uploading them.
+Either block the upload, only warn, or turn the scan off. False positives are listed in a .secrets-allowlist file in the module root`)
+		c.Flags().Int64Var(&flagUploadMaxSize, "module-max-size", module.DefaultMaxArchiveSize, `Maximum size in bytes of all files of a module archive once it's extracted.
+With -registry-url, the limit of the registry applies as well`)
+		c.Flags().IntVar(&flagUploadMaxFiles, "module-max-files", module.DefaultMaxArchiveFiles, `Maximum number of files of a module archive.
+With -registry-url, the limit of the registry applies as well`)
+		c.Flags().BoolVar(&flagDereferenceSymlinks, "dereference-symlinks", false, `Replace symlinks to files outside of the module directory by the content of the file.
+Otherwise, symlinks pointing outside of the module directory fail the upload`)
+	}
 }
 
 // uploadCmd uploads modules for legacy reasons.
@@ -73,7 +98,10 @@ var uploadCmd = &cobra.Command{
 }
 
 var uploadModuleCmd = &cobra.Command{
-	Use:          "module MODULE",
+	Use: "module MODULE",
+	Long: `Upload the modules in the directory MODULE.
+With --git, the modules are uploaded from the tags of a git repository, and MODULE is an optional directory within the repository.
+With --archive, a prebuilt module archive is uploaded, and MODULE is omitted`,
 	SilenceUsage: true,
 	RunE:         uploadModule,
 }
@@ -85,19 +113,32 @@ var uploadProviderCmd = &cobra.Command{
 }
 
 func uploadModule(cmd *cobra.Command, args []string) error {
-	storageBackend, err := setupStorage(context.Background())
-	if err != nil {
-		return fmt.Errorf("failed to set up storage: %w", err)
+	if flagModuleArchive == "" && (flagModuleNamespace != "" || flagModuleName != "" || flagModuleProvider != "" || flagModuleVersion != "") {
+		return errors.New("-namespace, -name, -provider and -version can only be used with -archive")
 	}
-
-	if len(args) == 0 {
-		return fmt.Errorf("missing argument")
+	if flagModuleArchive != "" && flagGitRepository != "" {
+		return errors.New("-archive and -git can't be combined")
+	}
+	if err := validateBatchFlags(); err != nil {
+		return err
 	}
 
-	if _, err := os.Stat(args[0]); errors.Is(err, os.ErrNotExist) {
+	uploader, err := setupModuleUploader(context.Background())
+	if err != nil {
 		return err
 	}
 
+	// The argument is an optional directory within the repository in git mode, and there is none for prebuilt archives
+	if flagGitRepository == "" && flagModuleArchive == "" {
+		if len(args) == 0 {
+			return fmt.Errorf("missing argument")
+		}
+
+		if _, err := os.Stat(args[0]); errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+
 	// Validate the semver version constraints
 	if flagVersionConstraintsSemver != "" {
 		constraints, err := version.NewConstraint(flagVersionConstraintsSemver)
@@ -116,58 +157,57 @@ func uploadModule(cmd *cobra.Command, args []string) error {
 		versionConstraintsRegex = constraints
 	}
 
-	return archiveModules(args[0], storageBackend)
-}
+	// Interrupting the command cancels the running uploads, while the remaining modules are reported as not processed
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
 
-func uploadProvider(cmd *cobra.Command, args []string) error {
-	if !filepath.IsAbs(flagFileSha256Sums) {
-		return fmt.Errorf("file path is not absolute: %s", flagFileSha256Sums)
+	ctx = provenanceContext(ctx)
+	if flagSkipValidation {
+		ctx = module.WithSkipValidation(ctx)
 	}
-
-	f, err := os.Open(flagFileSha256Sums)
-	if err != nil {
-		return fmt.Errorf("failed to open file at path %s: %w", flagFileSha256Sums, err)
+	if flagModuleArchive != "" {
+		return uploadModuleArchive(ctx, flagModuleArchive, uploader)
 	}
-	defer f.Close()
-
-	sums, err := core.NewSha256Sums(filepath.Base(flagFileSha256Sums), f)
-	if err != nil {
-		return err
+	if flagGitRepository != "" {
+		dir := ""
+		if len(args) > 0 {
+			dir = args[0]
+		}
+		return uploadModulesFromGit(ctx, flagGitRepository, dir, uploader)
 	}
+	return archiveModules(ctx, args[0], uploader)
+}
 
-	if err := validateShaSums(sums); err != nil {
-		return err
+// setupModuleUploader returns a client for the HTTP API of the registry if -registry-url is set, and the storage backend otherwise
+func setupModuleUploader(ctx context.Context) (moduleUploader, error) {
+	if flagRegistryURL != "" {
+		client, err := module.NewClient(ctx, flagRegistryURL, module.WithClientToken(flagRegistryToken))
+		if err != nil {
+			return nil, fmt.Errorf("failed to set up registry client: %w", err)
+		}
+		return client, nil
 	}
 
-	ctx := context.Background()
-	setupCtx, cancelSetupCtx := context.WithTimeout(ctx, 15*time.Second)
-	defer cancelSetupCtx()
-	storageBackend, err := setupStorage(setupCtx)
+	storageBackend, err := setupPublishStorage(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to set up storage: %w", err)
+		return nil, fmt.Errorf("failed to set up storage: %w", err)
 	}
+	return storageBackend, nil
+}
 
-	validateCtx, cancelValidateCtx := context.WithTimeout(ctx, 15*time.Second)
-	defer cancelValidateCtx()
-	signingKeys, err := storageBackend.SigningKeys(validateCtx, flagProviderNamespace)
-	if err != nil {
-		return err
+func uploadProvider(cmd *cobra.Command, args []string) error {
+	if !filepath.IsAbs(flagFileSha256Sums) {
+		return fmt.Errorf("file path is not absolute: %s", flagFileSha256Sums)
 	}
 
-	sumsBytes, err := os.ReadFile(flagFileSha256Sums)
+	f, err := os.Open(flagFileSha256Sums)
 	if err != nil {
-		return fmt.Errorf("failed to read file at path %s: %w", flagFileSha256Sums, err)
+		return fmt.Errorf("failed to open file at path %s: %w", flagFileSha256Sums, err)
 	}
+	defer f.Close()
 
-	// We expect the signature to be suffixed with the .sig extension
-	// https://developer.hashicorp.com/terraform/registry/providers/publishing#manually-preparing-a-release
-	p := fmt.Sprintf("%s.sig", flagFileSha256Sums)
-	sumsSigBytes, err := os.ReadFile(p)
+	sums, err := core.NewSha256Sums(filepath.Base(flagFileSha256Sums), f)
 	if err != nil {
-		return fmt.Errorf("failed to read file at path %s: %w", p, err)
-	}
-
-	if err := signingKeys.IsValidSha256Sums(sumsBytes, sumsSigBytes); err != nil {
 		return err
 	}
 
@@ -176,105 +216,61 @@ func uploadProvider(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to parse provider name: %v", err)
 	}
 
-	// Upload provider binary .zip archives
-	if len(flagProviderArchivePaths) > 0 {
-		for _, archivePath := range flagProviderArchivePaths {
-			if err := uploadProviderReleaseFile(ctx, storageBackend, archivePath, flagProviderNamespace, providerName); err != nil {
-				return err
-			}
-			slog.Info("successfully published provider binary", slog.String("name", filepath.Base(archivePath)))
-		}
-	} else {
-		baseDir := filepath.Dir(flagFileSha256Sums)
-		for fileName := range sums.Entries {
-			archivePath := filepath.Join(baseDir, fileName)
-			if err := uploadProviderReleaseFile(ctx, storageBackend, archivePath, flagProviderNamespace, providerName); err != nil {
-				return err
-			}
-			slog.Info("successfully published provider binary", slog.String("name", fileName))
-		}
-	}
-
-	// Upload *_SHA256SUMS file
-	if err = uploadProviderReleaseFile(ctx, storageBackend, flagFileSha256Sums, flagProviderNamespace, providerName); err != nil {
-		return err
+	providerVersion, err := sums.Version()
+	if err != nil {
+		return fmt.Errorf("failed to parse provider version: %v", err)
 	}
-	slog.Info("successfully published provider SHA256SUMS file", slog.String("name", filepath.Base(flagFileSha256Sums)))
 
-	// Upload *_SHA256SUMS.sig file
-	signaturePath := fmt.Sprintf("%s.sig", flagFileSha256Sums)
-	if err = uploadProviderReleaseFile(ctx, storageBackend, signaturePath, flagProviderNamespace, providerName); err != nil {
-		return err
+	// We expect the signature to be suffixed with the .sig extension
+	// https://developer.hashicorp.com/terraform/registry/providers/publishing#manually-preparing-a-release
+	release := &provider.Release{
+		Namespace:  flagProviderNamespace,
+		Name:       providerName,
+		Version:    providerVersion,
+		SHA256Sums: localReleaseFile(flagFileSha256Sums),
+		Signature:  localReleaseFile(fmt.Sprintf("%s.sig", flagFileSha256Sums)),
 	}
-	slog.Info("successfully published provider SHA256SUMS.sig file", slog.String("name", filepath.Base(signaturePath)))
 
-	return nil
-}
-
-func validateShaSums(sums *core.Sha256Sums) error {
 	// Check whether the user has given archive paths to upload on the command line as flags.
 	// If not, we try to determine the locations of the provider zip archives based on the path of the *_SHA256SUMS file and the filenames in that file
-	if len(flagProviderArchivePaths) != 0 {
-		if len(sums.Entries) != len(flagProviderArchivePaths) {
-			return fmt.Errorf("the number of provided archive paths doesn't match the number of entries in %s", flagFileSha256Sums)
-		}
-
+	if len(flagProviderArchivePaths) > 0 {
 		for _, archivePath := range flagProviderArchivePaths {
-			fileName := filepath.Base(archivePath)
-			checksum, exists := sums.Entries[fileName]
-			if !exists {
-				return fmt.Errorf("checksum for file %s is missing", fileName)
-			}
-			if err := validateShaSumsEntry(archivePath, checksum); err != nil {
-				return fmt.Errorf("failed to validate checksum for file %s", fileName)
-			}
+			release.Files = append(release.Files, localReleaseFile(archivePath))
 		}
 	} else {
 		baseDir := filepath.Dir(flagFileSha256Sums)
-		for fileName, checksum := range sums.Entries {
-			if err := validateShaSumsEntry(filepath.Join(baseDir, fileName), checksum); err != nil {
-				return fmt.Errorf("failed to validate checksum for file %s", fileName)
-			}
+		for fileName := range sums.Entries {
+			release.Files = append(release.Files, localReleaseFile(filepath.Join(baseDir, fileName)))
 		}
 	}
 
-	return nil
-}
-
-func validateShaSumsEntry(path string, checksum []byte) error {
-	binaryName := filepath.Base(path)
-	if !regexp.MustCompile("^terraform-provider-.+_.+_.+.(zip|json)$").MatchString(binaryName) {
-		return fmt.Errorf("provider binary %s file name is invalid", binaryName)
-	}
-
-	f, err := os.Open(path)
+	ctx := provenanceContext(context.Background())
+	setupCtx, cancelSetupCtx := context.WithTimeout(ctx, 15*time.Second)
+	defer cancelSetupCtx()
+	storageBackend, err := setupPublishStorage(setupCtx)
 	if err != nil {
-		return fmt.Errorf("failed to open provided archive file: %s", path)
+		return fmt.Errorf("failed to set up storage: %w", err)
 	}
-	defer f.Close()
 
-	c, err := core.Sha256Checksum(f)
+	validateCtx, cancelValidateCtx := context.WithTimeout(ctx, 15*time.Second)
+	defer cancelValidateCtx()
+	signingKeys, err := storageBackend.SigningKeys(validateCtx, flagProviderNamespace)
 	if err != nil {
 		return err
 	}
 
-	if !bytes.Equal(checksum, c) {
-		return fmt.Errorf("checksums don't match")
+	if err := provider.ValidateRelease(release, signingKeys); err != nil {
+		return err
 	}
 
-	return nil
+	return provider.UploadRelease(ctx, storageBackend, release)
 }
 
-func uploadProviderReleaseFile(ctx context.Context, storage provider.Storage, path, namespace, name string) error {
-	archiveFile, err := os.Open(path)
-	if err != nil {
-		return err
+func localReleaseFile(path string) provider.ReleaseFile {
+	return provider.ReleaseFile{
+		Name: filepath.Base(path),
+		Open: func() (io.ReadCloser, error) {
+			return os.Open(path)
+		},
 	}
-	defer archiveFile.Close()
-
-	uploadCtx, uploadCtxCancel := context.WithTimeout(ctx, 120*time.Second)
-	defer uploadCtxCancel()
-
-	fileName := filepath.Base(path)
-	return storage.UploadProviderReleaseFiles(uploadCtx, namespace, name, fileName, archiveFile)
 }
@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(verifyCmd)
+	verifyCmd.AddCommand(verifyModuleCmd, verifyProviderCmd)
+}
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Verify published artifacts against their provenance",
+	Long: `Verify published artifacts against the provenance recorded when they were uploaded.
+The provenance is printed as JSON, so that a bad release can be traced back to the pipeline that published it`,
+}
+
+var verifyModuleCmd = &cobra.Command{
+	Use:          "module NAMESPACE/NAME/PROVIDER VERSION",
+	Short:        "Verify the checksum of a module archive and print its provenance",
+	Args:         cobra.ExactArgs(2),
+	SilenceUsage: true,
+	RunE:         verifyModule,
+}
+
+var verifyProviderCmd = &cobra.Command{
+	Use:          "provider NAMESPACE/NAME VERSION",
+	Short:        "Verify the checksums of a provider release and print its provenance",
+	Args:         cobra.ExactArgs(2),
+	SilenceUsage: true,
+	RunE:         verifyProvider,
+}
+
+func verifyModule(cmd *cobra.Command, args []string) error {
+	parts := strings.Split(args[0], "/")
+	if len(parts) != 3 {
+		return fmt.Errorf("module has to be formatted as NAMESPACE/NAME/PROVIDER, but was %s", args[0])
+	}
+	namespace, name, provider, version := parts[0], parts[1], parts[2], args[1]
+
+	ctx := context.Background()
+	storageBackend, err := setupStorage(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to set up storage: %w", err)
+	}
+
+	p, err := storageBackend.GetModuleProvenance(ctx, namespace, name, provider, version)
+	if err != nil {
+		return fmt.Errorf("failed to get provenance of module %s %s: %w", args[0], version, err)
+	}
+
+	archive, err := storageBackend.DownloadModule(ctx, namespace, name, provider, version)
+	if err != nil {
+		return fmt.Errorf("failed to download module %s %s: %w", args[0], version, err)
+	}
+	defer archive.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, archive); err != nil {
+		return fmt.Errorf("failed to download module: %w", err)
+	}
+
+	if err := printProvenance(p); err != nil {
+		return err
+	}
+	if sum := hex.EncodeToString(h.Sum(nil)); sum != p.SHA256 {
+		return fmt.Errorf("%w: module archive has checksum %s, but %s was recorded at upload", core.ErrChecksumMismatch, sum, p.SHA256)
+	}
+
+	slog.Info("module archive matches its provenance", slog.String("module", args[0]), slog.String("version", version))
+	return nil
+}
+
+// verifyProvider compares the checksums recorded at upload with the SHA256SUMS file of the release
+func verifyProvider(cmd *cobra.Command, args []string) error {
+	parts := strings.Split(args[0], "/")
+	if len(parts) != 2 {
+		return fmt.Errorf("provider has to be formatted as NAMESPACE/NAME, but was %s", args[0])
+	}
+	namespace, name, version := parts[0], parts[1], args[1]
+
+	ctx := context.Background()
+	storageBackend, err := setupStorage(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to set up storage: %w", err)
+	}
+
+	provenance, err := storageBackend.GetProviderProvenance(ctx, namespace, name, version)
+	if err != nil {
+		return fmt.Errorf("failed to get provenance of provider %s %s: %w", args[0], version, err)
+	}
+
+	var errs []error
+	for _, p := range provenance {
+		if err := printProvenance(&p); err != nil {
+			return err
+		}
+
+		archive, err := core.NewProviderFromArchive(p.Artifact)
+		if err != nil {
+			// Only the archives are listed in the SHA256SUMS file
+			continue
+		}
+
+		res, err := storageBackend.GetProvider(ctx, namespace, name, version, archive.OS, archive.Arch)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to get provider %s: %w", p.Artifact, err))
+		} else if res.Shasum != p.SHA256 {
+			errs = append(errs, fmt.Errorf("%w: %s is listed with checksum %s, but %s was recorded at upload", core.ErrChecksumMismatch, p.Artifact, res.Shasum, p.SHA256))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	slog.Info("provider release matches its provenance", slog.String("provider", args[0]), slog.String("version", version))
+	return nil
+}
+
+func printProvenance(p *core.Provenance) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(p)
+}
@@ -0,0 +1,157 @@
+// Package alias redirects the addresses of renamed namespaces, modules and providers to their new addresses,
+// so that the sources of existing Terraform configurations keep working after a rename.
+package alias
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+)
+
+const (
+	TypeNamespace = "namespace"
+	TypeModule    = "module"
+	TypeProvider  = "provider"
+)
+
+// maxHops limits how many aliases are followed, e.g. when a renamed module is renamed again
+const maxHops = 8
+
+// Alias redirects the address From to the address To.
+// Addresses are a namespace, NAMESPACE/NAME/PROVIDER for modules or NAMESPACE/NAME for providers.
+// Namespace aliases redirect all modules and providers of the namespace.
+type Alias struct {
+	Type string `json:"type" hcl:"type,label"`
+	From string `json:"from" hcl:"from,label"`
+	To   string `json:"to" hcl:"to"`
+
+	// Deprecation is returned as warning to clients using the old address, which isn't deprecated if it's empty
+	Deprecation string `json:"deprecation,omitempty" hcl:"deprecation,optional"`
+}
+
+// ID identifies the alias, as there is at most one alias per type and address
+func (a Alias) ID() string {
+	return a.Type + "/" + a.From
+}
+
+// Validate checks that the alias redirects an address of its type to a different address of the same type.
+// Providers can only be moved to another namespace, as the name of a provider is part of its archives and signed checksums.
+func (a Alias) Validate() error {
+	segments := map[string]int{
+		TypeNamespace: 1,
+		TypeModule:    3,
+		TypeProvider:  2,
+	}
+	n, ok := segments[a.Type]
+	if !ok {
+		return fmt.Errorf("%w: type has to be one of %s, %s or %s, but was %q", ErrInvalidAlias, TypeNamespace, TypeModule, TypeProvider, a.Type)
+	}
+
+	from, to := strings.Split(a.From, "/"), strings.Split(a.To, "/")
+	for _, address := range [][]string{from, to} {
+		if len(address) != n {
+			return fmt.Errorf("%w: %s addresses have %d segments, but %s redirects to %s", ErrInvalidAlias, a.Type, n, a.From, a.To)
+		}
+		for _, segment := range address {
+			if segment == "" || strings.TrimSpace(segment) != segment {
+				return fmt.Errorf("%w: %s redirects to %s, which has an empty segment", ErrInvalidAlias, a.From, a.To)
+			}
+		}
+	}
+
+	if a.From == a.To {
+		return fmt.Errorf("%w: %s redirects to itself", ErrInvalidAlias, a.From)
+	} else if a.Type == TypeProvider && from[1] != to[1] {
+		return fmt.Errorf("%w: providers can only be moved to another namespace, but %s redirects to %s", ErrInvalidAlias, a.From, a.To)
+	}
+	return nil
+}
+
+// Aliases resolves addresses with a list of aliases
+type Aliases []Alias
+
+// lookup returns the alias of the type and address
+func (aliases Aliases) lookup(aliasType, from string) (Alias, bool) {
+	for _, a := range aliases {
+		if a.Type == aliasType && a.From == from {
+			return a, true
+		}
+	}
+	return Alias{}, false
+}
+
+// ResolveModule returns the module the aliases redirect the module to, together with the alias of the requested module.
+// A module alias takes precedence over the alias of its namespace, and the module is returned as is if there is none.
+func (aliases Aliases) ResolveModule(m core.Module) (core.Module, *Alias) {
+	var first *Alias
+	for i := 0; i < maxHops; i++ {
+		a, ok := aliases.lookup(TypeModule, m.ID(false))
+		if ok {
+			parts := strings.Split(a.To, "/")
+			m.Namespace, m.Name, m.Provider = parts[0], parts[1], parts[2]
+		} else if a, ok = aliases.lookup(TypeNamespace, m.Namespace); ok {
+			m.Namespace = a.To
+		} else {
+			break
+		}
+
+		if first == nil {
+			first = &a
+		}
+	}
+	return m, first
+}
+
+// ResolveProvider returns the namespace and name the aliases redirect the provider to, together with the alias of the requested provider.
+// A provider alias takes precedence over the alias of its namespace.
+func (aliases Aliases) ResolveProvider(namespace, name string) (string, string, *Alias) {
+	var first *Alias
+	for i := 0; i < maxHops; i++ {
+		a, ok := aliases.lookup(TypeProvider, namespace+"/"+name)
+		if ok {
+			namespace, name, _ = strings.Cut(a.To, "/")
+		} else if a, ok = aliases.lookup(TypeNamespace, namespace); ok {
+			namespace = a.To
+		} else {
+			break
+		}
+
+		if first == nil {
+			first = &a
+		}
+	}
+	return namespace, name, first
+}
+
+// Check fails if the aliases redirect an address in a cycle, or through more aliases than are followed
+func (aliases Aliases) Check() error {
+	for _, a := range aliases {
+		if aliases.redirected(a) {
+			return fmt.Errorf("%w: %s %s is redirected in a cycle or through more than %d aliases", ErrInvalidAlias, a.Type, a.From, maxHops)
+		}
+	}
+	return nil
+}
+
+// redirected returns whether the address of the alias is still redirected once the aliases were followed
+func (aliases Aliases) redirected(a Alias) bool {
+	switch a.Type {
+	case TypeModule:
+		parts := strings.Split(a.From, "/")
+		m, _ := aliases.ResolveModule(core.Module{Namespace: parts[0], Name: parts[1], Provider: parts[2]})
+		_, ok := aliases.lookup(TypeModule, m.ID(false))
+		_, nsOk := aliases.lookup(TypeNamespace, m.Namespace)
+		return ok || nsOk
+	case TypeProvider:
+		namespace, name, _ := strings.Cut(a.From, "/")
+		namespace, name, _ = aliases.ResolveProvider(namespace, name)
+		_, ok := aliases.lookup(TypeProvider, namespace+"/"+name)
+		_, nsOk := aliases.lookup(TypeNamespace, namespace)
+		return ok || nsOk
+	default:
+		namespace, _, _ := aliases.ResolveProvider(a.From, "")
+		_, ok := aliases.lookup(TypeNamespace, namespace)
+		return ok
+	}
+}
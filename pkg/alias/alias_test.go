@@ -0,0 +1,169 @@
+package alias
+
+import (
+	"testing"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAlias_Validate(t *testing.T) {
+	t.Parallel()
+
+	tc := []struct {
+		name  string
+		alias Alias
+		valid bool
+	}{
+		{
+			name:  "namespace",
+			alias: Alias{Type: TypeNamespace, From: "netwrok", To: "network"},
+			valid: true,
+		},
+		{
+			name:  "module",
+			alias: Alias{Type: TypeModule, From: "old/vpc/aws", To: "new/network/aws"},
+			valid: true,
+		},
+		{
+			name:  "provider to another namespace",
+			alias: Alias{Type: TypeProvider, From: "old/dummy", To: "new/dummy"},
+			valid: true,
+		},
+		{
+			name:  "provider renamed",
+			alias: Alias{Type: TypeProvider, From: "old/dummy", To: "old/other"},
+		},
+		{
+			name:  "unknown type",
+			alias: Alias{Type: "repository", From: "old", To: "new"},
+		},
+		{
+			name:  "module with too few segments",
+			alias: Alias{Type: TypeModule, From: "old/vpc", To: "new/vpc"},
+		},
+		{
+			name:  "empty segment",
+			alias: Alias{Type: TypeModule, From: "old/vpc/aws", To: "new//aws"},
+		},
+		{
+			name:  "itself",
+			alias: Alias{Type: TypeNamespace, From: "old", To: "old"},
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			err := c.alias.Validate()
+			if c.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrInvalidAlias)
+			}
+		})
+	}
+}
+
+func TestAliases_ResolveModule(t *testing.T) {
+	t.Parallel()
+
+	aliases := Aliases{
+		{Type: TypeNamespace, From: "netwrok", To: "network"},
+		{Type: TypeModule, From: "netwrok/vpc/aws", To: "platform/vpc/aws", Deprecation: "Use platform/vpc/aws"},
+		{Type: TypeModule, From: "old/subnet/aws", To: "netwrok/subnet/aws"},
+	}
+
+	tc := []struct {
+		name     string
+		module   core.Module
+		expected string
+		alias    string
+	}{
+		{
+			name:     "namespace alias",
+			module:   core.Module{Namespace: "netwrok", Name: "dns", Provider: "aws"},
+			expected: "network/dns/aws",
+			alias:    "namespace/netwrok",
+		},
+		{
+			name:     "module alias takes precedence",
+			module:   core.Module{Namespace: "netwrok", Name: "vpc", Provider: "aws"},
+			expected: "platform/vpc/aws",
+			alias:    "module/netwrok/vpc/aws",
+		},
+		{
+			name:     "chain of aliases",
+			module:   core.Module{Namespace: "old", Name: "subnet", Provider: "aws"},
+			expected: "network/subnet/aws",
+			alias:    "module/old/subnet/aws",
+		},
+		{
+			name:     "no alias",
+			module:   core.Module{Namespace: "network", Name: "vpc", Provider: "aws"},
+			expected: "network/vpc/aws",
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			m, a := aliases.ResolveModule(c.module)
+			assert.Equal(t, c.expected, m.ID(false))
+			if c.alias == "" {
+				assert.Nil(t, a)
+			} else if assert.NotNil(t, a) {
+				assert.Equal(t, c.alias, a.ID())
+			}
+		})
+	}
+}
+
+func TestAliases_ResolveProvider(t *testing.T) {
+	t.Parallel()
+
+	aliases := Aliases{
+		{Type: TypeNamespace, From: "old", To: "new"},
+		{Type: TypeProvider, From: "old/dummy", To: "vendor/dummy"},
+	}
+
+	namespace, name, a := aliases.ResolveProvider("old", "dummy")
+	assert.Equal(t, "vendor", namespace)
+	assert.Equal(t, "dummy", name)
+	if assert.NotNil(t, a) {
+		assert.Equal(t, TypeProvider, a.Type)
+	}
+
+	namespace, _, a = aliases.ResolveProvider("old", "other")
+	assert.Equal(t, "new", namespace)
+	if assert.NotNil(t, a) {
+		assert.Equal(t, TypeNamespace, a.Type)
+	}
+
+	namespace, _, a = aliases.ResolveProvider("vendor", "dummy")
+	assert.Equal(t, "vendor", namespace)
+	assert.Nil(t, a)
+}
+
+func TestAliases_Check(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, Aliases{
+		{Type: TypeNamespace, From: "a", To: "b"},
+		{Type: TypeNamespace, From: "b", To: "c"},
+	}.Check())
+
+	assert.ErrorIs(t, Aliases{
+		{Type: TypeNamespace, From: "a", To: "b"},
+		{Type: TypeNamespace, From: "b", To: "a"},
+	}.Check(), ErrInvalidAlias)
+
+	assert.ErrorIs(t, Aliases{
+		{Type: TypeModule, From: "a/vpc/aws", To: "b/vpc/aws"},
+		{Type: TypeNamespace, From: "b", To: "a"},
+	}.Check(), ErrInvalidAlias)
+
+	assert.ErrorIs(t, Aliases{
+		{Type: TypeProvider, From: "a/dummy", To: "b/dummy"},
+		{Type: TypeProvider, From: "b/dummy", To: "a/dummy"},
+	}.Check(), ErrInvalidAlias)
+}
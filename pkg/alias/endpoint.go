@@ -0,0 +1,46 @@
+package alias
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+)
+
+type listAliasesResponse struct {
+	Aliases []Alias `json:"aliases"`
+}
+
+func listAliasesEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, _ interface{}) (interface{}, error) {
+		res, err := svc.ListAliases(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		if res == nil {
+			res = []Alias{}
+		}
+
+		return listAliasesResponse{
+			Aliases: res,
+		}, nil
+	}
+}
+
+func addAliasEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		return svc.AddAlias(ctx, request.(Alias))
+	}
+}
+
+type deleteAliasRequest struct {
+	aliasType string
+	from      string
+}
+
+func deleteAliasEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(deleteAliasRequest)
+		return nil, svc.DeleteAlias(ctx, req.aliasType, req.from)
+	}
+}
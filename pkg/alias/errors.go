@@ -0,0 +1,11 @@
+package alias
+
+import "errors"
+
+var (
+	ErrAliasNotFound      = errors.New("failed to locate alias")
+	ErrAliasAlreadyExists = errors.New("alias already exists")
+	ErrAliasConfigured    = errors.New("alias is configured in the alias config file")
+	ErrInvalidAlias       = errors.New("invalid alias")
+	ErrManageDisabled     = errors.New("managing aliases is disabled")
+)
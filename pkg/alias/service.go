@@ -0,0 +1,90 @@
+package alias
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// Service manages the aliases of renamed namespaces, modules and providers
+type Service interface {
+	ListAliases(ctx context.Context) ([]Alias, error)
+	AddAlias(ctx context.Context, a Alias) (Alias, error)
+	DeleteAlias(ctx context.Context, aliasType, from string) error
+}
+
+type service struct {
+	storage       Storage
+	manageEnabled bool
+}
+
+// ServiceOption provides additional options for the Service.
+type ServiceOption func(*service)
+
+// WithManageEnabled allows adding and deleting aliases through the Service
+func WithManageEnabled(enabled bool) ServiceOption {
+	return func(s *service) {
+		s.manageEnabled = enabled
+	}
+}
+
+// NewService returns a fully initialized Service.
+func NewService(storage Storage, options ...ServiceOption) Service {
+	s := &service{
+		storage: storage,
+	}
+
+	for _, option := range options {
+		option(s)
+	}
+
+	return s
+}
+
+// ListAliases returns the aliases sorted by their type and address
+func (s *service) ListAliases(ctx context.Context) ([]Alias, error) {
+	aliases, err := s.storage.ListAliases(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(aliases, func(i, j int) bool {
+		return aliases[i].ID() < aliases[j].ID()
+	})
+	return aliases, nil
+}
+
+// AddAlias validates the alias and rejects aliases that would redirect addresses in a cycle
+func (s *service) AddAlias(ctx context.Context, a Alias) (Alias, error) {
+	if !s.manageEnabled {
+		return Alias{}, ErrManageDisabled
+	}
+
+	if err := a.Validate(); err != nil {
+		return Alias{}, err
+	}
+
+	aliases, err := s.storage.ListAliases(ctx)
+	if err != nil {
+		return Alias{}, err
+	}
+	if _, ok := Aliases(aliases).lookup(a.Type, a.From); ok {
+		return Alias{}, fmt.Errorf("%w: %s", ErrAliasAlreadyExists, a.ID())
+	}
+	if err := append(Aliases(aliases), a).Check(); err != nil {
+		return Alias{}, err
+	}
+
+	if err := s.storage.AddAlias(ctx, a); err != nil {
+		return Alias{}, err
+	}
+	return a, nil
+}
+
+func (s *service) DeleteAlias(ctx context.Context, aliasType, from string) error {
+	if !s.manageEnabled {
+		return ErrManageDisabled
+	}
+
+	return s.storage.DeleteAlias(ctx, aliasType, from)
+}
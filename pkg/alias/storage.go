@@ -0,0 +1,15 @@
+package alias
+
+import "context"
+
+// Storage persists the aliases managed through the API
+type Storage interface {
+	// ListAliases returns all aliases
+	ListAliases(ctx context.Context) ([]Alias, error)
+
+	// AddAlias stores the alias, and fails if there is an alias of the same type and address already
+	AddAlias(ctx context.Context, a Alias) error
+
+	// DeleteAlias removes the alias of the type and address
+	DeleteAlias(ctx context.Context, aliasType, from string) error
+}
@@ -0,0 +1,112 @@
+package alias
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	o11y "github.com/boring-registry/boring-registry/pkg/observability"
+
+	"github.com/go-kit/kit/auth/jwt"
+	"github.com/go-kit/kit/endpoint"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+)
+
+// MakeHandler returns a fully initialized http.Handler.
+func MakeHandler(svc Service, auth endpoint.Middleware, instrumentation o11y.Middleware, options ...httptransport.ServerOption) http.Handler {
+	r := mux.NewRouter().StrictSlash(true)
+
+	r.Methods("GET").Path(`/`).Handler(
+		instrumentation.WrapHandler(
+			httptransport.NewServer(
+				auth(listAliasesEndpoint(svc)),
+				httptransport.NopRequestDecoder,
+				httptransport.EncodeJSONResponse,
+				append(
+					options,
+					httptransport.ServerBefore(jwt.HTTPToContext()),
+				)...,
+			),
+		),
+	)
+
+	r.Methods("POST").Path(`/`).Handler(
+		instrumentation.WrapHandler(
+			httptransport.NewServer(
+				auth(addAliasEndpoint(svc)),
+				decodeAddAliasRequest,
+				encodeAddAliasResponse,
+				append(
+					options,
+					httptransport.ServerBefore(jwt.HTTPToContext()),
+				)...,
+			),
+		),
+	)
+
+	// The address of the alias contains slashes, e.g. DELETE /module/old-ns/vpc/aws
+	r.Methods("DELETE").Path(`/{type}/{from:.+}`).Handler(
+		instrumentation.WrapHandler(
+			httptransport.NewServer(
+				auth(deleteAliasEndpoint(svc)),
+				decodeDeleteAliasRequest,
+				encodeDeleteAliasResponse,
+				append(
+					options,
+					httptransport.ServerBefore(jwt.HTTPToContext()),
+				)...,
+			),
+		),
+	)
+
+	return r
+}
+
+// decodeAddAliasRequest decodes the alias from the JSON body, e.g. {"type": "module", "from": "old-ns/vpc/aws", "to": "new-ns/vpc/aws"}
+func decodeAddAliasRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var a Alias
+	if err := json.NewDecoder(r.Body).Decode(&a); err != nil {
+		return nil, fmt.Errorf("%w: failed to decode body: %v", core.ErrVarType, err)
+	}
+	return a, nil
+}
+
+func decodeDeleteAliasRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	vars := mux.Vars(r)
+	return deleteAliasRequest{
+		aliasType: vars["type"],
+		from:      vars["from"],
+	}, nil
+}
+
+// ErrorEncoder translates domain specific errors to HTTP status codes
+func ErrorEncoder(_ context.Context, err error, w http.ResponseWriter) {
+	if errors.Is(err, ErrAliasNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+	} else if errors.Is(err, ErrAliasAlreadyExists) || errors.Is(err, ErrAliasConfigured) {
+		w.WriteHeader(http.StatusConflict)
+	} else if errors.Is(err, ErrInvalidAlias) {
+		w.WriteHeader(http.StatusBadRequest)
+	} else if errors.Is(err, ErrManageDisabled) {
+		w.WriteHeader(http.StatusForbidden)
+	} else {
+		w.WriteHeader(core.GenericError(err))
+	}
+
+	core.HandleErrorResponse(err, w)
+}
+
+func encodeAddAliasResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusCreated)
+	return json.NewEncoder(w).Encode(response)
+}
+
+func encodeDeleteAliasResponse(_ context.Context, w http.ResponseWriter, _ interface{}) error {
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
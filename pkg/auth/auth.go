@@ -6,6 +6,7 @@ import (
 	"log/slog"
 
 	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/metadata"
 
 	"github.com/go-kit/kit/auth/jwt"
 	"github.com/go-kit/kit/endpoint"
@@ -23,13 +24,14 @@ func Middleware(providers ...Provider) endpoint.Middleware {
 
 			if token, ok := tokenValue.(string); ok {
 				for _, provider := range providers {
-					err := provider.Verify(ctx, token)
+					subject, err := provider.Verify(ctx, token)
 					if err != nil {
 						slog.Debug("failed to verify token", slog.String("err", err.Error()))
 						return nil, fmt.Errorf("failed to verify token: %w", err)
 					} else {
-						slog.Debug("successfully verified token")
-						return next(ctx, request)
+						slog.Debug("successfully verified token", slog.String("subject", subject))
+						// The subject is recorded as uploader in the provenance and metadata of uploads
+						return next(metadata.WithUploader(ctx, subject), request)
 					}
 				}
 			} else {
@@ -4,6 +4,8 @@ import (
 	"context"
 	"testing"
 
+	"github.com/boring-registry/boring-registry/pkg/metadata"
+
 	"github.com/go-kit/kit/auth/jwt"
 	"github.com/stretchr/testify/assert"
 )
@@ -52,3 +54,14 @@ func TestAuthMiddleware(t *testing.T) {
 func nopEndpoint(ctx context.Context, request interface{}) (interface{}, error) {
 	return true, nil
 }
+
+func TestAuthMiddleware_Subject(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.WithValue(context.Background(), jwt.JWTContextKey, "foo")
+	res, err := Middleware(NewStaticProvider("foo"))(func(ctx context.Context, _ interface{}) (interface{}, error) {
+		return metadata.UploaderFromContext(ctx), nil
+	})(ctx, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "static:2c26b46b68ff", res, "static tokens are identified by a fingerprint")
+}
@@ -3,5 +3,6 @@ package auth
 import "context"
 
 type Provider interface {
-	Verify(ctx context.Context, token string) error
+	// Verify returns the subject the token was issued to, which identifies the client in the provenance of uploads
+	Verify(ctx context.Context, token string) (string, error)
 }
@@ -17,7 +17,8 @@ type OktaProvider struct {
 
 func (p *OktaProvider) String() string { return "okta" }
 
-func (p *OktaProvider) Verify(ctx context.Context, token string) error {
+// Verify returns the sub claim of the token as subject
+func (p *OktaProvider) Verify(ctx context.Context, token string) (string, error) {
 	opts := jwtverifier.JwtVerifier{
 		Issuer:           p.issuer,
 		ClaimsToValidate: p.claims,
@@ -25,14 +26,16 @@ func (p *OktaProvider) Verify(ctx context.Context, token string) error {
 
 	verifier, err := opts.New()
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	if _, err := verifier.VerifyIdToken(token); err != nil {
-		return fmt.Errorf("%v: %w", core.ErrInvalidToken, err)
+	jwt, err := verifier.VerifyIdToken(token)
+	if err != nil {
+		return "", fmt.Errorf("%v: %w", core.ErrInvalidToken, err)
 	}
 
-	return nil
+	subject, _ := jwt.Claims["sub"].(string)
+	return subject, nil
 }
 
 func NewOktaProvider(issuer string, claims ...string) Provider {
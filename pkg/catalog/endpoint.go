@@ -0,0 +1,36 @@
+package catalog
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+
+	"github.com/go-kit/kit/endpoint"
+)
+
+type listNamespacesRequest struct {
+	page core.Page
+	url  *url.URL
+}
+
+type listNamespacesResponse struct {
+	Meta       core.PageMeta `json:"meta"`
+	Namespaces []Namespace   `json:"namespaces"`
+}
+
+func listNamespacesEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(listNamespacesRequest)
+
+		res, meta, err := svc.ListNamespaces(ctx, req.page)
+		if err != nil {
+			return nil, err
+		}
+
+		return listNamespacesResponse{
+			Meta:       meta.WithURLs(req.url),
+			Namespaces: res,
+		}, nil
+	}
+}
@@ -0,0 +1,103 @@
+// Package catalog answers which namespaces exist in the registry.
+// The modules and providers of a namespace are listed by the module and provider APIs.
+package catalog
+
+import (
+	"context"
+	"sort"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/namespace"
+)
+
+// Storage lists the published modules and providers, and the registered namespaces
+type Storage interface {
+	ListModules(ctx context.Context, namespace string) ([]core.Module, error)
+	ListProviders(ctx context.Context, namespace string) ([]core.ProviderVersion, error)
+	ListNamespaces(ctx context.Context) ([]namespace.Namespace, error)
+}
+
+// Service lists the namespaces of the registry
+type Service interface {
+	ListNamespaces(ctx context.Context, page core.Page) ([]Namespace, core.PageMeta, error)
+}
+
+// Namespace counts the modules and providers published in a namespace, regardless of their number of versions.
+// Registered namespaces are listed with their metadata, even if nothing was published to them yet.
+type Namespace struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Owners      []string `json:"owners,omitempty"`
+	Frozen      bool     `json:"frozen,omitempty"`
+	Registered  bool     `json:"registered"`
+	Modules     int      `json:"modules"`
+	Providers   int      `json:"providers"`
+}
+
+type service struct {
+	storage Storage
+}
+
+// NewService returns a fully initialized Service.
+func NewService(storage Storage) Service {
+	return &service{
+		storage: storage,
+	}
+}
+
+func (s *service) ListNamespaces(ctx context.Context, page core.Page) ([]Namespace, core.PageMeta, error) {
+	modules, err := s.storage.ListModules(ctx, "")
+	if err != nil {
+		return nil, core.PageMeta{}, err
+	}
+
+	providers, err := s.storage.ListProviders(ctx, "")
+	if err != nil {
+		return nil, core.PageMeta{}, err
+	}
+
+	namespaces := make(map[string]*Namespace)
+	namespace := func(name string) *Namespace {
+		if _, ok := namespaces[name]; !ok {
+			namespaces[name] = &Namespace{Name: name}
+		}
+		return namespaces[name]
+	}
+
+	seen := make(map[string]struct{})
+	for _, m := range modules {
+		if _, ok := seen[m.ID(false)]; !ok {
+			seen[m.ID(false)] = struct{}{}
+			namespace(m.Namespace).Modules++
+		}
+	}
+
+	seen = make(map[string]struct{})
+	for _, p := range providers {
+		id := p.Namespace + "/" + p.Name
+		if _, ok := seen[id]; !ok {
+			seen[id] = struct{}{}
+			namespace(p.Namespace).Providers++
+		}
+	}
+
+	registered, err := s.storage.ListNamespaces(ctx)
+	if err != nil {
+		return nil, core.PageMeta{}, err
+	}
+	for _, r := range registered {
+		n := namespace(r.Name)
+		n.Description, n.Owners, n.Frozen, n.Registered = r.Description, r.Owners, r.Frozen, true
+	}
+
+	result := make([]Namespace, 0, len(namespaces))
+	for _, n := range namespaces {
+		result = append(result, *n)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+
+	paginated, meta := core.Paginate(result, page)
+	return paginated, meta, nil
+}
@@ -0,0 +1,71 @@
+package catalog
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/module"
+	"github.com/boring-registry/boring-registry/pkg/namespace"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testStorage struct {
+	module.Storage
+	providers  []core.ProviderVersion
+	namespaces []namespace.Namespace
+}
+
+func (s *testStorage) ListProviders(context.Context, string) ([]core.ProviderVersion, error) {
+	return s.providers, nil
+}
+
+func (s *testStorage) ListNamespaces(context.Context) ([]namespace.Namespace, error) {
+	return s.namespaces, nil
+}
+
+func TestService_ListNamespaces(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	modules := module.NewInmemStorage()
+	for _, m := range []core.Module{
+		{Namespace: "example", Name: "vpc", Provider: "aws", Version: "1.0.0"},
+		{Namespace: "example", Name: "vpc", Provider: "aws", Version: "1.1.0"},
+		{Namespace: "example", Name: "vpc", Provider: "google", Version: "1.0.0"},
+		{Namespace: "other", Name: "dns", Provider: "aws", Version: "0.1.0"},
+	} {
+		_, err := modules.UploadModule(ctx, m.Namespace, m.Name, m.Provider, m.Version, strings.NewReader("content"))
+		assert.NoError(t, err)
+	}
+
+	svc := NewService(&testStorage{
+		Storage: modules,
+		providers: []core.ProviderVersion{
+			{Namespace: "example", Name: "dummy", Version: "1.0.0"},
+			{Namespace: "example", Name: "dummy", Version: "1.1.0"},
+			{Namespace: "providers-only", Name: "dummy", Version: "1.0.0"},
+		},
+		namespaces: []namespace.Namespace{
+			{Name: "example", Description: "Examples", Owners: []string{"team-platform"}},
+			{Name: "planned", Frozen: true},
+		},
+	})
+
+	got, meta, err := svc.ListNamespaces(ctx, core.Page{Limit: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, []Namespace{
+		{Name: "example", Description: "Examples", Owners: []string{"team-platform"}, Registered: true, Modules: 2, Providers: 1},
+		{Name: "other", Modules: 1},
+	}, got)
+	assert.Equal(t, 2, *meta.NextOffset)
+
+	got, _, err = svc.ListNamespaces(ctx, core.Page{Offset: 2, Limit: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, []Namespace{
+		{Name: "planned", Frozen: true, Registered: true},
+		{Name: "providers-only", Providers: 1},
+	}, got)
+}
@@ -0,0 +1,60 @@
+package catalog
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	o11y "github.com/boring-registry/boring-registry/pkg/observability"
+
+	"github.com/go-kit/kit/auth/jwt"
+	"github.com/go-kit/kit/endpoint"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+)
+
+// MakeHandler returns a fully initialized http.Handler.
+func MakeHandler(svc Service, auth endpoint.Middleware, instrumentation o11y.Middleware, options ...httptransport.ServerOption) http.Handler {
+	r := mux.NewRouter().StrictSlash(true)
+
+	r.Methods("GET").Path(`/`).Handler(
+		instrumentation.WrapHandler(
+			httptransport.NewServer(
+				auth(listNamespacesEndpoint(svc)),
+				decodeListNamespacesRequest,
+				httptransport.EncodeJSONResponse,
+				append(
+					options,
+					httptransport.ServerBefore(jwt.HTTPToContext()),
+				)...,
+			),
+		),
+	)
+
+	return r
+}
+
+func decodeListNamespacesRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	page, err := core.ParsePage(r.URL.Query())
+	if err != nil {
+		return nil, err
+	}
+
+	// The URL of the request is used for the links to the next and previous page, so the path must not be stripped
+	u, err := url.ParseRequestURI(r.RequestURI)
+	if err != nil {
+		u = r.URL
+	}
+
+	return listNamespacesRequest{
+		page: page,
+		url:  u,
+	}, nil
+}
+
+// ErrorEncoder translates domain specific errors to HTTP status codes
+func ErrorEncoder(_ context.Context, err error, w http.ResponseWriter) {
+	w.WriteHeader(core.GenericError(err))
+	core.HandleErrorResponse(err, w)
+}
@@ -0,0 +1,27 @@
+package core
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// PublishTimeout is the maximum duration for publishing an artifact through the HTTP API
+const PublishTimeout = 30 * time.Minute
+
+// ExtendDeadlines lifts the read and write deadlines of the http.Server for the request.
+// Uploading large archives takes longer than the regular requests of the registry protocols.
+func ExtendDeadlines(timeout time.Duration, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rc := http.NewResponseController(w)
+		deadline := time.Now().Add(timeout)
+		if err := rc.SetReadDeadline(deadline); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			slog.Warn("failed to extend read deadline", slog.String("err", err.Error()))
+		}
+		if err := rc.SetWriteDeadline(deadline); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			slog.Warn("failed to extend write deadline", slog.String("err", err.Error()))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
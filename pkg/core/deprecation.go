@@ -0,0 +1,58 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+)
+
+// deprecations collects the deprecation warnings of a request, which are recorded while it's served
+type deprecations struct {
+	mu       sync.Mutex
+	warnings []string
+}
+
+type deprecationsContextKey struct{}
+
+// DeprecationsToContext prepares the context of the request for collecting deprecation warnings
+func DeprecationsToContext(ctx context.Context, _ *http.Request) context.Context {
+	return context.WithValue(ctx, deprecationsContextKey{}, &deprecations{})
+}
+
+// Deprecate records the deprecation warning for the response to the request.
+// The warning is dropped if the context doesn't collect deprecation warnings, and is only recorded once.
+func Deprecate(ctx context.Context, warning string) {
+	d, ok := ctx.Value(deprecationsContextKey{}).(*deprecations)
+	if !ok {
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !slices.Contains(d.warnings, warning) {
+		d.warnings = append(d.warnings, warning)
+	}
+}
+
+// DeprecationsFromContext returns the deprecation warnings recorded for the request
+func DeprecationsFromContext(ctx context.Context) []string {
+	d, ok := ctx.Value(deprecationsContextKey{}).(*deprecations)
+	if !ok {
+		return nil
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return slices.Clone(d.warnings)
+}
+
+// DeprecationsToHeader adds a Warning header with the code 299 for every deprecation warning recorded for the request.
+// For more information see: https://www.rfc-editor.org/rfc/rfc7234#section-5.5.7
+func DeprecationsToHeader(ctx context.Context, w http.ResponseWriter) context.Context {
+	for _, warning := range DeprecationsFromContext(ctx) {
+		w.Header().Add("Warning", fmt.Sprintf("299 - %q", warning))
+	}
+	return ctx
+}
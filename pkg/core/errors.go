@@ -20,6 +20,8 @@ var (
 	// Storage errors
 	ErrObjectNotFound      = errors.New("failed to locate object")
 	ErrObjectAlreadyExists = errors.New("object already exists")
+	ErrChecksumMismatch    = errors.New("checksum mismatch")
+	ErrProvenanceNotFound  = errors.New("failed to locate provenance")
 )
 
 type ProviderError struct {
@@ -56,12 +58,14 @@ func (p ProviderError) Error() string {
 
 // GenericError returns the HTTP status code for module-agnostic boring-registry errors
 func GenericError(err error) int {
-	if errors.Is(err, ErrVarMissing) {
+	if errors.Is(err, ErrVarMissing) || errors.Is(err, ErrVarType) {
 		return http.StatusBadRequest
 	} else if errors.Is(err, ErrInvalidToken) || errors.Is(err, ErrUnauthorized) {
 		return http.StatusUnauthorized
 	} else if errors.Is(err, ErrObjectAlreadyExists) {
 		return http.StatusConflict
+	} else if errors.Is(err, ErrProvenanceNotFound) {
+		return http.StatusNotFound
 	}
 
 	// Default error
@@ -1,6 +1,9 @@
 package core
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
 
 // Module represents Terraform module metadata.
 type Module struct {
@@ -9,6 +12,10 @@ type Module struct {
 	Provider    string `json:"provider"`
 	Version     string `json:"version"`
 	DownloadURL string `json:"download_url"`
+
+	// PublishedAt is the time the module version was uploaded.
+	// It is only set by listings and stays zero if the storage backend doesn't provide it.
+	PublishedAt time.Time `json:"-"`
 }
 
 // ID returns the module metadata in a compact format.
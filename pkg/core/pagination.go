@@ -0,0 +1,98 @@
+package core
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+const (
+	// DefaultPageLimit is the number of items returned if the request doesn't specify a limit
+	DefaultPageLimit = 15
+
+	// MaxPageLimit is the maximum number of items returned at once
+	MaxPageLimit = 100
+)
+
+// Page selects a window of a listing with the offset and limit query parameters of the public Terraform registry
+type Page struct {
+	Offset int
+	Limit  int
+}
+
+// PageMeta describes the returned window of a listing.
+// It follows the meta object of the public Terraform registry, e.g. GET https://registry.terraform.io/v1/modules
+type PageMeta struct {
+	Limit         int    `json:"limit"`
+	CurrentOffset int    `json:"current_offset"`
+	NextOffset    *int   `json:"next_offset,omitempty"`
+	PrevOffset    *int   `json:"prev_offset,omitempty"`
+	NextURL       string `json:"next_url,omitempty"`
+	PrevURL       string `json:"prev_url,omitempty"`
+}
+
+// ParsePage reads the offset and limit query parameters
+func ParsePage(query url.Values) (Page, error) {
+	page := Page{Limit: DefaultPageLimit}
+
+	for param, v := range map[string]*int{"offset": &page.Offset, "limit": &page.Limit} {
+		if s := query.Get(param); s != "" {
+			i, err := strconv.Atoi(s)
+			if err != nil || i < 0 {
+				return Page{}, fmt.Errorf("%w: %s has to be a non-negative integer", ErrVarType, param)
+			}
+			*v = i
+		}
+	}
+
+	if page.Limit == 0 {
+		page.Limit = DefaultPageLimit
+	} else if page.Limit > MaxPageLimit {
+		page.Limit = MaxPageLimit
+	}
+	return page, nil
+}
+
+// Paginate returns the items of the page and the meta object describing it
+func Paginate[T any](items []T, page Page) ([]T, PageMeta) {
+	meta := PageMeta{
+		Limit:         page.Limit,
+		CurrentOffset: page.Offset,
+	}
+
+	if page.Offset > 0 {
+		prev := max(page.Offset-page.Limit, 0)
+		meta.PrevOffset = &prev
+	}
+
+	if page.Offset >= len(items) {
+		return []T{}, meta
+	}
+
+	end := page.Offset + page.Limit
+	if end < len(items) {
+		meta.NextOffset = &end
+	} else {
+		end = len(items)
+	}
+
+	return items[page.Offset:end], meta
+}
+
+// WithURLs adds the URLs of the next and previous page, which are derived from the URL of the request
+func (m PageMeta) WithURLs(u *url.URL) PageMeta {
+	pageURL := func(offset int) string {
+		query := u.Query()
+		query.Set("limit", strconv.Itoa(m.Limit))
+		query.Set("offset", strconv.Itoa(offset))
+		return (&url.URL{Path: u.Path, RawQuery: query.Encode()}).String()
+	}
+
+	if m.NextOffset != nil {
+		m.NextURL = pageURL(*m.NextOffset)
+	}
+	if m.PrevOffset != nil {
+		m.PrevURL = pageURL(*m.PrevOffset)
+	}
+	return m
+}
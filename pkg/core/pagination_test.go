@@ -0,0 +1,121 @@
+package core
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePage(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name        string
+		query       string
+		expected    Page
+		expectError bool
+	}{
+		{
+			name:     "defaults",
+			query:    "",
+			expected: Page{Offset: 0, Limit: DefaultPageLimit},
+		},
+		{
+			name:     "offset and limit",
+			query:    "offset=30&limit=10",
+			expected: Page{Offset: 30, Limit: 10},
+		},
+		{
+			name:     "limit is capped",
+			query:    "limit=1000",
+			expected: Page{Offset: 0, Limit: MaxPageLimit},
+		},
+		{
+			name:        "negative offset",
+			query:       "offset=-1",
+			expectError: true,
+		},
+		{
+			name:        "invalid limit",
+			query:       "limit=ten",
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			query, err := url.ParseQuery(tc.query)
+			assert.NoError(t, err)
+
+			page, err := ParsePage(query)
+			if tc.expectError {
+				assert.ErrorIs(t, err, ErrVarType)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, page)
+		})
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	t.Parallel()
+
+	items := []int{0, 1, 2, 3, 4}
+	intPtr := func(i int) *int { return &i }
+
+	testCases := []struct {
+		name          string
+		page          Page
+		expectedItems []int
+		expectedMeta  PageMeta
+	}{
+		{
+			name:          "first page",
+			page:          Page{Offset: 0, Limit: 2},
+			expectedItems: []int{0, 1},
+			expectedMeta:  PageMeta{Limit: 2, CurrentOffset: 0, NextOffset: intPtr(2)},
+		},
+		{
+			name:          "middle page",
+			page:          Page{Offset: 1, Limit: 2},
+			expectedItems: []int{1, 2},
+			expectedMeta:  PageMeta{Limit: 2, CurrentOffset: 1, NextOffset: intPtr(3), PrevOffset: intPtr(0)},
+		},
+		{
+			name:          "last page",
+			page:          Page{Offset: 4, Limit: 2},
+			expectedItems: []int{4},
+			expectedMeta:  PageMeta{Limit: 2, CurrentOffset: 4, PrevOffset: intPtr(2)},
+		},
+		{
+			name:          "offset beyond the items",
+			page:          Page{Offset: 10, Limit: 2},
+			expectedItems: []int{},
+			expectedMeta:  PageMeta{Limit: 2, CurrentOffset: 10, PrevOffset: intPtr(8)},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, meta := Paginate(items, tc.page)
+			assert.Equal(t, tc.expectedItems, result)
+			assert.Equal(t, tc.expectedMeta, meta)
+		})
+	}
+}
+
+func TestPageMeta_WithURLs(t *testing.T) {
+	t.Parallel()
+
+	next, prev := 20, 0
+	meta := PageMeta{Limit: 10, CurrentOffset: 10, NextOffset: &next, PrevOffset: &prev}
+
+	u, err := url.Parse("/v1/modules/search?q=vpc&offset=10&limit=10")
+	assert.NoError(t, err)
+
+	meta = meta.WithURLs(u)
+	assert.Equal(t, "/v1/modules/search?limit=10&offset=20&q=vpc", meta.NextURL)
+	assert.Equal(t, "/v1/modules/search?limit=10&offset=0&q=vpc", meta.PrevURL)
+}
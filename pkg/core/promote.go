@@ -0,0 +1,6 @@
+package core
+
+// PromoteRequestBody is the JSON body of requests promoting a module or provider version to another namespace
+type PromoteRequestBody struct {
+	TargetNamespace string `json:"target_namespace"`
+}
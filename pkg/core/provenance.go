@@ -0,0 +1,73 @@
+package core
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Headers carrying the provenance reported by the client when publishing through the HTTP API
+const (
+	HeaderClientVersion = "X-Boring-Registry-Client-Version"
+	HeaderGitCommit     = "X-Boring-Registry-Git-Commit"
+	HeaderGitRef        = "X-Boring-Registry-Git-Ref"
+	HeaderCIRunURL      = "X-Boring-Registry-CI-Run-URL"
+)
+
+// Provenance records who published an artifact, when, and from which pipeline.
+// It's stored next to every uploaded artifact, so that a bad release can be traced back to the pipeline that published it.
+type Provenance struct {
+	// Artifact is the file name of the artifact in the storage backend
+	Artifact   string    `json:"artifact"`
+	SHA256     string    `json:"sha256"`
+	Uploader   string    `json:"uploader,omitempty"`
+	UploadedAt time.Time `json:"uploaded_at"`
+
+	// The following attributes are reported by the client and can't be verified by the registry
+	ClientVersion string `json:"client_version,omitempty"`
+	GitCommit     string `json:"git_commit,omitempty"`
+	GitRef        string `json:"git_ref,omitempty"`
+	CIRunURL      string `json:"ci_run_url,omitempty"`
+
+	// CopiedFrom is the ID of the module or provider version the artifact was promoted from, e.g. staging/vpc/aws/1.0.0.
+	// Origin is the provenance of the artifact it was copied from, if it was recorded.
+	CopiedFrom string      `json:"copied_from,omitempty"`
+	Origin     *Provenance `json:"origin,omitempty"`
+}
+
+type provenanceContextKey struct{}
+
+// WithProvenance returns a context carrying the provenance reported by the client, which is recorded with uploads
+func WithProvenance(ctx context.Context, p Provenance) context.Context {
+	return context.WithValue(ctx, provenanceContextKey{}, p)
+}
+
+// ProvenanceFromContext returns the provenance reported by the client, which is empty if the context doesn't carry any
+func ProvenanceFromContext(ctx context.Context) Provenance {
+	p, _ := ctx.Value(provenanceContextKey{}).(Provenance)
+	return p
+}
+
+// ProvenanceToContext reads the provenance reported by the client from the request headers
+func ProvenanceToContext(ctx context.Context, r *http.Request) context.Context {
+	return WithProvenance(ctx, Provenance{
+		ClientVersion: r.Header.Get(HeaderClientVersion),
+		GitCommit:     r.Header.Get(HeaderGitCommit),
+		GitRef:        r.Header.Get(HeaderGitRef),
+		CIRunURL:      r.Header.Get(HeaderCIRunURL),
+	})
+}
+
+// SetProvenanceHeaders adds the provenance reported by the client to the request headers
+func SetProvenanceHeaders(h http.Header, p Provenance) {
+	for header, value := range map[string]string{
+		HeaderClientVersion: p.ClientVersion,
+		HeaderGitCommit:     p.GitCommit,
+		HeaderGitRef:        p.GitRef,
+		HeaderCIRunURL:      p.CIRunURL,
+	} {
+		if value != "" {
+			h.Set(header, value)
+		}
+	}
+}
@@ -104,6 +104,9 @@ func NewProviderFromArchive(filename string) (Provider, error) {
 	// Criterias for terraform archives:
 	// https://www.terraform.io/docs/registry/providers/publishing.html#manually-preparing-a-release
 	f := filepath.Base(filename) // This is just a precaution
+	if !strings.HasSuffix(f, ProviderExtension) {
+		return Provider{}, fmt.Errorf("couldn't parse provider file name: %s", filename)
+	}
 	trimmed := strings.TrimPrefix(f, ProviderPrefix)
 	trimmed = strings.TrimSuffix(trimmed, ProviderExtension)
 	tokens := strings.Split(trimmed, "_")
@@ -161,6 +164,9 @@ type GPGPublicKey struct {
 
 type ProviderVersions struct {
 	Versions []ProviderVersion `json:"versions,omitempty"`
+
+	// Warnings are shown by Terraform when it lists the versions, e.g. for deprecated provider addresses
+	Warnings []string `json:"warnings,omitempty"`
 }
 
 // The ProviderVersion is a copy from provider.ProviderVersion
@@ -221,6 +227,16 @@ func (s *Sha256Sums) Name() (string, error) {
 	return matches[1], nil
 }
 
+// Version returns the version of the provider of the SHA256SUMS file
+func (s *Sha256Sums) Version() (string, error) {
+	r := regexp.MustCompile("^terraform-provider-(?P<name>.+)_(?P<version>.+)_SHA256SUMS$")
+	matches := r.FindStringSubmatch(s.Filename)
+	if len(matches) != 3 {
+		return "", fmt.Errorf("regex for %s matched %d times instead of 3 times", s.Filename, len(matches))
+	}
+	return matches[2], nil
+}
+
 // Checksum returns the corresponding stringified checksum for the archive file name parameter
 func (s *Sha256Sums) Checksum(fileName string) (string, error) {
 	checksum, exists := s.Entries[fileName]
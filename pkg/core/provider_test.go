@@ -221,6 +221,11 @@ func TestNewProviderFromArchive(t *testing.T) {
 			name:        "invalid filename",
 			expectError: true,
 		},
+		{
+			name:        "sidecar of an archive",
+			fileName:    "terraform-provider-random_2.0.0_linux_amd64.zip.provenance.json",
+			expectError: true,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -0,0 +1,57 @@
+package core
+
+import (
+	"sort"
+
+	"github.com/hashicorp/go-version"
+)
+
+// LatestVersion returns the highest of the semantic versions.
+// Pre-releases are only considered if there is no stable version, and invalid versions are ignored.
+// An empty string is returned if none of the versions is valid.
+func LatestVersion(versions []string) string {
+	var latest, latestPrerelease *version.Version
+	var latestRaw, latestPrereleaseRaw string
+
+	for _, raw := range versions {
+		v, err := version.NewVersion(raw)
+		if err != nil {
+			continue
+		}
+
+		if v.Prerelease() != "" {
+			if latestPrerelease == nil || v.GreaterThan(latestPrerelease) {
+				latestPrerelease, latestPrereleaseRaw = v, raw
+			}
+		} else if latest == nil || v.GreaterThan(latest) {
+			latest, latestRaw = v, raw
+		}
+	}
+
+	if latest != nil {
+		return latestRaw
+	}
+	return latestPrereleaseRaw
+}
+
+// SortVersions sorts the semantic versions in ascending order.
+// Invalid versions are sorted lexically before all valid versions.
+func SortVersions(versions []string) {
+	parsed := make(map[string]*version.Version, len(versions))
+	for _, raw := range versions {
+		if v, err := version.NewVersion(raw); err == nil {
+			parsed[raw] = v
+		}
+	}
+
+	sort.SliceStable(versions, func(i, j int) bool {
+		vi, vj := parsed[versions[i]], parsed[versions[j]]
+		switch {
+		case vi == nil && vj == nil:
+			return versions[i] < versions[j]
+		case vi == nil || vj == nil:
+			return vi == nil
+		}
+		return vi.LessThan(vj)
+	})
+}
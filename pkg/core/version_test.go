@@ -0,0 +1,62 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLatestVersion(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		versions []string
+		expected string
+	}{
+		{
+			name:     "semantic ordering",
+			versions: []string{"1.2.0", "1.10.0", "1.9.3"},
+			expected: "1.10.0",
+		},
+		{
+			name:     "pre-releases are ignored if a stable version exists",
+			versions: []string{"1.0.0", "2.0.0-rc1"},
+			expected: "1.0.0",
+		},
+		{
+			name:     "only pre-releases",
+			versions: []string{"2.0.0-beta1", "2.0.0-rc1"},
+			expected: "2.0.0-rc1",
+		},
+		{
+			name:     "invalid versions are ignored",
+			versions: []string{"latest", "0.1.0"},
+			expected: "0.1.0",
+		},
+		{
+			name:     "the original representation is kept",
+			versions: []string{"v1.0.0", "0.9.0"},
+			expected: "v1.0.0",
+		},
+		{
+			name:     "no valid version",
+			versions: []string{"latest"},
+			expected: "",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, LatestVersion(tc.versions))
+		})
+	}
+}
+
+func TestSortVersions(t *testing.T) {
+	t.Parallel()
+
+	versions := []string{"1.10.0", "v1.2.0", "latest", "1.2.0-rc1", "0.9.0"}
+	SortVersions(versions)
+	assert.Equal(t, []string{"latest", "0.9.0", "1.2.0-rc1", "v1.2.0", "1.10.0"}, versions)
+}
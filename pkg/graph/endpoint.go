@@ -0,0 +1,65 @@
+package graph
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+)
+
+type graphRequest struct {
+	filter Filter
+}
+
+type dependenciesRequest struct {
+	namespace string
+	name      string
+	provider  string
+	version   string
+}
+
+type dependentsRequest struct {
+	query Query
+}
+
+type edgesResponse struct {
+	Edges []Edge `json:"edges"`
+}
+
+func graphEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(graphRequest)
+
+		res, err := svc.GetGraph(ctx, req.filter)
+		if err != nil {
+			return nil, err
+		}
+
+		return edgesResponse{Edges: res}, nil
+	}
+}
+
+func dependenciesEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(dependenciesRequest)
+
+		res, err := svc.GetDependencies(ctx, req.namespace, req.name, req.provider, req.version)
+		if err != nil {
+			return nil, err
+		}
+
+		return edgesResponse{Edges: res}, nil
+	}
+}
+
+func dependentsEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(dependentsRequest)
+
+		res, err := svc.GetDependents(ctx, req.query)
+		if err != nil {
+			return nil, err
+		}
+
+		return edgesResponse{Edges: res}, nil
+	}
+}
@@ -0,0 +1,8 @@
+package graph
+
+import "errors"
+
+var (
+	ErrInvalidAddress    = errors.New("invalid address")
+	ErrInvalidConstraint = errors.New("invalid version constraint")
+)
@@ -0,0 +1,155 @@
+// Package graph answers which modules and providers the published modules depend on, and which modules depend on them.
+// The edges are read from the module blocks and required_providers blocks that are extracted into the docs of a module version when it's uploaded.
+package graph
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Kind is the type of the dependency of an Edge
+type Kind string
+
+const (
+	KindModule   Kind = "module"
+	KindProvider Kind = "provider"
+)
+
+// Edge is a dependency of a published module version on a module or provider
+type Edge struct {
+	// From is the ID of the module version declaring the dependency, e.g. example/app/aws/1.0.0
+	From string `json:"from"`
+
+	// Path is the directory of the submodule declaring the dependency, which is empty for the root module
+	Path string `json:"path,omitempty"`
+
+	Kind Kind `json:"kind"`
+
+	// To is the source address of the dependency.
+	// Registry addresses are normalized to [<hostname>/]<namespace>/<name>[/<provider>], other module sources are kept as they are
+	To         string `json:"to"`
+	Constraint string `json:"constraint,omitempty"`
+}
+
+// address is a module or provider address of a registry.
+// The hostname is empty if the address doesn't contain one
+type address struct {
+	hostname  string
+	namespace string
+	name      string
+	provider  string
+}
+
+func (a address) String() string {
+	parts := []string{a.namespace, a.name}
+	if a.provider != "" {
+		parts = append(parts, a.provider)
+	}
+	if a.hostname != "" {
+		parts = append([]string{a.hostname}, parts...)
+	}
+	return strings.Join(parts, "/")
+}
+
+// matches returns whether the address matches the query, whose hostname is only compared if it is set
+func (a address) matches(query address) bool {
+	return (query.hostname == "" || strings.EqualFold(a.hostname, query.hostname)) &&
+		strings.EqualFold(a.namespace, query.namespace) &&
+		strings.EqualFold(a.name, query.name) &&
+		strings.EqualFold(a.provider, query.provider)
+}
+
+var (
+	addressPart  = regexp.MustCompile(`^[0-9A-Za-z](?:[0-9A-Za-z-_]{0,62}[0-9A-Za-z])?$`)
+	hostnamePart = regexp.MustCompile(`^[0-9A-Za-z.-]+(:[0-9]+)?$`)
+)
+
+// parseAddress parses a module address with parts=3 or a provider address with parts=2, both with an optional hostname
+func parseAddress(s string, parts int) (address, error) {
+	segments := strings.Split(s, "/")
+	var a address
+	if len(segments) == parts+1 {
+		if !hostnamePart.MatchString(segments[0]) || (!strings.ContainsAny(segments[0], ".:") && segments[0] != "localhost") {
+			return address{}, fmt.Errorf("%w: %s", ErrInvalidAddress, s)
+		}
+		a.hostname = strings.ToLower(segments[0])
+		segments = segments[1:]
+	}
+	if len(segments) != parts {
+		return address{}, fmt.Errorf("%w: %s", ErrInvalidAddress, s)
+	}
+	for _, segment := range segments {
+		if !addressPart.MatchString(segment) {
+			return address{}, fmt.Errorf("%w: %s", ErrInvalidAddress, s)
+		}
+	}
+
+	a.namespace, a.name = segments[0], segments[1]
+	if parts == 3 {
+		a.provider = segments[2]
+	}
+	return a, nil
+}
+
+// parseModuleSource returns the registry address of a module source, and false for other sources like local paths or git repositories
+func parseModuleSource(source string) (address, bool) {
+	if strings.Contains(source, "::") || strings.HasPrefix(source, "./") || strings.HasPrefix(source, "../") {
+		return address{}, false
+	}
+	// Registry sources can refer to a submodule, which is still a dependency on the module
+	source, _, _ = strings.Cut(source, "//")
+	a, err := parseAddress(source, 3)
+	if err != nil || a.hostname == "github.com" || a.hostname == "bitbucket.org" {
+		return address{}, false
+	}
+	return a, true
+}
+
+// providerSource returns the address of a provider requirement.
+// Requirements without source refer to the hashicorp namespace, like they do in Terraform
+func providerSource(name, source string) string {
+	if source == "" {
+		return "hashicorp/" + name
+	}
+	if a, err := parseAddress(source, 2); err == nil {
+		return strings.ToLower(a.String())
+	}
+	return source
+}
+
+// WriteDOT writes the edges as directed graph in the DOT language of Graphviz.
+// The edges are labeled with their version constraints.
+func WriteDOT(w io.Writer, edges []Edge) error {
+	sorted := make([]Edge, len(edges))
+	copy(sorted, edges)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].From != sorted[j].From {
+			return sorted[i].From < sorted[j].From
+		}
+		return sorted[i].To < sorted[j].To
+	})
+
+	b := new(strings.Builder)
+	b.WriteString("digraph dependencies {\n")
+	for _, e := range sorted {
+		fmt.Fprintf(b, "  %q -> %q", e.From, e.To)
+		var attrs []string
+		if e.Constraint != "" {
+			attrs = append(attrs, fmt.Sprintf("label=%q", e.Constraint))
+		}
+		if e.Kind == KindProvider {
+			attrs = append(attrs, "style=dashed")
+		}
+		if len(attrs) > 0 {
+			fmt.Fprintf(b, " [%s]", strings.Join(attrs, ", "))
+		}
+		b.WriteString(";\n")
+	}
+	b.WriteString("}\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,63 @@
+package graph
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseModuleSource(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		source   string
+		expected string
+		registry bool
+	}{
+		{source: "network/vpc/aws", expected: "network/vpc/aws", registry: true},
+		{source: "Registry.Example.com/network/vpc/aws", expected: "registry.example.com/network/vpc/aws", registry: true},
+		{source: "localhost:5601/network/vpc/aws", expected: "localhost:5601/network/vpc/aws", registry: true},
+		{source: "network/vpc/aws//modules/subnets", expected: "network/vpc/aws", registry: true},
+		{source: "./modules/vpc"},
+		{source: "../vpc"},
+		{source: "github.com/example/vpc"},
+		{source: "github.com/example/vpc/aws"},
+		{source: "git::https://example.com/vpc.git"},
+		{source: "s3::https://s3.amazonaws.com/bucket/vpc.zip"},
+		{source: "https://example.com/vpc.zip"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.source, func(t *testing.T) {
+			a, ok := parseModuleSource(tc.source)
+			assert.Equal(t, tc.registry, ok)
+			if tc.registry {
+				assert.Equal(t, tc.expected, a.String())
+			}
+		})
+	}
+}
+
+func TestProviderSource(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "hashicorp/aws", providerSource("aws", ""))
+	assert.Equal(t, "integrations/github", providerSource("github", "integrations/github"))
+	assert.Equal(t, "registry.terraform.io/hashicorp/aws", providerSource("aws", "Registry.Terraform.io/HashiCorp/AWS"))
+}
+
+func TestWriteDOT(t *testing.T) {
+	t.Parallel()
+
+	buf := new(bytes.Buffer)
+	assert.NoError(t, WriteDOT(buf, []Edge{
+		{From: "example/app/aws/1.1.0", Kind: KindProvider, To: "hashicorp/aws", Constraint: ">= 5.0"},
+		{From: "example/app/aws/1.1.0", Kind: KindModule, To: "network/vpc/aws"},
+	}))
+	assert.Equal(t, `digraph dependencies {
+  "example/app/aws/1.1.0" -> "hashicorp/aws" [label=">= 5.0", style=dashed];
+  "example/app/aws/1.1.0" -> "network/vpc/aws";
+}
+`, buf.String())
+}
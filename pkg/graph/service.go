@@ -0,0 +1,314 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/module"
+	"github.com/boring-registry/boring-registry/pkg/provider"
+
+	"github.com/hashicorp/go-version"
+)
+
+// Storage lists the published modules with their docs, and the published versions of the modules and providers that are depended on
+type Storage interface {
+	ListModules(ctx context.Context, namespace string) ([]core.Module, error)
+	ListModuleVersions(ctx context.Context, namespace, name, provider string) ([]core.Module, error)
+	DownloadModule(ctx context.Context, namespace, name, provider, version string) (io.ReadCloser, error)
+	GetModuleDocs(ctx context.Context, namespace, name, provider, version string) (*module.Docs, error)
+	ListProviderVersions(ctx context.Context, namespace, name string) (*core.ProviderVersions, error)
+}
+
+// Service answers which modules and providers the published modules depend on
+type Service interface {
+	// GetGraph returns the dependencies of the modules selected by the filter
+	GetGraph(ctx context.Context, filter Filter) ([]Edge, error)
+
+	// GetDependencies returns the direct dependencies of the module version, or of the latest version if the version is empty
+	GetDependencies(ctx context.Context, namespace, name, provider, version string) ([]Edge, error)
+
+	// GetDependents returns the dependencies of the modules selected by the filter on the module or provider of the query
+	GetDependents(ctx context.Context, query Query) ([]Edge, error)
+}
+
+// Filter selects the modules whose dependencies are returned
+type Filter struct {
+	// Namespace restricts the modules to a namespace if it isn't empty
+	Namespace string
+
+	// AllVersions selects every version of a module instead of only the latest version
+	AllVersions bool
+}
+
+// Query selects the dependents of a module or provider
+type Query struct {
+	Filter
+
+	Kind Kind
+
+	// Address is the registry address of the module or provider like it's used in the source attribute.
+	// The hostname is optional, and matches every hostname if it's missing
+	Address string
+
+	// Constraint selects the dependents whose version constraint allows a published version that matches it, e.g. ">= 3.0".
+	// Dependents are always selected if the module or provider isn't published in this registry, as the versions are unknown
+	Constraint string
+}
+
+type service struct {
+	storage Storage
+
+	// edges caches the dependencies by module version, which can't change once a version is published
+	mu    sync.RWMutex
+	edges map[string][]Edge
+}
+
+// NewService returns a fully initialized Service.
+func NewService(storage Storage) Service {
+	return &service{
+		storage: storage,
+		edges:   make(map[string][]Edge),
+	}
+}
+
+func (s *service) GetGraph(ctx context.Context, filter Filter) ([]Edge, error) {
+	modules, err := s.modules(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []Edge{}
+	for _, m := range modules {
+		edges, err := s.moduleEdges(ctx, m)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, edges...)
+	}
+	return result, nil
+}
+
+func (s *service) GetDependencies(ctx context.Context, namespace, name, provider, version string) ([]Edge, error) {
+	modules, err := s.storage.ListModuleVersions(ctx, namespace, name, provider)
+	if err != nil {
+		return nil, err
+	}
+
+	if version == "" {
+		versions := make([]string, 0, len(modules))
+		for _, m := range modules {
+			versions = append(versions, m.Version)
+		}
+		version = core.LatestVersion(versions)
+	}
+
+	for _, m := range modules {
+		if m.Version == version {
+			return s.moduleEdges(ctx, m)
+		}
+	}
+	return nil, fmt.Errorf("%w: %s/%s/%s/%s", module.ErrModuleNotFound, namespace, name, provider, version)
+}
+
+func (s *service) GetDependents(ctx context.Context, query Query) ([]Edge, error) {
+	var target address
+	var err error
+	switch query.Kind {
+	case KindModule:
+		target, err = parseAddress(query.Address, 3)
+	case KindProvider:
+		target, err = parseAddress(query.Address, 2)
+	default:
+		err = fmt.Errorf("%w: unknown kind %q", ErrInvalidAddress, query.Kind)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var constraint version.Constraints
+	var candidates []*version.Version
+	if query.Constraint != "" {
+		if constraint, err = version.NewConstraint(query.Constraint); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidConstraint, err)
+		}
+		if candidates, err = s.publishedVersions(ctx, query.Kind, target); err != nil {
+			return nil, err
+		}
+	}
+
+	edges, err := s.GetGraph(ctx, query.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []Edge{}
+	for _, e := range edges {
+		if e.Kind != query.Kind {
+			continue
+		}
+
+		var to address
+		if e.Kind == KindModule {
+			var ok bool
+			if to, ok = parseModuleSource(e.To); !ok {
+				continue
+			}
+		} else if to, err = parseAddress(e.To, 2); err != nil {
+			continue
+		}
+
+		if to.matches(target) && allows(e.Constraint, constraint, candidates) {
+			result = append(result, e)
+		}
+	}
+	return result, nil
+}
+
+// allows returns whether one of the candidates matches both the constraint of the edge and the constraint of the query.
+// Edges without valid constraint allow every version, and all edges are allowed if there are no candidates.
+func allows(edgeConstraint string, query version.Constraints, candidates []*version.Version) bool {
+	if query == nil || len(candidates) == 0 {
+		return true
+	}
+
+	c, err := version.NewConstraint(edgeConstraint)
+	if err != nil {
+		c = nil
+	}
+	for _, v := range candidates {
+		if query.Check(v) && (c == nil || c.Check(v)) {
+			return true
+		}
+	}
+	return false
+}
+
+// publishedVersions returns the versions of the module or provider that are published in this registry.
+// The hostname of the address is ignored, as the registry doesn't know the hostnames it's served at.
+func (s *service) publishedVersions(ctx context.Context, kind Kind, a address) ([]*version.Version, error) {
+	var raw []string
+	if kind == KindModule {
+		modules, err := s.storage.ListModuleVersions(ctx, a.namespace, a.name, a.provider)
+		if err != nil && !errors.Is(err, module.ErrModuleNotFound) {
+			return nil, err
+		}
+		for _, m := range modules {
+			raw = append(raw, m.Version)
+		}
+	} else {
+		providers, err := s.storage.ListProviderVersions(ctx, a.namespace, a.name)
+		if err != nil && !errors.Is(err, provider.ErrProviderNotFound) {
+			return nil, err
+		}
+		if providers != nil {
+			for _, p := range providers.Versions {
+				raw = append(raw, p.Version)
+			}
+		}
+	}
+
+	versions := make([]*version.Version, 0, len(raw))
+	for _, r := range raw {
+		if v, err := version.NewVersion(r); err == nil {
+			versions = append(versions, v)
+		}
+	}
+	return versions, nil
+}
+
+// modules returns the module versions selected by the filter, sorted by their ID
+func (s *service) modules(ctx context.Context, filter Filter) ([]core.Module, error) {
+	modules, err := s.storage.ListModules(ctx, filter.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	if !filter.AllVersions {
+		versions := make(map[string][]string)
+		for _, m := range modules {
+			versions[m.ID(false)] = append(versions[m.ID(false)], m.Version)
+		}
+
+		latest := make([]core.Module, 0, len(versions))
+		for _, m := range modules {
+			if m.Version == core.LatestVersion(versions[m.ID(false)]) {
+				latest = append(latest, m)
+			}
+		}
+		modules = latest
+	}
+
+	sort.Slice(modules, func(i, j int) bool {
+		return modules[i].ID(true) < modules[j].ID(true)
+	})
+	return modules, nil
+}
+
+// moduleEdges returns the dependencies of the module version.
+// Archives the docs can't be extracted from don't have any dependencies, so that a single broken version doesn't break the graph.
+func (s *service) moduleEdges(ctx context.Context, m core.Module) ([]Edge, error) {
+	id := m.ID(true)
+
+	s.mu.RLock()
+	edges, ok := s.edges[id]
+	s.mu.RUnlock()
+	if ok {
+		return edges, nil
+	}
+
+	docs, err := s.docs(ctx, m)
+	if errors.Is(err, module.ErrInvalidArchive) {
+		slog.Warn("failed to extract dependencies", slog.String("module", id), slog.String("err", err.Error()))
+		docs = &module.Docs{}
+	} else if err != nil {
+		return nil, err
+	}
+
+	edges = []Edge{}
+	for _, d := range append([]module.ModuleDocs{docs.Root}, docs.Submodules...) {
+		for _, dep := range d.Dependencies {
+			if dep.Source == "" || strings.HasPrefix(dep.Source, "./") || strings.HasPrefix(dep.Source, "../") {
+				continue
+			}
+
+			to := dep.Source
+			if a, ok := parseModuleSource(dep.Source); ok {
+				to = a.String()
+			}
+			edges = append(edges, Edge{From: id, Path: d.Path, Kind: KindModule, To: to, Constraint: dep.Version})
+		}
+
+		for _, dep := range d.ProviderDependencies {
+			edges = append(edges, Edge{From: id, Path: d.Path, Kind: KindProvider, To: providerSource(dep.Name, dep.Source), Constraint: dep.Version})
+		}
+	}
+
+	s.mu.Lock()
+	s.edges[id] = edges
+	s.mu.Unlock()
+	return edges, nil
+}
+
+// docs returns the docs stored when the module version was uploaded,
+// which are extracted from the archive for module versions uploaded before docs were stored
+func (s *service) docs(ctx context.Context, m core.Module) (*module.Docs, error) {
+	docs, err := s.storage.GetModuleDocs(ctx, m.Namespace, m.Name, m.Provider, m.Version)
+	if !errors.Is(err, module.ErrDocsNotFound) {
+		return docs, err
+	}
+
+	archive, err := s.storage.DownloadModule(ctx, m.Namespace, m.Name, m.Provider, m.Version)
+	if err != nil {
+		return nil, err
+	}
+	defer archive.Close()
+
+	return module.ExtractDocs(archive)
+}
@@ -0,0 +1,250 @@
+package graph
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"testing"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/module"
+	"github.com/boring-registry/boring-registry/pkg/provider"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testStorage struct {
+	*module.InmemStorage
+	providers []core.ProviderVersion
+}
+
+func (s *testStorage) ListProviderVersions(_ context.Context, namespace, name string) (*core.ProviderVersions, error) {
+	versions := &core.ProviderVersions{}
+	for _, p := range s.providers {
+		if p.Namespace == namespace && p.Name == name {
+			versions.Versions = append(versions.Versions, p)
+		}
+	}
+	if len(versions.Versions) == 0 {
+		return nil, provider.ErrProviderNotFound
+	}
+	return versions, nil
+}
+
+func testArchive(t *testing.T, files map[string]string) *bytes.Buffer {
+	t.Helper()
+
+	buf := new(bytes.Buffer)
+	gw := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gw)
+	for name, content := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content))}); err != nil {
+			t.Fatal(err)
+		}
+		_, _ = tw.Write([]byte(content))
+	}
+	_ = tw.Close()
+	_ = gw.Close()
+	return buf
+}
+
+func newTestService(t *testing.T) Service {
+	t.Helper()
+
+	ctx := context.Background()
+	modules := module.NewInmemStorage().(*module.InmemStorage)
+	for _, m := range []struct {
+		id    core.Module
+		files map[string]string
+	}{
+		{
+			id: core.Module{Namespace: "network", Name: "vpc", Provider: "aws", Version: "2.0.0"},
+			files: map[string]string{"main.tf": `
+terraform {
+  required_providers {
+    aws = { source = "hashicorp/aws", version = ">= 4.0" }
+  }
+}
+`},
+		},
+		{
+			id: core.Module{Namespace: "network", Name: "vpc", Provider: "aws", Version: "3.0.0"},
+			files: map[string]string{"main.tf": `
+terraform {
+  required_providers {
+    aws = { source = "hashicorp/aws", version = ">= 5.0" }
+  }
+}
+`},
+		},
+		{
+			id: core.Module{Namespace: "example", Name: "app", Provider: "aws", Version: "1.0.0"},
+			files: map[string]string{
+				"main.tf": `
+module "vpc" {
+  source  = "registry.example.com/network/vpc/aws"
+  version = "~> 2.0"
+}
+`,
+			},
+		},
+		{
+			id: core.Module{Namespace: "example", Name: "app", Provider: "aws", Version: "1.1.0"},
+			files: map[string]string{
+				"main.tf": `
+module "vpc" {
+  source  = "registry.example.com/network/vpc/aws"
+  version = ">= 3.0"
+}
+
+module "local" {
+  source = "./modules/local"
+}
+`,
+				"modules/dns/main.tf": `
+module "zone" {
+  source = "git::https://example.com/dns.git"
+}
+
+terraform {
+  required_providers {
+    aws = ">= 5.0"
+  }
+}
+`,
+			},
+		},
+		{
+			id: core.Module{Namespace: "other", Name: "legacy", Provider: "aws", Version: "0.1.0"},
+			files: map[string]string{
+				"main.tf": `
+module "vpc" {
+  source  = "registry.example.com/network/vpc/aws//modules/subnets"
+  version = "~> 2.0"
+}
+`,
+			},
+		},
+	} {
+		_, err := modules.UploadModule(ctx, m.id.Namespace, m.id.Name, m.id.Provider, m.id.Version, testArchive(t, m.files))
+		assert.NoError(t, err)
+	}
+
+	return NewService(&testStorage{
+		InmemStorage: modules,
+		providers: []core.ProviderVersion{
+			{Namespace: "hashicorp", Name: "aws", Version: "4.67.0"},
+			{Namespace: "hashicorp", Name: "aws", Version: "5.31.0"},
+		},
+	})
+}
+
+func TestService_GetGraph(t *testing.T) {
+	t.Parallel()
+
+	svc := newTestService(t)
+	ctx := context.Background()
+
+	edges, err := svc.GetGraph(ctx, Filter{Namespace: "example"})
+	assert.NoError(t, err)
+	assert.Equal(t, []Edge{
+		{From: "example/app/aws/1.1.0", Kind: KindModule, To: "registry.example.com/network/vpc/aws", Constraint: ">= 3.0"},
+		{From: "example/app/aws/1.1.0", Path: "modules/dns", Kind: KindModule, To: "git::https://example.com/dns.git"},
+		{From: "example/app/aws/1.1.0", Path: "modules/dns", Kind: KindProvider, To: "hashicorp/aws", Constraint: ">= 5.0"},
+	}, edges)
+
+	edges, err = svc.GetGraph(ctx, Filter{AllVersions: true})
+	assert.NoError(t, err)
+	assert.Len(t, edges, 7)
+}
+
+func TestService_GetDependencies(t *testing.T) {
+	t.Parallel()
+
+	svc := newTestService(t)
+	ctx := context.Background()
+
+	edges, err := svc.GetDependencies(ctx, "network", "vpc", "aws", "")
+	assert.NoError(t, err)
+	assert.Equal(t, []Edge{{From: "network/vpc/aws/3.0.0", Kind: KindProvider, To: "hashicorp/aws", Constraint: ">= 5.0"}}, edges)
+
+	edges, err = svc.GetDependencies(ctx, "example", "app", "aws", "1.0.0")
+	assert.NoError(t, err)
+	assert.Equal(t, []Edge{{From: "example/app/aws/1.0.0", Kind: KindModule, To: "registry.example.com/network/vpc/aws", Constraint: "~> 2.0"}}, edges)
+
+	_, err = svc.GetDependencies(ctx, "example", "app", "aws", "2.0.0")
+	assert.ErrorIs(t, err, module.ErrModuleNotFound)
+}
+
+func TestService_GetDependents(t *testing.T) {
+	t.Parallel()
+
+	svc := newTestService(t)
+	ctx := context.Background()
+
+	testCases := []struct {
+		name          string
+		query         Query
+		expectedFrom  []string
+		expectedError error
+	}{
+		{
+			name:         "module without hostname",
+			query:        Query{Kind: KindModule, Address: "network/vpc/aws"},
+			expectedFrom: []string{"example/app/aws/1.1.0", "other/legacy/aws/0.1.0"},
+		},
+		{
+			name:         "module of another registry",
+			query:        Query{Kind: KindModule, Address: "registry.terraform.io/network/vpc/aws"},
+			expectedFrom: []string{},
+		},
+		{
+			name:         "module with constraint",
+			query:        Query{Kind: KindModule, Address: "registry.example.com/network/vpc/aws", Constraint: ">= 3.0"},
+			expectedFrom: []string{"example/app/aws/1.1.0"},
+		},
+		{
+			name:         "module with constraint in all versions",
+			query:        Query{Filter: Filter{AllVersions: true}, Kind: KindModule, Address: "network/vpc/aws", Constraint: "< 3.0"},
+			expectedFrom: []string{"example/app/aws/1.0.0", "other/legacy/aws/0.1.0"},
+		},
+		{
+			name:         "provider with constraint",
+			query:        Query{Filter: Filter{AllVersions: true}, Kind: KindProvider, Address: "hashicorp/aws", Constraint: "< 5.0"},
+			expectedFrom: []string{"network/vpc/aws/2.0.0"},
+		},
+		{
+			name:         "provider that isn't published",
+			query:        Query{Kind: KindProvider, Address: "hashicorp/google", Constraint: ">= 5.0"},
+			expectedFrom: []string{},
+		},
+		{
+			name:          "invalid address",
+			query:         Query{Kind: KindModule, Address: "network/vpc"},
+			expectedError: ErrInvalidAddress,
+		},
+		{
+			name:          "invalid constraint",
+			query:         Query{Kind: KindProvider, Address: "hashicorp/aws", Constraint: "latest"},
+			expectedError: ErrInvalidConstraint,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			edges, err := svc.GetDependents(ctx, tc.query)
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+
+			from := []string{}
+			for _, e := range edges {
+				from = append(from, e.From)
+			}
+			assert.Equal(t, tc.expectedFrom, from)
+		})
+	}
+}
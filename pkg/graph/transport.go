@@ -0,0 +1,173 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/module"
+	o11y "github.com/boring-registry/boring-registry/pkg/observability"
+
+	"github.com/go-kit/kit/auth/jwt"
+	"github.com/go-kit/kit/endpoint"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+)
+
+type muxVar string
+
+const (
+	varNamespace muxVar = "namespace"
+	varName      muxVar = "name"
+	varProvider  muxVar = "provider"
+	varVersion   muxVar = "version"
+)
+
+// MakeHandler returns a fully initialized http.Handler.
+func MakeHandler(svc Service, auth endpoint.Middleware, instrumentation o11y.Middleware, options ...httptransport.ServerOption) http.Handler {
+	r := mux.NewRouter().StrictSlash(true)
+
+	r.Methods("GET").Path(`/`).Handler(
+		instrumentation.WrapHandler(
+			httptransport.NewServer(
+				auth(graphEndpoint(svc)),
+				decodeGraphRequest,
+				httptransport.EncodeJSONResponse,
+				append(
+					options,
+					httptransport.ServerBefore(jwt.HTTPToContext()),
+				)...,
+			),
+		),
+	)
+
+	r.Methods("GET").Path(`/dependents`).Handler(
+		instrumentation.WrapHandler(
+			httptransport.NewServer(
+				auth(dependentsEndpoint(svc)),
+				decodeDependentsRequest,
+				httptransport.EncodeJSONResponse,
+				append(
+					options,
+					httptransport.ServerBefore(jwt.HTTPToContext()),
+				)...,
+			),
+		),
+	)
+
+	for _, path := range []string{`/modules/{namespace}/{name}/{provider}`, `/modules/{namespace}/{name}/{provider}/{version}`} {
+		r.Methods("GET").Path(path).Handler(
+			instrumentation.WrapHandler(
+				httptransport.NewServer(
+					auth(dependenciesEndpoint(svc)),
+					decodeDependenciesRequest,
+					httptransport.EncodeJSONResponse,
+					append(
+						options,
+						httptransport.ServerBefore(extractMuxVars(varNamespace, varName, varProvider, varVersion)),
+						httptransport.ServerBefore(jwt.HTTPToContext()),
+					)...,
+				),
+			),
+		)
+	}
+
+	return r
+}
+
+func decodeGraphRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	filter, err := decodeFilter(r.URL.Query())
+	if err != nil {
+		return nil, err
+	}
+
+	return graphRequest{
+		filter: filter,
+	}, nil
+}
+
+func decodeDependenciesRequest(ctx context.Context, _ *http.Request) (interface{}, error) {
+	var req dependenciesRequest
+	for v, target := range map[muxVar]*string{varNamespace: &req.namespace, varName: &req.name, varProvider: &req.provider} {
+		value, ok := ctx.Value(v).(string)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", core.ErrVarMissing, v)
+		}
+		*target = value
+	}
+
+	// The version is missing when the dependencies of the latest version are requested
+	req.version, _ = ctx.Value(varVersion).(string)
+	return req, nil
+}
+
+// decodeDependentsRequest reads the address of the module or provider from the module or provider query parameter
+func decodeDependentsRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	query := r.URL.Query()
+	filter, err := decodeFilter(query)
+	if err != nil {
+		return nil, err
+	}
+
+	q := Query{
+		Filter:     filter,
+		Constraint: query.Get("version"),
+	}
+	switch m, p := query.Get("module"), query.Get("provider"); {
+	case m != "" && p != "":
+		return nil, fmt.Errorf("%w: only one of module and provider can be set", core.ErrVarType)
+	case m != "":
+		q.Kind, q.Address = KindModule, m
+	case p != "":
+		q.Kind, q.Address = KindProvider, p
+	default:
+		return nil, fmt.Errorf("%w: module or provider", core.ErrVarMissing)
+	}
+
+	return dependentsRequest{
+		query: q,
+	}, nil
+}
+
+func decodeFilter(query url.Values) (Filter, error) {
+	filter := Filter{
+		Namespace: query.Get("namespace"),
+	}
+	if v := query.Get("all_versions"); v != "" {
+		allVersions, err := strconv.ParseBool(v)
+		if err != nil {
+			return Filter{}, fmt.Errorf("%w: all_versions has to be a boolean", core.ErrVarType)
+		}
+		filter.AllVersions = allVersions
+	}
+	return filter, nil
+}
+
+// ErrorEncoder translates domain specific errors to HTTP status codes
+func ErrorEncoder(_ context.Context, err error, w http.ResponseWriter) {
+	if errors.Is(err, module.ErrModuleNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+	} else if errors.Is(err, ErrInvalidAddress) || errors.Is(err, ErrInvalidConstraint) {
+		w.WriteHeader(http.StatusBadRequest)
+	} else {
+		w.WriteHeader(core.GenericError(err))
+	}
+
+	core.HandleErrorResponse(err, w)
+}
+
+func extractMuxVars(keys ...muxVar) httptransport.RequestFunc {
+	return func(ctx context.Context, r *http.Request) context.Context {
+		for _, k := range keys {
+			if v, ok := mux.Vars(r)[string(k)]; ok {
+				ctx = context.WithValue(ctx, k, v)
+			}
+		}
+
+		return ctx
+	}
+}
@@ -0,0 +1,51 @@
+package metadata
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+)
+
+type listUploadsRequest struct {
+	filter Filter
+}
+
+type listUploadsResponse struct {
+	Uploads []Record `json:"uploads"`
+}
+
+func listUploadsEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(listUploadsRequest)
+
+		res, err := svc.ListUploads(ctx, req.filter)
+		if err != nil {
+			return nil, err
+		}
+
+		if res == nil {
+			res = []Record{}
+		}
+
+		return listUploadsResponse{
+			Uploads: res,
+		}, nil
+	}
+}
+
+type listNamespacesResponse struct {
+	Namespaces []NamespaceSummary `json:"namespaces"`
+}
+
+func listNamespacesEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, _ interface{}) (interface{}, error) {
+		res, err := svc.ListNamespaces(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		return listNamespacesResponse{
+			Namespaces: res,
+		}, nil
+	}
+}
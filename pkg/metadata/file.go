@@ -0,0 +1,258 @@
+package metadata
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// minJournalEntries is the number of changes the journal holds at least before it's compacted into the snapshot
+const minJournalEntries = 1000
+
+// FileStore is a Store keeping all records in memory, which are persisted to a snapshot file and a journal next to it.
+// Changes are appended to the journal, which is compacted into the snapshot once it holds more entries than the snapshot,
+// so that a change doesn't rewrite all records. No embedded database like bbolt or SQLite is vendored, which is why the
+// store is implemented on plain files. The files are owned by a single process, so they must not be shared between replicas.
+type FileStore struct {
+	mu      sync.RWMutex
+	path    string
+	journal *os.File
+	entries int
+	records map[string]Record
+}
+
+// journalEntry is a single change of the store, which either puts a record or deletes the record with the key
+type journalEntry struct {
+	Record *Record `json:"record,omitempty"`
+	Delete string  `json:"delete,omitempty"`
+}
+
+func (s *FileStore) Put(_ context.Context, records ...Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries := make([]journalEntry, 0, len(records))
+	for i := range records {
+		entries = append(entries, journalEntry{Record: &records[i]})
+	}
+	if err := s.append(entries); err != nil {
+		return err
+	}
+
+	for _, r := range records {
+		s.records[r.Key] = r
+	}
+	return s.compactIfNeeded()
+}
+
+func (s *FileStore) Delete(_ context.Context, keys ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries := make([]journalEntry, 0, len(keys))
+	for _, key := range keys {
+		entries = append(entries, journalEntry{Delete: key})
+	}
+	if err := s.append(entries); err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		delete(s.records, key)
+	}
+	return s.compactIfNeeded()
+}
+
+func (s *FileStore) Query(_ context.Context, filter Filter) ([]Record, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var records []Record
+	for _, r := range s.records {
+		if filter.Matches(r) {
+			records = append(records, r)
+		}
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].UploadedAt.Equal(records[j].UploadedAt) {
+			return records[i].Key < records[j].Key
+		}
+		return records[i].UploadedAt.After(records[j].UploadedAt)
+	})
+	return records, nil
+}
+
+func (s *FileStore) Replace(_ context.Context, records []Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records = make(map[string]Record, len(records))
+	for _, r := range records {
+		s.records[r.Key] = r
+	}
+	return s.compact()
+}
+
+// Close closes the journal. The store can't be changed afterwards
+func (s *FileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.journal.Close()
+}
+
+// append writes the entries to the journal and syncs it, so that the changes survive a crash
+func (s *FileStore) append(entries []journalEntry) error {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, e := range entries {
+		if err := encoder.Encode(e); err != nil {
+			return err
+		}
+	}
+
+	if _, err := s.journal.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to persist metadata: %w", err)
+	}
+	if err := s.journal.Sync(); err != nil {
+		return fmt.Errorf("failed to persist metadata: %w", err)
+	}
+	s.entries += len(entries)
+	return nil
+}
+
+func (s *FileStore) compactIfNeeded() error {
+	if s.entries < minJournalEntries || s.entries < len(s.records) {
+		return nil
+	}
+	return s.compact()
+}
+
+// compact writes all records to the snapshot and truncates the journal.
+// Replaying the journal on top of the new snapshot is harmless, in case the process stops before the journal is truncated.
+func (s *FileStore) compact() error {
+	if err := s.persist(); err != nil {
+		return err
+	}
+	if err := s.journal.Truncate(0); err != nil {
+		return fmt.Errorf("failed to truncate metadata journal: %w", err)
+	}
+	s.entries = 0
+	return nil
+}
+
+// persist writes the records to a temporary file first, which then replaces the snapshot atomically
+func (s *FileStore) persist() error {
+	records := make([]Record, 0, len(s.records))
+	for _, r := range s.records {
+		records = append(records, r)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Key < records[j].Key
+	})
+
+	b, err := json.Marshal(records)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to persist metadata: %w", err)
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to persist metadata: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to persist metadata: %w", err)
+	}
+
+	return os.Rename(f.Name(), s.path)
+}
+
+// replay applies the changes of the journal to the records loaded from the snapshot.
+// A truncated last entry is skipped, as the process stopped while appending it.
+func (s *FileStore) replay(path string) error {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64<<10), 16<<20)
+	for scanner.Scan() {
+		var e journalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			slog.Warn("skipping invalid entry of the metadata journal", slog.String("path", path), slog.String("err", err.Error()))
+			continue
+		}
+
+		if e.Record != nil {
+			s.records[e.Record.Key] = *e.Record
+		} else if e.Delete != "" {
+			delete(s.records, e.Delete)
+		}
+	}
+	return scanner.Err()
+}
+
+// NewFileStore returns a FileStore persisting to the file at path and the journal at path.journal.
+// Existing records are loaded from the files, and the journal is compacted into the snapshot.
+func NewFileStore(path string) (*FileStore, error) {
+	if path == "" {
+		return nil, errors.New("path of the metadata store is empty")
+	}
+
+	s := &FileStore{
+		path:    path,
+		records: make(map[string]Record),
+	}
+
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, err
+	} else {
+		var records []Record
+		if err := json.Unmarshal(b, &records); err != nil {
+			return nil, fmt.Errorf("failed to load metadata store %s: %w", path, err)
+		}
+		for _, r := range records {
+			s.records[r.Key] = r
+		}
+	}
+
+	journalPath := path + ".journal"
+	if err := s.replay(journalPath); err != nil {
+		return nil, fmt.Errorf("failed to load metadata journal %s: %w", journalPath, err)
+	}
+
+	s.journal, err = os.OpenFile(journalPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.compact(); err != nil {
+		s.journal.Close()
+		return nil, err
+	}
+
+	return s, nil
+}
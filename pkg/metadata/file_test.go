@@ -0,0 +1,122 @@
+package metadata
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileStore(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "nested", "metadata.json")
+	now := time.Now().UTC()
+
+	s, err := NewFileStore(path)
+	assert.NoError(t, err)
+	t.Cleanup(func() { s.Close() })
+
+	records := []Record{
+		{Key: "modules/example/vpc/aws/example-vpc-aws-1.0.0.tar.gz", Kind: KindModule, Namespace: "example", Name: "vpc", Provider: "aws", Version: "1.0.0", UploadedAt: now.Add(-48 * time.Hour)},
+		{Key: "modules/example/vpc/aws/example-vpc-aws-1.1.0.tar.gz", Kind: KindModule, Namespace: "example", Name: "vpc", Provider: "aws", Version: "1.1.0", UploadedAt: now},
+		{Key: "providers/other/dummy/terraform-provider-dummy_1.0.0_linux_amd64.zip", Kind: KindProvider, Namespace: "other", Name: "dummy", Version: "1.0.0", UploadedAt: now.Add(-time.Hour)},
+	}
+	assert.NoError(t, s.Put(ctx, records...))
+
+	testCases := []struct {
+		name   string
+		filter Filter
+		keys   []string
+	}{
+		{
+			name:   "all records sorted by upload time",
+			filter: Filter{},
+			keys:   []string{records[1].Key, records[2].Key, records[0].Key},
+		},
+		{
+			name:   "namespace",
+			filter: Filter{Namespace: "example"},
+			keys:   []string{records[1].Key, records[0].Key},
+		},
+		{
+			name:   "kind",
+			filter: Filter{Kind: KindProvider},
+			keys:   []string{records[2].Key},
+		},
+		{
+			name:   "since",
+			filter: Filter{Since: now.Add(-24 * time.Hour)},
+			keys:   []string{records[1].Key, records[2].Key},
+		},
+		{
+			name:   "until is exclusive",
+			filter: Filter{Until: now},
+			keys:   []string{records[2].Key, records[0].Key},
+		},
+	}
+
+	for _, tc := range testCases {
+		got, err := s.Query(ctx, tc.filter)
+		assert.NoError(t, err)
+		var keys []string
+		for _, r := range got {
+			keys = append(keys, r.Key)
+		}
+		assert.Equal(t, tc.keys, keys, tc.name)
+	}
+
+	// The records are loaded again from the file
+	assert.NoError(t, s.Delete(ctx, records[0].Key))
+	reloaded, err := NewFileStore(path)
+	assert.NoError(t, err)
+	t.Cleanup(func() { reloaded.Close() })
+	got, err := reloaded.Query(ctx, Filter{})
+	assert.NoError(t, err)
+	assert.Len(t, got, 2)
+
+	assert.NoError(t, reloaded.Replace(ctx, records[:1]))
+	got, err = reloaded.Query(ctx, Filter{})
+	assert.NoError(t, err)
+	assert.Equal(t, records[:1], got)
+}
+
+func TestFileStore_Journal(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "metadata.json")
+	record := Record{Key: "modules/example/vpc/aws/example-vpc-aws-1.0.0.tar.gz", Kind: KindModule, Namespace: "example", Version: "1.0.0", UploadedAt: time.Now().UTC()}
+
+	s, err := NewFileStore(path)
+	assert.NoError(t, err)
+	t.Cleanup(func() { s.Close() })
+	assert.NoError(t, s.Put(ctx, record))
+
+	// Changes are only appended to the journal, the snapshot is written when the journal is compacted
+	snapshot, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "[]", string(snapshot))
+
+	// An entry that was only partially appended is skipped
+	f, err := os.OpenFile(path+".journal", os.O_WRONLY|os.O_APPEND, 0o644)
+	assert.NoError(t, err)
+	_, err = f.WriteString(`{"delete":"modules/exa`)
+	assert.NoError(t, err)
+	assert.NoError(t, f.Close())
+
+	reloaded, err := NewFileStore(path)
+	assert.NoError(t, err)
+	t.Cleanup(func() { reloaded.Close() })
+	got, err := reloaded.Query(ctx, Filter{})
+	assert.NoError(t, err)
+	assert.Equal(t, []Record{record}, got)
+
+	journal, err := os.ReadFile(path + ".journal")
+	assert.NoError(t, err)
+	assert.Empty(t, journal)
+}
@@ -0,0 +1,101 @@
+// Package metadata records every upload to the storage backend in an embedded store.
+// This allows answering questions like "what was published this week" without scanning the storage backend.
+package metadata
+
+import (
+	"context"
+	"time"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+)
+
+// Kind is the type of artifact a Record describes
+type Kind string
+
+const (
+	KindModule           Kind = "module"
+	KindProvider         Kind = "provider"
+	KindMirroredProvider Kind = "mirrored_provider"
+)
+
+// Record describes a single uploaded artifact.
+// Providers are recorded per archive, so Platforms always holds a single platform for them.
+type Record struct {
+	Key        string          `json:"key"`
+	Kind       Kind            `json:"kind"`
+	Hostname   string          `json:"hostname,omitempty"`
+	Namespace  string          `json:"namespace"`
+	Name       string          `json:"name"`
+	Provider   string          `json:"provider,omitempty"`
+	Version    string          `json:"version"`
+	Platforms  []core.Platform `json:"platforms,omitempty"`
+	Size       int64           `json:"size"`
+	SHA256     string          `json:"sha256,omitempty"`
+	Uploader   string          `json:"uploader,omitempty"`
+	UploadedAt time.Time       `json:"uploaded_at"`
+}
+
+// Filter selects records. Empty attributes match every record
+type Filter struct {
+	Kind      Kind
+	Hostname  string
+	Namespace string
+	Name      string
+	Provider  string
+	Version   string
+	Since     time.Time
+	Until     time.Time
+}
+
+// Matches returns whether the record is selected by the filter
+func (f Filter) Matches(r Record) bool {
+	switch {
+	case f.Kind != "" && f.Kind != r.Kind:
+		return false
+	case f.Hostname != "" && f.Hostname != r.Hostname:
+		return false
+	case f.Namespace != "" && f.Namespace != r.Namespace:
+		return false
+	case f.Name != "" && f.Name != r.Name:
+		return false
+	case f.Provider != "" && f.Provider != r.Provider:
+		return false
+	case f.Version != "" && f.Version != r.Version:
+		return false
+	case !f.Since.IsZero() && r.UploadedAt.Before(f.Since):
+		return false
+	case !f.Until.IsZero() && !r.UploadedAt.Before(f.Until):
+		return false
+	}
+	return true
+}
+
+// Store persists records, which are identified by their storage key
+type Store interface {
+	// Put adds the records or replaces records with the same key
+	Put(ctx context.Context, records ...Record) error
+
+	// Delete removes the records with the given keys
+	Delete(ctx context.Context, keys ...string) error
+
+	// Query returns all records matching the filter, sorted by the upload time with the most recent first
+	Query(ctx context.Context, filter Filter) ([]Record, error)
+
+	// Replace replaces all records of the store, which is used when rebuilding the store from the storage backend
+	Replace(ctx context.Context, records []Record) error
+}
+
+type contextKey string
+
+const uploaderContextKey contextKey = "uploader"
+
+// WithUploader returns a context carrying the identity of the uploader, which is recorded with uploads
+func WithUploader(ctx context.Context, uploader string) context.Context {
+	return context.WithValue(ctx, uploaderContextKey, uploader)
+}
+
+// UploaderFromContext returns the identity of the uploader or an empty string
+func UploaderFromContext(ctx context.Context) string {
+	uploader, _ := ctx.Value(uploaderContextKey).(string)
+	return uploader
+}
@@ -0,0 +1,83 @@
+package metadata
+
+import (
+	"context"
+	"sort"
+)
+
+// Service answers queries about uploaded artifacts from the Store
+type Service interface {
+	ListUploads(ctx context.Context, filter Filter) ([]Record, error)
+	ListNamespaces(ctx context.Context) ([]NamespaceSummary, error)
+}
+
+// NamespaceSummary aggregates the modules and providers published in a namespace.
+// Mirrored providers aren't part of the summary, as they don't belong to a namespace of this registry.
+type NamespaceSummary struct {
+	Namespace string `json:"namespace"`
+	Modules   int    `json:"modules"`
+	Providers int    `json:"providers"`
+	Uploads   int    `json:"uploads"`
+	Size      int64  `json:"size"`
+}
+
+type service struct {
+	store Store
+}
+
+// NewService returns a fully initialized Service.
+func NewService(store Store) Service {
+	return &service{
+		store: store,
+	}
+}
+
+func (s *service) ListUploads(ctx context.Context, filter Filter) ([]Record, error) {
+	return s.store.Query(ctx, filter)
+}
+
+func (s *service) ListNamespaces(ctx context.Context) ([]NamespaceSummary, error) {
+	records, err := s.store.Query(ctx, Filter{})
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make(map[string]*NamespaceSummary)
+	// Module versions and provider versions are counted once, even though providers are recorded per platform
+	versions := make(map[string]struct{})
+	for _, r := range records {
+		if r.Kind == KindMirroredProvider {
+			continue
+		}
+
+		summary, ok := summaries[r.Namespace]
+		if !ok {
+			summary = &NamespaceSummary{Namespace: r.Namespace}
+			summaries[r.Namespace] = summary
+		}
+		summary.Uploads++
+		summary.Size += r.Size
+
+		id := string(r.Kind) + "/" + r.Namespace + "/" + r.Name + "/" + r.Provider + "/" + r.Version
+		if _, ok := versions[id]; ok {
+			continue
+		}
+		versions[id] = struct{}{}
+
+		if r.Kind == KindModule {
+			summary.Modules++
+		} else {
+			summary.Providers++
+		}
+	}
+
+	result := make([]NamespaceSummary, 0, len(summaries))
+	for _, summary := range summaries {
+		result = append(result, *summary)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Namespace < result[j].Namespace
+	})
+
+	return result, nil
+}
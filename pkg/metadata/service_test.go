@@ -0,0 +1,35 @@
+package metadata
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestService_ListNamespaces(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	store, err := NewFileStore(filepath.Join(t.TempDir(), "metadata.json"))
+	assert.NoError(t, err)
+
+	assert.NoError(t, store.Put(ctx,
+		Record{Key: "a", Kind: KindModule, Namespace: "example", Name: "vpc", Provider: "aws", Version: "1.0.0", Size: 10},
+		Record{Key: "b", Kind: KindModule, Namespace: "example", Name: "vpc", Provider: "aws", Version: "1.1.0", Size: 20},
+		Record{Key: "c", Kind: KindProvider, Namespace: "example", Name: "dummy", Version: "1.0.0", Size: 100, Platforms: []core.Platform{{OS: "linux", Arch: "amd64"}}},
+		Record{Key: "d", Kind: KindProvider, Namespace: "example", Name: "dummy", Version: "1.0.0", Size: 100, Platforms: []core.Platform{{OS: "darwin", Arch: "arm64"}}},
+		Record{Key: "e", Kind: KindModule, Namespace: "other", Name: "vpc", Provider: "aws", Version: "1.0.0", Size: 5},
+		Record{Key: "f", Kind: KindMirroredProvider, Hostname: "registry.terraform.io", Namespace: "hashicorp", Name: "aws", Version: "5.0.0", Size: 1000},
+	))
+
+	got, err := NewService(store).ListNamespaces(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []NamespaceSummary{
+		{Namespace: "example", Modules: 2, Providers: 1, Uploads: 4, Size: 230},
+		{Namespace: "other", Modules: 1, Uploads: 1, Size: 5},
+	}, got)
+}
@@ -0,0 +1,89 @@
+package metadata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	o11y "github.com/boring-registry/boring-registry/pkg/observability"
+
+	"github.com/go-kit/kit/auth/jwt"
+	"github.com/go-kit/kit/endpoint"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+)
+
+// MakeHandler returns a fully initialized http.Handler.
+func MakeHandler(svc Service, auth endpoint.Middleware, instrumentation o11y.Middleware, options ...httptransport.ServerOption) http.Handler {
+	r := mux.NewRouter().StrictSlash(true)
+
+	r.Methods("GET").Path(`/uploads`).Handler(
+		instrumentation.WrapHandler(
+			httptransport.NewServer(
+				auth(listUploadsEndpoint(svc)),
+				decodeListUploadsRequest,
+				httptransport.EncodeJSONResponse,
+				append(
+					options,
+					httptransport.ServerBefore(jwt.HTTPToContext()),
+				)...,
+			),
+		),
+	)
+
+	r.Methods("GET").Path(`/namespaces`).Handler(
+		instrumentation.WrapHandler(
+			httptransport.NewServer(
+				auth(listNamespacesEndpoint(svc)),
+				httptransport.NopRequestDecoder,
+				httptransport.EncodeJSONResponse,
+				append(
+					options,
+					httptransport.ServerBefore(jwt.HTTPToContext()),
+				)...,
+			),
+		),
+	)
+
+	return r
+}
+
+func decodeListUploadsRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	query := r.URL.Query()
+	filter := Filter{
+		Kind:      Kind(query.Get("kind")),
+		Hostname:  query.Get("hostname"),
+		Namespace: query.Get("namespace"),
+		Name:      query.Get("name"),
+		Provider:  query.Get("provider"),
+		Version:   query.Get("version"),
+	}
+
+	for param, t := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		if v := query.Get(param); v != "" {
+			parsed, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s has to be formatted as RFC 3339", core.ErrVarType, param)
+			}
+			*t = parsed
+		}
+	}
+
+	return listUploadsRequest{
+		filter: filter,
+	}, nil
+}
+
+// ErrorEncoder translates domain specific errors to HTTP status codes
+func ErrorEncoder(_ context.Context, err error, w http.ResponseWriter) {
+	if errors.Is(err, core.ErrVarType) {
+		w.WriteHeader(http.StatusBadRequest)
+	} else {
+		w.WriteHeader(core.GenericError(err))
+	}
+
+	core.HandleErrorResponse(err, w)
+}
@@ -0,0 +1,526 @@
+package module
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+)
+
+// ValidateArchive checks that r is a gzipped tarball of a module.
+// The archive must contain at least one file, and all entries have to stay within the module directory.
+// If the archive contains a module spec file in its root, the metadata has to match the module.
+// The extracted size and the number of files are limited, and unless the content validation is disabled,
+// every .tf and .tf.json file has to parse and declare well-formed variables and outputs.
+// All diagnostics of the Terraform files are reported at once in an ErrInvalidModule error.
+// Finally, all files are scanned for secrets, which are reported in an ErrSecretsFound error unless the scan only warns.
+func ValidateArchive(r io.Reader, m core.Module, options ...ValidateOption) error {
+	o := newValidateOptions(options...)
+	contents := newContentValidator()
+	secrets := &secretScanner{}
+
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+	defer gr.Close()
+
+	tr := tar.NewReader(gr)
+	files := 0
+	size := int64(0)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+		}
+
+		name := path.Clean(strings.TrimPrefix(hdr.Name, "./"))
+		if !isLocalPath(name) {
+			return fmt.Errorf("%w: %s points outside of the module", ErrInvalidArchive, hdr.Name)
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeReg:
+			files++
+			size += hdr.Size
+			if files > o.maxFiles {
+				return fmt.Errorf("%w: archive contains more than %d files", ErrInvalidArchive, o.maxFiles)
+			}
+			if size > o.maxSize {
+				return fmt.Errorf("%w: extracted archive is larger than %d bytes", ErrInvalidArchive, o.maxSize)
+			}
+		case tar.TypeDir:
+		case tar.TypeSymlink:
+			if path.IsAbs(hdr.Linkname) || !isLocalPath(path.Join(path.Dir(name), hdr.Linkname)) {
+				return fmt.Errorf("%w: symlink %s points outside of the module", ErrInvalidArchive, hdr.Name)
+			}
+		default:
+			return fmt.Errorf("%w: %s has unsupported type %q", ErrInvalidArchive, hdr.Name, hdr.Typeflag)
+		}
+
+		if hdr.Typeflag == tar.TypeSymlink && o.contents && isTerraformFile(name) {
+			// The target of the symlink is validated itself
+			contents.dirs[path.Dir(name)] = true
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		// Only the files that are parsed are read into memory, while all other files are streamed through the secret scan
+		parsed := (o.contents && isTerraformFile(name)) || name == SpecFileName || name == SecretsAllowlistFileName
+		var src []byte
+		var content io.Reader = tr
+		if parsed {
+			if hdr.Size > maxParsedFileSize {
+				return fmt.Errorf("%w: %s is larger than %d bytes", ErrInvalidArchive, name, maxParsedFileSize)
+			}
+			if src, err = readAll(tr); err != nil {
+				return err
+			}
+			content = bytes.NewReader(src)
+		}
+
+		if o.secretScan != SecretScanOff {
+			if err := secrets.scan(name, content); err != nil {
+				return err
+			}
+		}
+		if o.contents && isTerraformFile(name) {
+			contents.add(name, src)
+		}
+
+		if name == SpecFileName {
+			// The version is omitted from the spec files of modules published from git tags
+			spec, err := decodeSpec(bytes.NewReader(src))
+			if err == nil && spec.Metadata.Version == "" {
+				spec.Metadata.Version = m.Version
+			}
+			if err == nil {
+				err = spec.Validate()
+			}
+			if err != nil {
+				return fmt.Errorf("%w: failed to parse %s: %v", ErrInvalidArchive, SpecFileName, err)
+			}
+			if actual := (core.Module{
+				Namespace: spec.Metadata.Namespace,
+				Name:      spec.Metadata.Name,
+				Provider:  spec.Metadata.Provider,
+				Version:   spec.Metadata.Version,
+			}); actual.ID(true) != m.ID(true) {
+				return fmt.Errorf("%w: %s describes %s instead of %s", ErrInvalidArchive, SpecFileName, actual.ID(true), m.ID(true))
+			}
+		}
+	}
+
+	if files == 0 {
+		return fmt.Errorf("%w: archive doesn't contain any files", ErrInvalidArchive)
+	}
+
+	// Drain the remaining padding, so that the whole archive was read
+	if _, err := io.Copy(io.Discard, gr); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+
+	if o.contents {
+		if err := contents.err(); err != nil {
+			return err
+		}
+	}
+	return secrets.err(o.secretScan)
+}
+
+// maxParsedFileSize is the maximum size of the Terraform files, the spec file and the secrets allowlist, which are parsed in memory
+const maxParsedFileSize = 16 << 20
+
+// archiveEpoch is the modification time of all archive entries, so that archives don't depend on when the files were checked out
+var archiveEpoch = time.Unix(0, 0)
+
+// archiveEntry is a file or symlink of the module directory
+type archiveEntry struct {
+	// name is the path within the archive, separated by slashes
+	name string
+
+	// source is the file whose content is archived, which is the target for symlinks that are resolved
+	source string
+
+	// linkname is the relative target of symlinks that are preserved
+	linkname string
+}
+
+type archiveOptions struct {
+	dereferenceSymlinks bool
+}
+
+// ArchiveOption configures how module directories are archived
+type ArchiveOption func(*archiveOptions)
+
+// WithDereferenceSymlinks configures whether symlinks to files outside of the module root are replaced by the content of the file.
+// Otherwise, they fail the archive.
+func WithDereferenceSymlinks(enabled bool) ArchiveOption {
+	return func(o *archiveOptions) {
+		o.dereferenceSymlinks = enabled
+	}
+}
+
+// WriteArchive writes the files below root as gzipped tarball to w.
+// Files excluded by the .terraformignore file of root, or by the default rules if there is none, are skipped.
+// The archive is reproducible: entries are sorted by name, and timestamps, ownership and the gzip header are zeroed,
+// so that the same files always result in the same bytes.
+// Symlinks to files and directories within root are preserved. Symlinks pointing outside of root fail the archive,
+// unless dereferencing is enabled with WithDereferenceSymlinks, which replaces symlinks to files outside of root by the file.
+func WriteArchive(root string, w io.Writer, options ...ArchiveOption) error {
+	o := archiveOptions{}
+	for _, option := range options {
+		option(&o)
+	}
+
+	rules, err := readIgnoreRules(root)
+	if err != nil {
+		return err
+	}
+
+	entries, err := archiveEntries(root, rules, o)
+	if err != nil {
+		return err
+	}
+
+	// The header of the gzip writer is left empty, so it neither contains a name nor a timestamp
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+	for _, e := range entries {
+		if err := writeArchiveEntry(tw, e); err != nil {
+			return err
+		}
+	}
+
+	// The writers have to be closed explicitly, as closing them flushes the remaining data
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gw.Close()
+}
+
+// archiveEntries returns the files and symlinks below root that aren't ignored, sorted by their name
+func archiveEntries(root string, rules ignoreRules, o archiveOptions) ([]archiveEntry, error) {
+	resolvedRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []archiveEntry
+	err = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		} else if rel == "." {
+			return nil
+		}
+		name := filepath.ToSlash(rel)
+
+		switch {
+		case d.IsDir():
+			if rules.ignored(name, true) {
+				return filepath.SkipDir
+			}
+		case d.Type()&fs.ModeSymlink != 0:
+			entry, ok, err := symlinkEntry(resolvedRoot, p, name, rules, o)
+			if err != nil {
+				return err
+			} else if ok {
+				entries = append(entries, entry)
+			}
+		case d.Type().IsRegular():
+			if !rules.ignored(name, false) {
+				entries = append(entries, archiveEntry{name: name, source: p})
+			}
+		}
+		// Other file types like sockets or devices aren't part of modules
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].name < entries[j].name
+	})
+	return entries, nil
+}
+
+// symlinkEntry preserves the symlink if its target is within the module root.
+// Symlinks pointing outside of the module root fail the archive, so that files like credentials aren't published by accident,
+// unless dereferencing is enabled, which resolves symlinks to files outside of the module root.
+// Broken symlinks and symlinks to directories outside of the module root always fail the archive.
+func symlinkEntry(resolvedRoot, p, name string, rules ignoreRules, o archiveOptions) (archiveEntry, bool, error) {
+	target, err := filepath.EvalSymlinks(p)
+	if err != nil {
+		return archiveEntry{}, false, fmt.Errorf("symlink %s is broken: %w", name, err)
+	}
+	fi, err := os.Stat(target)
+	if err != nil {
+		return archiveEntry{}, false, err
+	}
+	if rules.ignored(name, fi.IsDir()) {
+		return archiveEntry{}, false, nil
+	}
+
+	if rel, err := filepath.Rel(resolvedRoot, target); err == nil && isLocalPath(filepath.ToSlash(rel)) {
+		// The parent directories of the symlink aren't symlinks, as they would have been resolved otherwise
+		linkname, err := filepath.Rel(filepath.Dir(filepath.FromSlash(name)), rel)
+		if err != nil {
+			return archiveEntry{}, false, err
+		}
+		return archiveEntry{name: name, linkname: filepath.ToSlash(linkname)}, true, nil
+	}
+
+	if !o.dereferenceSymlinks {
+		return archiveEntry{}, false, fmt.Errorf("%w: %s -> %s", ErrSymlinkOutsideModule, name, target)
+	}
+	if !fi.Mode().IsRegular() {
+		return archiveEntry{}, false, fmt.Errorf("symlink %s points to %s outside of the module, which isn't a file", name, target)
+	}
+	return archiveEntry{name: name, source: target}, true, nil
+}
+
+// writeArchiveEntry writes the entry with a normalized header, which only keeps whether a file is executable
+func writeArchiveEntry(tw *tar.Writer, e archiveEntry) error {
+	if e.linkname != "" {
+		return tw.WriteHeader(symlinkHeader(e.name, e.linkname))
+	}
+
+	f, err := os.Open(e.source)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	if err := tw.WriteHeader(fileHeader(e.name, fi.Size(), fi.Mode())); err != nil {
+		return err
+	}
+
+	_, err = io.Copy(tw, f)
+	return err
+}
+
+// fileHeader returns the normalized header of a regular file
+func fileHeader(name string, size int64, mode fs.FileMode) *tar.Header {
+	perm := int64(0644)
+	if mode.Perm()&0100 != 0 {
+		perm = 0755
+	}
+	return &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Mode:     perm,
+		Size:     size,
+		ModTime:  archiveEpoch,
+	}
+}
+
+// symlinkHeader returns the normalized header of a symlink
+func symlinkHeader(name, linkname string) *tar.Header {
+	return &tar.Header{
+		Typeflag: tar.TypeSymlink,
+		Name:     name,
+		Linkname: linkname,
+		Mode:     0777,
+		ModTime:  archiveEpoch,
+	}
+}
+
+// ConvertZipArchive writes the files of the zip archive as gzipped tarball to w, which is the format modules are stored in.
+// Like for WriteArchive, the entries are sorted and their headers normalized, so that the same zip archive always results in the same bytes.
+// Directory entries are dropped, and symlinks are preserved. The tarball isn't validated, which is left to ValidateArchive.
+func ConvertZipArchive(r io.ReaderAt, size int64, w io.Writer) error {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+
+	files := make([]*zip.File, 0, len(zr.File))
+	for _, f := range zr.File {
+		if !f.FileInfo().IsDir() {
+			files = append(files, f)
+		}
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Name < files[j].Name
+	})
+
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+	for _, f := range files {
+		if err := convertZipFile(tw, f); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gw.Close()
+}
+
+func convertZipFile(tw *tar.Writer, f *zip.File) error {
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+	defer rc.Close()
+
+	// The target of a symlink is stored as its content
+	if f.Mode()&fs.ModeSymlink != 0 {
+		linkname, err := readAll(rc)
+		if err != nil {
+			return err
+		}
+		return tw.WriteHeader(symlinkHeader(f.Name, string(linkname)))
+	}
+
+	if err := tw.WriteHeader(fileHeader(f.Name, int64(f.UncompressedSize64), f.Mode())); err != nil {
+		return err
+	}
+	if _, err := io.Copy(tw, rc); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+	return nil
+}
+
+// ArchiveSpec returns the module spec file in the root of the gzipped tarball, or nil if the archive doesn't contain one.
+// The spec isn't validated, as its metadata may be incomplete, e.g. if the version is derived from elsewhere.
+func ArchiveSpec(r io.Reader) (*Spec, error) {
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+	defer gr.Close()
+
+	tr := tar.NewReader(gr)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+		}
+
+		if hdr.Typeflag != tar.TypeReg || path.Clean(strings.TrimPrefix(hdr.Name, "./")) != SpecFileName {
+			continue
+		}
+		spec, err := decodeSpec(tr)
+		if err != nil {
+			return nil, fmt.Errorf("%w: failed to parse %s: %v", ErrInvalidArchive, SpecFileName, err)
+		}
+		return spec, nil
+	}
+}
+
+// readAll reads the content of an archive entry
+func readAll(r io.Reader) ([]byte, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+	return b, nil
+}
+
+func isLocalPath(name string) bool {
+	return name != ".." && !strings.HasPrefix(name, "../") && !path.IsAbs(name)
+}
+
+// validatingReader validates the archive while it is streamed to the storage backend.
+// The end of the archive is only passed on after the validation succeeded,
+// so that storage backends never complete the upload of an invalid archive.
+type validatingReader struct {
+	r    io.Reader
+	pw   *io.PipeWriter
+	done chan error
+
+	// read records whether the archive was read at all
+	read bool
+}
+
+func newValidatingReader(r io.Reader, m core.Module, options ...ValidateOption) *validatingReader {
+	pr, pw := io.Pipe()
+	v := &validatingReader{
+		r:    r,
+		pw:   pw,
+		done: make(chan error, 1),
+	}
+
+	go func() {
+		err := ValidateArchive(pr, m, options...)
+		if err == nil {
+			// Consume any trailing data, so the writing side never blocks
+			_, err = io.Copy(io.Discard, pr)
+		}
+		pr.CloseWithError(err)
+		v.done <- err
+	}()
+
+	return v
+}
+
+func (v *validatingReader) Read(p []byte) (int, error) {
+	v.read = true
+	n, err := v.r.Read(p)
+	if n > 0 {
+		if _, writeErr := v.pw.Write(p[:n]); writeErr != nil {
+			return 0, writeErr
+		}
+	}
+
+	if errors.Is(err, io.EOF) {
+		v.pw.Close()
+		if validateErr := <-v.done; validateErr != nil {
+			v.done <- validateErr
+			return n, validateErr
+		}
+		v.done <- nil
+	} else if err != nil {
+		v.pw.CloseWithError(err)
+	}
+
+	return n, err
+}
+
+// Close stops the validation in case the archive wasn't read completely
+func (v *validatingReader) Close() error {
+	v.pw.CloseWithError(io.ErrUnexpectedEOF)
+	return nil
+}
+
+// started returns whether the archive was read, in which case the storage backend might have stored parts of it
+func (v *validatingReader) started() bool {
+	return v.read
+}
+
+// err returns the validation result once the archive was read completely or the reader was closed
+func (v *validatingReader) err() error {
+	err := <-v.done
+	v.done <- err
+	return err
+}
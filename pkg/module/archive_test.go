@@ -0,0 +1,395 @@
+package module
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testArchive(headers ...*tar.Header) *bytes.Buffer {
+	buf := new(bytes.Buffer)
+	gw := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gw)
+	for _, hdr := range headers {
+		_ = tw.WriteHeader(hdr)
+		if hdr.Typeflag == tar.TypeReg {
+			_, _ = tw.Write(bytes.Repeat([]byte(" "), int(hdr.Size)))
+		}
+	}
+	_ = tw.Close()
+	_ = gw.Close()
+	return buf
+}
+
+func TestValidateArchive(t *testing.T) {
+	t.Parallel()
+
+	m := core.Module{Namespace: "example", Name: "s3", Provider: "aws", Version: "1.0.0"}
+	spec := func(version string) string {
+		return `metadata {
+  namespace = "example"
+  name      = "s3"
+  provider  = "aws"
+  version   = "` + version + `"
+}`
+	}
+
+	testCases := []struct {
+		description string
+		archive     *bytes.Buffer
+		wantErr     bool
+	}{
+		{
+			description: "valid archive",
+			archive:     testModuleData(map[string]string{"main.tf": "", "modules/bucket/main.tf": ""}),
+		},
+		{
+			description: "matching module spec",
+			archive:     testModuleData(map[string]string{"main.tf": "", SpecFileName: spec("1.0.0")}),
+		},
+		{
+			description: "module spec without version",
+			archive:     testModuleData(map[string]string{"main.tf": "", SpecFileName: strings.Replace(spec(""), `  version   = ""`+"\n", "", 1)}),
+		},
+		{
+			description: "mismatching module spec",
+			archive:     testModuleData(map[string]string{"main.tf": "", SpecFileName: spec("2.0.0")}),
+			wantErr:     true,
+		},
+		{
+			description: "not gzipped",
+			archive:     bytes.NewBufferString("main.tf"),
+			wantErr:     true,
+		},
+		{
+			description: "empty archive",
+			archive:     testArchive(&tar.Header{Name: "modules/", Typeflag: tar.TypeDir}),
+			wantErr:     true,
+		},
+		{
+			description: "path traversal",
+			archive:     testArchive(&tar.Header{Name: "../main.tf", Typeflag: tar.TypeReg, Size: 1}),
+			wantErr:     true,
+		},
+		{
+			description: "local symlink",
+			archive: testArchive(
+				&tar.Header{Name: "./main.tf", Typeflag: tar.TypeReg, Size: 1},
+				&tar.Header{Name: "modules/main.tf", Typeflag: tar.TypeSymlink, Linkname: "../main.tf"},
+			),
+		},
+		{
+			description: "symlink outside of the module",
+			archive: testArchive(
+				&tar.Header{Name: "main.tf", Typeflag: tar.TypeReg, Size: 1},
+				&tar.Header{Name: "secrets", Typeflag: tar.TypeSymlink, Linkname: "../../etc/passwd"},
+			),
+			wantErr: true,
+		},
+		{
+			description: "device file",
+			archive:     testArchive(&tar.Header{Name: "dev", Typeflag: tar.TypeChar}),
+			wantErr:     true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			err := ValidateArchive(tc.archive, m)
+			if tc.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidArchive)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidatingReader(t *testing.T) {
+	t.Parallel()
+
+	m := core.Module{Namespace: "example", Name: "s3", Provider: "aws", Version: "1.0.0"}
+
+	valid := testModuleData(map[string]string{"main.tf": strings.Repeat("# resource\n", 1<<16)})
+	content := valid.Bytes()
+	r := newValidatingReader(bytes.NewReader(content), m)
+	b := new(bytes.Buffer)
+	_, err := b.ReadFrom(r)
+	assert.NoError(t, err)
+	assert.Equal(t, content, b.Bytes())
+	assert.NoError(t, r.err())
+
+	// The end of an invalid archive is never passed on
+	r = newValidatingReader(strings.NewReader("not an archive"), m)
+	_, err = b.ReadFrom(r)
+	assert.ErrorIs(t, err, ErrInvalidArchive)
+	assert.ErrorIs(t, r.err(), ErrInvalidArchive)
+
+	// Closing the reader stops the validation
+	r = newValidatingReader(bytes.NewReader(content), m)
+	_, err = r.Read(make([]byte, 16))
+	assert.NoError(t, err)
+	assert.NoError(t, r.Close())
+	assert.Error(t, r.err())
+}
+
+// archiveNames returns the name of every entry of the archive, and the target of symlinks after an arrow
+func archiveNames(t *testing.T, archive []byte) []string {
+	t.Helper()
+
+	gr, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gr)
+
+	var names []string
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.True(t, hdr.ModTime.Equal(archiveEpoch))
+		assert.Zero(t, hdr.Uid)
+		assert.Zero(t, hdr.Gid)
+		if hdr.Typeflag == tar.TypeSymlink {
+			names = append(names, hdr.Name+" -> "+hdr.Linkname)
+		} else {
+			names = append(names, hdr.Name)
+		}
+	}
+	return names
+}
+
+func writeTestFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+
+	for name, content := range files {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestWriteArchive(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	root := filepath.Join(dir, "module")
+	writeTestFiles(t, dir, map[string]string{
+		"shared/versions.tf": "terraform {}",
+	})
+	writeTestFiles(t, root, map[string]string{
+		"main.tf":                    "",
+		"variables.tf":               "",
+		"modules/b/main.tf":          "",
+		"modules/a/main.tf":          "",
+		".git/HEAD":                  "ref: refs/heads/main",
+		".terraform/modules/x.tf":    "",
+		"terraform.tfstate":          "{}",
+		"modules/a/.main.tf.swp":     "",
+		"modules/a/terraform.tfvars": "",
+	})
+	assert.NoError(t, os.Symlink("../shared/versions.tf", filepath.Join(root, "versions.tf")))
+	assert.NoError(t, os.Symlink("../a/main.tf", filepath.Join(root, "modules/b/a.tf")))
+	assert.NoError(t, os.Symlink("modules/a", filepath.Join(root, "a")))
+
+	// Symlinks to files outside of the module are only archived if they are dereferenced
+	assert.ErrorIs(t, WriteArchive(root, io.Discard), ErrSymlinkOutsideModule)
+	dereference := WithDereferenceSymlinks(true)
+
+	buf := new(bytes.Buffer)
+	assert.NoError(t, WriteArchive(root, buf, dereference))
+	assert.Equal(t, []string{
+		"a -> modules/a",
+		"main.tf",
+		"modules/a/main.tf",
+		"modules/a/terraform.tfvars",
+		"modules/b/a.tf -> ../a/main.tf",
+		"modules/b/main.tf",
+		"variables.tf",
+		"versions.tf",
+	}, archiveNames(t, buf.Bytes()))
+	assert.NoError(t, ValidateArchive(bytes.NewReader(buf.Bytes()), core.Module{}, WithSecretScan(SecretScanOff)))
+
+	// Touching the files doesn't change the archive
+	future := time.Now().Add(time.Hour)
+	assert.NoError(t, os.Chtimes(filepath.Join(root, "main.tf"), future, future))
+	again := new(bytes.Buffer)
+	assert.NoError(t, WriteArchive(root, again, dereference))
+	assert.Equal(t, buf.Bytes(), again.Bytes())
+
+	// A .terraformignore file replaces the default rules
+	writeTestFiles(t, root, map[string]string{IgnoreFileName: "*.tfvars\n.git/\n.terraform/\n*.swp\nmodules/b/\n"})
+	buf.Reset()
+	assert.NoError(t, WriteArchive(root, buf, dereference))
+	assert.Equal(t, []string{
+		IgnoreFileName,
+		"a -> modules/a",
+		"main.tf",
+		"modules/a/main.tf",
+		"terraform.tfstate",
+		"variables.tf",
+		"versions.tf",
+	}, archiveNames(t, buf.Bytes()))
+
+	// Symlinks to directories outside of the module can't be archived
+	assert.NoError(t, os.Symlink("../shared", filepath.Join(root, "shared")))
+	assert.Error(t, WriteArchive(root, io.Discard, dereference))
+}
+
+func TestWriteArchive_SymlinkOutsideModule(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	root := filepath.Join(dir, "module")
+	writeTestFiles(t, dir, map[string]string{
+		"home/.aws/credentials": "[default]",
+		"secrets.tfvars":        `password = "secret"`,
+	})
+	writeTestFiles(t, root, map[string]string{"main.tf": ""})
+
+	for name, target := range map[string]string{
+		"creds":          filepath.Join(dir, "home/.aws/credentials"),
+		"secrets.tfvars": "../secrets.tfvars",
+	} {
+		link := filepath.Join(root, name)
+		assert.NoError(t, os.Symlink(target, link))
+		assert.ErrorIs(t, WriteArchive(root, io.Discard), ErrSymlinkOutsideModule, name)
+		assert.NoError(t, os.Remove(link))
+	}
+}
+
+func TestConvertZipArchive(t *testing.T) {
+	t.Parallel()
+
+	zipped := new(bytes.Buffer)
+	zw := zip.NewWriter(zipped)
+	for _, f := range []struct {
+		name    string
+		mode    os.FileMode
+		content string
+	}{
+		{name: "variables.tf", mode: 0644, content: `variable "name" {}`},
+		{name: "modules/", mode: os.ModeDir | 0755},
+		{name: "modules/a/main.tf", mode: 0644, content: "# module a"},
+		{name: "main.tf", mode: 0644, content: `output "name" { value = var.name }`},
+		{name: "a", mode: os.ModeSymlink | 0777, content: "modules/a"},
+		{name: "scripts/run.sh", mode: 0700, content: "#!/bin/sh"},
+	} {
+		hdr := &zip.FileHeader{Name: f.name, Method: zip.Deflate}
+		hdr.SetMode(f.mode)
+		w, err := zw.CreateHeader(hdr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = w.Write([]byte(f.content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := new(bytes.Buffer)
+	assert.NoError(t, ConvertZipArchive(bytes.NewReader(zipped.Bytes()), int64(zipped.Len()), buf))
+	assert.Equal(t, []string{
+		"a -> modules/a",
+		"main.tf",
+		"modules/a/main.tf",
+		"scripts/run.sh",
+		"variables.tf",
+	}, archiveNames(t, buf.Bytes()))
+	assert.NoError(t, ValidateArchive(bytes.NewReader(buf.Bytes()), core.Module{}))
+
+	gr, err := gzip.NewReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gr)
+	modes := make(map[string]int64)
+	for hdr, err := tr.Next(); err == nil; hdr, err = tr.Next() {
+		modes[hdr.Name] = hdr.Mode
+	}
+	assert.Equal(t, int64(0755), modes["scripts/run.sh"])
+	assert.Equal(t, int64(0644), modes["main.tf"])
+
+	// Converting the same archive results in the same bytes
+	again := new(bytes.Buffer)
+	assert.NoError(t, ConvertZipArchive(bytes.NewReader(zipped.Bytes()), int64(zipped.Len()), again))
+	assert.Equal(t, buf.Bytes(), again.Bytes())
+
+	err = ConvertZipArchive(bytes.NewReader([]byte("not a zip")), 9, io.Discard)
+	assert.ErrorIs(t, err, ErrInvalidArchive)
+}
+
+func TestArchiveSpec(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		files   map[string]string
+		want    *Spec
+		wantErr error
+	}{
+		{
+			name: "spec without version",
+			files: map[string]string{"main.tf": "", SpecFileName: `metadata {
+  namespace = "example"
+  name      = "vpc"
+  provider  = "aws"
+}`},
+			want: &Spec{Metadata: Metadata{Namespace: "example", Name: "vpc", Provider: "aws"}},
+		},
+		{
+			name:  "spec in a subdirectory",
+			files: map[string]string{"main.tf": "", "modules/a/" + SpecFileName: "invalid"},
+		},
+		{
+			name:    "invalid spec",
+			files:   map[string]string{SpecFileName: "metadata {"},
+			wantErr: ErrInvalidArchive,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			buf := new(bytes.Buffer)
+			gw := gzip.NewWriter(buf)
+			tw := tar.NewWriter(gw)
+			for name, content := range tc.files {
+				_ = tw.WriteHeader(fileHeader(name, int64(len(content)), 0644))
+				_, _ = tw.Write([]byte(content))
+			}
+			_ = tw.Close()
+			_ = gw.Close()
+
+			spec, err := ArchiveSpec(buf)
+			assert.ErrorIs(t, err, tc.wantErr)
+			assert.Equal(t, tc.want, spec)
+		})
+	}
+
+	_, err := ArchiveSpec(strings.NewReader("not a tarball"))
+	assert.ErrorIs(t, err, ErrInvalidArchive)
+}
@@ -0,0 +1,171 @@
+package module
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/discovery"
+)
+
+// Client publishes modules through the HTTP API of a boring-registry instead of accessing the storage backend directly
+type Client struct {
+	baseURL *url.URL
+	token   string
+	client  *http.Client
+}
+
+// GetModule returns the module if it exists in the registry
+func (c *Client) GetModule(ctx context.Context, namespace, name, provider, version string) (core.Module, error) {
+	u := c.baseURL.JoinPath(namespace, name, provider, version, "download")
+	resp, err := c.do(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return core.Module{}, err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp, http.StatusNoContent); err != nil {
+		return core.Module{}, err
+	}
+
+	return core.Module{
+		Namespace:   namespace,
+		Name:        name,
+		Provider:    provider,
+		Version:     version,
+		DownloadURL: resp.Header.Get("X-Terraform-Get"),
+	}, nil
+}
+
+// UploadModule publishes the module archive to the registry.
+// The registry is asked to skip the validation of the module contents if the context was created with WithSkipValidation.
+func (c *Client) UploadModule(ctx context.Context, namespace, name, provider, version string, body io.Reader) (core.Module, error) {
+	u := c.baseURL.JoinPath(namespace, name, provider, version)
+	if SkipValidationFromContext(ctx) {
+		u.RawQuery = url.Values{skipValidationParam: []string{"true"}}.Encode()
+	}
+	resp, err := c.do(ctx, http.MethodPost, u, body)
+	if err != nil {
+		return core.Module{}, err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp, http.StatusCreated); err != nil {
+		return core.Module{}, err
+	}
+
+	var m core.Module
+	if err := json.NewDecoder(resp.Body).Decode(&m); err != nil {
+		return core.Module{}, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return m, nil
+}
+
+func (c *Client) do(ctx context.Context, method string, u *url.URL, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/gzip")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.token))
+	}
+	core.SetProvenanceHeaders(req.Header, core.ProvenanceFromContext(ctx))
+
+	return c.client.Do(req)
+}
+
+// checkResponse translates the error responses of the registry back into the errors of this package
+func checkResponse(resp *http.Response, expected int) error {
+	if resp.StatusCode == expected {
+		return nil
+	}
+
+	var errResp struct {
+		Errors []string `json:"errors"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&errResp)
+	err := fmt.Errorf("unexpected status code %d for %s %s: %s", resp.StatusCode, resp.Request.Method, resp.Request.URL, strings.Join(errResp.Errors, ", "))
+
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		return fmt.Errorf("%w: %v", ErrModuleNotFound, err)
+	case http.StatusConflict:
+		return fmt.Errorf("%w: %v", ErrModuleAlreadyExists, err)
+	case http.StatusUnauthorized:
+		return fmt.Errorf("%w: %v", core.ErrUnauthorized, err)
+	case http.StatusForbidden:
+		return fmt.Errorf("%w: %v", ErrPublishDisabled, err)
+	}
+	return err
+}
+
+// ClientOption provides additional options for the Client.
+type ClientOption func(*Client)
+
+// WithClientToken configures the token, which is sent as bearer token to the registry
+func WithClientToken(token string) ClientOption {
+	return func(c *Client) {
+		c.token = token
+	}
+}
+
+// WithClientHTTPClient configures the http.Client used for requests to the registry
+func WithClientHTTPClient(client *http.Client) ClientOption {
+	return func(c *Client) {
+		c.client = client
+	}
+}
+
+// NewClient returns a Client for the registry at registryURL.
+// The path of the module API is resolved with the remote service discovery of the registry.
+func NewClient(ctx context.Context, registryURL string, options ...ClientOption) (*Client, error) {
+	u, err := url.Parse(registryURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse registry URL: %w", err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("registry URL %s has to be absolute", registryURL)
+	}
+
+	c := &Client{
+		client: http.DefaultClient,
+	}
+	for _, option := range options {
+		option(c)
+	}
+
+	wellKnown := u.JoinPath(".well-known", "terraform.json")
+	resp, err := c.do(ctx, http.MethodGet, wellKnown, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover the module API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to discover the module API: unexpected status code %d for %s", resp.StatusCode, wellKnown)
+	}
+
+	var discovered discovery.WellKnownEndpointResponse
+	if err := json.NewDecoder(resp.Body).Decode(&discovered); err != nil {
+		return nil, fmt.Errorf("failed to decode service discovery response: %w", err)
+	} else if discovered.ModulesV1 == "" {
+		return nil, errors.New("registry doesn't serve modules")
+	}
+
+	modules, err := url.Parse(discovered.ModulesV1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse modules.v1 URL: %w", err)
+	}
+	c.baseURL = u.ResolveReference(modules)
+
+	return c, nil
+}
@@ -0,0 +1,96 @@
+package module
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/boring-registry/boring-registry/pkg/auth"
+	"github.com/boring-registry/boring-registry/pkg/core"
+	o11y "github.com/boring-registry/boring-registry/pkg/observability"
+
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+type noopInstrumentation struct{}
+
+func (noopInstrumentation) WrapHandler(handler http.Handler) http.HandlerFunc {
+	return handler.ServeHTTP
+}
+
+func newTestRegistry(t *testing.T, storage Storage) *httptest.Server {
+	t.Helper()
+
+	labels := []string{o11y.NamespaceLabel, o11y.NameLabel, o11y.ProviderLabel, o11y.VersionLabel}
+	metrics := &o11y.ModuleMetrics{
+		ListVersions: prometheus.NewCounterVec(prometheus.CounterOpts{Name: "list"}, labels[:3]),
+		Download:     prometheus.NewCounterVec(prometheus.CounterOpts{Name: "download"}, labels),
+		Publish:      prometheus.NewCounterVec(prometheus.CounterOpts{Name: "publish"}, labels),
+	}
+	svc := NewService(storage, core.NewProxyUrlService(false, "/proxy"), WithPublishEnabled(true), WithSkipValidationAllowed(true))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/terraform.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"modules.v1":"/v1/modules/"}`))
+	})
+	mux.Handle("/v1/modules/", http.StripPrefix("/v1/modules", MakeHandler(
+		svc,
+		auth.Middleware(auth.NewStaticProvider("secret")),
+		metrics,
+		noopInstrumentation{},
+		httptransport.ServerErrorEncoder(ErrorEncoder),
+	)))
+
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestClient(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	storage := NewInmemStorage()
+	ts := newTestRegistry(t, storage)
+
+	c, err := NewClient(ctx, ts.URL, WithClientToken("secret"))
+	assert.NoError(t, err)
+
+	_, err = c.GetModule(ctx, "example", "s3", "aws", "1.0.0")
+	assert.ErrorIs(t, err, ErrModuleNotFound)
+
+	m, err := c.UploadModule(core.WithProvenance(ctx, core.Provenance{GitCommit: "0123abc"}), "example", "s3", "aws", "1.0.0", testModuleData(map[string]string{"main.tf": ""}))
+	assert.NoError(t, err)
+	assert.Equal(t, "1.0.0", m.Version)
+
+	p, err := storage.GetModuleProvenance(ctx, "example", "s3", "aws", "1.0.0")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "0123abc", p.GitCommit)
+		assert.Equal(t, "static:2bb80d537b1d", p.Uploader)
+	}
+
+	_, err = c.GetModule(ctx, "example", "s3", "aws", "1.0.0")
+	assert.NoError(t, err)
+
+	_, err = c.UploadModule(ctx, "example", "s3", "aws", "1.0.0", testModuleData(map[string]string{"main.tf": ""}))
+	assert.ErrorIs(t, err, ErrModuleAlreadyExists)
+
+	_, err = c.UploadModule(ctx, "example", "s3", "aws", "2.0.0", strings.NewReader("not an archive"))
+	assert.ErrorContains(t, err, "status code 400")
+
+	invalid := map[string]string{"main.tf": `output "id" {}`}
+	_, err = c.UploadModule(ctx, "example", "s3", "aws", "2.0.0", testModuleData(invalid))
+	assert.ErrorContains(t, err, `The argument "value" is required`)
+	_, err = c.UploadModule(WithSkipValidation(ctx), "example", "s3", "aws", "2.0.0", testModuleData(invalid))
+	assert.NoError(t, err)
+
+	unauthorized, err := NewClient(ctx, ts.URL, WithClientToken("invalid"))
+	assert.NoError(t, err)
+	_, err = unauthorized.UploadModule(ctx, "example", "s3", "aws", "3.0.0", testModuleData(map[string]string{"main.tf": ""}))
+	assert.ErrorIs(t, err, core.ErrUnauthorized)
+}
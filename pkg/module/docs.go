@@ -0,0 +1,334 @@
+package module
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// maxDocsFileSize limits the size of the files that are read from a module archive to extract the docs.
+// Larger files are skipped, and READMEs are truncated.
+const maxDocsFileSize = 1 << 20
+
+// submodulesDir contains the submodules of a module, following the standard module structure
+const submodulesDir = "modules"
+
+// Docs is the documentation of a module version like it's shown by the public Terraform registry.
+// It's extracted from the .tf files and READMEs of the module archive.
+type Docs struct {
+	Root       ModuleDocs   `json:"root"`
+	Submodules []ModuleDocs `json:"submodules"`
+}
+
+// ModuleDocs documents the root module or a submodule
+type ModuleDocs struct {
+	// Path is the directory of the module within the archive, which is empty for the root module
+	Path   string `json:"path"`
+	Readme string `json:"readme"`
+
+	// Empty is true if the directory doesn't contain any .tf files
+	Empty bool `json:"empty"`
+
+	Inputs               []Input              `json:"inputs"`
+	Outputs              []Output             `json:"outputs"`
+	Dependencies         []Dependency         `json:"dependencies"`
+	ProviderDependencies []ProviderDependency `json:"provider_dependencies"`
+
+	// RequiredVersion is the Terraform version constraint of the module
+	RequiredVersion string `json:"required_version,omitempty"`
+}
+
+// Input is a variable of a module
+type Input struct {
+	Name        string `json:"name"`
+	Type        string `json:"type,omitempty"`
+	Description string `json:"description,omitempty"`
+
+	// Default is the JSON encoded default value. Defaults that aren't static values are reported as their HCL expression
+	Default   json.RawMessage `json:"default,omitempty"`
+	Required  bool            `json:"required"`
+	Sensitive bool            `json:"sensitive,omitempty"`
+}
+
+// Output is an output value of a module
+type Output struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Sensitive   bool   `json:"sensitive,omitempty"`
+}
+
+// Dependency is a module called by a module
+type Dependency struct {
+	Name    string `json:"name"`
+	Source  string `json:"source"`
+	Version string `json:"version,omitempty"`
+}
+
+// ProviderDependency is an entry of the required_providers block of a module
+type ProviderDependency struct {
+	Name    string `json:"name"`
+	Source  string `json:"source,omitempty"`
+	Version string `json:"version,omitempty"`
+}
+
+// moduleFiles are the files of a module directory that are relevant for its docs
+type moduleFiles struct {
+	readme string
+	tf     map[string][]byte
+}
+
+// ExtractDocs reads the docs of the root module and its submodules from the gzipped module archive r.
+// Files that can't be parsed are skipped, as the docs are only informational.
+func ExtractDocs(r io.Reader) (*Docs, error) {
+	docs, _, err := Extract(r)
+	return docs, err
+}
+
+// Extract reads the docs and the info of the module spec file from the gzipped module archive r in a single pass.
+// The info is nil if the archive doesn't contain a valid spec file with an info block.
+func Extract(r io.Reader) (*Docs, *Info, error) {
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+	defer gr.Close()
+
+	var spec []byte
+	dirs := make(map[string]*moduleFiles)
+	tr := tar.NewReader(gr)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+		}
+
+		name := path.Clean(strings.TrimPrefix(hdr.Name, "./"))
+		dir, file := path.Split(name)
+		dir = strings.TrimSuffix(dir, "/")
+		if hdr.Typeflag != tar.TypeReg || !isDocsDir(dir) {
+			continue
+		}
+
+		if dir == "" && file == SpecFileName && hdr.Size <= maxDocsFileSize {
+			if spec, err = io.ReadAll(tr); err != nil {
+				return nil, nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+			}
+			continue
+		}
+
+		isReadme := strings.EqualFold(file, "README.md") || strings.EqualFold(file, "README")
+		isTf := strings.HasSuffix(file, ".tf")
+		if !isReadme && !(isTf && hdr.Size <= maxDocsFileSize) {
+			continue
+		}
+
+		b, err := io.ReadAll(io.LimitReader(tr, maxDocsFileSize))
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+		}
+
+		if _, ok := dirs[dir]; !ok {
+			dirs[dir] = &moduleFiles{tf: make(map[string][]byte)}
+		}
+		if isReadme {
+			dirs[dir].readme = string(b)
+		} else {
+			dirs[dir].tf[name] = b
+		}
+	}
+
+	docs := &Docs{Submodules: []ModuleDocs{}}
+	if root, ok := dirs[""]; ok {
+		docs.Root = root.docs("")
+	} else {
+		docs.Root = (&moduleFiles{}).docs("")
+	}
+
+	for dir, files := range dirs {
+		if dir != "" {
+			docs.Submodules = append(docs.Submodules, files.docs(dir))
+		}
+	}
+	sort.Slice(docs.Submodules, func(i, j int) bool {
+		return docs.Submodules[i].Path < docs.Submodules[j].Path
+	})
+
+	// Consume the remaining padding, so that the whole archive was read
+	if _, err := io.Copy(io.Discard, gr); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+
+	var info *Info
+	if spec != nil {
+		if s, err := Parse(bytes.NewReader(spec)); err == nil {
+			info = s.Info
+		}
+	}
+	return docs, info, nil
+}
+
+// isDocsDir returns whether the directory is the root module or a submodule in modules/<name>
+func isDocsDir(dir string) bool {
+	if dir == "" {
+		return true
+	}
+	parent, _ := path.Split(dir)
+	return parent == submodulesDir+"/"
+}
+
+// docs parses the .tf files of the directory in the order of their names
+func (f *moduleFiles) docs(dir string) ModuleDocs {
+	d := ModuleDocs{
+		Path:                 dir,
+		Readme:               f.readme,
+		Empty:                len(f.tf) == 0,
+		Inputs:               []Input{},
+		Outputs:              []Output{},
+		Dependencies:         []Dependency{},
+		ProviderDependencies: []ProviderDependency{},
+	}
+
+	names := make([]string, 0, len(f.tf))
+	for name := range f.tf {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		src := f.tf[name]
+		file, diags := hclsyntax.ParseConfig(src, name, hcl.InitialPos)
+		if diags.HasErrors() {
+			continue
+		}
+
+		for _, block := range file.Body.(*hclsyntax.Body).Blocks {
+			d.addBlock(block, src)
+		}
+	}
+
+	return d
+}
+
+func (d *ModuleDocs) addBlock(block *hclsyntax.Block, src []byte) {
+	attrs := block.Body.Attributes
+
+	switch {
+	case block.Type == "variable" && len(block.Labels) == 1:
+		input := Input{
+			Name:        block.Labels[0],
+			Description: stringValue(attrs["description"]),
+			Sensitive:   boolValue(attrs["sensitive"]),
+			Required:    true,
+		}
+		if attr, ok := attrs["type"]; ok {
+			input.Type = string(attr.Expr.Range().SliceBytes(src))
+		}
+		if attr, ok := attrs["default"]; ok {
+			input.Default = jsonValue(attr.Expr, src)
+			input.Required = false
+		}
+		d.Inputs = append(d.Inputs, input)
+	case block.Type == "output" && len(block.Labels) == 1:
+		d.Outputs = append(d.Outputs, Output{
+			Name:        block.Labels[0],
+			Description: stringValue(attrs["description"]),
+			Sensitive:   boolValue(attrs["sensitive"]),
+		})
+	case block.Type == "module" && len(block.Labels) == 1:
+		d.Dependencies = append(d.Dependencies, Dependency{
+			Name:    block.Labels[0],
+			Source:  stringValue(attrs["source"]),
+			Version: stringValue(attrs["version"]),
+		})
+	case block.Type == "terraform":
+		if v := stringValue(attrs["required_version"]); v != "" {
+			d.RequiredVersion = v
+		}
+		for _, b := range block.Body.Blocks {
+			if b.Type == "required_providers" {
+				d.addRequiredProviders(b.Body.Attributes)
+			}
+		}
+	}
+}
+
+// addRequiredProviders reads the provider requirements, which are either objects or legacy version constraints.
+// The attributes of the objects are read one by one, as configuration_aliases references can't be evaluated.
+func (d *ModuleDocs) addRequiredProviders(attrs hclsyntax.Attributes) {
+	names := make([]string, 0, len(attrs))
+	for name := range attrs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		dep := ProviderDependency{Name: name}
+
+		if obj, ok := attrs[name].Expr.(*hclsyntax.ObjectConsExpr); ok {
+			for _, item := range obj.Items {
+				switch hcl.ExprAsKeyword(item.KeyExpr) {
+				case "source":
+					dep.Source = exprString(item.ValueExpr)
+				case "version":
+					dep.Version = exprString(item.ValueExpr)
+				}
+			}
+		} else {
+			dep.Version = exprString(attrs[name].Expr)
+		}
+
+		d.ProviderDependencies = append(d.ProviderDependencies, dep)
+	}
+}
+
+func exprString(expr hcl.Expression) string {
+	v, diags := expr.Value(nil)
+	if diags.HasErrors() || v.IsNull() || !v.IsKnown() || v.Type() != cty.String {
+		return ""
+	}
+	return v.AsString()
+}
+
+func stringValue(attr *hclsyntax.Attribute) string {
+	if attr == nil {
+		return ""
+	}
+	return exprString(attr.Expr)
+}
+
+func boolValue(attr *hclsyntax.Attribute) bool {
+	if attr == nil {
+		return false
+	}
+	v, diags := attr.Expr.Value(nil)
+	if diags.HasErrors() || v.IsNull() || !v.IsKnown() || v.Type() != cty.Bool {
+		return false
+	}
+	return v.True()
+}
+
+// jsonValue encodes static values as JSON, and other expressions as JSON string of their HCL source
+func jsonValue(expr hclsyntax.Expression, src []byte) json.RawMessage {
+	if v, diags := expr.Value(nil); !diags.HasErrors() && v.IsWhollyKnown() {
+		if b, err := ctyjson.Marshal(v, v.Type()); err == nil {
+			return b
+		}
+	}
+
+	b, _ := json.Marshal(string(expr.Range().SliceBytes(src)))
+	return b
+}
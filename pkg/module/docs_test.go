@@ -0,0 +1,153 @@
+package module
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractDocs(t *testing.T) {
+	t.Parallel()
+
+	archive := testModuleData(map[string]string{
+		"README.md": "# VPC",
+		"variables.tf": `
+variable "cidr" {
+  type        = string
+  description = "CIDR block of the VPC"
+}
+
+variable "tags" {
+  type    = map(string)
+  default = { team = "platform" }
+}
+
+variable "name" {
+  default   = "vpc-${local.suffix}"
+  sensitive = true
+}
+`,
+		"outputs.tf": `
+output "id" {
+  description = "ID of the VPC"
+}
+`,
+		"versions.tf": `
+terraform {
+  required_version = ">= 1.5"
+
+  required_providers {
+    aws = {
+      source                = "hashicorp/aws"
+      version               = "~> 5.0"
+      configuration_aliases = [aws.peer]
+    }
+    random = "~> 3.0"
+  }
+}
+`,
+		"main.tf": `
+module "subnets" {
+  source  = "example/subnets/aws"
+  version = "1.2.0"
+}
+`,
+		"broken.tf":                        `variable "broken" {`,
+		"modules/endpoint/README.md":       "# Endpoint",
+		"modules/endpoint/main.tf":         `variable "service" {}`,
+		"modules/endpoint/nested/main.tf":  `variable "ignored" {}`,
+		"examples/complete/main.tf":        `variable "ignored" {}`,
+		"modules/endpoint/terraform.tfvar": `service = "s3"`,
+	})
+
+	docs, err := ExtractDocs(archive)
+	assert.NoError(t, err)
+
+	root := docs.Root
+	assert.Equal(t, "", root.Path)
+	assert.Equal(t, "# VPC", root.Readme)
+	assert.False(t, root.Empty)
+	assert.Equal(t, ">= 1.5", root.RequiredVersion)
+	assert.Equal(t, []Input{
+		{Name: "cidr", Type: "string", Description: "CIDR block of the VPC", Required: true},
+		{Name: "tags", Type: "map(string)", Default: json.RawMessage(`{"team":"platform"}`)},
+		{Name: "name", Default: json.RawMessage(`"\"vpc-${local.suffix}\""`), Sensitive: true},
+	}, root.Inputs)
+	assert.Equal(t, []Output{{Name: "id", Description: "ID of the VPC"}}, root.Outputs)
+	assert.Equal(t, []Dependency{{Name: "subnets", Source: "example/subnets/aws", Version: "1.2.0"}}, root.Dependencies)
+	assert.Equal(t, []ProviderDependency{
+		{Name: "aws", Source: "hashicorp/aws", Version: "~> 5.0"},
+		{Name: "random", Version: "~> 3.0"},
+	}, root.ProviderDependencies)
+
+	if assert.Len(t, docs.Submodules, 1) {
+		sub := docs.Submodules[0]
+		assert.Equal(t, "modules/endpoint", sub.Path)
+		assert.Equal(t, "# Endpoint", sub.Readme)
+		assert.Equal(t, []Input{{Name: "service", Required: true}}, sub.Inputs)
+	}
+}
+
+func TestExtractDocs_Empty(t *testing.T) {
+	t.Parallel()
+
+	docs, err := ExtractDocs(testModuleData(map[string]string{"README.md": "# Empty"}))
+	assert.NoError(t, err)
+	assert.True(t, docs.Root.Empty)
+	assert.Equal(t, "# Empty", docs.Root.Readme)
+	assert.Empty(t, docs.Submodules)
+
+	_, err = ExtractDocs(testModuleData(nil))
+	assert.NoError(t, err)
+}
+
+func TestExtract_Info(t *testing.T) {
+	t.Parallel()
+
+	metadata := `
+metadata {
+  namespace = "example"
+  name      = "vpc"
+  provider  = "aws"
+  version   = "1.0.0"
+}
+`
+
+	testCases := []struct {
+		name     string
+		files    map[string]string
+		expected *Info
+	}{
+		{
+			name: "info",
+			files: map[string]string{
+				SpecFileName: metadata + `info {
+  description = "Creates a VPC"
+  tags        = ["network"]
+}`,
+			},
+			expected: &Info{Description: "Creates a VPC", Tags: []string{"network"}},
+		},
+		{
+			name:  "spec without info",
+			files: map[string]string{SpecFileName: metadata},
+		},
+		{
+			name:  "invalid info",
+			files: map[string]string{SpecFileName: metadata + `info { source = "invalid" }`},
+		},
+		{
+			name:  "spec of a submodule",
+			files: map[string]string{"modules/subnets/" + SpecFileName: metadata + `info { description = "Subnets" }`},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, info, err := Extract(testModuleData(tc.files))
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, info)
+		})
+	}
+}
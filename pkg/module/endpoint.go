@@ -2,7 +2,12 @@ package module
 
 import (
 	"context"
+	"io"
+	"net/url"
+	"path"
+	"time"
 
+	"github.com/boring-registry/boring-registry/pkg/core"
 	o11y "github.com/boring-registry/boring-registry/pkg/observability"
 
 	"github.com/go-kit/kit/endpoint"
@@ -90,3 +95,220 @@ func downloadEndpoint(svc Service, metrics *o11y.ModuleMetrics) endpoint.Endpoin
 		}, nil
 	}
 }
+
+type publishRequest struct {
+	namespace string
+	name      string
+	provider  string
+	version   string
+	body      io.Reader
+}
+
+type publishResponse struct {
+	core.Module
+}
+
+func publishEndpoint(svc Service, metrics *o11y.ModuleMetrics) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(publishRequest)
+
+		metrics.Publish.With(prometheus.Labels{
+			o11y.NamespaceLabel: req.namespace,
+			o11y.NameLabel:      req.name,
+			o11y.ProviderLabel:  req.provider,
+			o11y.VersionLabel:   req.version,
+		}).Inc()
+
+		res, err := svc.PublishModule(ctx, req.namespace, req.name, req.provider, req.version, req.body)
+		if err != nil {
+			return nil, err
+		}
+
+		return publishResponse{res}, nil
+	}
+}
+
+type promoteRequest struct {
+	namespace       string
+	name            string
+	provider        string
+	version         string
+	targetNamespace string
+}
+
+func promoteEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(promoteRequest)
+
+		res, err := svc.PromoteModule(ctx, req.namespace, req.name, req.provider, req.version, req.targetNamespace)
+		if err != nil {
+			return nil, err
+		}
+
+		return publishResponse{res}, nil
+	}
+}
+
+type catalogRequest struct {
+	filter ListFilter
+	page   core.Page
+	url    *url.URL
+}
+
+type catalogResponseModule struct {
+	ID        string `json:"id"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Provider  string `json:"provider"`
+	Version   string `json:"version"`
+
+	PublishedAt *time.Time `json:"published_at,omitempty"`
+	Info        *Info      `json:"info,omitempty"`
+}
+
+type catalogResponse struct {
+	Meta    core.PageMeta           `json:"meta"`
+	Modules []catalogResponseModule `json:"modules"`
+}
+
+func catalogEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(catalogRequest)
+
+		res, meta, err := svc.ListModules(ctx, req.filter, req.page)
+		if err != nil {
+			return nil, err
+		}
+
+		modules := make([]catalogResponseModule, 0, len(res))
+		for _, m := range res {
+			modules = append(modules, catalogResponseModule{
+				ID:        m.ID(true),
+				Namespace: m.Namespace,
+				Name:      m.Name,
+				Provider:  m.Provider,
+				Version:   m.Version,
+
+				PublishedAt: publishedAt(m.Module),
+				Info:        m.Info,
+			})
+		}
+
+		return catalogResponse{
+			Meta:    meta.WithURLs(req.url),
+			Modules: modules,
+		}, nil
+	}
+}
+
+// publishedAt returns nil for modules without an upload time, so that the field is omitted
+func publishedAt(m core.Module) *time.Time {
+	if m.PublishedAt.IsZero() {
+		return nil
+	}
+	t := m.PublishedAt.UTC()
+	return &t
+}
+
+// detailsRequest requests the latest version of the module if the version is empty
+type detailsRequest struct {
+	namespace string
+	name      string
+	provider  string
+	version   string
+	url       *url.URL
+}
+
+// detailsResponse contains the attributes of the module detail endpoint of the public Terraform registry,
+// which are read by dependency update tools like Renovate and Dependabot
+type detailsResponse struct {
+	ID          string     `json:"id"`
+	Namespace   string     `json:"namespace"`
+	Name        string     `json:"name"`
+	Provider    string     `json:"provider"`
+	Version     string     `json:"version"`
+	Description string     `json:"description,omitempty"`
+	Source      string     `json:"source"`
+	PublishedAt *time.Time `json:"published_at,omitempty"`
+	Versions    []string   `json:"versions"`
+	Info        *Info      `json:"info,omitempty"`
+}
+
+func detailsEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(detailsRequest)
+
+		res, err := svc.GetModuleDetails(ctx, req.namespace, req.name, req.provider, req.version)
+		if err != nil {
+			return nil, err
+		}
+
+		var description string
+		if res.Info != nil {
+			description = res.Info.Description
+		}
+
+		return detailsResponse{
+			ID:          res.ID(true),
+			Namespace:   res.Namespace,
+			Name:        res.Name,
+			Provider:    res.Provider,
+			Version:     res.Version,
+			Description: description,
+			Source:      res.Source,
+			PublishedAt: publishedAt(res.Module),
+			Versions:    res.Versions,
+			Info:        res.Info,
+		}, nil
+	}
+}
+
+// downloadLatestResponse redirects to the download of the latest version like the public Terraform registry
+type downloadLatestResponse struct {
+	location string
+}
+
+func downloadLatestEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(detailsRequest)
+
+		res, err := svc.GetModuleDetails(ctx, req.namespace, req.name, req.provider, "")
+		if err != nil {
+			return nil, err
+		}
+
+		return downloadLatestResponse{
+			location: path.Join(path.Dir(req.url.Path), url.PathEscape(res.Version), "download"),
+		}, nil
+	}
+}
+
+type docsRequest struct {
+	namespace string
+	name      string
+	provider  string
+	version   string
+}
+
+func docsEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(docsRequest)
+
+		return svc.GetModuleDocs(ctx, req.namespace, req.name, req.provider, req.version)
+	}
+}
+
+type provenanceRequest struct {
+	namespace string
+	name      string
+	provider  string
+	version   string
+}
+
+func provenanceEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(provenanceRequest)
+
+		return svc.GetModuleProvenance(ctx, req.namespace, req.name, req.provider, req.version)
+	}
+}
@@ -4,8 +4,16 @@ import "errors"
 
 var (
 	// Module errors
-	ErrModuleNotFound      = errors.New("failed to locate module")
-	ErrModuleUploadFailed  = errors.New("failed to upload module")
-	ErrModuleAlreadyExists = errors.New("module already exists")
-	ErrModuleListFailed    = errors.New("failed to list module versions")
+	ErrModuleNotFound         = errors.New("failed to locate module")
+	ErrModuleUploadFailed     = errors.New("failed to upload module")
+	ErrModuleAlreadyExists    = errors.New("module already exists")
+	ErrModuleListFailed       = errors.New("failed to list module versions")
+	ErrInvalidArchive         = errors.New("invalid module archive")
+	ErrInvalidModule          = errors.New("invalid module")
+	ErrSecretsFound           = errors.New("module contains potential secrets")
+	ErrSymlinkOutsideModule   = errors.New("symlink points outside of the module")
+	ErrPublishDisabled        = errors.New("publishing modules is disabled")
+	ErrSkipValidationDisabled = errors.New("skipping the module validation is disabled")
+	ErrDocsNotFound           = errors.New("failed to locate module docs")
+	ErrInfoNotFound           = errors.New("failed to locate module info")
 )
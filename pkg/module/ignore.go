@@ -0,0 +1,142 @@
+package module
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// IgnoreFileName is the file in the module root listing the files that are excluded from the module archive
+const IgnoreFileName = ".terraformignore"
+
+// defaultIgnoreRules are used if the module doesn't contain a .terraformignore file
+var defaultIgnoreRules = []string{
+	".git/",
+	".terraform/",
+	"terraform.tfstate",
+	"terraform.tfstate.*",
+	".DS_Store",
+	".idea/",
+	".vscode/",
+	"*~",
+	".*.swp",
+}
+
+// ignoreRule is a single pattern of a .terraformignore file
+type ignoreRule struct {
+	pattern *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// ignoreRules decides which files of the module directory are archived.
+// The patterns follow the syntax of .gitignore files, like .terraformignore files read by Terraform:
+// A pattern without a slash matches at any depth, otherwise it's relative to the module root.
+// A trailing slash only matches directories, ** matches any number of directories, and a leading ! includes the path again.
+// The last matching pattern wins, and the contents of an ignored directory can't be included again.
+type ignoreRules []ignoreRule
+
+// readIgnoreRules reads the .terraformignore file of the module root, or returns the default rules if there is none
+func readIgnoreRules(root string) (ignoreRules, error) {
+	f, err := os.Open(filepath.Join(root, IgnoreFileName))
+	if os.IsNotExist(err) {
+		return parseIgnoreRules(strings.NewReader(strings.Join(defaultIgnoreRules, "\n")))
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return parseIgnoreRules(f)
+}
+
+func parseIgnoreRules(r io.Reader) (ignoreRules, error) {
+	var rules ignoreRules
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		rule := ignoreRule{}
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+
+		// Patterns without a slash match the name of a file or directory at any depth
+		anchored := strings.Contains(line, "/")
+		line = strings.TrimPrefix(line, "/")
+		if line == "" {
+			continue
+		}
+
+		expr := ignorePatternExpr(line)
+		if !anchored {
+			expr = "(?:.*/)?" + expr
+		}
+		pattern, err := regexp.Compile("^" + expr + "$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %s in %s: %w", scanner.Text(), IgnoreFileName, err)
+		}
+		rule.pattern = pattern
+		rules = append(rules, rule)
+	}
+	return rules, scanner.Err()
+}
+
+// ignorePatternExpr translates the wildcards of a pattern into a regular expression
+func ignorePatternExpr(pattern string) string {
+	var b strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(pattern[i:], ']')
+			if end < 0 {
+				b.WriteString(regexp.QuoteMeta(string(c)))
+				continue
+			}
+			class := pattern[i+1 : i+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
+
+// ignored returns whether the path relative to the module root, separated by slashes, is excluded from the archive
+func (rules ignoreRules) ignored(name string, dir bool) bool {
+	ignored := false
+	for _, rule := range rules {
+		if rule.dirOnly && !dir {
+			continue
+		}
+		if rule.pattern.MatchString(name) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
@@ -0,0 +1,50 @@
+package module
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIgnoreRules(t *testing.T) {
+	t.Parallel()
+
+	rules, err := parseIgnoreRules(strings.NewReader(`
+# comment
+*.log
+!keep.log
+/build
+docs/**/*.png
+tmp/
+**/fixtures/*.json
+`))
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		name    string
+		dir     bool
+		ignored bool
+	}{
+		{name: "main.tf"},
+		{name: "debug.log", ignored: true},
+		{name: "modules/vpc/debug.log", ignored: true},
+		{name: "keep.log"},
+		{name: "build", ignored: true},
+		{name: "modules/build"},
+		{name: "docs/diagram.png", ignored: true},
+		{name: "docs/images/vpc/diagram.png", ignored: true},
+		{name: "modules/docs/diagram.png"},
+		{name: "tmp", dir: true, ignored: true},
+		{name: "modules/tmp", dir: true, ignored: true},
+		{name: "tmp"},
+		{name: "fixtures/plan.json", ignored: true},
+		{name: "tests/fixtures/plan.json", ignored: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.ignored, rules.ignored(tc.name, tc.dir))
+		})
+	}
+}
@@ -2,6 +2,7 @@ package module
 
 import (
 	"context"
+	"io"
 	"log/slog"
 	"time"
 
@@ -70,3 +71,145 @@ func (mw loggingMiddleware) GetModule(ctx context.Context, namespace, name, prov
 
 	return mw.next.GetModule(ctx, namespace, name, provider, version)
 }
+
+func (mw loggingMiddleware) PublishModule(ctx context.Context, namespace, name, provider, version string, body io.Reader) (module core.Module, err error) {
+	defer func(begin time.Time) {
+		logger := slog.Default().With(
+			slog.String("op", "PublishModule"),
+			slog.Group("module",
+				slog.String("namespace", namespace),
+				slog.String("name", name),
+				slog.String("provider", provider),
+				slog.String("version", version),
+			),
+		)
+
+		if err != nil {
+			logger.Error("failed to publish module", slog.String("err", err.Error()))
+			return
+		}
+
+		logger.Info("publish module", slog.String("took", time.Since(begin).String()), slog.String("module", module.ID(true)))
+	}(time.Now())
+
+	return mw.next.PublishModule(ctx, namespace, name, provider, version, body)
+}
+
+func (mw loggingMiddleware) ListModules(ctx context.Context, filter ListFilter, page core.Page) (modules []Details, meta core.PageMeta, err error) {
+	defer func(begin time.Time) {
+		logger := slog.Default().With(
+			slog.String("op", "ListModules"),
+			slog.Group("filter",
+				slog.String("namespace", filter.Namespace),
+				slog.String("provider", filter.Provider),
+				slog.String("query", filter.Query),
+				slog.String("tag", filter.Tag),
+				slog.String("team", filter.Team),
+			),
+			slog.Int("offset", page.Offset),
+			slog.Int("limit", page.Limit),
+		)
+
+		if err != nil {
+			logger.Error("failed to list modules", slog.String("err", err.Error()))
+			return
+		}
+
+		logger.Info("list modules", slog.String("took", time.Since(begin).String()), slog.Int("modules", len(modules)))
+	}(time.Now())
+
+	return mw.next.ListModules(ctx, filter, page)
+}
+
+func (mw loggingMiddleware) GetModuleDetails(ctx context.Context, namespace, name, provider, version string) (details Details, err error) {
+	defer func(begin time.Time) {
+		logger := slog.Default().With(
+			slog.String("op", "GetModuleDetails"),
+			slog.Group("module",
+				slog.String("namespace", namespace),
+				slog.String("name", name),
+				slog.String("provider", provider),
+				slog.String("version", version),
+			),
+		)
+
+		if err != nil {
+			logger.Error("failed to get module details", slog.String("err", err.Error()))
+			return
+		}
+
+		logger.Info("get module details", slog.String("took", time.Since(begin).String()), slog.String("module", details.ID(true)))
+	}(time.Now())
+
+	return mw.next.GetModuleDetails(ctx, namespace, name, provider, version)
+}
+
+func (mw loggingMiddleware) GetModuleDocs(ctx context.Context, namespace, name, provider, version string) (docs *Docs, err error) {
+	defer func(begin time.Time) {
+		logger := slog.Default().With(
+			slog.String("op", "GetModuleDocs"),
+			slog.Group("module",
+				slog.String("namespace", namespace),
+				slog.String("name", name),
+				slog.String("provider", provider),
+				slog.String("version", version),
+			),
+		)
+
+		if err != nil {
+			logger.Error("failed to get module docs", slog.String("err", err.Error()))
+			return
+		}
+
+		logger.Info("get module docs", slog.String("took", time.Since(begin).String()), slog.Int("submodules", len(docs.Submodules)))
+	}(time.Now())
+
+	return mw.next.GetModuleDocs(ctx, namespace, name, provider, version)
+}
+
+func (mw loggingMiddleware) GetModuleProvenance(ctx context.Context, namespace, name, provider, version string) (p *core.Provenance, err error) {
+	defer func(begin time.Time) {
+		logger := slog.Default().With(
+			slog.String("op", "GetModuleProvenance"),
+			slog.Group("module",
+				slog.String("namespace", namespace),
+				slog.String("name", name),
+				slog.String("provider", provider),
+				slog.String("version", version),
+			),
+		)
+
+		if err != nil {
+			logger.Error("failed to get module provenance", slog.String("err", err.Error()))
+			return
+		}
+
+		logger.Info("get module provenance", slog.String("took", time.Since(begin).String()))
+	}(time.Now())
+
+	return mw.next.GetModuleProvenance(ctx, namespace, name, provider, version)
+}
+
+func (mw loggingMiddleware) PromoteModule(ctx context.Context, namespace, name, provider, version, targetNamespace string) (module core.Module, err error) {
+	defer func(begin time.Time) {
+		logger := slog.Default().With(
+			slog.String("op", "PromoteModule"),
+			slog.Group("module",
+				slog.String("namespace", namespace),
+				slog.String("name", name),
+				slog.String("provider", provider),
+				slog.String("version", version),
+			),
+			slog.String("target_namespace", targetNamespace),
+		)
+
+		if err != nil {
+			logger.Error("failed to promote module", slog.String("err", err.Error()))
+			return
+		}
+
+		logger.Info("promote module", slog.String("took", time.Since(begin).String()))
+	}(time.Now())
+
+	return mw.next.PromoteModule(ctx, namespace, name, provider, version, targetNamespace)
+}
@@ -4,15 +4,24 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net/url"
 	"os"
+	"regexp"
 
 	"github.com/hashicorp/go-version"
 	"github.com/hashicorp/hcl/v2/hclsimple"
 )
 
+// SpecFileName is the name of the module spec file in the root directory of a module
+const SpecFileName = "boring-registry.hcl"
+
+// tagPattern restricts tags to lowercase keywords, so that they can be used as filters
+var tagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
 // Spec represents a module spec with metadata.
 type Spec struct {
 	Metadata Metadata `hcl:"metadata,block" json:"metadata"`
+	Info     *Info    `hcl:"info,block" json:"info,omitempty"`
 }
 
 // Metadata provides information about a given module version.
@@ -20,7 +29,23 @@ type Metadata struct {
 	Namespace string `hcl:"namespace" json:"namespace"`
 	Name      string `hcl:"name" json:"name"`
 	Provider  string `hcl:"provider" json:"provider"`
-	Version   string `hcl:"version" json:"version"`
+	Version   string `hcl:"version,optional" json:"version"`
+}
+
+// Info describes a module for the people using it. All attributes are optional.
+type Info struct {
+	Description string `hcl:"description,optional" json:"description,omitempty"`
+
+	// Team is the team owning the module, Owners are the people or groups to contact about it
+	Team   string   `hcl:"team,optional" json:"team,omitempty"`
+	Owners []string `hcl:"owners,optional" json:"owners,omitempty"`
+
+	// Source is the URL of the source repository and Changelog the URL of the changelog of the module
+	Source    string `hcl:"source,optional" json:"source,omitempty"`
+	Changelog string `hcl:"changelog,optional" json:"changelog,omitempty"`
+
+	Tags    []string `hcl:"tags,optional" json:"tags,omitempty"`
+	License string   `hcl:"license,optional" json:"license,omitempty"`
 }
 
 // Validate ensures that a spec is valid.
@@ -47,40 +72,106 @@ func (s *Spec) Validate() error {
 		errs = append(errs, err)
 	}
 
+	if s.Info != nil {
+		errs = append(errs, s.Info.validate())
+	}
+
+	return errors.Join(errs...)
+}
+
+func (i *Info) validate() error {
+	var errs []error
+
+	for _, attr := range []struct{ name, value string }{{"info.source", i.Source}, {"info.changelog", i.Changelog}} {
+		if attr.value == "" {
+			continue
+		}
+		if u, err := url.Parse(attr.value); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("%s must be an http or https URL: %s", attr.name, attr.value))
+		}
+	}
+
+	for _, owner := range i.Owners {
+		if owner == "" {
+			errs = append(errs, errors.New("info.owners cannot contain empty owners"))
+		}
+	}
+
+	seen := make(map[string]struct{})
+	for _, tag := range i.Tags {
+		if !tagPattern.MatchString(tag) {
+			errs = append(errs, fmt.Errorf("info.tags must only contain lowercase letters, digits and dashes: %q", tag))
+		} else if _, ok := seen[tag]; ok {
+			errs = append(errs, fmt.Errorf("info.tags contains %s more than once", tag))
+		}
+		seen[tag] = struct{}{}
+	}
+
 	return errors.Join(errs...)
 }
 
+// HasTag returns whether the module is tagged with the tag
+func (i *Info) HasTag(tag string) bool {
+	for _, t := range i.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
 func (s *Spec) Name() string {
 	return fmt.Sprintf("%s/%s/%s/%s", s.Metadata.Namespace, s.Metadata.Name, s.Metadata.Provider, s.Metadata.Version)
 }
 
 // ParseFile parses a module spec file.
 func ParseFile(path string) (*Spec, error) {
+	return ParseFileWithVersion(path, "")
+}
+
+// ParseFileWithVersion parses a module spec file, whose version is replaced with the version if it isn't empty.
+// This allows deriving the version from elsewhere, e.g. from a git tag, in which case the spec file doesn't need a version.
+func ParseFileWithVersion(path, version string) (*Spec, error) {
 	file, err := os.Open(path)
 	if err != nil {
 		return nil, err
 	}
 	defer file.Close()
 
-	return Parse(file)
+	return parse(file, version)
 }
 
 // Parse parses a module spec.
 func Parse(r io.Reader) (*Spec, error) {
-	spec := &Spec{}
+	return parse(r, "")
+}
 
-	b, err := io.ReadAll(r)
+func parse(r io.Reader, version string) (*Spec, error) {
+	spec, err := decodeSpec(r)
 	if err != nil {
 		return nil, err
 	}
+	if version != "" {
+		spec.Metadata.Version = version
+	}
 
-	if err := hclsimple.Decode("boring-registry.hcl", b, nil, spec); err != nil {
+	if err := spec.Validate(); err != nil {
 		return nil, err
 	}
 
-	if err := spec.Validate(); err != nil {
+	return spec, nil
+}
+
+// decodeSpec decodes the spec file without validating it
+func decodeSpec(r io.Reader) (*Spec, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
 		return nil, err
 	}
 
+	spec := &Spec{}
+	if err := hclsimple.Decode(SpecFileName, b, nil, spec); err != nil {
+		return nil, err
+	}
 	return spec, nil
 }
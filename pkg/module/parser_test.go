@@ -2,6 +2,8 @@ package module
 
 import (
 	"io"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -29,7 +31,7 @@ func TestParser(t *testing.T) {
             }
 			`),
 			expected: &Spec{
-				Metadata{
+				Metadata: Metadata{
 					Name:      "s3",
 					Namespace: "example",
 					Version:   "1.0.0",
@@ -37,6 +39,62 @@ func TestParser(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "valid spec with info",
+			input: strings.NewReader(`
+            metadata {
+              name      = "s3"
+              namespace = "example"
+              version   = "1.0.0"
+              provider  = "aws"
+            }
+
+            info {
+              description = "Creates an S3 bucket"
+              team        = "platform"
+              owners      = ["alice@example.com"]
+              source      = "https://github.com/example/terraform-aws-s3"
+              changelog   = "https://github.com/example/terraform-aws-s3/blob/main/CHANGELOG.md"
+              tags        = ["storage", "s3"]
+              license     = "MIT"
+            }
+			`),
+			expected: &Spec{
+				Metadata: Metadata{
+					Name:      "s3",
+					Namespace: "example",
+					Version:   "1.0.0",
+					Provider:  "aws",
+				},
+				Info: &Info{
+					Description: "Creates an S3 bucket",
+					Team:        "platform",
+					Owners:      []string{"alice@example.com"},
+					Source:      "https://github.com/example/terraform-aws-s3",
+					Changelog:   "https://github.com/example/terraform-aws-s3/blob/main/CHANGELOG.md",
+					Tags:        []string{"storage", "s3"},
+					License:     "MIT",
+				},
+			},
+		},
+		{
+			name: "invalid info",
+			input: strings.NewReader(`
+            metadata {
+              name      = "s3"
+              namespace = "example"
+              version   = "1.0.0"
+              provider  = "aws"
+            }
+
+            info {
+              source = "git@github.com:example/terraform-aws-s3.git"
+              tags   = ["Storage", "s3", "s3"]
+              owners = [""]
+            }
+			`),
+			expectedError: true,
+		},
 		{
 			name:          "empty spec",
 			input:         strings.NewReader(``),
@@ -55,6 +113,18 @@ func TestParser(t *testing.T) {
 			expected:      nil,
 			expectedError: true,
 		},
+		{
+			name: "missing version",
+			input: strings.NewReader(`
+            metadata {
+              namespace = "tier"
+              name      = "s3"
+              provider  = "aws"
+            }
+			`),
+			expected:      nil,
+			expectedError: true,
+		},
 		{
 			name: "empty fields",
 			input: strings.NewReader(`
@@ -86,3 +156,26 @@ func TestParser(t *testing.T) {
 		})
 	}
 }
+
+func TestParseFileWithVersion(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), SpecFileName)
+	assert.NoError(t, os.WriteFile(path, []byte(`
+metadata {
+  namespace = "tier"
+  name      = "s3"
+  provider  = "aws"
+}
+`), 0644))
+
+	_, err := ParseFile(path)
+	assert.ErrorContains(t, err, "metadata.version cannot be empty")
+
+	spec, err := ParseFileWithVersion(path, "1.4.0")
+	assert.NoError(t, err)
+	assert.Equal(t, "tier/s3/aws/1.4.0", spec.Name())
+
+	_, err = ParseFileWithVersion(path, "latest")
+	assert.Error(t, err)
+}
@@ -0,0 +1,252 @@
+package module
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// SecretsAllowlistFileName is the file in the module root listing the findings of the secret scan that are false positives
+const SecretsAllowlistFileName = ".secrets-allowlist"
+
+// SecretScanMode configures what happens if the secret scan finds secrets in a module archive
+type SecretScanMode string
+
+const (
+	// SecretScanBlock rejects archives containing secrets
+	SecretScanBlock SecretScanMode = "block"
+	// SecretScanWarn logs the secrets found in archives, but accepts them
+	SecretScanWarn SecretScanMode = "warn"
+	// SecretScanOff disables the secret scan
+	SecretScanOff SecretScanMode = "off"
+)
+
+// ParseSecretScanMode returns the mode with the name, which is one of block, warn or off
+func ParseSecretScanMode(mode string) (SecretScanMode, error) {
+	switch m := SecretScanMode(mode); m {
+	case SecretScanBlock, SecretScanWarn, SecretScanOff:
+		return m, nil
+	}
+	return "", fmt.Errorf("invalid secret scan mode %s, has to be block, warn or off", mode)
+}
+
+// secretFileRule flags files that shouldn't be part of a module, regardless of their content
+type secretFileRule struct {
+	id    string
+	match func(name string) bool
+}
+
+// secretContentRule flags the lines of a file matching the pattern
+type secretContentRule struct {
+	id      string
+	pattern *regexp.Regexp
+
+	// minEntropy is the minimum Shannon entropy in bits per character of the first submatch, if the pattern has one
+	minEntropy float64
+}
+
+var (
+	secretFileRules = []secretFileRule{
+		{id: "terraform-state", match: func(name string) bool {
+			base := path.Base(name)
+			return strings.HasSuffix(base, ".tfstate") || strings.Contains(base, ".tfstate.")
+		}},
+		{id: "terraform-variables", match: func(name string) bool {
+			return strings.HasSuffix(name, ".tfvars") || strings.HasSuffix(name, ".tfvars.json")
+		}},
+		{id: "terraform-directory", match: func(name string) bool {
+			return name == ".terraform" || strings.HasPrefix(name, ".terraform/") || strings.Contains(name, "/.terraform/")
+		}},
+		{id: "ssh-private-key", match: func(name string) bool {
+			switch path.Base(name) {
+			case "id_rsa", "id_dsa", "id_ecdsa", "id_ed25519":
+				return true
+			}
+			return false
+		}},
+	}
+
+	secretContentRules = []secretContentRule{
+		{id: "aws-access-key-id", pattern: regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+		{id: "aws-secret-access-key", pattern: regexp.MustCompile(`(?i)aws.{0,20}secret.{0,20}[=:]\s*["']?([A-Za-z0-9/+]{40})\b`), minEntropy: 4},
+		{id: "private-key", pattern: regexp.MustCompile(`-----BEGIN[A-Z ]* PRIVATE KEY-----`)},
+		{id: "high-entropy-string", pattern: regexp.MustCompile(`["']([A-Za-z0-9+/=_-]{32,})["']`), minEntropy: 4.5},
+	}
+)
+
+// SecretFinding is a secret found in a module archive
+type SecretFinding struct {
+	Path string
+	Line int
+	Rule string
+}
+
+func (f SecretFinding) String() string {
+	if f.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s", f.Path, f.Line, f.Rule)
+	}
+	return fmt.Sprintf("%s: %s", f.Path, f.Rule)
+}
+
+// secretAllowRule allows the findings of a rule, or of all rules if it's empty, in the files matching the pattern
+type secretAllowRule struct {
+	files ignoreRules
+	rule  string
+}
+
+// secretScanner collects the findings of all files of an archive.
+// The allowlist is applied once all files were scanned, as it can appear anywhere in the archive.
+type secretScanner struct {
+	findings  []SecretFinding
+	allowlist []secretAllowRule
+}
+
+// secretScanBufferSize bounds the memory used for scanning a file.
+// Lines that are longer are scanned in chunks of this size, so secrets spanning two chunks aren't found.
+const secretScanBufferSize = 64 << 10
+
+// scan checks the name and the content of the file, which is streamed line by line
+func (s *secretScanner) scan(name string, r io.Reader) error {
+	if name == SecretsAllowlistFileName {
+		content, err := io.ReadAll(r)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+		}
+		return s.parseAllowlist(content)
+	}
+
+	for _, rule := range secretFileRules {
+		if rule.match(name) {
+			s.findings = append(s.findings, SecretFinding{Path: name, Rule: rule.id})
+		}
+	}
+
+	// Binary files aren't scanned
+	br := bufio.NewReaderSize(r, secretScanBufferSize)
+	head, err := br.Peek(8000)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+	if bytes.IndexByte(head, 0) >= 0 {
+		return nil
+	}
+
+	line := 1
+	for {
+		chunk, isPrefix, err := br.ReadLine()
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+		}
+
+		for _, rule := range secretContentRules {
+			if rule.matches(string(chunk)) {
+				s.findings = append(s.findings, SecretFinding{Path: name, Line: line, Rule: rule.id})
+			}
+		}
+		if !isPrefix {
+			line++
+		}
+	}
+}
+
+func (r secretContentRule) matches(line string) bool {
+	for _, m := range r.pattern.FindAllStringSubmatch(line, -1) {
+		if len(m) < 2 || entropy(m[1]) >= r.minEntropy {
+			return true
+		}
+	}
+	return false
+}
+
+// entropy returns the Shannon entropy of s in bits per character
+func entropy(s string) float64 {
+	counts := make(map[rune]int)
+	for _, c := range s {
+		counts[c]++
+	}
+
+	e := 0.0
+	for _, n := range counts {
+		p := float64(n) / float64(len(s))
+		e -= p * math.Log2(p)
+	}
+	return e
+}
+
+// parseAllowlist reads lines consisting of a pattern in the syntax of .terraformignore files and an optional rule, e.g.:
+//
+//	examples/**/*.tfvars
+//	README.md aws-access-key-id
+func (s *secretScanner) parseAllowlist(content []byte) error {
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if len(fields) > 2 {
+			return fmt.Errorf("%w: invalid line in %s: %s", ErrInvalidArchive, SecretsAllowlistFileName, scanner.Text())
+		}
+
+		files, err := parseIgnoreRules(strings.NewReader(fields[0]))
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+		}
+		rule := secretAllowRule{files: files}
+		if len(fields) == 2 {
+			rule.rule = fields[1]
+		}
+		s.allowlist = append(s.allowlist, rule)
+	}
+	return scanner.Err()
+}
+
+func (s *secretScanner) allowed(f SecretFinding) bool {
+	for _, a := range s.allowlist {
+		if a.rule != "" && a.rule != f.Rule {
+			continue
+		}
+
+		// A pattern matching a parent directory allows all files within it
+		if a.files.ignored(f.Path, false) {
+			return true
+		}
+		for dir := path.Dir(f.Path); dir != "."; dir = path.Dir(dir) {
+			if a.files.ignored(dir, true) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// err returns an ErrSecretsFound error reporting all findings that aren't allowed if the mode blocks them.
+// Otherwise, the findings are logged as warning.
+func (s *secretScanner) err(mode SecretScanMode) error {
+	var findings []string
+	for _, f := range s.findings {
+		if !s.allowed(f) {
+			findings = append(findings, f.String())
+		}
+	}
+	if len(findings) == 0 {
+		return nil
+	}
+
+	if mode == SecretScanWarn {
+		for _, f := range findings {
+			slog.Warn("module archive contains a potential secret", slog.String("finding", f))
+		}
+		return nil
+	}
+	return fmt.Errorf("%w, add false positives to %s:\n%s", ErrSecretsFound, SecretsAllowlistFileName, strings.Join(findings, "\n"))
+}
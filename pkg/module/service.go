@@ -2,8 +2,16 @@ package module
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"strings"
 
 	"github.com/boring-registry/boring-registry/pkg/core"
+
+	goversion "github.com/hashicorp/go-version"
 )
 
 // Service implements the Module Registry Protocol.
@@ -11,19 +19,123 @@ import (
 type Service interface {
 	GetModule(ctx context.Context, namespace, name, provider, version string) (core.Module, error)
 	ListModuleVersions(ctx context.Context, namespace, name, provider string) ([]core.Module, error)
+	PublishModule(ctx context.Context, namespace, name, provider, version string, body io.Reader) (core.Module, error)
+
+	// ListModules returns the details of the latest version of every module matching the filter, sorted by the module ID
+	ListModules(ctx context.Context, filter ListFilter, page core.Page) ([]Details, core.PageMeta, error)
+
+	// GetModuleDetails returns the details of the module version, or of the latest version if the version is empty
+	GetModuleDetails(ctx context.Context, namespace, name, provider, version string) (Details, error)
+
+	// GetModuleDocs returns the inputs, outputs, requirements and READMEs of the module version and its submodules
+	GetModuleDocs(ctx context.Context, namespace, name, provider, version string) (*Docs, error)
+
+	// GetModuleProvenance returns who published the module version, when, and from which pipeline
+	GetModuleProvenance(ctx context.Context, namespace, name, provider, version string) (*core.Provenance, error)
+
+	// PromoteModule copies the module version to the target namespace without modifying the archive
+	PromoteModule(ctx context.Context, namespace, name, provider, version, targetNamespace string) (core.Module, error)
+}
+
+// Details describes a module version and lists all versions of the module
+type Details struct {
+	core.Module
+
+	// Source is the URL of the source repository of the module, if it's known
+	Source string
+
+	// Info is the info of the module spec file, which is nil if the module version was uploaded without it
+	Info *Info
+
+	// Versions are all versions of the module in ascending order
+	Versions []string
+}
+
+// ListFilter selects the modules of a listing. Empty attributes match every module
+type ListFilter struct {
+	Namespace string
+	Provider  string
+
+	// Query matches modules whose namespace, name or provider contain it, ignoring the case
+	Query string
+
+	// Tag and Team match the info of the module spec file
+	Tag  string
+	Team string
+}
+
+// needsInfo returns whether the filter matches the info of the modules, which is stored separately
+func (f ListFilter) needsInfo() bool {
+	return f.Tag != "" || f.Team != ""
+}
+
+func (f ListFilter) matchesInfo(info *Info) bool {
+	if !f.needsInfo() {
+		return true
+	} else if info == nil {
+		return false
+	}
+
+	return (f.Tag == "" || info.HasTag(f.Tag)) && (f.Team == "" || f.Team == info.Team)
+}
+
+func (f ListFilter) matches(m core.Module) bool {
+	if f.Provider != "" && f.Provider != m.Provider {
+		return false
+	}
+
+	query := strings.ToLower(f.Query)
+	return strings.Contains(strings.ToLower(m.Namespace), query) ||
+		strings.Contains(strings.ToLower(m.Name), query) ||
+		strings.Contains(strings.ToLower(m.Provider), query)
 }
 
 type service struct {
-	storage Storage
-	proxy   core.ProxyUrlService
+	storage        Storage
+	proxy          core.ProxyUrlService
+	publishEnabled bool
+	validation     []ValidateOption
+
+	// skipValidationAllowed allows publishers to skip the validation of the module contents
+	skipValidationAllowed bool
+}
+
+// ServiceOption provides additional options for the Service.
+type ServiceOption func(*service)
+
+// WithPublishEnabled allows publishing modules through the Service
+func WithPublishEnabled(enabled bool) ServiceOption {
+	return func(s *service) {
+		s.publishEnabled = enabled
+	}
+}
+
+// WithSkipValidationAllowed allows publishers to skip the validation of the module contents, which is rejected otherwise
+func WithSkipValidationAllowed(allowed bool) ServiceOption {
+	return func(s *service) {
+		s.skipValidationAllowed = allowed
+	}
+}
+
+// WithValidation configures the validation of the archives of published modules
+func WithValidation(options ...ValidateOption) ServiceOption {
+	return func(s *service) {
+		s.validation = options
+	}
 }
 
 // NewService returns a fully initialized Service.
-func NewService(storage Storage, proxy core.ProxyUrlService) Service {
-	return &service{
+func NewService(storage Storage, proxy core.ProxyUrlService, options ...ServiceOption) Service {
+	s := &service{
 		storage: storage,
 		proxy:   proxy,
 	}
+
+	for _, option := range options {
+		option(s)
+	}
+
+	return s
 }
 
 func (s *service) GetModule(ctx context.Context, namespace, name, provider, version string) (core.Module, error) {
@@ -32,7 +144,13 @@ func (s *service) GetModule(ctx context.Context, namespace, name, provider, vers
 		return core.Module{}, err
 	}
 
-	if s.proxy.IsProxyEnabled(ctx) {
+	return s.proxyDownloadURL(ctx, res)
+}
+
+// proxyDownloadURL rewrites the download URL to the download proxy.
+// Addresses of other source types like git:: aren't downloaded over HTTP and are kept as they are.
+func (s *service) proxyDownloadURL(ctx context.Context, res core.Module) (core.Module, error) {
+	if s.proxy.IsProxyEnabled(ctx) && (strings.HasPrefix(res.DownloadURL, "http://") || strings.HasPrefix(res.DownloadURL, "https://")) {
 		downloadUrl, err := s.proxy.GetProxyUrl(ctx, res.DownloadURL)
 		if err != nil {
 			return core.Module{}, err
@@ -41,7 +159,7 @@ func (s *service) GetModule(ctx context.Context, namespace, name, provider, vers
 		res.DownloadURL = downloadUrl
 	}
 
-	return res, err
+	return res, nil
 }
 
 func (s *service) ListModuleVersions(ctx context.Context, namespace, name, provider string) ([]core.Module, error) {
@@ -52,3 +170,217 @@ func (s *service) ListModuleVersions(ctx context.Context, namespace, name, provi
 
 	return res, nil
 }
+
+func (s *service) ListModules(ctx context.Context, filter ListFilter, page core.Page) ([]Details, core.PageMeta, error) {
+	res, err := s.storage.ListModules(ctx, filter.Namespace)
+	if err != nil {
+		return nil, core.PageMeta{}, err
+	}
+
+	versions := make(map[string][]core.Module)
+	for _, m := range res {
+		if filter.matches(m) {
+			versions[m.ID(false)] = append(versions[m.ID(false)], m)
+		}
+	}
+
+	modules := make([]Details, 0, len(versions))
+	for id, moduleVersions := range versions {
+		v := make([]string, 0, len(moduleVersions))
+		for _, m := range moduleVersions {
+			v = append(v, m.Version)
+		}
+
+		latest := core.LatestVersion(v)
+		if latest == "" {
+			slog.Warn("skipping module without a valid version", slog.String("module", id), slog.Any("versions", v))
+			continue
+		}
+		for _, m := range moduleVersions {
+			if m.Version == latest {
+				m.DownloadURL = ""
+				core.SortVersions(v)
+				modules = append(modules, Details{Module: m, Versions: v})
+				break
+			}
+		}
+	}
+	sort.Slice(modules, func(i, j int) bool {
+		return modules[i].ID(false) < modules[j].ID(false)
+	})
+
+	// The info is stored in a separate object per module version, so it's only read for every module if the filter needs it
+	loaded := filter.needsInfo()
+	if loaded {
+		filtered := modules[:0]
+		for _, m := range modules {
+			if err := s.loadInfo(ctx, &m); err != nil {
+				return nil, core.PageMeta{}, err
+			}
+			if filter.matchesInfo(m.Info) {
+				filtered = append(filtered, m)
+			}
+		}
+		modules = filtered
+	}
+
+	result, meta := core.Paginate(modules, page)
+	if !loaded {
+		for i := range result {
+			if err := s.loadInfo(ctx, &result[i]); err != nil {
+				return nil, core.PageMeta{}, err
+			}
+		}
+	}
+	return result, meta, nil
+}
+
+// loadInfo adds the info of the module version to the details, if the module version was uploaded with it
+func (s *service) loadInfo(ctx context.Context, d *Details) error {
+	info, err := s.storage.GetModuleInfo(ctx, d.Namespace, d.Name, d.Provider, d.Version)
+	if errors.Is(err, ErrInfoNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	d.Info = info
+	d.Source = info.Source
+	return nil
+}
+
+func (s *service) GetModuleDetails(ctx context.Context, namespace, name, provider, version string) (Details, error) {
+	m := core.Module{
+		Namespace: namespace,
+		Name:      name,
+		Provider:  provider,
+		Version:   version,
+	}
+
+	res, err := s.storage.ListModuleVersions(ctx, namespace, name, provider)
+	if err != nil {
+		return Details{}, err
+	} else if len(res) == 0 {
+		return Details{}, fmt.Errorf("%w: %s", ErrModuleNotFound, m.ID(false))
+	}
+
+	versions := make([]string, 0, len(res))
+	for _, r := range res {
+		versions = append(versions, r.Version)
+	}
+	core.SortVersions(versions)
+
+	if version == "" {
+		version = core.LatestVersion(versions)
+	}
+
+	for _, r := range res {
+		if r.Version == version {
+			r.DownloadURL = ""
+			details := Details{
+				Module:   r,
+				Versions: versions,
+			}
+			return details, s.loadInfo(ctx, &details)
+		}
+	}
+
+	return Details{}, fmt.Errorf("%w: %s", ErrModuleNotFound, m.ID(true))
+}
+
+// GetModuleDocs falls back to extracting the docs from the module archive,
+// as modules that were uploaded before the docs were introduced don't have them
+func (s *service) GetModuleDocs(ctx context.Context, namespace, name, provider, version string) (*Docs, error) {
+	docs, err := s.storage.GetModuleDocs(ctx, namespace, name, provider, version)
+	if !errors.Is(err, ErrDocsNotFound) {
+		return docs, err
+	}
+
+	archive, err := s.storage.DownloadModule(ctx, namespace, name, provider, version)
+	if err != nil {
+		return nil, err
+	}
+	defer archive.Close()
+
+	return ExtractDocs(archive)
+}
+
+func (s *service) GetModuleProvenance(ctx context.Context, namespace, name, provider, version string) (*core.Provenance, error) {
+	return s.storage.GetModuleProvenance(ctx, namespace, name, provider, version)
+}
+
+// PublishModule validates the archive while uploading it to the storage backend.
+// The validation of the module contents is skipped if the context asks for it and the Service allows it, whereas the archive itself is always validated.
+// The module is deleted again if the validation fails after the storage backend started reading the archive,
+// as not every storage backend aborts the upload on the error of the archive.
+// Published module versions are immutable, so existing versions can't be overwritten.
+func (s *service) PublishModule(ctx context.Context, namespace, name, provider, version string, body io.Reader) (core.Module, error) {
+	if !s.publishEnabled {
+		return core.Module{}, ErrPublishDisabled
+	}
+
+	m := core.Module{
+		Namespace: namespace,
+		Name:      name,
+		Provider:  provider,
+		Version:   version,
+	}
+	if _, err := goversion.NewVersion(version); err != nil {
+		return core.Module{}, fmt.Errorf("%w: version %s: %v", core.ErrVarType, version, err)
+	}
+
+	validation := s.validation
+	if SkipValidationFromContext(ctx) {
+		if !s.skipValidationAllowed {
+			return core.Module{}, ErrSkipValidationDisabled
+		}
+		validation = append(validation[:len(validation):len(validation)], WithContentValidation(false))
+	}
+
+	if _, err := s.storage.GetModule(ctx, namespace, name, provider, version); err == nil {
+		return core.Module{}, fmt.Errorf("%w: %s", ErrModuleAlreadyExists, m.ID(true))
+	}
+
+	archive := newValidatingReader(body, m, validation...)
+	res, err := s.storage.UploadModule(ctx, namespace, name, provider, version, archive)
+
+	// Closing the archive stops the validation in case the storage backend didn't read it completely
+	archive.Close()
+	if !archive.started() || errors.Is(err, core.ErrObjectAlreadyExists) || errors.Is(err, ErrPublishDisabled) {
+		// The storage backend rejected the module version before it read the archive
+		if err != nil {
+			return core.Module{}, err
+		}
+	} else if validateErr := archive.err(); validateErr != nil {
+		// Report the validation error instead of the error of the storage backend, which might not wrap it
+		if deleteErr := s.storage.DeleteModule(ctx, namespace, name, provider, version); deleteErr != nil && !errors.Is(deleteErr, ErrModuleNotFound) {
+			return core.Module{}, errors.Join(validateErr, fmt.Errorf("failed to delete the rejected module: %w", deleteErr))
+		}
+		return core.Module{}, validateErr
+	} else if err != nil {
+		return core.Module{}, err
+	}
+
+	return s.proxyDownloadURL(ctx, res)
+}
+
+// PromoteModule copies the module version to the target namespace, e.g. from staging to prod.
+// The archive isn't validated again, as it's copied as-is and was validated when it was published.
+func (s *service) PromoteModule(ctx context.Context, namespace, name, provider, version, targetNamespace string) (core.Module, error) {
+	if !s.publishEnabled {
+		return core.Module{}, ErrPublishDisabled
+	}
+
+	if targetNamespace == "" || targetNamespace == namespace {
+		return core.Module{}, fmt.Errorf("%w: target namespace %q has to differ from the namespace %s", core.ErrVarType, targetNamespace, namespace)
+	}
+
+	src := core.Module{Namespace: namespace, Name: name, Provider: provider, Version: version}
+	dst := core.Module{Namespace: targetNamespace, Name: name, Provider: provider, Version: version}
+	res, err := s.storage.CopyModule(ctx, src, dst)
+	if err != nil {
+		return core.Module{}, err
+	}
+
+	return s.proxyDownloadURL(ctx, res)
+}
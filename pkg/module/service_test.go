@@ -5,9 +5,11 @@ import (
 	"bytes"
 	"compress/gzip"
 	"context"
+	"fmt"
 	"io"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/boring-registry/boring-registry/pkg/core"
 
@@ -97,6 +99,7 @@ func TestService_GetModule(t *testing.T) {
 				assert.Error(err)
 			case false:
 				assert.NoError(err)
+				module.PublishedAt = time.Time{}
 				assert.Equal(tc.module, module)
 			}
 		})
@@ -189,6 +192,8 @@ func TestService_ListModuleVersions(t *testing.T) {
 					module.DownloadURL = ""
 					versions = append(versions, module.Version)
 					module.Version = ""
+					assert.False(module.PublishedAt.IsZero())
+					module.PublishedAt = time.Time{}
 					assert.Equal(tc.module, module)
 				}
 				assert.ElementsMatch(tc.versions, versions)
@@ -196,3 +201,387 @@ func TestService_ListModuleVersions(t *testing.T) {
 		})
 	}
 }
+
+// lenientStorage stores whatever it read of the archive, like storage backends that don't abort uploads on the error of the reader
+type lenientStorage struct {
+	Storage
+}
+
+func (s lenientStorage) UploadModule(ctx context.Context, namespace, name, provider, version string, body io.Reader) (core.Module, error) {
+	b, _ := io.ReadAll(body)
+	return s.Storage.UploadModule(ctx, namespace, name, provider, version, bytes.NewReader(b))
+}
+
+func TestService_PublishModule(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name           string
+		version        string
+		data           io.Reader
+		disabled       bool
+		skipValidation bool
+		skipAllowed    bool
+		lenient        bool
+		wantErrIs      error
+	}{
+		{
+			name:    "valid publish",
+			version: "1.0.0",
+			data:    testModuleData(map[string]string{"main.tf": `name = "foo"`}),
+		},
+		{
+			name:      "existing version",
+			version:   "0.1.0",
+			data:      testModuleData(map[string]string{"main.tf": `name = "foo"`}),
+			wantErrIs: ErrModuleAlreadyExists,
+		},
+		{
+			name:      "invalid archive",
+			version:   "1.0.0",
+			data:      strings.NewReader("main.tf"),
+			wantErrIs: ErrInvalidArchive,
+		},
+		{
+			name:      "invalid module",
+			version:   "1.0.0",
+			data:      testModuleData(map[string]string{"main.tf": `variable "name" { foo = 1 }`}),
+			wantErrIs: ErrInvalidModule,
+		},
+		{
+			name:           "skipped content validation",
+			version:        "1.0.0",
+			data:           testModuleData(map[string]string{"main.tf": `variable "name" { foo = 1 }`}),
+			skipValidation: true,
+			skipAllowed:    true,
+		},
+		{
+			name:           "skipped content validation of an invalid archive",
+			version:        "1.0.0",
+			data:           strings.NewReader("main.tf"),
+			skipValidation: true,
+			skipAllowed:    true,
+			wantErrIs:      ErrInvalidArchive,
+		},
+		{
+			name:           "skipping the content validation isn't allowed",
+			version:        "1.0.0",
+			data:           testModuleData(map[string]string{"main.tf": `variable "name" { foo = 1 }`}),
+			skipValidation: true,
+			wantErrIs:      ErrSkipValidationDisabled,
+		},
+		{
+			name:      "storage backend ignoring the validation error",
+			version:   "1.0.0",
+			data:      testModuleData(map[string]string{"main.tf": `variable "name" { foo = 1 }`}),
+			lenient:   true,
+			wantErrIs: ErrInvalidModule,
+		},
+		{
+			name:      "invalid version",
+			version:   "latest",
+			data:      testModuleData(map[string]string{"main.tf": `name = "foo"`}),
+			wantErrIs: core.ErrVarType,
+		},
+		{
+			name:      "publishing disabled",
+			version:   "1.0.0",
+			data:      testModuleData(map[string]string{"main.tf": `name = "foo"`}),
+			disabled:  true,
+			wantErrIs: ErrPublishDisabled,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				ctx     = context.Background()
+				storage = NewInmemStorage()
+				proxy   = core.NewProxyUrlService(false, "/proxy")
+				options = []ServiceOption{WithPublishEnabled(!tc.disabled), WithSkipValidationAllowed(tc.skipAllowed)}
+				svc     = NewService(storage, proxy, options...)
+			)
+			if tc.lenient {
+				svc = NewService(lenientStorage{storage}, proxy, options...)
+			}
+
+			_, err := storage.UploadModule(ctx, "example", "s3", "aws", "0.1.0", testModuleData(map[string]string{"main.tf": ""}))
+			assert.NoError(t, err)
+
+			if tc.skipValidation {
+				ctx = WithSkipValidation(ctx)
+			}
+			m, err := svc.PublishModule(ctx, "example", "s3", "aws", tc.version, tc.data)
+			if tc.wantErrIs != nil {
+				assert.ErrorIs(t, err, tc.wantErrIs)
+				versions, err := storage.ListModuleVersions(ctx, "example", "s3", "aws")
+				assert.NoError(t, err)
+				assert.Len(t, versions, 1)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.version, m.Version)
+			_, err = storage.GetModule(ctx, "example", "s3", "aws", tc.version)
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestService_PromoteModule(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name      string
+		version   string
+		target    string
+		disabled  bool
+		wantErrIs error
+	}{
+		{
+			name:    "valid promotion",
+			version: "1.0.0",
+			target:  "prod",
+		},
+		{
+			name:      "missing version",
+			version:   "2.0.0",
+			target:    "prod",
+			wantErrIs: ErrModuleNotFound,
+		},
+		{
+			name:      "existing version in target namespace",
+			version:   "0.1.0",
+			target:    "prod",
+			wantErrIs: ErrModuleAlreadyExists,
+		},
+		{
+			name:      "same namespace",
+			version:   "1.0.0",
+			target:    "staging",
+			wantErrIs: core.ErrVarType,
+		},
+		{
+			name:      "publishing disabled",
+			version:   "1.0.0",
+			target:    "prod",
+			disabled:  true,
+			wantErrIs: ErrPublishDisabled,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				ctx     = core.WithProvenance(context.Background(), core.Provenance{GitCommit: "0123abc"})
+				storage = NewInmemStorage()
+				proxy   = core.NewProxyUrlService(false, "/proxy")
+				svc     = NewService(storage, proxy, WithPublishEnabled(!tc.disabled))
+			)
+
+			archive := testModuleData(map[string]string{"main.tf": ""}).Bytes()
+			for _, m := range []core.Module{
+				{Namespace: "staging", Version: "0.1.0"},
+				{Namespace: "staging", Version: "1.0.0"},
+				{Namespace: "prod", Version: "0.1.0"},
+			} {
+				_, err := storage.UploadModule(context.Background(), m.Namespace, "s3", "aws", m.Version, bytes.NewReader(archive))
+				assert.NoError(t, err)
+			}
+
+			m, err := svc.PromoteModule(ctx, "staging", "s3", "aws", tc.version, tc.target)
+			if tc.wantErrIs != nil {
+				assert.ErrorIs(t, err, tc.wantErrIs)
+				versions, err := storage.ListModuleVersions(ctx, "prod", "s3", "aws")
+				assert.NoError(t, err)
+				assert.Len(t, versions, 1)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, "prod/s3/aws/1.0.0", m.ID(true))
+
+			promoted, err := storage.DownloadModule(ctx, "prod", "s3", "aws", tc.version)
+			assert.NoError(t, err)
+			b, _ := io.ReadAll(promoted)
+			assert.Equal(t, archive, b)
+
+			p, err := storage.GetModuleProvenance(ctx, "prod", "s3", "aws", tc.version)
+			assert.NoError(t, err)
+			assert.Equal(t, "staging/s3/aws/1.0.0", p.CopiedFrom)
+			assert.Equal(t, "0123abc", p.GitCommit)
+			if assert.NotNil(t, p.Origin) {
+				assert.Equal(t, p.Origin.SHA256, p.SHA256)
+			}
+		})
+	}
+}
+
+func TestService_ListModules(t *testing.T) {
+	ctx := context.Background()
+	storage := NewInmemStorage()
+	for _, m := range []core.Module{
+		{Namespace: "example", Name: "vpc", Provider: "aws", Version: "1.2.0"},
+		{Namespace: "example", Name: "vpc", Provider: "aws", Version: "1.10.0"},
+		{Namespace: "example", Name: "vpc", Provider: "google", Version: "0.1.0"},
+		{Namespace: "example", Name: "dns", Provider: "aws", Version: "2.0.0-rc1"},
+		{Namespace: "other", Name: "shared-vpc", Provider: "aws", Version: "1.0.0"},
+		// Modules without any valid version are skipped
+		{Namespace: "other", Name: "legacy", Provider: "aws", Version: "latest"},
+	} {
+		_, err := storage.UploadModule(ctx, m.Namespace, m.Name, m.Provider, m.Version, strings.NewReader("content"))
+		assert.NoError(t, err)
+	}
+	for _, m := range []struct {
+		name string
+		team string
+	}{{"network", "platform"}, {"database", "data"}} {
+		_, err := storage.UploadModule(ctx, "teams", m.name, "aws", "1.0.0", testModuleData(map[string]string{
+			SpecFileName: fmt.Sprintf(`
+metadata {
+  namespace = "teams"
+  name      = %q
+  provider  = "aws"
+  version   = "1.0.0"
+}
+
+info {
+  team = %q
+  tags = ["aws", %q]
+}
+`, m.name, m.team, m.name),
+		}))
+		assert.NoError(t, err)
+	}
+	svc := NewService(storage, core.NewProxyUrlService(false, "/proxy"))
+
+	testCases := []struct {
+		name     string
+		filter   ListFilter
+		page     core.Page
+		expected []string
+		next     bool
+	}{
+		{
+			name:     "latest versions of all modules",
+			page:     core.Page{Limit: 10},
+			expected: []string{"example/dns/aws/2.0.0-rc1", "example/vpc/aws/1.10.0", "example/vpc/google/0.1.0", "other/shared-vpc/aws/1.0.0", "teams/database/aws/1.0.0", "teams/network/aws/1.0.0"},
+		},
+		{
+			name:     "paginated",
+			page:     core.Page{Offset: 1, Limit: 2},
+			expected: []string{"example/vpc/aws/1.10.0", "example/vpc/google/0.1.0"},
+			next:     true,
+		},
+		{
+			name:     "namespace and provider",
+			filter:   ListFilter{Namespace: "example", Provider: "aws"},
+			page:     core.Page{Limit: 10},
+			expected: []string{"example/dns/aws/2.0.0-rc1", "example/vpc/aws/1.10.0"},
+		},
+		{
+			name:     "search",
+			filter:   ListFilter{Query: "VPC"},
+			page:     core.Page{Limit: 10},
+			expected: []string{"example/vpc/aws/1.10.0", "example/vpc/google/0.1.0", "other/shared-vpc/aws/1.0.0"},
+		},
+		{
+			name:     "no matches",
+			filter:   ListFilter{Query: "storage"},
+			page:     core.Page{Limit: 10},
+			expected: []string{},
+		},
+		{
+			name:     "tag",
+			filter:   ListFilter{Tag: "aws"},
+			page:     core.Page{Limit: 10},
+			expected: []string{"teams/database/aws/1.0.0", "teams/network/aws/1.0.0"},
+		},
+		{
+			name:     "tag and team",
+			filter:   ListFilter{Tag: "aws", Team: "platform"},
+			page:     core.Page{Limit: 10},
+			expected: []string{"teams/network/aws/1.0.0"},
+		},
+		{
+			name:     "paginated tag",
+			filter:   ListFilter{Tag: "aws"},
+			page:     core.Page{Offset: 1, Limit: 1},
+			expected: []string{"teams/network/aws/1.0.0"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			modules, meta, err := svc.ListModules(ctx, tc.filter, tc.page)
+			assert.NoError(t, err)
+
+			ids := []string{}
+			for _, m := range modules {
+				ids = append(ids, m.ID(true))
+			}
+			assert.Equal(t, tc.expected, ids)
+			assert.Equal(t, tc.next, meta.NextOffset != nil)
+
+			for _, m := range modules {
+				assert.Equal(t, m.Namespace == "teams", m.Info != nil, m.ID(true))
+			}
+		})
+	}
+}
+
+func TestService_GetModuleDetails(t *testing.T) {
+	ctx := context.Background()
+	storage := NewInmemStorage()
+	for _, version := range []string{"1.2.0", "1.10.0", "2.0.0-rc1"} {
+		_, err := storage.UploadModule(ctx, "example", "vpc", "aws", version, strings.NewReader("content"))
+		assert.NoError(t, err)
+	}
+	svc := NewService(storage, core.NewProxyUrlService(false, "/proxy"))
+
+	testCases := []struct {
+		name            string
+		module          string
+		version         string
+		expectedVersion string
+		wantErrIs       error
+	}{
+		{
+			name:            "latest version",
+			module:          "vpc",
+			expectedVersion: "1.10.0",
+		},
+		{
+			name:            "specific version",
+			module:          "vpc",
+			version:         "2.0.0-rc1",
+			expectedVersion: "2.0.0-rc1",
+		},
+		{
+			name:      "missing version",
+			module:    "vpc",
+			version:   "3.0.0",
+			wantErrIs: ErrModuleNotFound,
+		},
+		{
+			name:      "missing module",
+			module:    "dns",
+			wantErrIs: ErrModuleNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			details, err := svc.GetModuleDetails(ctx, "example", tc.module, "aws", tc.version)
+			if tc.wantErrIs != nil {
+				assert.ErrorIs(t, err, tc.wantErrIs)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedVersion, details.Version)
+			assert.Equal(t, []string{"1.2.0", "1.10.0", "2.0.0-rc1"}, details.Versions)
+			assert.False(t, details.PublishedAt.IsZero())
+		})
+	}
+}
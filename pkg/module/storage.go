@@ -12,5 +12,32 @@ type Storage interface {
 	// GetModule should return an ErrModuleNotFound error if the requested module version cannot be found
 	GetModule(ctx context.Context, namespace, name, provider, version string) (core.Module, error)
 	ListModuleVersions(ctx context.Context, namespace, name, provider string) ([]core.Module, error)
+
+	// ListModules returns every version of all modules, restricted to the namespace if it isn't empty
+	ListModules(ctx context.Context, namespace string) ([]core.Module, error)
 	UploadModule(ctx context.Context, namespace, name, provider, version string, body io.Reader) (core.Module, error)
+
+	// DownloadModule streams the module archive and should return an ErrModuleNotFound error if the module version cannot be found.
+	// The caller has to close the returned io.ReadCloser
+	DownloadModule(ctx context.Context, namespace, name, provider, version string) (io.ReadCloser, error)
+
+	// GetModuleDocs returns the docs extracted when the module version was uploaded.
+	// It should return an ErrDocsNotFound error if the module version was uploaded without docs
+	GetModuleDocs(ctx context.Context, namespace, name, provider, version string) (*Docs, error)
+
+	// GetModuleInfo returns the info of the module spec file stored when the module version was uploaded.
+	// It should return an ErrInfoNotFound error if the module version was uploaded without info
+	GetModuleInfo(ctx context.Context, namespace, name, provider, version string) (*Info, error)
+
+	// GetModuleProvenance returns who published the module version, when, and from which pipeline.
+	// It should return a core.ErrProvenanceNotFound error if the module version was uploaded without provenance
+	GetModuleProvenance(ctx context.Context, namespace, name, provider, version string) (*core.Provenance, error)
+
+	// CopyModule copies the archive of the module version and everything stored with it to another module.
+	// It should return an ErrModuleNotFound error if the source doesn't exist, and an ErrModuleAlreadyExists error if the destination exists
+	CopyModule(ctx context.Context, src, dst core.Module) (core.Module, error)
+
+	// DeleteModule removes the archive of the module version and everything stored with it.
+	// It should return an ErrModuleNotFound error if the module version cannot be found
+	DeleteModule(ctx context.Context, namespace, name, provider, version string) error
 }
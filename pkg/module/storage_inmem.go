@@ -1,14 +1,19 @@
 package module
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
 	"path"
 	"sync"
+	"time"
 
 	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/metadata"
 )
 
 // InmemStorage is a Storage implementation
@@ -16,7 +21,8 @@ import (
 type InmemStorage struct {
 	mu            sync.RWMutex
 	modules       map[string]core.Module
-	moduleData    map[string]io.Reader
+	moduleData    map[string][]byte
+	provenance    map[string]core.Provenance
 	archiveFormat string
 }
 
@@ -34,7 +40,7 @@ func (s *InmemStorage) GetModule(_ context.Context, namespace, name, provider, v
 	id := m.ID(true)
 	module, ok := s.modules[id]
 	if !ok {
-		return core.Module{}, fmt.Errorf("module not found: %s", id)
+		return core.Module{}, fmt.Errorf("%w: %s", ErrModuleNotFound, id)
 	}
 
 	return module, nil
@@ -55,7 +61,21 @@ func (s *InmemStorage) ListModuleVersions(ctx context.Context, namespace, name,
 	}
 
 	if len(modules) == 0 {
-		return nil, fmt.Errorf("no modules found for namespace=%s name=%s provider=%s", namespace, name, provider)
+		return nil, fmt.Errorf("%w: no modules found for namespace=%s name=%s provider=%s", ErrModuleNotFound, namespace, name, provider)
+	}
+
+	return modules, nil
+}
+
+func (s *InmemStorage) ListModules(_ context.Context, namespace string) ([]core.Module, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var modules []core.Module
+	for _, module := range s.modules {
+		if namespace == "" || module.Namespace == namespace {
+			modules = append(modules, module)
+		}
 	}
 
 	return modules, nil
@@ -78,28 +98,169 @@ func (s *InmemStorage) UploadModule(ctx context.Context, namespace, name, provid
 		return core.Module{}, errors.New("version not defined")
 	}
 
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return core.Module{}, err
+	}
+
 	s.mu.Lock()
 
 	m := core.Module{
-		Namespace: namespace,
-		Name:      name,
-		Provider:  provider,
-		Version:   version,
+		Namespace:   namespace,
+		Name:        name,
+		Provider:    provider,
+		Version:     version,
+		PublishedAt: time.Now().UTC(),
 	}
 
 	id := m.ID(true)
 	if _, ok := s.modules[id]; ok {
+		s.mu.Unlock()
 		return core.Module{}, fmt.Errorf("module exists already: %s", id)
 	}
 
 	s.modules[id] = m
 
-	s.moduleData[id] = body
+	s.moduleData[id] = data
+
+	sum := sha256.Sum256(data)
+	p := core.ProvenanceFromContext(ctx)
+	p.Artifact = fmt.Sprintf("%s-%s-%s-%s.%s", namespace, name, provider, version, s.archiveFormat)
+	p.SHA256 = hex.EncodeToString(sum[:])
+	p.Uploader = metadata.UploaderFromContext(ctx)
+	p.UploadedAt = m.PublishedAt
+	s.provenance[id] = p
 	s.mu.Unlock()
 
 	return s.GetModule(ctx, namespace, name, provider, version)
 }
 
+func (s *InmemStorage) DownloadModule(_ context.Context, namespace, name, provider, version string) (io.ReadCloser, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	m := core.Module{
+		Namespace: namespace,
+		Name:      name,
+		Provider:  provider,
+		Version:   version,
+	}
+	data, ok := s.moduleData[m.ID(true)]
+	if !ok {
+		return nil, ErrModuleNotFound
+	}
+
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// GetModuleDocs always returns an ErrDocsNotFound error, as the InmemStorage doesn't extract docs
+func (s *InmemStorage) GetModuleDocs(_ context.Context, namespace, name, provider, version string) (*Docs, error) {
+	m := core.Module{
+		Namespace: namespace,
+		Name:      name,
+		Provider:  provider,
+		Version:   version,
+	}
+	return nil, fmt.Errorf("%w: %s", ErrDocsNotFound, m.ID(true))
+}
+
+// GetModuleInfo extracts the info from the stored module archive, archives that can't be read don't have any info
+func (s *InmemStorage) GetModuleInfo(ctx context.Context, namespace, name, provider, version string) (*Info, error) {
+	archive, err := s.DownloadModule(ctx, namespace, name, provider, version)
+	if err != nil {
+		return nil, err
+	}
+
+	_, info, err := Extract(archive)
+	if err != nil || info == nil {
+		m := core.Module{
+			Namespace: namespace,
+			Name:      name,
+			Provider:  provider,
+			Version:   version,
+		}
+		return nil, fmt.Errorf("%w: %s", ErrInfoNotFound, m.ID(true))
+	}
+	return info, nil
+}
+
+func (s *InmemStorage) GetModuleProvenance(_ context.Context, namespace, name, provider, version string) (*core.Provenance, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	m := core.Module{
+		Namespace: namespace,
+		Name:      name,
+		Provider:  provider,
+		Version:   version,
+	}
+	p, ok := s.provenance[m.ID(true)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", core.ErrProvenanceNotFound, m.ID(true))
+	}
+	return &p, nil
+}
+
+// CopyModule copies the archive of the module version and records the provenance of the copy
+func (s *InmemStorage) CopyModule(ctx context.Context, src, dst core.Module) (core.Module, error) {
+	s.mu.Lock()
+	data, ok := s.moduleData[src.ID(true)]
+	if !ok {
+		s.mu.Unlock()
+		return core.Module{}, fmt.Errorf("%w: %s", ErrModuleNotFound, src.ID(true))
+	}
+	if _, ok := s.modules[dst.ID(true)]; ok {
+		s.mu.Unlock()
+		return core.Module{}, fmt.Errorf("%w: %s", ErrModuleAlreadyExists, dst.ID(true))
+	}
+
+	m := core.Module{
+		Namespace:   dst.Namespace,
+		Name:        dst.Name,
+		Provider:    dst.Provider,
+		Version:     dst.Version,
+		PublishedAt: time.Now().UTC(),
+	}
+	s.modules[m.ID(true)] = m
+	s.moduleData[m.ID(true)] = data
+
+	p := core.ProvenanceFromContext(ctx)
+	p.Artifact = fmt.Sprintf("%s-%s-%s-%s.%s", m.Namespace, m.Name, m.Provider, m.Version, s.archiveFormat)
+	p.Uploader = metadata.UploaderFromContext(ctx)
+	p.UploadedAt = m.PublishedAt
+	p.CopiedFrom = src.ID(true)
+	if origin, ok := s.provenance[src.ID(true)]; ok {
+		p.SHA256 = origin.SHA256
+		p.Origin = &origin
+	}
+	s.provenance[m.ID(true)] = p
+	s.mu.Unlock()
+
+	return s.GetModule(ctx, m.Namespace, m.Name, m.Provider, m.Version)
+}
+
+// DeleteModule removes the module version together with its provenance
+func (s *InmemStorage) DeleteModule(_ context.Context, namespace, name, provider, version string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m := core.Module{
+		Namespace: namespace,
+		Name:      name,
+		Provider:  provider,
+		Version:   version,
+	}
+	id := m.ID(true)
+	if _, ok := s.modules[id]; !ok {
+		return fmt.Errorf("%w: %s", ErrModuleNotFound, id)
+	}
+
+	delete(s.modules, id)
+	delete(s.moduleData, id)
+	delete(s.provenance, id)
+	return nil
+}
+
 func (s *InmemStorage) MigrateModules(ctx context.Context, dryRun bool) error {
 	panic("MigrateModules should not be called for InmemStorage")
 }
@@ -118,7 +279,8 @@ func WithInmemArchiveFormat(archiveFormat string) InmemStorageOption {
 func NewInmemStorage(options ...InmemStorageOption) Storage {
 	s := &InmemStorage{
 		modules:       make(map[string]core.Module),
-		moduleData:    make(map[string]io.Reader),
+		moduleData:    make(map[string][]byte),
+		provenance:    make(map[string]core.Provenance),
 		archiveFormat: "tar.gz",
 	}
 
@@ -2,9 +2,12 @@ package module
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strings"
 
 	"github.com/boring-registry/boring-registry/pkg/core"
 	o11y "github.com/boring-registry/boring-registry/pkg/observability"
@@ -24,10 +27,31 @@ const (
 	varVersion   muxVar = "version"
 )
 
+// maxPromoteRequestSize limits the body of promote requests, which is decoded before the request is authenticated
+const maxPromoteRequestSize = 1 << 20
+
 // MakeHandler returns a fully initialized http.Handler.
 func MakeHandler(svc Service, auth endpoint.Middleware, metrics *o11y.ModuleMetrics, instrumentation o11y.Middleware, options ...httptransport.ServerOption) http.Handler {
 	r := mux.NewRouter().StrictSlash(true)
 
+	// The search has to be registered before the namespace listing, as it would match the namespace otherwise
+	for _, path := range []string{`/`, `/search`, `/{namespace}`} {
+		r.Methods("GET").Path(path).Handler(
+			instrumentation.WrapHandler(
+				httptransport.NewServer(
+					auth(catalogEndpoint(svc)),
+					decodeCatalogRequest,
+					httptransport.EncodeJSONResponse,
+					append(
+						options,
+						httptransport.ServerBefore(extractMuxVars(varNamespace)),
+						httptransport.ServerBefore(jwt.HTTPToContext()),
+					)...,
+				),
+			),
+		)
+	}
+
 	r.Methods("GET").Path(`/{namespace}/{name}/{provider}/versions`).Handler(
 		instrumentation.WrapHandler(
 			httptransport.NewServer(
@@ -38,6 +62,8 @@ func MakeHandler(svc Service, auth endpoint.Middleware, metrics *o11y.ModuleMetr
 					options,
 					httptransport.ServerBefore(extractMuxVars(varNamespace, varName, varProvider)),
 					httptransport.ServerBefore(jwt.HTTPToContext()),
+					httptransport.ServerBefore(core.DeprecationsToContext),
+					httptransport.ServerAfter(core.DeprecationsToHeader),
 				)...,
 			),
 		),
@@ -54,11 +80,119 @@ func MakeHandler(svc Service, auth endpoint.Middleware, metrics *o11y.ModuleMetr
 					httptransport.ServerBefore(extractMuxVars(varNamespace, varName, varProvider, varVersion)),
 					httptransport.ServerBefore(core.ExtractRootUrl()),
 					httptransport.ServerBefore(jwt.HTTPToContext()),
+					httptransport.ServerBefore(core.DeprecationsToContext),
+					httptransport.ServerAfter(core.DeprecationsToHeader),
+				)...,
+			),
+		),
+	)
+
+	r.Methods("GET").Path(`/{namespace}/{name}/{provider}/{version}/docs`).Handler(
+		instrumentation.WrapHandler(
+			httptransport.NewServer(
+				auth(docsEndpoint(svc)),
+				decodeDocsRequest,
+				httptransport.EncodeJSONResponse,
+				append(
+					options,
+					httptransport.ServerBefore(extractMuxVars(varNamespace, varName, varProvider, varVersion)),
+					httptransport.ServerBefore(jwt.HTTPToContext()),
+				)...,
+			),
+		),
+	)
+
+	r.Methods("GET").Path(`/{namespace}/{name}/{provider}/{version}/provenance`).Handler(
+		instrumentation.WrapHandler(
+			httptransport.NewServer(
+				auth(provenanceEndpoint(svc)),
+				decodeProvenanceRequest,
+				httptransport.EncodeJSONResponse,
+				append(
+					options,
+					httptransport.ServerBefore(extractMuxVars(varNamespace, varName, varProvider, varVersion)),
+					httptransport.ServerBefore(jwt.HTTPToContext()),
 				)...,
 			),
 		),
 	)
 
+	// The download of the latest version has to be registered before the details of a version, as it would match the version otherwise
+	r.Methods("GET").Path(`/{namespace}/{name}/{provider}/download`).Handler(
+		instrumentation.WrapHandler(
+			httptransport.NewServer(
+				auth(downloadLatestEndpoint(svc)),
+				decodeDetailsRequest,
+				encodeDownloadLatestResponse,
+				append(
+					options,
+					httptransport.ServerBefore(extractMuxVars(varNamespace, varName, varProvider)),
+					httptransport.ServerBefore(jwt.HTTPToContext()),
+					httptransport.ServerBefore(core.DeprecationsToContext),
+					httptransport.ServerAfter(core.DeprecationsToHeader),
+				)...,
+			),
+		),
+	)
+
+	for _, path := range []string{`/{namespace}/{name}/{provider}`, `/{namespace}/{name}/{provider}/{version}`} {
+		r.Methods("GET").Path(path).Handler(
+			instrumentation.WrapHandler(
+				httptransport.NewServer(
+					auth(detailsEndpoint(svc)),
+					decodeDetailsRequest,
+					httptransport.EncodeJSONResponse,
+					append(
+						options,
+						httptransport.ServerBefore(extractMuxVars(varNamespace, varName, varProvider, varVersion)),
+						httptransport.ServerBefore(jwt.HTTPToContext()),
+					)...,
+				),
+			),
+		)
+	}
+
+	r.Methods("POST").Path(`/{namespace}/{name}/{provider}/{version}`).Handler(
+		core.ExtendDeadlines(
+			core.PublishTimeout,
+			instrumentation.WrapHandler(
+				httptransport.NewServer(
+					auth(publishEndpoint(svc, metrics)),
+					decodePublishRequest,
+					encodePublishResponse,
+					append(
+						options,
+						httptransport.ServerBefore(extractMuxVars(varNamespace, varName, varProvider, varVersion)),
+						httptransport.ServerBefore(core.ExtractRootUrl()),
+						httptransport.ServerBefore(core.ProvenanceToContext),
+						httptransport.ServerBefore(skipValidationToContext),
+						httptransport.ServerBefore(jwt.HTTPToContext()),
+					)...,
+				),
+			),
+		),
+	)
+
+	r.Methods("POST").Path(`/{namespace}/{name}/{provider}/{version}/promote`).Handler(
+		core.ExtendDeadlines(
+			core.PublishTimeout,
+			instrumentation.WrapHandler(
+				httptransport.NewServer(
+					auth(promoteEndpoint(svc)),
+					decodePromoteRequest,
+					encodePublishResponse,
+					append(
+						options,
+						httptransport.ServerBefore(extractMuxVars(varNamespace, varName, varProvider, varVersion)),
+						httptransport.ServerBefore(core.ExtractRootUrl()),
+						httptransport.ServerBefore(core.ProvenanceToContext),
+						httptransport.ServerBefore(jwt.HTTPToContext()),
+					)...,
+				),
+			),
+		),
+	)
+
 	return r
 }
 
@@ -114,11 +248,141 @@ func decodeDownloadRequest(ctx context.Context, r *http.Request) (interface{}, e
 	}, nil
 }
 
+func decodePublishRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	req, err := decodeDownloadRequest(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	download := req.(downloadRequest)
+
+	return publishRequest{
+		namespace: download.namespace,
+		name:      download.name,
+		provider:  download.provider,
+		version:   download.version,
+		body:      r.Body,
+	}, nil
+}
+
+// decodePromoteRequest reads the target namespace from the JSON body, e.g. {"target_namespace": "prod"}
+func decodePromoteRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	req, err := decodeDownloadRequest(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	download := req.(downloadRequest)
+
+	var body core.PromoteRequestBody
+	if err := json.NewDecoder(http.MaxBytesReader(nil, r.Body, maxPromoteRequestSize)).Decode(&body); err != nil {
+		return nil, fmt.Errorf("%w: failed to decode body: %v", core.ErrVarType, err)
+	}
+	if body.TargetNamespace == "" {
+		return nil, fmt.Errorf("%w: target_namespace", core.ErrVarMissing)
+	}
+
+	return promoteRequest{
+		namespace:       download.namespace,
+		name:            download.name,
+		provider:        download.provider,
+		version:         download.version,
+		targetNamespace: body.TargetNamespace,
+	}, nil
+}
+
+func decodeDocsRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	req, err := decodeDownloadRequest(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	return docsRequest(req.(downloadRequest)), nil
+}
+
+func decodeProvenanceRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	req, err := decodeDownloadRequest(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	return provenanceRequest(req.(downloadRequest)), nil
+}
+
+func decodeDetailsRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	req, err := decodeListRequest(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	list := req.(listRequest)
+
+	// The version is missing when the latest version is requested
+	version, _ := ctx.Value(varVersion).(string)
+
+	// The URL of the request is used for the redirect to the download of the latest version, so the path must not be stripped
+	u, err := url.ParseRequestURI(r.RequestURI)
+	if err != nil {
+		u = r.URL
+	}
+
+	return detailsRequest{
+		namespace: list.namespace,
+		name:      list.name,
+		provider:  list.provider,
+		version:   version,
+		url:       u,
+	}, nil
+}
+
+// decodeCatalogRequest decodes the module listing and search requests.
+// The query parameters follow the public Terraform registry, e.g. GET https://registry.terraform.io/v1/modules/search?q=vpc
+func decodeCatalogRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	query := r.URL.Query()
+	page, err := core.ParsePage(query)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := ListFilter{
+		Provider: query.Get("provider"),
+		Tag:      query.Get("tag"),
+		Team:     query.Get("team"),
+	}
+	if namespace, ok := ctx.Value(varNamespace).(string); ok {
+		filter.Namespace = namespace
+	} else {
+		filter.Namespace = query.Get("namespace")
+	}
+
+	if strings.HasSuffix(r.URL.Path, "/search") {
+		filter.Query = query.Get("q")
+		if filter.Query == "" {
+			return nil, fmt.Errorf("%w: q", core.ErrVarMissing)
+		}
+	}
+
+	// The URL of the request is used for the links to the next and previous page, so the path must not be stripped
+	u, err := url.ParseRequestURI(r.RequestURI)
+	if err != nil {
+		u = r.URL
+	}
+
+	return catalogRequest{
+		filter: filter,
+		page:   page,
+		url:    u,
+	}, nil
+}
+
 // ErrorEncoder translates domain specific errors to HTTP status codes
 func ErrorEncoder(_ context.Context, err error, w http.ResponseWriter) {
 
-	if errors.Is(err, ErrModuleNotFound) {
+	if errors.Is(err, ErrModuleNotFound) || errors.Is(err, ErrDocsNotFound) {
 		w.WriteHeader(http.StatusNotFound)
+	} else if errors.Is(err, ErrModuleAlreadyExists) {
+		w.WriteHeader(http.StatusConflict)
+	} else if errors.Is(err, ErrInvalidArchive) || errors.Is(err, ErrInvalidModule) || errors.Is(err, ErrSecretsFound) {
+		w.WriteHeader(http.StatusBadRequest)
+	} else if errors.Is(err, ErrPublishDisabled) || errors.Is(err, ErrSkipValidationDisabled) {
+		w.WriteHeader(http.StatusForbidden)
 	} else {
 		w.WriteHeader(core.GenericError(err))
 	}
@@ -144,3 +408,16 @@ func encodeDownloadResponse(ctx context.Context, w http.ResponseWriter, response
 	w.WriteHeader(http.StatusNoContent)
 	return nil
 }
+
+func encodeDownloadLatestResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
+	res := response.(downloadLatestResponse)
+	w.Header().Set("Location", res.location)
+	w.WriteHeader(http.StatusFound)
+	return nil
+}
+
+func encodePublishResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusCreated)
+	return json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,186 @@
+package module
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMakeHandler_Details(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	storage := NewInmemStorage()
+	for _, version := range []string{"1.0.0", "1.1.0"} {
+		_, err := storage.UploadModule(ctx, "example", "s3", "aws", version, strings.NewReader("content"))
+		assert.NoError(t, err)
+	}
+	ts := newTestRegistry(t, storage)
+
+	client := &http.Client{
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	get := func(path string) *http.Response {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+path, nil)
+		assert.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer secret")
+		resp, err := client.Do(req)
+		assert.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	testCases := []struct {
+		name            string
+		path            string
+		expectedStatus  int
+		expectedVersion string
+	}{
+		{
+			name:            "latest version",
+			path:            "/v1/modules/example/s3/aws",
+			expectedStatus:  http.StatusOK,
+			expectedVersion: "1.1.0",
+		},
+		{
+			name:            "specific version",
+			path:            "/v1/modules/example/s3/aws/1.0.0",
+			expectedStatus:  http.StatusOK,
+			expectedVersion: "1.0.0",
+		},
+		{
+			name:           "missing version",
+			path:           "/v1/modules/example/s3/aws/2.0.0",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "missing module",
+			path:           "/v1/modules/example/dns/aws",
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp := get(tc.path)
+			assert.Equal(t, tc.expectedStatus, resp.StatusCode)
+			if tc.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var details detailsResponse
+			assert.NoError(t, json.NewDecoder(resp.Body).Decode(&details))
+			assert.Equal(t, "example/s3/aws/"+tc.expectedVersion, details.ID)
+			assert.Equal(t, tc.expectedVersion, details.Version)
+			assert.Equal(t, []string{"1.0.0", "1.1.0"}, details.Versions)
+			assert.NotNil(t, details.PublishedAt)
+		})
+	}
+
+	t.Run("download of the latest version", func(t *testing.T) {
+		resp := get("/v1/modules/example/s3/aws/download")
+		assert.Equal(t, http.StatusFound, resp.StatusCode)
+		assert.Equal(t, "/v1/modules/example/s3/aws/1.1.0/download", resp.Header.Get("Location"))
+
+		resp = get(resp.Header.Get("Location"))
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	})
+
+	t.Run("docs", func(t *testing.T) {
+		_, err := storage.UploadModule(ctx, "example", "vpc", "aws", "1.0.0", testModuleData(map[string]string{
+			"README.md": "# VPC",
+			"main.tf":   `variable "cidr" {}`,
+		}))
+		assert.NoError(t, err)
+
+		resp := get("/v1/modules/example/vpc/aws/1.0.0/docs")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var docs Docs
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&docs))
+		assert.Equal(t, "# VPC", docs.Root.Readme)
+		assert.Equal(t, []Input{{Name: "cidr", Required: true}}, docs.Root.Inputs)
+
+		resp = get("/v1/modules/example/vpc/aws/2.0.0/docs")
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("info", func(t *testing.T) {
+		_, err := storage.UploadModule(ctx, "example", "dns", "aws", "1.0.0", testModuleData(map[string]string{
+			SpecFileName: `
+metadata {
+  namespace = "example"
+  name      = "dns"
+  provider  = "aws"
+  version   = "1.0.0"
+}
+
+info {
+  description = "Manages DNS zones"
+  source      = "https://github.com/example/terraform-aws-dns"
+  team        = "network"
+}
+`,
+		}))
+		assert.NoError(t, err)
+
+		resp := get("/v1/modules/example/dns/aws")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var details detailsResponse
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&details))
+		assert.Equal(t, "Manages DNS zones", details.Description)
+		assert.Equal(t, "https://github.com/example/terraform-aws-dns", details.Source)
+		if assert.NotNil(t, details.Info) {
+			assert.Equal(t, "network", details.Info.Team)
+		}
+
+		resp = get("/v1/modules/?team=network")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var catalog catalogResponse
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&catalog))
+		if assert.Len(t, catalog.Modules, 1) {
+			assert.Equal(t, "example/dns/aws/1.0.0", catalog.Modules[0].ID)
+		}
+	})
+
+	t.Run("provenance", func(t *testing.T) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, ts.URL+"/v1/modules/example/iam/aws/1.0.0", testModuleData(map[string]string{"main.tf": ""}))
+		assert.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer secret")
+		req.Header.Set(core.HeaderGitCommit, "0123abc")
+		req.Header.Set(core.HeaderCIRunURL, "https://ci.example.com/runs/1")
+		resp, err := client.Do(req)
+		assert.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		resp = get("/v1/modules/example/iam/aws/1.0.0/provenance")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var p core.Provenance
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&p))
+		assert.Equal(t, "example-iam-aws-1.0.0.tar.gz", p.Artifact)
+		assert.Equal(t, "0123abc", p.GitCommit)
+		assert.Equal(t, "https://ci.example.com/runs/1", p.CIRunURL)
+		assert.Equal(t, "static:2bb80d537b1d", p.Uploader)
+		assert.Len(t, p.SHA256, 64)
+
+		resp = get("/v1/modules/example/iam/aws/2.0.0/provenance")
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("versions are still listed", func(t *testing.T) {
+		resp := get("/v1/modules/example/s3/aws/versions")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+}
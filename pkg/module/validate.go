@@ -0,0 +1,241 @@
+package module
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/json"
+)
+
+const (
+	// DefaultMaxArchiveSize is the maximum size of all files of a module archive once it's extracted
+	DefaultMaxArchiveSize int64 = 100 << 20
+
+	// DefaultMaxArchiveFiles is the maximum number of files of a module archive
+	DefaultMaxArchiveFiles = 10000
+
+	// skipValidationParam is the query parameter of publish requests which skips the validation of the module contents
+	skipValidationParam = "skip_validation"
+)
+
+type validateOptions struct {
+	maxSize    int64
+	maxFiles   int
+	contents   bool
+	secretScan SecretScanMode
+}
+
+// ValidateOption configures the validation of module archives
+type ValidateOption func(*validateOptions)
+
+// WithMaxArchiveSize limits the size of all files of the archive once it's extracted
+func WithMaxArchiveSize(size int64) ValidateOption {
+	return func(o *validateOptions) {
+		o.maxSize = size
+	}
+}
+
+// WithMaxArchiveFiles limits the number of files of the archive
+func WithMaxArchiveFiles(files int) ValidateOption {
+	return func(o *validateOptions) {
+		o.maxFiles = files
+	}
+}
+
+// WithContentValidation configures whether the Terraform files of the archive are parsed and validated.
+// The structure of the archive and its limits are always validated.
+func WithContentValidation(enabled bool) ValidateOption {
+	return func(o *validateOptions) {
+		o.contents = enabled
+	}
+}
+
+// WithSecretScan configures whether archives containing potential secrets are rejected, only logged, or not scanned at all
+func WithSecretScan(mode SecretScanMode) ValidateOption {
+	return func(o *validateOptions) {
+		o.secretScan = mode
+	}
+}
+
+func newValidateOptions(options ...ValidateOption) validateOptions {
+	o := validateOptions{
+		maxSize:    DefaultMaxArchiveSize,
+		maxFiles:   DefaultMaxArchiveFiles,
+		contents:   true,
+		secretScan: SecretScanBlock,
+	}
+	for _, option := range options {
+		option(&o)
+	}
+	return o
+}
+
+type skipValidationContextKey struct{}
+
+// WithSkipValidation returns a context which skips the validation of the module contents when the module is published.
+// The Client forwards it to the registry, which only skips validating the Terraform files but not the archive itself.
+func WithSkipValidation(ctx context.Context) context.Context {
+	return context.WithValue(ctx, skipValidationContextKey{}, true)
+}
+
+// SkipValidationFromContext returns whether the validation of the module contents should be skipped
+func SkipValidationFromContext(ctx context.Context) bool {
+	skip, _ := ctx.Value(skipValidationContextKey{}).(bool)
+	return skip
+}
+
+// skipValidationToContext skips the validation of the module contents if the publish request asks for it
+func skipValidationToContext(ctx context.Context, r *http.Request) context.Context {
+	if skip, _ := strconv.ParseBool(r.URL.Query().Get(skipValidationParam)); skip {
+		return WithSkipValidation(ctx)
+	}
+	return ctx
+}
+
+var (
+	variableSchema = &hcl.BodySchema{
+		Attributes: []hcl.AttributeSchema{
+			{Name: "description"},
+			{Name: "default"},
+			{Name: "type"},
+			{Name: "sensitive"},
+			{Name: "nullable"},
+			{Name: "ephemeral"},
+		},
+		Blocks: []hcl.BlockHeaderSchema{
+			{Type: "validation"},
+		},
+	}
+	outputSchema = &hcl.BodySchema{
+		Attributes: []hcl.AttributeSchema{
+			{Name: "value", Required: true},
+			{Name: "description"},
+			{Name: "sensitive"},
+			{Name: "depends_on"},
+			{Name: "ephemeral"},
+		},
+		Blocks: []hcl.BlockHeaderSchema{
+			{Type: "precondition"},
+		},
+	}
+	conditionSchema = &hcl.BodySchema{
+		Attributes: []hcl.AttributeSchema{
+			{Name: "condition", Required: true},
+			{Name: "error_message", Required: true},
+		},
+	}
+	fileSchema = &hcl.BodySchema{
+		Blocks: []hcl.BlockHeaderSchema{
+			{Type: "variable", LabelNames: []string{"name"}},
+			{Type: "output", LabelNames: []string{"name"}},
+		},
+	}
+)
+
+// contentValidator collects the diagnostics of all Terraform files of an archive
+type contentValidator struct {
+	diags hcl.Diagnostics
+
+	// declared records the first declaration of every variable and output of a module directory
+	declared map[string]hcl.Range
+
+	// dirs records whether a directory contains Terraform files
+	dirs map[string]bool
+}
+
+func newContentValidator() *contentValidator {
+	return &contentValidator{
+		declared: make(map[string]hcl.Range),
+		dirs:     make(map[string]bool),
+	}
+}
+
+// isTerraformFile returns whether the file is a Terraform configuration file
+func isTerraformFile(name string) bool {
+	return strings.HasSuffix(name, ".tf") || strings.HasSuffix(name, ".tf.json")
+}
+
+// add parses the Terraform file and validates its variables and outputs
+func (v *contentValidator) add(name string, src []byte) {
+	dir := path.Dir(name)
+	v.dirs[dir] = true
+
+	var file *hcl.File
+	var diags hcl.Diagnostics
+	if strings.HasSuffix(name, ".tf.json") {
+		file, diags = json.Parse(src, name)
+	} else {
+		file, diags = hclsyntax.ParseConfig(src, name, hcl.InitialPos)
+	}
+	v.diags = append(v.diags, diags...)
+	if diags.HasErrors() || file == nil {
+		return
+	}
+
+	content, _, diags := file.Body.PartialContent(fileSchema)
+	v.diags = append(v.diags, diags...)
+	for _, block := range content.Blocks {
+		schema := variableSchema
+		if block.Type == "output" {
+			schema = outputSchema
+		}
+		v.addBlock(dir, block, schema)
+	}
+}
+
+func (v *contentValidator) addBlock(dir string, block *hcl.Block, schema *hcl.BodySchema) {
+	name := block.Labels[0]
+	if !hclsyntax.ValidIdentifier(name) {
+		v.diags = append(v.diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  fmt.Sprintf("Invalid %s name", block.Type),
+			Detail:   fmt.Sprintf("%q isn't a valid identifier.", name),
+			Subject:  block.LabelRanges[0].Ptr(),
+		})
+	}
+
+	key := fmt.Sprintf("%s/%s.%s", dir, block.Type, name)
+	if first, ok := v.declared[key]; ok {
+		v.diags = append(v.diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  fmt.Sprintf("Duplicate %s declaration", block.Type),
+			Detail:   fmt.Sprintf("The %s %q was already declared at %s.", block.Type, name, first),
+			Subject:  block.DefRange.Ptr(),
+		})
+	} else {
+		v.declared[key] = block.DefRange
+	}
+
+	content, diags := block.Body.Content(schema)
+	v.diags = append(v.diags, diags...)
+	for _, b := range content.Blocks {
+		_, diags := b.Body.Content(conditionSchema)
+		v.diags = append(v.diags, diags...)
+	}
+}
+
+// err returns an ErrInvalidModule error reporting all diagnostics, or nil if there are no errors
+func (v *contentValidator) err() error {
+	if !v.dirs["."] {
+		v.diags = append(v.diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Empty module",
+			Detail:   "The root directory of the module doesn't contain any .tf or .tf.json files.",
+		})
+	}
+	if !v.diags.HasErrors() {
+		return nil
+	}
+
+	var lines []string
+	for _, d := range v.diags.Errs() {
+		lines = append(lines, d.Error())
+	}
+	return fmt.Errorf("%w:\n%s", ErrInvalidModule, strings.Join(lines, "\n"))
+}
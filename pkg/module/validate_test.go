@@ -0,0 +1,130 @@
+package module
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateArchive_Contents(t *testing.T) {
+	t.Parallel()
+
+	m := core.Module{Namespace: "example", Name: "s3", Provider: "aws", Version: "1.0.0"}
+
+	testCases := []struct {
+		description string
+		files       map[string]string
+		options     []ValidateOption
+		wantErr     error
+		wantDiags   []string
+	}{
+		{
+			description: "valid module",
+			files: map[string]string{
+				"main.tf": `
+variable "name" {
+  type    = string
+  default = "example"
+
+  validation {
+    condition     = length(var.name) > 0
+    error_message = "The name must not be empty."
+  }
+}
+
+output "name" {
+  value = var.name
+}
+`,
+				"outputs.tf.json":          `{"output": {"id": {"value": "${var.name}"}}}`,
+				"modules/bucket/main.tf":   `variable "name" {}`,
+				"modules/bucket/README.md": "# Bucket",
+			},
+		},
+		{
+			description: "invalid syntax",
+			files:       map[string]string{"main.tf": `resource "aws_s3_bucket" "this" {`},
+			wantErr:     ErrInvalidModule,
+			wantDiags:   []string{"main.tf:1,33-34: Unclosed configuration block"},
+		},
+		{
+			description: "invalid JSON",
+			files:       map[string]string{"main.tf": "", "variables.tf.json": `{"variable": `},
+			wantErr:     ErrInvalidModule,
+			wantDiags:   []string{"variables.tf.json"},
+		},
+		{
+			description: "malformed variables and outputs",
+			files: map[string]string{
+				"main.tf": `
+variable "name" {
+  typ = string
+}
+
+variable "1name" {}
+
+output "id" {
+  description = "missing value"
+}
+`,
+				"variables.tf": `variable "name" {}`,
+				"modules/bucket/main.tf": `
+variable "name" {
+  validation {
+    condition = true
+  }
+}
+`,
+			},
+			wantErr: ErrInvalidModule,
+			wantDiags: []string{
+				`main.tf:3,3-6: Unsupported argument`,
+				`main.tf:6,10-17: Invalid variable name`,
+				`main.tf:8,13-13: Missing required argument; The argument "value" is required`,
+				`modules/bucket/main.tf:3,14-14: Missing required argument; The argument "error_message" is required`,
+				`Duplicate variable declaration`,
+			},
+		},
+		{
+			description: "empty module",
+			files:       map[string]string{"README.md": "# S3", "modules/bucket/main.tf": ""},
+			wantErr:     ErrInvalidModule,
+			wantDiags:   []string{"Empty module"},
+		},
+		{
+			description: "skipped content validation",
+			files:       map[string]string{"main.tf": `resource "aws_s3_bucket" "this" {`},
+			options:     []ValidateOption{WithContentValidation(false)},
+		},
+		{
+			description: "too many files",
+			files:       map[string]string{"main.tf": "", "outputs.tf": "", "variables.tf": ""},
+			options:     []ValidateOption{WithMaxArchiveFiles(2), WithContentValidation(false)},
+			wantErr:     ErrInvalidArchive,
+		},
+		{
+			description: "too large",
+			files:       map[string]string{"main.tf": strings.Repeat("#", 1024)},
+			options:     []ValidateOption{WithMaxArchiveSize(1023), WithContentValidation(false)},
+			wantErr:     ErrInvalidArchive,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			err := ValidateArchive(testModuleData(tc.files), m, tc.options...)
+			if tc.wantErr == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			assert.ErrorIs(t, err, tc.wantErr)
+			for _, diag := range tc.wantDiags {
+				assert.Contains(t, err.Error(), diag)
+			}
+		})
+	}
+}
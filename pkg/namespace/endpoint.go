@@ -0,0 +1,37 @@
+package namespace
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+)
+
+type namespaceRequest struct {
+	name string
+}
+
+func getNamespaceEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(namespaceRequest)
+		return svc.GetNamespace(ctx, req.name)
+	}
+}
+
+func addNamespaceEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		return svc.AddNamespace(ctx, request.(Namespace))
+	}
+}
+
+func updateNamespaceEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		return svc.UpdateNamespace(ctx, request.(Namespace))
+	}
+}
+
+func deleteNamespaceEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(namespaceRequest)
+		return nil, svc.DeleteNamespace(ctx, req.name)
+	}
+}
@@ -0,0 +1,12 @@
+package namespace
+
+import "errors"
+
+var (
+	ErrNamespaceNotFound      = errors.New("failed to locate namespace")
+	ErrNamespaceAlreadyExists = errors.New("namespace already exists")
+	ErrNamespaceFrozen        = errors.New("namespace is frozen")
+	ErrNameNotAllowed         = errors.New("name isn't allowed in the namespace")
+	ErrInvalidNamespace       = errors.New("invalid namespace")
+	ErrManageDisabled         = errors.New("managing namespaces is disabled")
+)
@@ -0,0 +1,89 @@
+// Package namespace registers the namespaces of the registry with their metadata and naming rules,
+// so that modules and providers can't be published to namespaces that don't exist, e.g. because of a typo.
+package namespace
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Namespace describes a namespace of modules and providers
+type Namespace struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Owners      []string `json:"owners,omitempty"`
+
+	// NamePatterns are regular expressions of which the names of modules and providers have to match one in full.
+	// All names are allowed if there are none.
+	NamePatterns []string `json:"name_patterns,omitempty"`
+
+	// Frozen namespaces are read-only, so nothing can be published to them
+	Frozen bool `json:"frozen"`
+}
+
+// Validate checks that the name is a single path segment and that the name patterns are valid regular expressions
+func (n Namespace) Validate() error {
+	if n.Name == "" || strings.ContainsAny(n.Name, "/ \t\n") {
+		return fmt.Errorf("%w: name %q has to be a single path segment without whitespace", ErrInvalidNamespace, n.Name)
+	}
+
+	for _, p := range n.NamePatterns {
+		if _, err := compilePattern(p); err != nil {
+			return fmt.Errorf("%w: name pattern %q: %v", ErrInvalidNamespace, p, err)
+		}
+	}
+	return nil
+}
+
+// AllowsName returns whether a module or provider with the name can be published to the namespace
+func (n Namespace) AllowsName(name string) bool {
+	if len(n.NamePatterns) == 0 {
+		return true
+	}
+
+	for _, p := range n.NamePatterns {
+		if re, err := compilePattern(p); err == nil && re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// compilePattern anchors the pattern, so that it has to match the whole name
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	return regexp.Compile(`^(?:` + pattern + `)$`)
+}
+
+// Namespaces checks publishing against a list of namespaces
+type Namespaces []Namespace
+
+// Lookup returns the namespace with the name
+func (namespaces Namespaces) Lookup(name string) (Namespace, bool) {
+	for _, n := range namespaces {
+		if n.Name == name {
+			return n, true
+		}
+	}
+	return Namespace{}, false
+}
+
+// CheckPublish fails if a module or provider with the name can't be published to the namespace,
+// because the namespace is frozen or the name doesn't match its name patterns.
+// Namespaces that aren't registered are rejected once any namespace is registered, or if they are required.
+func (namespaces Namespaces) CheckPublish(namespace, name string, required bool) error {
+	n, ok := namespaces.Lookup(namespace)
+	if !ok {
+		if required || len(namespaces) > 0 {
+			return fmt.Errorf("%w: %s", ErrNamespaceNotFound, namespace)
+		}
+		return nil
+	}
+
+	if n.Frozen {
+		return fmt.Errorf("%w: %s", ErrNamespaceFrozen, namespace)
+	} else if !n.AllowsName(name) {
+		return fmt.Errorf("%w: %s doesn't match the name patterns %s of the namespace %s", ErrNameNotAllowed, name, strings.Join(n.NamePatterns, ", "), namespace)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package namespace
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNamespace_Validate(t *testing.T) {
+	t.Parallel()
+
+	tc := []struct {
+		name      string
+		namespace Namespace
+		valid     bool
+	}{
+		{
+			name:      "valid",
+			namespace: Namespace{Name: "network", NamePatterns: []string{"net-.*"}},
+			valid:     true,
+		},
+		{
+			name:      "empty name",
+			namespace: Namespace{},
+		},
+		{
+			name:      "name with slash",
+			namespace: Namespace{Name: "network/vpc"},
+		},
+		{
+			name:      "invalid pattern",
+			namespace: Namespace{Name: "network", NamePatterns: []string{"("}},
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			err := c.namespace.Validate()
+			if c.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrInvalidNamespace)
+			}
+		})
+	}
+}
+
+func TestNamespace_AllowsName(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, Namespace{Name: "network"}.AllowsName("anything"))
+
+	n := Namespace{Name: "network", NamePatterns: []string{"net-.*", "dns"}}
+	assert.True(t, n.AllowsName("net-vpc"))
+	assert.True(t, n.AllowsName("dns"))
+	assert.False(t, n.AllowsName("vpc"))
+	assert.False(t, n.AllowsName("my-net-vpc"), "patterns have to match the whole name")
+	assert.False(t, n.AllowsName("dns-resolver"), "patterns have to match the whole name")
+}
+
+func TestNamespaces_CheckPublish(t *testing.T) {
+	t.Parallel()
+
+	namespaces := Namespaces{
+		{Name: "network", NamePatterns: []string{"net-.*"}},
+		{Name: "legacy", Frozen: true},
+	}
+
+	tc := []struct {
+		name      string
+		namespace string
+		module    string
+		required  bool
+		err       error
+	}{
+		{name: "allowed", namespace: "network", module: "net-vpc"},
+		{name: "name not allowed", namespace: "network", module: "vpc", err: ErrNameNotAllowed},
+		{name: "frozen", namespace: "legacy", module: "vpc", err: ErrNamespaceFrozen},
+		{name: "unknown", namespace: "netwrok", module: "net-vpc", err: ErrNamespaceNotFound},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			err := namespaces.CheckPublish(c.namespace, c.module, c.required)
+			if c.err == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, c.err)
+			}
+		})
+	}
+
+	// Without registered namespaces, unregistered ones are only rejected if they are required
+	assert.NoError(t, Namespaces(nil).CheckPublish("netwrok", "net-vpc", false))
+	assert.ErrorIs(t, Namespaces(nil).CheckPublish("netwrok", "net-vpc", true), ErrNamespaceNotFound)
+}
@@ -0,0 +1,108 @@
+package namespace
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// Service manages the registered namespaces
+type Service interface {
+	ListNamespaces(ctx context.Context) ([]Namespace, error)
+	GetNamespace(ctx context.Context, name string) (Namespace, error)
+	AddNamespace(ctx context.Context, n Namespace) (Namespace, error)
+	UpdateNamespace(ctx context.Context, n Namespace) (Namespace, error)
+	DeleteNamespace(ctx context.Context, name string) error
+}
+
+type service struct {
+	storage       Storage
+	manageEnabled bool
+}
+
+// ServiceOption provides additional options for the Service.
+type ServiceOption func(*service)
+
+// WithManageEnabled allows adding, updating and deleting namespaces through the Service
+func WithManageEnabled(enabled bool) ServiceOption {
+	return func(s *service) {
+		s.manageEnabled = enabled
+	}
+}
+
+// NewService returns a fully initialized Service.
+func NewService(storage Storage, options ...ServiceOption) Service {
+	s := &service{
+		storage: storage,
+	}
+
+	for _, option := range options {
+		option(s)
+	}
+
+	return s
+}
+
+// ListNamespaces returns the namespaces sorted by their name
+func (s *service) ListNamespaces(ctx context.Context) ([]Namespace, error) {
+	namespaces, err := s.storage.ListNamespaces(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(namespaces, func(i, j int) bool {
+		return namespaces[i].Name < namespaces[j].Name
+	})
+	return namespaces, nil
+}
+
+func (s *service) GetNamespace(ctx context.Context, name string) (Namespace, error) {
+	namespaces, err := s.storage.ListNamespaces(ctx)
+	if err != nil {
+		return Namespace{}, err
+	}
+
+	n, ok := Namespaces(namespaces).Lookup(name)
+	if !ok {
+		return Namespace{}, fmt.Errorf("%w: %s", ErrNamespaceNotFound, name)
+	}
+	return n, nil
+}
+
+func (s *service) AddNamespace(ctx context.Context, n Namespace) (Namespace, error) {
+	if !s.manageEnabled {
+		return Namespace{}, ErrManageDisabled
+	}
+
+	if err := n.Validate(); err != nil {
+		return Namespace{}, err
+	}
+
+	if err := s.storage.AddNamespace(ctx, n); err != nil {
+		return Namespace{}, err
+	}
+	return n, nil
+}
+
+func (s *service) UpdateNamespace(ctx context.Context, n Namespace) (Namespace, error) {
+	if !s.manageEnabled {
+		return Namespace{}, ErrManageDisabled
+	}
+
+	if err := n.Validate(); err != nil {
+		return Namespace{}, err
+	}
+
+	if err := s.storage.UpdateNamespace(ctx, n); err != nil {
+		return Namespace{}, err
+	}
+	return n, nil
+}
+
+func (s *service) DeleteNamespace(ctx context.Context, name string) error {
+	if !s.manageEnabled {
+		return ErrManageDisabled
+	}
+
+	return s.storage.DeleteNamespace(ctx, name)
+}
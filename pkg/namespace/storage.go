@@ -0,0 +1,18 @@
+package namespace
+
+import "context"
+
+// Storage persists the registered namespaces
+type Storage interface {
+	// ListNamespaces returns all registered namespaces
+	ListNamespaces(ctx context.Context) ([]Namespace, error)
+
+	// AddNamespace registers the namespace, and fails if a namespace with the same name is registered already
+	AddNamespace(ctx context.Context, n Namespace) error
+
+	// UpdateNamespace replaces the registered namespace with the same name
+	UpdateNamespace(ctx context.Context, n Namespace) error
+
+	// DeleteNamespace removes the namespace from the registry, whereas its modules and providers are kept
+	DeleteNamespace(ctx context.Context, name string) error
+}
@@ -0,0 +1,139 @@
+package namespace
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	o11y "github.com/boring-registry/boring-registry/pkg/observability"
+
+	"github.com/go-kit/kit/auth/jwt"
+	"github.com/go-kit/kit/endpoint"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+)
+
+// MakeHandler returns a fully initialized http.Handler.
+func MakeHandler(svc Service, auth endpoint.Middleware, instrumentation o11y.Middleware, options ...httptransport.ServerOption) http.Handler {
+	r := mux.NewRouter().StrictSlash(true)
+
+	// The namespaces are listed by the catalog, which adds the metadata of the registered namespaces
+	r.Methods("POST").Path(`/`).Handler(
+		instrumentation.WrapHandler(
+			httptransport.NewServer(
+				auth(addNamespaceEndpoint(svc)),
+				decodeAddNamespaceRequest,
+				encodeAddNamespaceResponse,
+				append(
+					options,
+					httptransport.ServerBefore(jwt.HTTPToContext()),
+				)...,
+			),
+		),
+	)
+
+	r.Methods("GET").Path(`/{name}`).Handler(
+		instrumentation.WrapHandler(
+			httptransport.NewServer(
+				auth(getNamespaceEndpoint(svc)),
+				decodeNamespaceRequest,
+				httptransport.EncodeJSONResponse,
+				append(
+					options,
+					httptransport.ServerBefore(jwt.HTTPToContext()),
+				)...,
+			),
+		),
+	)
+
+	r.Methods("PUT").Path(`/{name}`).Handler(
+		instrumentation.WrapHandler(
+			httptransport.NewServer(
+				auth(updateNamespaceEndpoint(svc)),
+				decodeUpdateNamespaceRequest,
+				httptransport.EncodeJSONResponse,
+				append(
+					options,
+					httptransport.ServerBefore(jwt.HTTPToContext()),
+				)...,
+			),
+		),
+	)
+
+	r.Methods("DELETE").Path(`/{name}`).Handler(
+		instrumentation.WrapHandler(
+			httptransport.NewServer(
+				auth(deleteNamespaceEndpoint(svc)),
+				decodeNamespaceRequest,
+				encodeDeleteNamespaceResponse,
+				append(
+					options,
+					httptransport.ServerBefore(jwt.HTTPToContext()),
+				)...,
+			),
+		),
+	)
+
+	return r
+}
+
+// decodeAddNamespaceRequest decodes the namespace from the JSON body, e.g. {"name": "network", "owners": ["team-network"]}
+func decodeAddNamespaceRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var n Namespace
+	if err := json.NewDecoder(r.Body).Decode(&n); err != nil {
+		return nil, fmt.Errorf("%w: failed to decode body: %v", core.ErrVarType, err)
+	}
+	return n, nil
+}
+
+// decodeUpdateNamespaceRequest decodes the namespace from the JSON body, whose name is taken from the path
+func decodeUpdateNamespaceRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var n Namespace
+	if err := json.NewDecoder(r.Body).Decode(&n); err != nil {
+		return nil, fmt.Errorf("%w: failed to decode body: %v", core.ErrVarType, err)
+	}
+
+	name := mux.Vars(r)["name"]
+	if n.Name != "" && n.Name != name {
+		return nil, fmt.Errorf("%w: namespaces can't be renamed, but the name %s differs from %s", ErrInvalidNamespace, n.Name, name)
+	}
+	n.Name = name
+	return n, nil
+}
+
+func decodeNamespaceRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	return namespaceRequest{
+		name: mux.Vars(r)["name"],
+	}, nil
+}
+
+// ErrorEncoder translates domain specific errors to HTTP status codes
+func ErrorEncoder(_ context.Context, err error, w http.ResponseWriter) {
+	if errors.Is(err, ErrNamespaceNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+	} else if errors.Is(err, ErrNamespaceAlreadyExists) {
+		w.WriteHeader(http.StatusConflict)
+	} else if errors.Is(err, ErrInvalidNamespace) {
+		w.WriteHeader(http.StatusBadRequest)
+	} else if errors.Is(err, ErrManageDisabled) {
+		w.WriteHeader(http.StatusForbidden)
+	} else {
+		w.WriteHeader(core.GenericError(err))
+	}
+
+	core.HandleErrorResponse(err, w)
+}
+
+func encodeAddNamespaceResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusCreated)
+	return json.NewEncoder(w).Encode(response)
+}
+
+func encodeDeleteNamespaceResponse(_ context.Context, w http.ResponseWriter, _ interface{}) error {
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
@@ -35,10 +35,12 @@ type MirrorMetrics struct {
 type ModuleMetrics struct {
 	ListVersions *prometheus.CounterVec
 	Download     *prometheus.CounterVec
+	Publish      *prometheus.CounterVec
 }
 type ProviderMetrics struct {
 	ListVersions *prometheus.CounterVec
 	Download     *prometheus.CounterVec
+	Publish      *prometheus.CounterVec
 }
 type ProxyMetrics struct {
 	Download *prometheus.CounterVec
@@ -115,6 +117,15 @@ func NewMetrics(buckets []float64) *ServerMetrics {
 				},
 				[]string{NamespaceLabel, NameLabel, VersionLabel, OsLabel, ArchLabel},
 			),
+			Publish: promauto.NewCounterVec(
+				prometheus.CounterOpts{
+					Namespace: boringNamespace,
+					Subsystem: providersSubsystem,
+					Name:      "publish_version_total",
+					Help:      "The total number of provider publish requests",
+				},
+				[]string{NamespaceLabel, NameLabel, VersionLabel},
+			),
 		},
 		Module: &ModuleMetrics{
 			ListVersions: promauto.NewCounterVec(
@@ -135,6 +146,15 @@ func NewMetrics(buckets []float64) *ServerMetrics {
 				},
 				[]string{NamespaceLabel, NameLabel, ProviderLabel, VersionLabel},
 			),
+			Publish: promauto.NewCounterVec(
+				prometheus.CounterOpts{
+					Namespace: boringNamespace,
+					Subsystem: modulesSubsystem,
+					Name:      "publish_version_total",
+					Help:      "The total number of module publish requests",
+				},
+				[]string{NamespaceLabel, NameLabel, ProviderLabel, VersionLabel},
+			),
 		},
 		Proxy: &ProxyMetrics{
 			Download: promauto.NewCounterVec(
@@ -2,6 +2,8 @@ package provider
 
 import (
 	"context"
+	"fmt"
+	"net/url"
 
 	"github.com/boring-registry/boring-registry/pkg/core"
 	o11y "github.com/boring-registry/boring-registry/pkg/observability"
@@ -76,3 +78,147 @@ func downloadEndpoint(svc Service, metrics *o11y.ProviderMetrics) endpoint.Endpo
 		}, nil
 	}
 }
+
+type publishRequest struct {
+	namespace string
+	name      string
+	version   string
+	read      ReleaseReader
+}
+
+func publishEndpoint(svc Service, metrics *o11y.ProviderMetrics) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(publishRequest)
+
+		metrics.Publish.With(prometheus.Labels{
+			o11y.NamespaceLabel: req.namespace,
+			o11y.NameLabel:      req.name,
+			o11y.VersionLabel:   req.version,
+		}).Inc()
+
+		return svc.PublishProvider(ctx, req.namespace, req.name, req.version, req.read)
+	}
+}
+
+type promoteRequest struct {
+	namespace       string
+	name            string
+	version         string
+	targetNamespace string
+}
+
+func promoteEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(promoteRequest)
+		return svc.PromoteProvider(ctx, req.namespace, req.name, req.version, req.targetNamespace)
+	}
+}
+
+type catalogRequest struct {
+	filter ListFilter
+	page   core.Page
+	url    *url.URL
+}
+
+type catalogResponseProvider struct {
+	ID        string          `json:"id"`
+	Namespace string          `json:"namespace"`
+	Name      string          `json:"name"`
+	Version   string          `json:"version"`
+	Platforms []core.Platform `json:"platforms"`
+}
+
+type catalogResponse struct {
+	Meta      core.PageMeta             `json:"meta"`
+	Providers []catalogResponseProvider `json:"providers"`
+}
+
+func catalogEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(catalogRequest)
+
+		res, meta, err := svc.ListProviders(ctx, req.filter, req.page)
+		if err != nil {
+			return nil, err
+		}
+
+		providers := make([]catalogResponseProvider, 0, len(res))
+		for _, p := range res {
+			providers = append(providers, catalogResponseProvider{
+				ID:        fmt.Sprintf("%s/%s/%s", p.Namespace, p.Name, p.Version),
+				Namespace: p.Namespace,
+				Name:      p.Name,
+				Version:   p.Version,
+				Platforms: p.Platforms,
+			})
+		}
+
+		return catalogResponse{
+			Meta:      meta.WithURLs(req.url),
+			Providers: providers,
+		}, nil
+	}
+}
+
+// detailsRequest requests the latest version of the provider if the version is empty
+type detailsRequest struct {
+	namespace string
+	name      string
+	version   string
+}
+
+// detailsResponse contains the attributes of the provider detail endpoint of the public Terraform registry,
+// which are read by dependency update tools like Renovate
+type detailsResponse struct {
+	ID        string          `json:"id"`
+	Namespace string          `json:"namespace"`
+	Name      string          `json:"name"`
+	Version   string          `json:"version"`
+	Protocols []string        `json:"protocols,omitempty"`
+	Platforms []core.Platform `json:"platforms"`
+	Versions  []string        `json:"versions"`
+}
+
+func detailsEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(detailsRequest)
+
+		res, err := svc.GetProviderDetails(ctx, req.namespace, req.name, req.version)
+		if err != nil {
+			return nil, err
+		}
+
+		return detailsResponse{
+			ID:        fmt.Sprintf("%s/%s/%s", res.Namespace, res.Name, res.Version),
+			Namespace: res.Namespace,
+			Name:      res.Name,
+			Version:   res.Version,
+			Protocols: res.Protocols,
+			Platforms: res.Platforms,
+			Versions:  res.Versions,
+		}, nil
+	}
+}
+
+type provenanceRequest struct {
+	namespace string
+	name      string
+	version   string
+}
+
+type provenanceResponse struct {
+	Artifacts []core.Provenance `json:"artifacts"`
+}
+
+func provenanceEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(provenanceRequest)
+
+		res, err := svc.GetProviderProvenance(ctx, req.namespace, req.name, req.version)
+		if err != nil {
+			return nil, err
+		}
+
+		return provenanceResponse{Artifacts: res}, nil
+	}
+}
@@ -4,5 +4,9 @@ import "errors"
 
 var (
 	// Provider errors
-	ErrProviderNotFound = errors.New("failed to locate provider")
+	ErrProviderNotFound      = errors.New("failed to locate provider")
+	ErrProviderAlreadyExists = errors.New("provider already exists")
+	ErrInvalidRelease        = errors.New("invalid provider release")
+	ErrPublishDisabled       = errors.New("publishing providers is disabled")
+	ErrReleaseTooLarge       = errors.New("provider release too large")
 )
@@ -68,3 +68,115 @@ func (mw loggingMiddleware) GetProvider(ctx context.Context, namespace, name, ve
 
 	return mw.next.GetProvider(ctx, namespace, name, version, os, arch)
 }
+
+func (mw loggingMiddleware) PublishProvider(ctx context.Context, namespace, name, v string, read ReleaseReader) (version *core.ProviderVersion, err error) {
+	defer func(begin time.Time) {
+		logger := slog.Default().With(
+			slog.String("op", "PublishProvider"),
+			slog.Group("provider",
+				slog.String("namespace", namespace),
+				slog.String("name", name),
+				slog.String("version", v),
+			),
+		)
+
+		if err != nil {
+			logger.Error("failed to publish provider", slog.String("err", err.Error()))
+			return
+		}
+
+		logger.Info("publish provider", slog.String("took", time.Since(begin).String()), slog.Int("platforms", len(version.Platforms)))
+	}(time.Now())
+
+	return mw.next.PublishProvider(ctx, namespace, name, v, read)
+}
+
+func (mw loggingMiddleware) ListProviders(ctx context.Context, filter ListFilter, page core.Page) (providers []core.ProviderVersion, meta core.PageMeta, err error) {
+	defer func(begin time.Time) {
+		logger := slog.Default().With(
+			slog.String("op", "ListProviders"),
+			slog.Group("filter",
+				slog.String("namespace", filter.Namespace),
+				slog.String("query", filter.Query),
+			),
+			slog.Int("offset", page.Offset),
+			slog.Int("limit", page.Limit),
+		)
+
+		if err != nil {
+			logger.Error("failed to list providers", slog.String("err", err.Error()))
+			return
+		}
+
+		logger.Info("list providers", slog.String("took", time.Since(begin).String()), slog.Int("providers", len(providers)))
+	}(time.Now())
+
+	return mw.next.ListProviders(ctx, filter, page)
+}
+
+func (mw loggingMiddleware) GetProviderDetails(ctx context.Context, namespace, name, version string) (details Details, err error) {
+	defer func(begin time.Time) {
+		logger := slog.Default().With(
+			slog.String("op", "GetProviderDetails"),
+			slog.Group("provider",
+				slog.String("namespace", namespace),
+				slog.String("name", name),
+				slog.String("version", version),
+			),
+		)
+
+		if err != nil {
+			logger.Error("failed to get provider details", slog.String("err", err.Error()))
+			return
+		}
+
+		logger.Info("get provider details", slog.String("took", time.Since(begin).String()), slog.String("resolved_version", details.Version))
+	}(time.Now())
+
+	return mw.next.GetProviderDetails(ctx, namespace, name, version)
+}
+
+func (mw loggingMiddleware) GetProviderProvenance(ctx context.Context, namespace, name, version string) (provenance []core.Provenance, err error) {
+	defer func(begin time.Time) {
+		logger := slog.Default().With(
+			slog.String("op", "GetProviderProvenance"),
+			slog.Group("provider",
+				slog.String("namespace", namespace),
+				slog.String("name", name),
+				slog.String("version", version),
+			),
+		)
+
+		if err != nil {
+			logger.Error("failed to get provider provenance", slog.String("err", err.Error()))
+			return
+		}
+
+		logger.Info("get provider provenance", slog.String("took", time.Since(begin).String()), slog.Int("artifacts", len(provenance)))
+	}(time.Now())
+
+	return mw.next.GetProviderProvenance(ctx, namespace, name, version)
+}
+
+func (mw loggingMiddleware) PromoteProvider(ctx context.Context, namespace, name, version, targetNamespace string) (promoted *core.ProviderVersion, err error) {
+	defer func(begin time.Time) {
+		logger := slog.Default().With(
+			slog.String("op", "PromoteProvider"),
+			slog.Group("provider",
+				slog.String("namespace", namespace),
+				slog.String("name", name),
+				slog.String("version", version),
+			),
+			slog.String("target_namespace", targetNamespace),
+		)
+
+		if err != nil {
+			logger.Error("failed to promote provider", slog.String("err", err.Error()))
+			return
+		}
+
+		logger.Info("promote provider", slog.String("took", time.Since(begin).String()), slog.Int("platforms", len(promoted.Platforms)))
+	}(time.Now())
+
+	return mw.next.PromoteProvider(ctx, namespace, name, version, targetNamespace)
+}
@@ -0,0 +1,269 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+)
+
+// releaseFileNameRegex matches the archives and the manifest of a provider release
+var releaseFileNameRegex = regexp.MustCompile(`^terraform-provider-.+_.+_.+\.(zip|json)$`)
+
+// ReleaseFile is a single file of a provider release
+type ReleaseFile struct {
+	Name string
+	Open func() (io.ReadCloser, error)
+}
+
+func (f ReleaseFile) read() ([]byte, error) {
+	r, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return io.ReadAll(r)
+}
+
+// Release contains all files making up a provider release.
+// See https://developer.hashicorp.com/terraform/registry/providers/publishing#manually-preparing-a-release
+type Release struct {
+	Namespace  string
+	Name       string
+	Version    string
+	SHA256Sums ReleaseFile
+	Signature  ReleaseFile
+
+	// Files are the platform archives and the optional manifest, which all have to be listed in the SHA256SUMS file
+	Files []ReleaseFile
+}
+
+// DefaultMaxReleaseSize is the default maximum size of all files of a published release
+const DefaultMaxReleaseSize = 2 << 30
+
+// ReleaseReader reads the files of a release, which must not exceed maxSize bytes in total
+type ReleaseReader func(maxSize int64) (*Release, error)
+
+// Platforms returns the platforms of the release archives
+func (r *Release) Platforms() []core.Platform {
+	var platforms []core.Platform
+	for _, f := range r.Files {
+		if p, err := core.NewProviderFromArchive(f.Name); err == nil && strings.HasSuffix(f.Name, core.ProviderExtension) {
+			platforms = append(platforms, core.Platform{OS: p.OS, Arch: p.Arch})
+		}
+	}
+	return platforms
+}
+
+// FileError is the validation error of a single release file
+type FileError struct {
+	File  string `json:"file"`
+	Error string `json:"error"`
+}
+
+// ReleaseError is returned if files of a release failed the validation
+type ReleaseError struct {
+	Files []FileError
+}
+
+func (e *ReleaseError) Error() string {
+	var messages []string
+	for _, f := range e.Files {
+		messages = append(messages, fmt.Sprintf("%s: %s", f.File, f.Error))
+	}
+	return fmt.Sprintf("%v: %s", ErrInvalidRelease, strings.Join(messages, "; "))
+}
+
+func (e *ReleaseError) Unwrap() error {
+	return ErrInvalidRelease
+}
+
+func (e *ReleaseError) add(file string, err error) {
+	e.Files = append(e.Files, FileError{File: file, Error: err.Error()})
+}
+
+// ValidateRelease verifies the signature of the SHA256SUMS file with the signing keys of the namespace
+// and the checksums of all release files. All failing files are reported in a *ReleaseError.
+func ValidateRelease(release *Release, signingKeys *core.SigningKeys) error {
+	p := core.Provider{Name: release.Name, Version: release.Version}
+	releaseErr := &ReleaseError{}
+
+	sumsBytes, err := release.SHA256Sums.read()
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", release.SHA256Sums.Name, err)
+	}
+	if release.SHA256Sums.Name != p.ShasumFileName() {
+		releaseErr.add(release.SHA256Sums.Name, fmt.Errorf("file name has to be %s", p.ShasumFileName()))
+	}
+	sums, err := core.NewSha256Sums(p.ShasumFileName(), bytes.NewReader(sumsBytes))
+	if err != nil {
+		releaseErr.add(release.SHA256Sums.Name, err)
+		return releaseErr
+	}
+
+	if release.Signature.Name != p.ShasumSignatureFileName() {
+		releaseErr.add(release.Signature.Name, fmt.Errorf("file name has to be %s", p.ShasumSignatureFileName()))
+	}
+	sigBytes, err := release.Signature.read()
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", release.Signature.Name, err)
+	}
+	if err := signingKeys.IsValidSha256Sums(sumsBytes, sigBytes); err != nil {
+		releaseErr.add(release.Signature.Name, err)
+	}
+
+	uploaded := make(map[string]bool)
+	prefix := fmt.Sprintf("%s%s_%s_", core.ProviderPrefix, release.Name, release.Version)
+	for _, f := range release.Files {
+		uploaded[f.Name] = true
+		if err := validateReleaseFile(f, prefix, sums); err != nil {
+			releaseErr.add(f.Name, err)
+		}
+	}
+
+	var missing []string
+	for name := range sums.Entries {
+		if !uploaded[name] {
+			missing = append(missing, name)
+		}
+	}
+	sort.Strings(missing)
+	for _, name := range missing {
+		releaseErr.add(name, errors.New("file is listed in the SHA256SUMS file but missing"))
+	}
+
+	if len(releaseErr.Files) > 0 {
+		return releaseErr
+	}
+	return nil
+}
+
+// verifySignature verifies the signature of the SHA256SUMS file with the signing keys, without reading the other files of the release
+func verifySignature(release *Release, signingKeys *core.SigningKeys) error {
+	if release.Signature.Open == nil {
+		p := core.Provider{Name: release.Name, Version: release.Version}
+		return &ReleaseError{Files: []FileError{{File: p.ShasumSignatureFileName(), Error: "file is missing"}}}
+	}
+
+	sumsBytes, err := release.SHA256Sums.read()
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", release.SHA256Sums.Name, err)
+	}
+	sigBytes, err := release.Signature.read()
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", release.Signature.Name, err)
+	}
+
+	if err := signingKeys.IsValidSha256Sums(sumsBytes, sigBytes); err != nil {
+		return &ReleaseError{Files: []FileError{{File: release.Signature.Name, Error: err.Error()}}}
+	}
+	return nil
+}
+
+func validateReleaseFile(f ReleaseFile, prefix string, sums *core.Sha256Sums) error {
+	if !releaseFileNameRegex.MatchString(f.Name) || !strings.HasPrefix(f.Name, prefix) {
+		return errors.New("file name is invalid")
+	}
+
+	expected, ok := sums.Entries[f.Name]
+	if !ok {
+		return errors.New("checksum is missing in the SHA256SUMS file")
+	}
+
+	r, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	checksum, err := core.Sha256Checksum(r)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(expected, checksum) {
+		return errors.New("checksum doesn't match the SHA256SUMS file")
+	}
+	return nil
+}
+
+// fileUploadTimeout limits the upload of every single file of a release
+const fileUploadTimeout = 120 * time.Second
+
+// UploadRelease uploads a validated release to the storage backend.
+// The SHA256SUMS file and its signature are uploaded first, so that storage backends can cross-check the archives with them.
+// They are only kept in case they exist from a previous, interrupted upload with the same content, as the archives wouldn't match them otherwise.
+func UploadRelease(ctx context.Context, storage Storage, release *Release) error {
+	for _, f := range []ReleaseFile{release.SHA256Sums, release.Signature} {
+		err := uploadReleaseFile(ctx, storage, release, f)
+		if errors.Is(err, core.ErrObjectAlreadyExists) {
+			if err := compareStoredFile(ctx, storage, release, f); err != nil {
+				return err
+			}
+			slog.Info("provider release file exists already", slog.String("name", f.Name))
+			continue
+		} else if err != nil {
+			return err
+		}
+		slog.Info("successfully published provider release file", slog.String("name", f.Name))
+	}
+
+	for _, f := range release.Files {
+		if err := uploadReleaseFile(ctx, storage, release, f); err != nil {
+			return err
+		}
+		slog.Info("successfully published provider binary", slog.String("name", f.Name))
+	}
+
+	return nil
+}
+
+func uploadReleaseFile(ctx context.Context, storage Storage, release *Release, f ReleaseFile) error {
+	r, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	uploadCtx, cancel := context.WithTimeout(ctx, fileUploadTimeout)
+	defer cancel()
+
+	return storage.UploadProviderReleaseFiles(uploadCtx, release.Namespace, release.Name, f.Name, r)
+}
+
+// compareStoredFile fails unless the stored SHA256SUMS file or signature is identical to the one of the release
+func compareStoredFile(ctx context.Context, storage Storage, release *Release, f ReleaseFile) error {
+	stored, err := storage.GetProviderRelease(ctx, release.Namespace, release.Name, release.Version)
+	if err != nil {
+		return fmt.Errorf("failed to read the stored provider release: %w", err)
+	}
+
+	storedFile := stored.SHA256Sums
+	if f.Name == release.Signature.Name {
+		storedFile = stored.Signature
+	}
+	if storedFile.Open == nil {
+		return fmt.Errorf("%w: %s", core.ErrObjectAlreadyExists, f.Name)
+	}
+
+	storedContent, err := storedFile.read()
+	if err != nil {
+		return err
+	}
+	content, err := f.read()
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(storedContent, content) {
+		return fmt.Errorf("%w: %s differs from the stored file", core.ErrObjectAlreadyExists, f.Name)
+	}
+	return nil
+}
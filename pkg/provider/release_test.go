@@ -0,0 +1,252 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"testing"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+	"github.com/stretchr/testify/assert"
+)
+
+func memoryReleaseFile(name string, content []byte) ReleaseFile {
+	return ReleaseFile{
+		Name: name,
+		Open: func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(content)), nil
+		},
+	}
+}
+
+func testSigningKeys(t *testing.T) (*openpgp.Entity, *core.SigningKeys) {
+	t.Helper()
+
+	c := &packet.Config{
+		Rand:    rand.New(rand.NewSource(42)),
+		RSABits: 2048,
+	}
+	e, err := openpgp.NewEntity("boring-registry", "test", "boring-registry@example.com", c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := new(bytes.Buffer)
+	w, err := armor.Encode(buf, openpgp.PublicKeyType, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Serialize(w); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	return e, &core.SigningKeys{GPGPublicKeys: []core.GPGPublicKey{{ASCIIArmor: buf.String()}}}
+}
+
+func TestValidateRelease(t *testing.T) {
+	t.Parallel()
+
+	entity, signingKeys := testSigningKeys(t)
+
+	archives := map[string][]byte{
+		"terraform-provider-dummy_1.0.0_linux_amd64.zip":  []byte("linux"),
+		"terraform-provider-dummy_1.0.0_darwin_arm64.zip": []byte("darwin"),
+	}
+	sums := new(bytes.Buffer)
+	for _, name := range []string{"terraform-provider-dummy_1.0.0_darwin_arm64.zip", "terraform-provider-dummy_1.0.0_linux_amd64.zip"} {
+		fmt.Fprintf(sums, "%x  %s\n", sha256.Sum256(archives[name]), name)
+	}
+	sig := new(bytes.Buffer)
+	if err := openpgp.DetachSign(sig, entity, bytes.NewReader(sums.Bytes()), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	newRelease := func() *Release {
+		return &Release{
+			Namespace:  "hashicorp",
+			Name:       "dummy",
+			Version:    "1.0.0",
+			SHA256Sums: memoryReleaseFile("terraform-provider-dummy_1.0.0_SHA256SUMS", sums.Bytes()),
+			Signature:  memoryReleaseFile("terraform-provider-dummy_1.0.0_SHA256SUMS.sig", sig.Bytes()),
+			Files: []ReleaseFile{
+				memoryReleaseFile("terraform-provider-dummy_1.0.0_darwin_arm64.zip", archives["terraform-provider-dummy_1.0.0_darwin_arm64.zip"]),
+				memoryReleaseFile("terraform-provider-dummy_1.0.0_linux_amd64.zip", archives["terraform-provider-dummy_1.0.0_linux_amd64.zip"]),
+			},
+		}
+	}
+
+	testCases := []struct {
+		name          string
+		release       func() *Release
+		expectedFiles []string
+	}{
+		{
+			name:    "valid release",
+			release: newRelease,
+		},
+		{
+			name: "invalid signature",
+			release: func() *Release {
+				r := newRelease()
+				r.Signature = memoryReleaseFile(r.Signature.Name, []byte("invalid"))
+				return r
+			},
+			expectedFiles: []string{"terraform-provider-dummy_1.0.0_SHA256SUMS.sig"},
+		},
+		{
+			name: "checksum mismatch",
+			release: func() *Release {
+				r := newRelease()
+				r.Files[1] = memoryReleaseFile(r.Files[1].Name, []byte("corrupted"))
+				return r
+			},
+			expectedFiles: []string{"terraform-provider-dummy_1.0.0_linux_amd64.zip"},
+		},
+		{
+			name: "missing and unlisted archives",
+			release: func() *Release {
+				r := newRelease()
+				r.Files[1] = memoryReleaseFile("terraform-provider-dummy_1.0.0_windows_amd64.zip", []byte("windows"))
+				return r
+			},
+			expectedFiles: []string{
+				"terraform-provider-dummy_1.0.0_windows_amd64.zip",
+				"terraform-provider-dummy_1.0.0_linux_amd64.zip",
+			},
+		},
+		{
+			name: "archive of another version",
+			release: func() *Release {
+				r := newRelease()
+				r.Version = "2.0.0"
+				return r
+			},
+			expectedFiles: []string{
+				"terraform-provider-dummy_1.0.0_SHA256SUMS",
+				"terraform-provider-dummy_1.0.0_SHA256SUMS.sig",
+				"terraform-provider-dummy_1.0.0_darwin_arm64.zip",
+				"terraform-provider-dummy_1.0.0_linux_amd64.zip",
+			},
+		},
+		{
+			name: "unparsable SHA256SUMS file",
+			release: func() *Release {
+				r := newRelease()
+				r.SHA256Sums = memoryReleaseFile(r.SHA256Sums.Name, []byte("invalid"))
+				return r
+			},
+			expectedFiles: []string{"terraform-provider-dummy_1.0.0_SHA256SUMS"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := ValidateRelease(tc.release(), signingKeys)
+			if tc.expectedFiles == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			assert.ErrorIs(t, err, ErrInvalidRelease)
+			var releaseErr *ReleaseError
+			if assert.True(t, errors.As(err, &releaseErr)) {
+				var files []string
+				for _, f := range releaseErr.Files {
+					files = append(files, f.File)
+				}
+				assert.Equal(t, tc.expectedFiles, files)
+			}
+		})
+	}
+}
+
+func TestRelease_Platforms(t *testing.T) {
+	t.Parallel()
+
+	r := &Release{
+		Files: []ReleaseFile{
+			{Name: "terraform-provider-dummy_1.0.0_linux_amd64.zip"},
+			{Name: "terraform-provider-dummy_1.0.0_manifest.json"},
+			{Name: "terraform-provider-dummy_1.0.0_darwin_arm64.zip"},
+		},
+	}
+
+	assert.Equal(t, []core.Platform{{OS: "linux", Arch: "amd64"}, {OS: "darwin", Arch: "arm64"}}, r.Platforms())
+}
+
+func TestUploadRelease(t *testing.T) {
+	t.Parallel()
+
+	sums := []byte(fmt.Sprintf("%x  terraform-provider-dummy_1.0.0_linux_amd64.zip\n", sha256.Sum256([]byte("linux"))))
+	release := &Release{
+		Namespace:  "hashicorp",
+		Name:       "dummy",
+		Version:    "1.0.0",
+		SHA256Sums: memoryReleaseFile("terraform-provider-dummy_1.0.0_SHA256SUMS", sums),
+		Signature:  memoryReleaseFile("terraform-provider-dummy_1.0.0_SHA256SUMS.sig", []byte("sig")),
+		Files:      []ReleaseFile{memoryReleaseFile("terraform-provider-dummy_1.0.0_linux_amd64.zip", []byte("linux"))},
+	}
+
+	testCases := []struct {
+		name          string
+		existing      map[string][]byte
+		expectedError error
+	}{
+		{
+			name: "new release",
+		},
+		{
+			name: "interrupted upload",
+			existing: map[string][]byte{
+				"terraform-provider-dummy_1.0.0_SHA256SUMS":     sums,
+				"terraform-provider-dummy_1.0.0_SHA256SUMS.sig": []byte("sig"),
+			},
+		},
+		{
+			name: "different SHA256SUMS file",
+			existing: map[string][]byte{
+				"terraform-provider-dummy_1.0.0_SHA256SUMS": []byte("other"),
+			},
+			expectedError: core.ErrObjectAlreadyExists,
+		},
+		{
+			name: "different signature",
+			existing: map[string][]byte{
+				"terraform-provider-dummy_1.0.0_SHA256SUMS":     sums,
+				"terraform-provider-dummy_1.0.0_SHA256SUMS.sig": []byte("other"),
+			},
+			expectedError: core.ErrObjectAlreadyExists,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			storage := &memoryStorage{files: map[string][]byte{}}
+			for name, content := range tc.existing {
+				storage.files[name] = content
+			}
+
+			err := UploadRelease(context.Background(), storage, release)
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				assert.NotContains(t, storage.files, "terraform-provider-dummy_1.0.0_linux_amd64.zip")
+				return
+			}
+			assert.NoError(t, err)
+			assert.Len(t, storage.files, 3)
+		})
+	}
+}
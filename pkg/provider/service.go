@@ -2,8 +2,15 @@ package provider
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
 
 	"github.com/boring-registry/boring-registry/pkg/core"
+
+	"github.com/hashicorp/go-version"
 )
 
 // Service implements the Provider Registry Protocol.
@@ -11,19 +18,82 @@ import (
 type Service interface {
 	GetProvider(ctx context.Context, namespace, name, version, os, arch string) (*core.Provider, error)
 	ListProviderVersions(ctx context.Context, namespace, name string) (*core.ProviderVersions, error)
+
+	// GetProviderDetails returns a version of the provider together with all of its versions.
+	// The latest version is returned if the version is empty.
+	GetProviderDetails(ctx context.Context, namespace, name, version string) (Details, error)
+
+	// PublishProvider reads the release only if publishing is enabled, so that rejected uploads aren't read
+	PublishProvider(ctx context.Context, namespace, name, version string, read ReleaseReader) (*core.ProviderVersion, error)
+
+	// ListProviders returns the latest version of every provider matching the filter, sorted by namespace and name
+	ListProviders(ctx context.Context, filter ListFilter, page core.Page) ([]core.ProviderVersion, core.PageMeta, error)
+
+	// GetProviderProvenance returns the provenance of every file of the provider release
+	GetProviderProvenance(ctx context.Context, namespace, name, version string) ([]core.Provenance, error)
+
+	// PromoteProvider copies the release with all of its platforms to the target namespace without modifying or re-signing any file
+	PromoteProvider(ctx context.Context, namespace, name, version, targetNamespace string) (*core.ProviderVersion, error)
+}
+
+// ListFilter selects the providers of a listing. Empty attributes match every provider
+type ListFilter struct {
+	Namespace string
+
+	// Query matches providers whose namespace or name contain it, ignoring the case
+	Query string
+}
+
+func (f ListFilter) matches(p core.ProviderVersion) bool {
+	query := strings.ToLower(f.Query)
+	return strings.Contains(strings.ToLower(p.Namespace), query) || strings.Contains(strings.ToLower(p.Name), query)
+}
+
+// Details describes a version of a provider in the context of all of its versions
+type Details struct {
+	core.ProviderVersion
+
+	// Versions are all versions of the provider in ascending order
+	Versions []string
 }
 
 type service struct {
-	storage Storage
-	proxy   core.ProxyUrlService
+	storage        Storage
+	proxy          core.ProxyUrlService
+	publishEnabled bool
+	maxReleaseSize int64
+}
+
+// ServiceOption provides additional options for the Service.
+type ServiceOption func(*service)
+
+// WithPublishEnabled allows publishing provider releases through the Service
+func WithPublishEnabled(enabled bool) ServiceOption {
+	return func(s *service) {
+		s.publishEnabled = enabled
+	}
+}
+
+// WithMaxReleaseSize limits the total size in bytes of all files of a published release
+func WithMaxReleaseSize(size int64) ServiceOption {
+	return func(s *service) {
+		s.maxReleaseSize = size
+	}
 }
 
 // NewService returns a fully initialized Service.
-func NewService(storage Storage, proxy core.ProxyUrlService) Service {
-	return &service{
-		storage: storage,
-		proxy:   proxy,
+func NewService(storage Storage, proxy core.ProxyUrlService, options ...ServiceOption) Service {
+	s := &service{
+		storage:        storage,
+		proxy:          proxy,
+		maxReleaseSize: DefaultMaxReleaseSize,
 	}
+
+	for _, option := range options {
+		option(s)
+	}
+
+	return s
 }
 
 func (s *service) GetProvider(ctx context.Context, namespace, name, version, os, arch string) (*core.Provider, error) {
@@ -58,3 +128,182 @@ func (s *service) GetProvider(ctx context.Context, namespace, name, version, os,
 func (s *service) ListProviderVersions(ctx context.Context, namespace, name string) (*core.ProviderVersions, error) {
 	return s.storage.ListProviderVersions(ctx, namespace, name)
 }
+
+func (s *service) GetProviderDetails(ctx context.Context, namespace, name, version string) (Details, error) {
+	res, err := s.storage.ListProviderVersions(ctx, namespace, name)
+	if err != nil {
+		return Details{}, err
+	}
+	if res == nil || len(res.Versions) == 0 {
+		return Details{}, fmt.Errorf("%w: %s/%s", ErrProviderNotFound, namespace, name)
+	}
+
+	versions := make([]string, 0, len(res.Versions))
+	for _, v := range res.Versions {
+		versions = append(versions, v.Version)
+	}
+	core.SortVersions(versions)
+
+	if version == "" {
+		version = core.LatestVersion(versions)
+	}
+
+	for _, v := range res.Versions {
+		if v.Version == version {
+			v.Namespace, v.Name = namespace, name
+			return Details{ProviderVersion: v, Versions: versions}, nil
+		}
+	}
+	return Details{}, fmt.Errorf("%w: %s/%s %s", ErrProviderNotFound, namespace, name, version)
+}
+
+func (s *service) GetProviderProvenance(ctx context.Context, namespace, name, version string) ([]core.Provenance, error) {
+	return s.storage.GetProviderProvenance(ctx, namespace, name, version)
+}
+
+func (s *service) ListProviders(ctx context.Context, filter ListFilter, page core.Page) ([]core.ProviderVersion, core.PageMeta, error) {
+	res, err := s.storage.ListProviders(ctx, filter.Namespace)
+	if err != nil {
+		return nil, core.PageMeta{}, err
+	}
+
+	id := func(p core.ProviderVersion) string {
+		return p.Namespace + "/" + p.Name
+	}
+
+	versions := make(map[string][]core.ProviderVersion)
+	for _, p := range res {
+		if filter.matches(p) {
+			versions[id(p)] = append(versions[id(p)], p)
+		}
+	}
+
+	providers := make([]core.ProviderVersion, 0, len(versions))
+	for providerID, providerVersions := range versions {
+		v := make([]string, 0, len(providerVersions))
+		for _, p := range providerVersions {
+			v = append(v, p.Version)
+		}
+
+		latest := core.LatestVersion(v)
+		if latest == "" {
+			slog.Warn("skipping provider without a valid version", slog.String("provider", providerID), slog.Any("versions", v))
+			continue
+		}
+		for _, p := range providerVersions {
+			if p.Version == latest {
+				providers = append(providers, p)
+				break
+			}
+		}
+	}
+	sort.Slice(providers, func(i, j int) bool {
+		return id(providers[i]) < id(providers[j])
+	})
+
+	result, meta := core.Paginate(providers, page)
+	return result, meta, nil
+}
+
+// PublishProvider validates the release with the signing keys of the namespace before any file is stored.
+// Published provider versions are immutable, so existing platforms can't be overwritten.
+func (s *service) PublishProvider(ctx context.Context, namespace, name, v string, read ReleaseReader) (*core.ProviderVersion, error) {
+	if !s.publishEnabled {
+		return nil, ErrPublishDisabled
+	}
+
+	if _, err := version.NewVersion(v); err != nil {
+		return nil, fmt.Errorf("%w: version %s: %v", core.ErrVarType, v, err)
+	}
+
+	release, err := read(s.maxReleaseSize)
+	if err != nil {
+		return nil, err
+	}
+
+	platforms := release.Platforms()
+	if len(platforms) == 0 {
+		return nil, &ReleaseError{Files: []FileError{{File: release.SHA256Sums.Name, Error: "release doesn't contain any provider archives"}}}
+	}
+
+	for _, p := range platforms {
+		if _, err := s.storage.GetProvider(ctx, release.Namespace, release.Name, release.Version, p.OS, p.Arch); err == nil {
+			return nil, fmt.Errorf("%w: %s/%s %s for %s_%s", ErrProviderAlreadyExists, release.Namespace, release.Name, release.Version, p.OS, p.Arch)
+		}
+	}
+
+	signingKeys, err := s.storage.SigningKeys(ctx, release.Namespace)
+	if errors.Is(err, core.ErrObjectNotFound) {
+		return nil, &ReleaseError{Files: []FileError{{File: release.Signature.Name, Error: fmt.Sprintf("no signing keys found for namespace %s", release.Namespace)}}}
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := ValidateRelease(release, signingKeys); err != nil {
+		return nil, err
+	}
+
+	if err := UploadRelease(ctx, s.storage, release); err != nil {
+		return nil, err
+	}
+
+	return &core.ProviderVersion{
+		Namespace: release.Namespace,
+		Name:      release.Name,
+		Version:   release.Version,
+		Platforms: platforms,
+	}, nil
+}
+
+// PromoteProvider copies the release to the target namespace, e.g. from staging to prod.
+// The files are copied as-is, so the SHA256SUMS file has to be signed with a key of the target namespace already.
+func (s *service) PromoteProvider(ctx context.Context, namespace, name, version, targetNamespace string) (*core.ProviderVersion, error) {
+	if !s.publishEnabled {
+		return nil, ErrPublishDisabled
+	}
+
+	if targetNamespace == "" || targetNamespace == namespace {
+		return nil, fmt.Errorf("%w: target namespace %q has to differ from the namespace %s", core.ErrVarType, targetNamespace, namespace)
+	}
+
+	release, err := s.storage.GetProviderRelease(ctx, namespace, name, version)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only a complete release in the target namespace is a conflict, so that an interrupted promotion can be retried.
+	// The storage backend keeps the files existing already, as long as they are identical.
+	platforms := release.Platforms()
+	existing := 0
+	for _, p := range platforms {
+		if _, err := s.storage.GetProvider(ctx, targetNamespace, name, version, p.OS, p.Arch); err == nil {
+			existing++
+		}
+	}
+	if len(platforms) > 0 && existing == len(platforms) {
+		return nil, fmt.Errorf("%w: %s/%s %s", ErrProviderAlreadyExists, targetNamespace, name, version)
+	}
+
+	signingKeys, err := s.storage.SigningKeys(ctx, targetNamespace)
+	if errors.Is(err, core.ErrObjectNotFound) {
+		p := core.Provider{Name: name, Version: version}
+		return nil, &ReleaseError{Files: []FileError{{File: p.ShasumSignatureFileName(), Error: fmt.Sprintf("no signing keys found for namespace %s", targetNamespace)}}}
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := verifySignature(release, signingKeys); err != nil {
+		return nil, err
+	}
+
+	if err := s.storage.CopyProviderRelease(ctx, namespace, name, version, targetNamespace); err != nil {
+		return nil, err
+	}
+
+	return &core.ProviderVersion{
+		Namespace: targetNamespace,
+		Name:      name,
+		Version:   version,
+		Platforms: platforms,
+	}, nil
+}
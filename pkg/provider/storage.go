@@ -12,10 +12,26 @@ type Storage interface {
 	GetProvider(ctx context.Context, namespace, name, version, os, arch string) (*core.Provider, error)
 	ListProviderVersions(ctx context.Context, namespace, name string) (*core.ProviderVersions, error)
 
+	// ListProviders returns every version of all providers, restricted to the namespace if it isn't empty
+	ListProviders(ctx context.Context, namespace string) ([]core.ProviderVersion, error)
+
 	// UploadProviderReleaseFiles is used to upload all artifacts which make up a provider release
 	// https://developer.hashicorp.com/terraform/registry/providers/publishing#manually-preparing-a-release
 	UploadProviderReleaseFiles(ctx context.Context, namespace, name, filename string, file io.Reader) error
 
+	// GetProviderProvenance returns who published the files of the provider release, when, and from which pipeline.
+	// It should return a core.ErrProvenanceNotFound error if the release was uploaded without provenance
+	GetProviderProvenance(ctx context.Context, namespace, name, version string) ([]core.Provenance, error)
+
+	// GetProviderRelease returns all files of the provider release, which are read from the storage backend once they are opened
+	GetProviderRelease(ctx context.Context, namespace, name, version string) (*Release, error)
+
+	// CopyProviderRelease copies all files of the provider release to another namespace, where none of its archives may exist already
+	CopyProviderRelease(ctx context.Context, namespace, name, version, targetNamespace string) error
+
+	// DeleteProviderRelease removes all files of the provider release
+	DeleteProviderRelease(ctx context.Context, namespace, name, version string) error
+
 	// SigningKeys downloads and returns the keys for a given namespace from the configured storage backend
 	SigningKeys(ctx context.Context, namespace string) (*core.SigningKeys, error)
 }
@@ -2,9 +2,15 @@ package provider
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
 
 	"github.com/boring-registry/boring-registry/pkg/core"
 	o11y "github.com/boring-registry/boring-registry/pkg/observability"
@@ -25,10 +31,34 @@ const (
 	varVersion   muxVar = "version"
 )
 
+// maxPublishMemory is the maximum size of the release files held in memory while publishing
+const maxPublishMemory = 32 << 20
+
+// maxPromoteRequestSize limits the body of promote requests, which is decoded before the request is authenticated
+const maxPromoteRequestSize = 1 << 20
+
 // MakeHandler returns a fully initialized http.Handler.
 func MakeHandler(svc Service, auth endpoint.Middleware, metrics *o11y.ProviderMetrics, instrumentation o11y.Middleware, options ...httptransport.ServerOption) http.Handler {
 	r := mux.NewRouter().StrictSlash(true)
 
+	// The search has to be registered before the namespace listing, as it would match the namespace otherwise
+	for _, path := range []string{`/`, `/search`, `/{namespace}`} {
+		r.Methods("GET").Path(path).Handler(
+			instrumentation.WrapHandler(
+				httptransport.NewServer(
+					auth(catalogEndpoint(svc)),
+					decodeCatalogRequest,
+					httptransport.EncodeJSONResponse,
+					append(
+						options,
+						httptransport.ServerBefore(extractMuxVars(varNamespace)),
+						httptransport.ServerBefore(jwt.HTTPToContext()),
+					)...,
+				),
+			),
+		)
+	}
+
 	r.Methods("GET").Path(`/{namespace}/{name}/versions`).Handler(
 		instrumentation.WrapHandler(
 			httptransport.NewServer(
@@ -39,6 +69,41 @@ func MakeHandler(svc Service, auth endpoint.Middleware, metrics *o11y.ProviderMe
 					options,
 					httptransport.ServerBefore(extractMuxVars(varNamespace, varName)),
 					httptransport.ServerBefore(jwt.HTTPToContext()),
+					httptransport.ServerBefore(core.DeprecationsToContext),
+					httptransport.ServerAfter(core.DeprecationsToHeader),
+				)...,
+			),
+		),
+	)
+
+	// The details are registered after the version listing, as the version would match the versions otherwise
+	for _, path := range []string{`/{namespace}/{name}`, `/{namespace}/{name}/{version}`} {
+		r.Methods("GET").Path(path).Handler(
+			instrumentation.WrapHandler(
+				httptransport.NewServer(
+					auth(detailsEndpoint(svc)),
+					decodeDetailsRequest,
+					httptransport.EncodeJSONResponse,
+					append(
+						options,
+						httptransport.ServerBefore(extractMuxVars(varNamespace, varName, varVersion)),
+						httptransport.ServerBefore(jwt.HTTPToContext()),
+					)...,
+				),
+			),
+		)
+	}
+
+	r.Methods("GET").Path(`/{namespace}/{name}/{version}/provenance`).Handler(
+		instrumentation.WrapHandler(
+			httptransport.NewServer(
+				auth(provenanceEndpoint(svc)),
+				decodeProvenanceRequest,
+				httptransport.EncodeJSONResponse,
+				append(
+					options,
+					httptransport.ServerBefore(extractMuxVars(varNamespace, varName, varVersion)),
+					httptransport.ServerBefore(jwt.HTTPToContext()),
 				)...,
 			),
 		),
@@ -55,11 +120,51 @@ func MakeHandler(svc Service, auth endpoint.Middleware, metrics *o11y.ProviderMe
 					httptransport.ServerBefore(extractMuxVars(varNamespace, varName, varOS, varArch, varVersion)),
 					httptransport.ServerBefore(core.ExtractRootUrl()),
 					httptransport.ServerBefore(jwt.HTTPToContext()),
+					httptransport.ServerBefore(core.DeprecationsToContext),
+					httptransport.ServerAfter(core.DeprecationsToHeader),
 				)...,
 			),
 		),
 	)
 
+	r.Methods("POST").Path(`/{namespace}/{name}/{version}`).Handler(
+		core.ExtendDeadlines(
+			core.PublishTimeout,
+			instrumentation.WrapHandler(
+				httptransport.NewServer(
+					auth(publishEndpoint(svc, metrics)),
+					decodePublishRequest,
+					encodePublishResponse,
+					append(
+						options,
+						httptransport.ServerBefore(extractMuxVars(varNamespace, varName, varVersion)),
+						httptransport.ServerBefore(core.ProvenanceToContext),
+						httptransport.ServerBefore(jwt.HTTPToContext()),
+					)...,
+				),
+			),
+		),
+	)
+
+	r.Methods("POST").Path(`/{namespace}/{name}/{version}/promote`).Handler(
+		core.ExtendDeadlines(
+			core.PublishTimeout,
+			instrumentation.WrapHandler(
+				httptransport.NewServer(
+					auth(promoteEndpoint(svc)),
+					decodePromoteRequest,
+					encodePublishResponse,
+					append(
+						options,
+						httptransport.ServerBefore(extractMuxVars(varNamespace, varName, varVersion)),
+						httptransport.ServerBefore(core.ProvenanceToContext),
+						httptransport.ServerBefore(jwt.HTTPToContext()),
+					)...,
+				),
+			),
+		),
+	)
+
 	return r
 }
 
@@ -80,6 +185,71 @@ func decodeListRequest(ctx context.Context, r *http.Request) (interface{}, error
 	}, nil
 }
 
+// decodeDetailsRequest decodes the provider detail requests, the version is optional
+func decodeDetailsRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	req, err := decodeListRequest(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	list := req.(listRequest)
+
+	version, _ := ctx.Value(varVersion).(string)
+	return detailsRequest{
+		namespace: list.namespace,
+		name:      list.name,
+		version:   version,
+	}, nil
+}
+
+func decodeProvenanceRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	req, err := decodeDetailsRequest(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	details := req.(detailsRequest)
+
+	if details.version == "" {
+		return nil, fmt.Errorf("%w: version", core.ErrVarMissing)
+	}
+	return provenanceRequest(details), nil
+}
+
+// decodeCatalogRequest decodes the provider listing and search requests.
+// The query parameters follow the module listing of the public Terraform registry.
+func decodeCatalogRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	query := r.URL.Query()
+	page, err := core.ParsePage(query)
+	if err != nil {
+		return nil, err
+	}
+
+	var filter ListFilter
+	if namespace, ok := ctx.Value(varNamespace).(string); ok {
+		filter.Namespace = namespace
+	} else {
+		filter.Namespace = query.Get("namespace")
+	}
+
+	if strings.HasSuffix(r.URL.Path, "/search") {
+		filter.Query = query.Get("q")
+		if filter.Query == "" {
+			return nil, fmt.Errorf("%w: q", core.ErrVarMissing)
+		}
+	}
+
+	// The URL of the request is used for the links to the next and previous page, so the path must not be stripped
+	u, err := url.ParseRequestURI(r.RequestURI)
+	if err != nil {
+		u = r.URL
+	}
+
+	return catalogRequest{
+		filter: filter,
+		page:   page,
+		url:    u,
+	}, nil
+}
+
 func decodeDownloadRequest(ctx context.Context, r *http.Request) (interface{}, error) {
 	namespace, ok := ctx.Value(varNamespace).(string)
 	if !ok {
@@ -115,11 +285,133 @@ func decodeDownloadRequest(ctx context.Context, r *http.Request) (interface{}, e
 	}, nil
 }
 
+// decodePublishRequest defers reading the release files until the request is authorized and publishing is enabled
+func decodePublishRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	req, err := decodeListRequest(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	list := req.(listRequest)
+
+	version, ok := ctx.Value(varVersion).(string)
+	if !ok {
+		return nil, fmt.Errorf("%w: version", core.ErrVarMissing)
+	}
+
+	return publishRequest{
+		namespace: list.namespace,
+		name:      list.name,
+		version:   version,
+		read: func(maxSize int64) (*Release, error) {
+			return readRelease(r, list.namespace, list.name, version, maxSize)
+		},
+	}, nil
+}
+
+// readRelease reads the release files from the multipart form.
+// Files are identified by their file name, while the names of the form fields are ignored.
+func readRelease(r *http.Request, namespace, name, version string, maxSize int64) (*Release, error) {
+	r.Body = http.MaxBytesReader(nil, r.Body, maxSize)
+
+	// Files exceeding the memory limit are stored in temporary files, which are removed by the http.Server after the request
+	if err := r.ParseMultipartForm(maxPublishMemory); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return nil, fmt.Errorf("%w: files exceed %d bytes", ErrReleaseTooLarge, maxBytesErr.Limit)
+		}
+		return nil, fmt.Errorf("%w: failed to parse multipart form: %v", ErrInvalidRelease, err)
+	}
+
+	release := &Release{
+		Namespace: namespace,
+		Name:      name,
+		Version:   version,
+	}
+	releaseErr := &ReleaseError{}
+	for _, headers := range r.MultipartForm.File {
+		for _, header := range headers {
+			f := ReleaseFile{
+				Name: path.Base(header.Filename),
+				Open: func() (io.ReadCloser, error) {
+					return header.Open()
+				},
+			}
+
+			switch {
+			case strings.HasSuffix(f.Name, "_SHA256SUMS"):
+				if release.SHA256Sums.Open != nil {
+					releaseErr.add(f.Name, errors.New("only a single SHA256SUMS file is allowed"))
+				}
+				release.SHA256Sums = f
+			case strings.HasSuffix(f.Name, "_SHA256SUMS.sig"):
+				if release.Signature.Open != nil {
+					releaseErr.add(f.Name, errors.New("only a single SHA256SUMS signature is allowed"))
+				}
+				release.Signature = f
+			default:
+				release.Files = append(release.Files, f)
+			}
+		}
+	}
+	sort.Slice(release.Files, func(i, j int) bool {
+		return release.Files[i].Name < release.Files[j].Name
+	})
+
+	p := core.Provider{Name: release.Name, Version: release.Version}
+	if release.SHA256Sums.Open == nil {
+		releaseErr.add(p.ShasumFileName(), errors.New("file is missing"))
+	}
+	if release.Signature.Open == nil {
+		releaseErr.add(p.ShasumSignatureFileName(), errors.New("file is missing"))
+	}
+	if len(releaseErr.Files) > 0 {
+		return nil, releaseErr
+	}
+
+	return release, nil
+}
+
+// decodePromoteRequest reads the target namespace from the JSON body, e.g. {"target_namespace": "prod"}
+func decodePromoteRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	req, err := decodeProvenanceRequest(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	release := req.(provenanceRequest)
+
+	var body core.PromoteRequestBody
+	if err := json.NewDecoder(http.MaxBytesReader(nil, r.Body, maxPromoteRequestSize)).Decode(&body); err != nil {
+		return nil, fmt.Errorf("%w: failed to decode body: %v", core.ErrVarType, err)
+	}
+	if body.TargetNamespace == "" {
+		return nil, fmt.Errorf("%w: target_namespace", core.ErrVarMissing)
+	}
+
+	return promoteRequest{
+		namespace:       release.namespace,
+		name:            release.name,
+		version:         release.version,
+		targetNamespace: body.TargetNamespace,
+	}, nil
+}
+
 // ErrorEncoder translates domain specific errors to HTTP status codes
 func ErrorEncoder(_ context.Context, err error, w http.ResponseWriter) {
 	var providerError *core.ProviderError
-	if errors.Is(err, ErrProviderNotFound) {
+	var releaseError *ReleaseError
+	if errors.As(err, &releaseError) {
+		encodeReleaseError(releaseError, w)
+		return
+	} else if errors.Is(err, ErrProviderNotFound) {
 		w.WriteHeader(http.StatusNotFound)
+	} else if errors.Is(err, ErrProviderAlreadyExists) {
+		w.WriteHeader(http.StatusConflict)
+	} else if errors.Is(err, ErrInvalidRelease) {
+		w.WriteHeader(http.StatusBadRequest)
+	} else if errors.Is(err, ErrPublishDisabled) {
+		w.WriteHeader(http.StatusForbidden)
+	} else if errors.Is(err, ErrReleaseTooLarge) {
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
 	} else if errors.As(err, &providerError) {
 		w.WriteHeader(providerError.StatusCode)
 	} else {
@@ -140,3 +432,23 @@ func extractMuxVars(keys ...muxVar) httptransport.RequestFunc {
 		return ctx
 	}
 }
+
+// encodeReleaseError reports the validation error of every failing file in addition to the regular error response
+func encodeReleaseError(err *ReleaseError, w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusBadRequest)
+
+	_ = json.NewEncoder(w).Encode(struct {
+		Errors []string    `json:"errors"`
+		Files  []FileError `json:"files"`
+	}{
+		Errors: []string{err.Error()},
+		Files:  err.Files,
+	})
+}
+
+func encodePublishResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusCreated)
+	return json.NewEncoder(w).Encode(response)
+}
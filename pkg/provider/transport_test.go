@@ -0,0 +1,567 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	o11y "github.com/boring-registry/boring-registry/pkg/observability"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/go-kit/kit/endpoint"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+type noopInstrumentation struct{}
+
+func (noopInstrumentation) WrapHandler(handler http.Handler) http.HandlerFunc {
+	return handler.ServeHTTP
+}
+
+// memoryStorage stores the uploaded release files of a single namespace
+type memoryStorage struct {
+	signingKeys *core.SigningKeys
+	files       map[string][]byte
+	provenance  []core.Provenance
+
+	// namespace restricts the files to the namespace, whereas they belong to every namespace if it's empty
+	namespace string
+
+	// namespaceKeys overrides the signing keys of a namespace, a nil value means that the namespace has no signing keys
+	namespaceKeys map[string]*core.SigningKeys
+
+	// promoted records the target namespaces of promoted releases
+	promoted []string
+}
+
+func (s *memoryStorage) GetProvider(_ context.Context, namespace, name, version, os, arch string) (*core.Provider, error) {
+	p := core.Provider{Namespace: namespace, Name: name, Version: version, OS: os, Arch: arch}
+	if s.namespace != "" && s.namespace != namespace {
+		return nil, ErrProviderNotFound
+	}
+	if _, ok := s.files[p.ArchiveFileName()]; !ok {
+		return nil, ErrProviderNotFound
+	}
+	return &p, nil
+}
+
+func (s *memoryStorage) ListProviderVersions(_ context.Context, namespace, name string) (*core.ProviderVersions, error) {
+	platforms := make(map[string][]core.Platform)
+	for filename := range s.files {
+		p, err := core.NewProviderFromArchive(filename)
+		if err != nil || p.Name != name {
+			continue
+		}
+		platforms[p.Version] = append(platforms[p.Version], core.Platform{OS: p.OS, Arch: p.Arch})
+	}
+	if len(platforms) == 0 {
+		return nil, ErrProviderNotFound
+	}
+
+	versions := &core.ProviderVersions{}
+	for version, p := range platforms {
+		versions.Versions = append(versions.Versions, core.ProviderVersion{Namespace: namespace, Name: name, Version: version, Platforms: p})
+	}
+	return versions, nil
+}
+
+func (s *memoryStorage) ListProviders(context.Context, string) ([]core.ProviderVersion, error) {
+	return nil, nil
+}
+
+func (s *memoryStorage) UploadProviderReleaseFiles(ctx context.Context, _, _, filename string, file io.Reader) error {
+	if _, ok := s.files[filename]; ok {
+		return core.ErrObjectAlreadyExists
+	}
+	b, err := io.ReadAll(file)
+	if err != nil {
+		return err
+	}
+	s.files[filename] = b
+
+	p := core.ProvenanceFromContext(ctx)
+	p.Artifact = filename
+	p.SHA256 = fmt.Sprintf("%x", sha256.Sum256(b))
+	s.provenance = append(s.provenance, p)
+	return nil
+}
+
+func (s *memoryStorage) GetProviderProvenance(context.Context, string, string, string) ([]core.Provenance, error) {
+	if len(s.provenance) == 0 {
+		return nil, core.ErrProvenanceNotFound
+	}
+	return s.provenance, nil
+}
+
+func (s *memoryStorage) GetProviderRelease(_ context.Context, namespace, name, version string) (*Release, error) {
+	release := &Release{Namespace: namespace, Name: name, Version: version}
+	p := core.Provider{Name: name, Version: version}
+	for filename, content := range s.files {
+		f := ReleaseFile{
+			Name: filename,
+			Open: func() (io.ReadCloser, error) {
+				return io.NopCloser(bytes.NewReader(content)), nil
+			},
+		}
+		switch filename {
+		case p.ShasumFileName():
+			release.SHA256Sums = f
+		case p.ShasumSignatureFileName():
+			release.Signature = f
+		default:
+			release.Files = append(release.Files, f)
+		}
+	}
+	if release.SHA256Sums.Open == nil {
+		return nil, ErrProviderNotFound
+	}
+	return release, nil
+}
+
+func (s *memoryStorage) CopyProviderRelease(_ context.Context, _, _, _, targetNamespace string) error {
+	s.promoted = append(s.promoted, targetNamespace)
+	return nil
+}
+
+func (s *memoryStorage) DeleteProviderRelease(_ context.Context, _, _, _ string) error {
+	s.files = nil
+	return nil
+}
+
+func (s *memoryStorage) SigningKeys(_ context.Context, namespace string) (*core.SigningKeys, error) {
+	if keys, ok := s.namespaceKeys[namespace]; ok {
+		if keys == nil {
+			return nil, core.ErrObjectNotFound
+		}
+		return keys, nil
+	}
+	return s.signingKeys, nil
+}
+
+func newPublishRequest(t *testing.T, files map[string][]byte) *http.Request {
+	t.Helper()
+
+	body := new(bytes.Buffer)
+	w := multipart.NewWriter(body)
+	for name, content := range files {
+		fw, err := w.CreateFormFile("file", name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = fw.Write(content)
+	}
+	w.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/hashicorp/dummy/1.0.0", body)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	return req
+}
+
+func newTestHandler(storage Storage) http.Handler {
+	return newTestHandlerWithAuth(storage, endpoint.Middleware(func(next endpoint.Endpoint) endpoint.Endpoint { return next }), WithPublishEnabled(true))
+}
+
+func newTestHandlerWithAuth(storage Storage, auth endpoint.Middleware, options ...ServiceOption) http.Handler {
+	labels := []string{o11y.NamespaceLabel, o11y.NameLabel, o11y.VersionLabel, o11y.OsLabel, o11y.ArchLabel}
+	metrics := &o11y.ProviderMetrics{
+		ListVersions: prometheus.NewCounterVec(prometheus.CounterOpts{Name: "list"}, labels[:2]),
+		Download:     prometheus.NewCounterVec(prometheus.CounterOpts{Name: "download"}, labels),
+		Publish:      prometheus.NewCounterVec(prometheus.CounterOpts{Name: "publish"}, labels[:3]),
+	}
+	return MakeHandler(
+		NewService(storage, core.NewProxyUrlService(false, "/proxy"), options...),
+		auth,
+		metrics,
+		noopInstrumentation{},
+		httptransport.ServerErrorEncoder(ErrorEncoder),
+	)
+}
+
+func TestMakeHandler_Publish(t *testing.T) {
+	t.Parallel()
+
+	entity, signingKeys := testSigningKeys(t)
+
+	archive := []byte("linux")
+	sums := []byte(fmt.Sprintf("%x  terraform-provider-dummy_1.0.0_linux_amd64.zip\n", sha256.Sum256(archive)))
+	sig := new(bytes.Buffer)
+	if err := openpgp.DetachSign(sig, entity, bytes.NewReader(sums), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name           string
+		existing       map[string][]byte
+		files          map[string][]byte
+		expectedStatus int
+		expectedFiles  []FileError
+	}{
+		{
+			name: "valid release",
+			files: map[string][]byte{
+				"terraform-provider-dummy_1.0.0_SHA256SUMS":      sums,
+				"terraform-provider-dummy_1.0.0_SHA256SUMS.sig":  sig.Bytes(),
+				"terraform-provider-dummy_1.0.0_linux_amd64.zip": archive,
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name: "checksum mismatch",
+			files: map[string][]byte{
+				"terraform-provider-dummy_1.0.0_SHA256SUMS":      sums,
+				"terraform-provider-dummy_1.0.0_SHA256SUMS.sig":  sig.Bytes(),
+				"terraform-provider-dummy_1.0.0_linux_amd64.zip": []byte("corrupted"),
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedFiles: []FileError{
+				{File: "terraform-provider-dummy_1.0.0_linux_amd64.zip", Error: "checksum doesn't match the SHA256SUMS file"},
+			},
+		},
+		{
+			name: "missing signature",
+			files: map[string][]byte{
+				"terraform-provider-dummy_1.0.0_SHA256SUMS":      sums,
+				"terraform-provider-dummy_1.0.0_linux_amd64.zip": archive,
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedFiles: []FileError{
+				{File: "terraform-provider-dummy_1.0.0_SHA256SUMS.sig", Error: "file is missing"},
+			},
+		},
+		{
+			name: "existing version",
+			existing: map[string][]byte{
+				"terraform-provider-dummy_1.0.0_linux_amd64.zip": archive,
+			},
+			files: map[string][]byte{
+				"terraform-provider-dummy_1.0.0_SHA256SUMS":      sums,
+				"terraform-provider-dummy_1.0.0_SHA256SUMS.sig":  sig.Bytes(),
+				"terraform-provider-dummy_1.0.0_linux_amd64.zip": archive,
+			},
+			expectedStatus: http.StatusConflict,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			storage := &memoryStorage{signingKeys: signingKeys, files: map[string][]byte{}}
+			for name, content := range tc.existing {
+				storage.files[name] = content
+			}
+
+			rec := httptest.NewRecorder()
+			newTestHandler(storage).ServeHTTP(rec, newPublishRequest(t, tc.files))
+			assert.Equal(t, tc.expectedStatus, rec.Code, rec.Body.String())
+
+			if tc.expectedStatus == http.StatusCreated {
+				assert.Len(t, storage.files, len(tc.files))
+				return
+			}
+			assert.Len(t, storage.files, len(tc.existing))
+
+			if tc.expectedFiles != nil {
+				var resp struct {
+					Files []FileError `json:"files"`
+				}
+				assert.NoError(t, json.NewDecoder(strings.NewReader(rec.Body.String())).Decode(&resp))
+				assert.Equal(t, tc.expectedFiles, resp.Files)
+			}
+		})
+	}
+}
+
+// readRecorder records whether the request body was read
+type readRecorder struct {
+	io.Reader
+	read bool
+}
+
+func (r *readRecorder) Read(p []byte) (int, error) {
+	r.read = true
+	return r.Reader.Read(p)
+}
+
+func TestMakeHandler_PublishRejected(t *testing.T) {
+	t.Parallel()
+
+	allow := endpoint.Middleware(func(next endpoint.Endpoint) endpoint.Endpoint { return next })
+	deny := endpoint.Middleware(func(endpoint.Endpoint) endpoint.Endpoint {
+		return func(context.Context, interface{}) (interface{}, error) {
+			return nil, core.ErrUnauthorized
+		}
+	})
+
+	testCases := []struct {
+		name           string
+		auth           endpoint.Middleware
+		options        []ServiceOption
+		expectedStatus int
+		expectedRead   bool
+	}{
+		{
+			name:           "unauthenticated",
+			auth:           deny,
+			options:        []ServiceOption{WithPublishEnabled(true)},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "publishing disabled",
+			auth:           allow,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "release too large",
+			auth:           allow,
+			options:        []ServiceOption{WithPublishEnabled(true), WithMaxReleaseSize(16)},
+			expectedStatus: http.StatusRequestEntityTooLarge,
+			expectedRead:   true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			storage := &memoryStorage{files: map[string][]byte{}}
+			req := newPublishRequest(t, map[string][]byte{
+				"terraform-provider-dummy_1.0.0_linux_amd64.zip": []byte("linux"),
+			})
+			body := &readRecorder{Reader: req.Body}
+			req.Body = io.NopCloser(body)
+
+			rec := httptest.NewRecorder()
+			newTestHandlerWithAuth(storage, tc.auth, tc.options...).ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedStatus, rec.Code, rec.Body.String())
+			assert.Equal(t, tc.expectedRead, body.read)
+			assert.Empty(t, storage.files)
+		})
+	}
+}
+
+func TestMakeHandler_Promote(t *testing.T) {
+	t.Parallel()
+
+	entity, signingKeys := testSigningKeys(t)
+
+	archive := []byte("linux")
+	sums := []byte(fmt.Sprintf("%x  terraform-provider-dummy_1.0.0_linux_amd64.zip\n", sha256.Sum256(archive)))
+	sig := new(bytes.Buffer)
+	if err := openpgp.DetachSign(sig, entity, bytes.NewReader(sums), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name           string
+		path           string
+		body           string
+		namespaceKeys  map[string]*core.SigningKeys
+		expectedStatus int
+	}{
+		{
+			name:           "valid promotion",
+			path:           "/hashicorp/dummy/1.0.0/promote",
+			body:           `{"target_namespace": "prod"}`,
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "existing version in target namespace",
+			path:           "/hashicorp/dummy/1.0.0/promote",
+			body:           `{"target_namespace": "hashicorp"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "missing target namespace",
+			path:           "/hashicorp/dummy/1.0.0/promote",
+			body:           `{}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "oversized body",
+			path:           "/hashicorp/dummy/1.0.0/promote",
+			body:           fmt.Sprintf(`{"target_namespace": "prod", "padding": "%s"}`, strings.Repeat("x", maxPromoteRequestSize)),
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "missing version",
+			path:           "/hashicorp/dummy/2.0.0/promote",
+			body:           `{"target_namespace": "prod"}`,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "target namespace without signing keys",
+			path:           "/hashicorp/dummy/1.0.0/promote",
+			body:           `{"target_namespace": "prod"}`,
+			namespaceKeys:  map[string]*core.SigningKeys{"prod": nil},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "release not signed by the keys of the target namespace",
+			path:           "/hashicorp/dummy/1.0.0/promote",
+			body:           `{"target_namespace": "prod"}`,
+			namespaceKeys:  map[string]*core.SigningKeys{"prod": {}},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			storage := &memoryStorage{
+				signingKeys: signingKeys,
+				files: map[string][]byte{
+					"terraform-provider-dummy_1.0.0_SHA256SUMS":      sums,
+					"terraform-provider-dummy_1.0.0_SHA256SUMS.sig":  sig.Bytes(),
+					"terraform-provider-dummy_1.0.0_linux_amd64.zip": archive,
+				},
+				namespace:     "hashicorp",
+				namespaceKeys: tc.namespaceKeys,
+			}
+
+			rec := httptest.NewRecorder()
+			newTestHandler(storage).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, tc.path, strings.NewReader(tc.body)))
+			assert.Equal(t, tc.expectedStatus, rec.Code, rec.Body.String())
+
+			if tc.expectedStatus != http.StatusCreated {
+				assert.Empty(t, storage.promoted)
+				return
+			}
+			assert.Equal(t, []string{"prod"}, storage.promoted)
+
+			var version core.ProviderVersion
+			assert.NoError(t, json.NewDecoder(rec.Body).Decode(&version))
+			assert.Equal(t, "prod", version.Namespace)
+			assert.Equal(t, []core.Platform{{OS: "linux", Arch: "amd64"}}, version.Platforms)
+		})
+	}
+}
+
+func TestMakeHandler_Details(t *testing.T) {
+	t.Parallel()
+
+	storage := &memoryStorage{files: map[string][]byte{
+		"terraform-provider-dummy_1.2.0_linux_amd64.zip":     nil,
+		"terraform-provider-dummy_1.10.0_linux_amd64.zip":    nil,
+		"terraform-provider-dummy_1.10.0_darwin_arm64.zip":   nil,
+		"terraform-provider-dummy_2.0.0-rc1_linux_amd64.zip": nil,
+	}}
+	handler := newTestHandler(storage)
+
+	testCases := []struct {
+		name              string
+		path              string
+		expectedStatus    int
+		expectedVersion   string
+		expectedPlatforms int
+	}{
+		{
+			name:              "latest version",
+			path:              "/hashicorp/dummy",
+			expectedStatus:    http.StatusOK,
+			expectedVersion:   "1.10.0",
+			expectedPlatforms: 2,
+		},
+		{
+			name:              "specific version",
+			path:              "/hashicorp/dummy/2.0.0-rc1",
+			expectedStatus:    http.StatusOK,
+			expectedVersion:   "2.0.0-rc1",
+			expectedPlatforms: 1,
+		},
+		{
+			name:           "missing version",
+			path:           "/hashicorp/dummy/3.0.0",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "missing provider",
+			path:           "/hashicorp/random",
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tc.path, nil))
+			assert.Equal(t, tc.expectedStatus, rec.Code, rec.Body.String())
+			if tc.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var details detailsResponse
+			assert.NoError(t, json.NewDecoder(rec.Body).Decode(&details))
+			assert.Equal(t, "hashicorp/dummy/"+tc.expectedVersion, details.ID)
+			assert.Equal(t, tc.expectedVersion, details.Version)
+			assert.Equal(t, []string{"1.2.0", "1.10.0", "2.0.0-rc1"}, details.Versions)
+			assert.Len(t, details.Platforms, tc.expectedPlatforms)
+		})
+	}
+
+	t.Run("versions are still listed", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/hashicorp/dummy/versions", nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"versions"`)
+	})
+}
+
+func TestMakeHandler_Provenance(t *testing.T) {
+	t.Parallel()
+
+	entity, signingKeys := testSigningKeys(t)
+
+	archive := []byte("linux")
+	sums := []byte(fmt.Sprintf("%x  terraform-provider-dummy_1.0.0_linux_amd64.zip\n", sha256.Sum256(archive)))
+	sig := new(bytes.Buffer)
+	if err := openpgp.DetachSign(sig, entity, bytes.NewReader(sums), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	storage := &memoryStorage{signingKeys: signingKeys, files: map[string][]byte{}}
+	handler := newTestHandler(storage)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/hashicorp/dummy/1.0.0/provenance", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	req := newPublishRequest(t, map[string][]byte{
+		"terraform-provider-dummy_1.0.0_SHA256SUMS":      sums,
+		"terraform-provider-dummy_1.0.0_SHA256SUMS.sig":  sig.Bytes(),
+		"terraform-provider-dummy_1.0.0_linux_amd64.zip": archive,
+	})
+	req.Header.Set(core.HeaderGitCommit, "0123abc")
+	req.Header.Set(core.HeaderCIRunURL, "https://ci.example.com/runs/1")
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/hashicorp/dummy/1.0.0/provenance", nil))
+	assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var resp provenanceResponse
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	assert.Len(t, resp.Artifacts, 3)
+	for _, p := range resp.Artifacts {
+		assert.Equal(t, "0123abc", p.GitCommit)
+		assert.Equal(t, "https://ci.example.com/runs/1", p.CIRunURL)
+		if p.Artifact == "terraform-provider-dummy_1.0.0_linux_amd64.zip" {
+			assert.Equal(t, fmt.Sprintf("%x", sha256.Sum256(archive)), p.SHA256)
+		}
+	}
+}
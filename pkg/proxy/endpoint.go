@@ -47,6 +47,10 @@ func proxyEndpoint(storage Storage, metrics *o11y.ProxyMetrics) endpoint.Endpoin
 			return nil, ErrInvalidRequestUrl
 		}
 
+		if authorizer, ok := storage.(RequestAuthorizer); ok {
+			authorizer.AuthorizeRequest(req)
+		}
+
 		// Send the HTTP request
 		client := &http.Client{}
 		resp, err := client.Do(req)
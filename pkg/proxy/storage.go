@@ -2,6 +2,7 @@ package proxy
 
 import (
 	"context"
+	"net/http"
 )
 
 // Storage represents the Storage of Terraform providers and modules.
@@ -9,3 +10,9 @@ type Storage interface {
 	// Get a valid download URL from proxy link
 	GetDownloadUrl(ctx context.Context, url string) (string, error)
 }
+
+// RequestAuthorizer is optionally implemented by a Storage which requires credentials to download from the URL
+// returned by GetDownloadUrl.
+type RequestAuthorizer interface {
+	AuthorizeRequest(req *http.Request)
+}
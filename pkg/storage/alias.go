@@ -0,0 +1,343 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/boring-registry/boring-registry/pkg/alias"
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/module"
+	"github.com/boring-registry/boring-registry/pkg/provider"
+	"github.com/boring-registry/boring-registry/pkg/proxy"
+
+	"github.com/hashicorp/hcl/v2/hclsimple"
+)
+
+// storedAliases is the content of the object holding the aliases managed through the API
+type storedAliases struct {
+	Aliases []alias.Alias `json:"aliases"`
+}
+
+// listAliases returns the stored aliases, which are empty if none were stored yet
+func listAliases(ctx context.Context, s objectStorage) ([]alias.Alias, error) {
+	prefix, _ := s.layout()
+
+	var stored storedAliases
+	err := readSidecar(ctx, s, aliasesPath(prefix), alias.ErrAliasNotFound, &stored)
+	if err != nil && !errors.Is(err, alias.ErrAliasNotFound) {
+		return nil, err
+	}
+	return stored.Aliases, nil
+}
+
+// addAlias adds the alias to the stored aliases
+func addAlias(ctx context.Context, s objectStorage, a alias.Alias) error {
+	prefix, _ := s.layout()
+	return updateSidecar(ctx, s, aliasesPath(prefix), func(stored *storedAliases) error {
+		for _, existing := range stored.Aliases {
+			if existing.ID() == a.ID() {
+				return fmt.Errorf("%w: %s", alias.ErrAliasAlreadyExists, a.ID())
+			}
+		}
+		stored.Aliases = append(stored.Aliases, a)
+		return nil
+	})
+}
+
+// deleteAlias removes the alias of the type and address from the stored aliases
+func deleteAlias(ctx context.Context, s objectStorage, aliasType, from string) error {
+	id := alias.Alias{Type: aliasType, From: from}.ID()
+	prefix, _ := s.layout()
+	return updateSidecar(ctx, s, aliasesPath(prefix), func(stored *storedAliases) error {
+		remaining := make([]alias.Alias, 0, len(stored.Aliases))
+		for _, a := range stored.Aliases {
+			if a.ID() != id {
+				remaining = append(remaining, a)
+			}
+		}
+		if len(remaining) == len(stored.Aliases) {
+			return fmt.Errorf("%w: %s", alias.ErrAliasNotFound, id)
+		}
+		stored.Aliases = remaining
+		return nil
+	})
+}
+
+// ParseAliases reads the aliases from an HCL file like:
+//
+//	alias "module" "old-ns/vpc/aws" {
+//	  to          = "new-ns/vpc/aws"
+//	  deprecation = "The module moved to new-ns/vpc/aws, please update the source"
+//	}
+//
+//	alias "namespace" "netwrok" {
+//	  to = "network"
+//	}
+func ParseAliases(file string) ([]alias.Alias, error) {
+	config := struct {
+		Aliases []alias.Alias `hcl:"alias,block"`
+	}{}
+	if err := hclsimple.DecodeFile(file, nil, &config); err != nil {
+		return nil, err
+	}
+	return config.Aliases, nil
+}
+
+// AliasStorage decorates a Storage and redirects the addresses of renamed namespaces, modules and providers to their new addresses.
+// Versions, downloads, docs and provenance of an old address are served from the new address, and a deprecation warning
+// of the alias is recorded for the response to the request. Publishing to an old address is rejected, as it would never be served.
+// The aliases of the alias config file can't be changed, whereas the aliases managed through the API are stored in the decorated Storage.
+type AliasStorage struct {
+	Storage
+	configured alias.Aliases
+
+	expiry   time.Duration
+	mu       sync.Mutex
+	stored   alias.Aliases
+	listedAt time.Time
+}
+
+// ListAliases returns the configured and the stored aliases
+func (s *AliasStorage) ListAliases(ctx context.Context) ([]alias.Alias, error) {
+	stored, err := s.Storage.ListAliases(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return append(s.configured[:len(s.configured):len(s.configured)], stored...), nil
+}
+
+// AddAlias stores the alias, unless it's configured in the alias config file
+func (s *AliasStorage) AddAlias(ctx context.Context, a alias.Alias) error {
+	if s.isConfigured(a.Type, a.From) {
+		return fmt.Errorf("%w: %s", alias.ErrAliasConfigured, a.ID())
+	}
+
+	defer s.invalidate()
+	return s.Storage.AddAlias(ctx, a)
+}
+
+// DeleteAlias removes the stored alias, whereas configured aliases can only be removed from the alias config file
+func (s *AliasStorage) DeleteAlias(ctx context.Context, aliasType, from string) error {
+	if s.isConfigured(aliasType, from) {
+		return fmt.Errorf("%w: %s/%s", alias.ErrAliasConfigured, aliasType, from)
+	}
+
+	defer s.invalidate()
+	return s.Storage.DeleteAlias(ctx, aliasType, from)
+}
+
+func (s *AliasStorage) GetModule(ctx context.Context, namespace, name, provider, version string) (core.Module, error) {
+	m := s.resolveModule(ctx, namespace, name, provider)
+	return s.Storage.GetModule(ctx, m.Namespace, m.Name, m.Provider, version)
+}
+
+func (s *AliasStorage) ListModuleVersions(ctx context.Context, namespace, name, provider string) ([]core.Module, error) {
+	m := s.resolveModule(ctx, namespace, name, provider)
+	return s.Storage.ListModuleVersions(ctx, m.Namespace, m.Name, m.Provider)
+}
+
+func (s *AliasStorage) DownloadModule(ctx context.Context, namespace, name, provider, version string) (io.ReadCloser, error) {
+	m := s.resolveModule(ctx, namespace, name, provider)
+	return s.Storage.DownloadModule(ctx, m.Namespace, m.Name, m.Provider, version)
+}
+
+func (s *AliasStorage) GetModuleDocs(ctx context.Context, namespace, name, provider, version string) (*module.Docs, error) {
+	m := s.resolveModule(ctx, namespace, name, provider)
+	return s.Storage.GetModuleDocs(ctx, m.Namespace, m.Name, m.Provider, version)
+}
+
+func (s *AliasStorage) GetModuleInfo(ctx context.Context, namespace, name, provider, version string) (*module.Info, error) {
+	m := s.resolveModule(ctx, namespace, name, provider)
+	return s.Storage.GetModuleInfo(ctx, m.Namespace, m.Name, m.Provider, version)
+}
+
+func (s *AliasStorage) GetModuleProvenance(ctx context.Context, namespace, name, provider, version string) (*core.Provenance, error) {
+	m := s.resolveModule(ctx, namespace, name, provider)
+	return s.Storage.GetModuleProvenance(ctx, m.Namespace, m.Name, m.Provider, version)
+}
+
+// UploadModule rejects uploads to old addresses
+func (s *AliasStorage) UploadModule(ctx context.Context, namespace, name, provider, version string, body io.Reader) (core.Module, error) {
+	if err := s.rejectModule(ctx, namespace, name, provider); err != nil {
+		return core.Module{}, err
+	}
+	return s.Storage.UploadModule(ctx, namespace, name, provider, version, body)
+}
+
+// CopyModule copies the module version from its new address, and rejects copies to old addresses
+func (s *AliasStorage) CopyModule(ctx context.Context, src, dst core.Module) (core.Module, error) {
+	if err := s.rejectModule(ctx, dst.Namespace, dst.Name, dst.Provider); err != nil {
+		return core.Module{}, err
+	}
+
+	resolved := s.resolveModule(ctx, src.Namespace, src.Name, src.Provider)
+	resolved.Version = src.Version
+	return s.Storage.CopyModule(ctx, resolved, dst)
+}
+
+func (s *AliasStorage) GetProvider(ctx context.Context, namespace, name, version, os, arch string) (*core.Provider, error) {
+	namespace, name = s.resolveProvider(ctx, namespace, name)
+	return s.Storage.GetProvider(ctx, namespace, name, version, os, arch)
+}
+
+// ListProviderVersions adds the deprecation warning of the alias to the versions, which Terraform shows to the user
+func (s *AliasStorage) ListProviderVersions(ctx context.Context, namespace, name string) (*core.ProviderVersions, error) {
+	resolvedNamespace, resolvedName, a := s.aliases(ctx).ResolveProvider(namespace, name)
+	versions, err := s.Storage.ListProviderVersions(ctx, resolvedNamespace, resolvedName)
+	if err != nil || a == nil || a.Deprecation == "" {
+		return versions, err
+	}
+
+	warning := deprecationWarning(alias.TypeProvider, namespace+"/"+name, resolvedNamespace+"/"+resolvedName, a)
+	core.Deprecate(ctx, warning)
+	res := *versions
+	res.Warnings = append(res.Warnings[:len(res.Warnings):len(res.Warnings)], warning)
+	return &res, nil
+}
+
+func (s *AliasStorage) GetProviderProvenance(ctx context.Context, namespace, name, version string) ([]core.Provenance, error) {
+	namespace, name = s.resolveProvider(ctx, namespace, name)
+	return s.Storage.GetProviderProvenance(ctx, namespace, name, version)
+}
+
+func (s *AliasStorage) GetProviderRelease(ctx context.Context, namespace, name, version string) (*provider.Release, error) {
+	namespace, name = s.resolveProvider(ctx, namespace, name)
+	return s.Storage.GetProviderRelease(ctx, namespace, name, version)
+}
+
+// UploadProviderReleaseFiles rejects uploads to old addresses
+func (s *AliasStorage) UploadProviderReleaseFiles(ctx context.Context, namespace, name, filename string, file io.Reader) error {
+	if resolvedNamespace, resolvedName, a := s.aliases(ctx).ResolveProvider(namespace, name); a != nil {
+		return fmt.Errorf("%w: %s/%s has moved to %s/%s", provider.ErrPublishDisabled, namespace, name, resolvedNamespace, resolvedName)
+	}
+	return s.Storage.UploadProviderReleaseFiles(ctx, namespace, name, filename, file)
+}
+
+// CopyProviderRelease copies the provider release from its new address, and rejects copies to old addresses
+func (s *AliasStorage) CopyProviderRelease(ctx context.Context, namespace, name, version, targetNamespace string) error {
+	if resolvedNamespace, resolvedName, a := s.aliases(ctx).ResolveProvider(targetNamespace, name); a != nil {
+		return fmt.Errorf("%w: %s/%s has moved to %s/%s", provider.ErrPublishDisabled, targetNamespace, name, resolvedNamespace, resolvedName)
+	}
+
+	namespace, name = s.resolveProvider(ctx, namespace, name)
+	return s.Storage.CopyProviderRelease(ctx, namespace, name, version, targetNamespace)
+}
+
+// AuthorizeRequest forwards the credentials of the decorated Storage to the download proxy
+func (s *AliasStorage) AuthorizeRequest(req *http.Request) {
+	if authorizer, ok := s.Storage.(proxy.RequestAuthorizer); ok {
+		authorizer.AuthorizeRequest(req)
+	}
+}
+
+// resolveModule returns the new address of the module and records the deprecation warning of its alias
+func (s *AliasStorage) resolveModule(ctx context.Context, namespace, name, provider string) core.Module {
+	requested := core.Module{Namespace: namespace, Name: name, Provider: provider}
+	m, a := s.aliases(ctx).ResolveModule(requested)
+	if a != nil && a.Deprecation != "" {
+		core.Deprecate(ctx, deprecationWarning(alias.TypeModule, requested.ID(false), m.ID(false), a))
+	}
+	return m
+}
+
+// resolveProvider returns the new address of the provider and records the deprecation warning of its alias
+func (s *AliasStorage) resolveProvider(ctx context.Context, namespace, name string) (string, string) {
+	resolvedNamespace, resolvedName, a := s.aliases(ctx).ResolveProvider(namespace, name)
+	if a != nil && a.Deprecation != "" {
+		core.Deprecate(ctx, deprecationWarning(alias.TypeProvider, namespace+"/"+name, resolvedNamespace+"/"+resolvedName, a))
+	}
+	return resolvedNamespace, resolvedName
+}
+
+func (s *AliasStorage) rejectModule(ctx context.Context, namespace, name, provider string) error {
+	requested := core.Module{Namespace: namespace, Name: name, Provider: provider}
+	if m, a := s.aliases(ctx).ResolveModule(requested); a != nil {
+		return fmt.Errorf("%w: %s has moved to %s", module.ErrPublishDisabled, requested.ID(false), m.ID(false))
+	}
+	return nil
+}
+
+func deprecationWarning(kind, from, to string, a *alias.Alias) string {
+	return fmt.Sprintf("The %s %s has moved to %s. %s", kind, from, to, a.Deprecation)
+}
+
+func (s *AliasStorage) isConfigured(aliasType, from string) bool {
+	for _, a := range s.configured {
+		if a.Type == aliasType && a.From == from {
+			return true
+		}
+	}
+	return false
+}
+
+// aliases returns the configured and the stored aliases.
+// The stored aliases are read again once they are older than the expiry, and the previous ones are kept if reading them fails.
+func (s *AliasStorage) aliases(ctx context.Context) alias.Aliases {
+	s.mu.Lock()
+	stored, listedAt := s.stored, s.listedAt
+	s.mu.Unlock()
+
+	if listedAt.IsZero() || time.Since(listedAt) >= s.expiry {
+		listed, err := s.Storage.ListAliases(ctx)
+		if err != nil {
+			slog.Warn("failed to list aliases", slog.String("err", err.Error()))
+		} else {
+			stored = listed
+		}
+
+		s.mu.Lock()
+		s.stored, s.listedAt = stored, time.Now()
+		s.mu.Unlock()
+	}
+
+	return append(s.configured[:len(s.configured):len(s.configured)], stored...)
+}
+
+func (s *AliasStorage) invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.listedAt = time.Time{}
+}
+
+// AliasStorageOption configures an AliasStorage
+type AliasStorageOption func(*AliasStorage)
+
+// WithAliasStorageExpiry configures how long the stored aliases are cached before they are read again
+func WithAliasStorageExpiry(d time.Duration) AliasStorageOption {
+	return func(s *AliasStorage) {
+		s.expiry = d
+	}
+}
+
+// NewAliasStorage returns an AliasStorage decorating the Storage, which validates the configured aliases
+func NewAliasStorage(s Storage, configured []alias.Alias, options ...AliasStorageOption) (*AliasStorage, error) {
+	a := &AliasStorage{
+		Storage: s,
+		expiry:  time.Minute,
+	}
+
+	for _, c := range configured {
+		if err := c.Validate(); err != nil {
+			return nil, err
+		}
+		if a.isConfigured(c.Type, c.From) {
+			return nil, fmt.Errorf("alias %s is configured twice", c.ID())
+		}
+		a.configured = append(a.configured, c)
+	}
+	if err := a.configured.Check(); err != nil {
+		return nil, err
+	}
+
+	for _, option := range options {
+		option(a)
+	}
+
+	return a, nil
+}
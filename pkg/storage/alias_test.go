@@ -0,0 +1,169 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/boring-registry/boring-registry/pkg/alias"
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/module"
+	"github.com/boring-registry/boring-registry/pkg/provider"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAliasStorage(t *testing.T) {
+	t.Parallel()
+
+	s := newTestPluginStorage(t, t.TempDir())
+	ctx := context.Background()
+
+	_, err := s.UploadModule(ctx, "network", "vpc", "aws", "1.0.0", strings.NewReader("archive"))
+	assert.NoError(t, err)
+	sums := fmt.Sprintf("%x  terraform-provider-dummy_1.0.0_linux_amd64.zip\n", sha256.Sum256([]byte("linux")))
+	assert.NoError(t, s.UploadProviderReleaseFiles(ctx, "vendor", "dummy", "terraform-provider-dummy_1.0.0_SHA256SUMS", strings.NewReader(sums)))
+	assert.NoError(t, s.UploadProviderReleaseFiles(ctx, "vendor", "dummy", "terraform-provider-dummy_1.0.0_linux_amd64.zip", strings.NewReader("linux")))
+
+	configured := []alias.Alias{{Type: alias.TypeNamespace, From: "netwrok", To: "network"}}
+	a, err := NewAliasStorage(s, configured)
+	assert.NoError(t, err)
+
+	err = a.AddAlias(ctx, alias.Alias{Type: alias.TypeNamespace, From: "netwrok", To: "other"})
+	assert.ErrorIs(t, err, alias.ErrAliasConfigured)
+	assert.ErrorIs(t, a.DeleteAlias(ctx, alias.TypeNamespace, "netwrok"), alias.ErrAliasConfigured)
+
+	assert.NoError(t, a.AddAlias(ctx, alias.Alias{Type: alias.TypeProvider, From: "legacy/dummy", To: "vendor/dummy", Deprecation: "Update the source."}))
+	assert.ErrorIs(t, a.AddAlias(ctx, alias.Alias{Type: alias.TypeProvider, From: "legacy/dummy", To: "other/dummy"}), alias.ErrAliasAlreadyExists)
+
+	aliases, err := a.ListAliases(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, aliases, 2)
+
+	// The aliases added through the API are stored in the decorated storage
+	stored, err := s.ListAliases(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, stored, 1)
+
+	m, err := a.GetModule(ctx, "netwrok", "vpc", "aws", "1.0.0")
+	assert.NoError(t, err)
+	assert.Equal(t, "network", m.Namespace)
+
+	_, err = a.UploadModule(ctx, "netwrok", "vpc", "aws", "2.0.0", strings.NewReader("archive"))
+	assert.ErrorIs(t, err, module.ErrPublishDisabled)
+
+	deprecationCtx := core.DeprecationsToContext(ctx, &http.Request{})
+	versions, err := a.ListProviderVersions(deprecationCtx, "legacy", "dummy")
+	assert.NoError(t, err)
+	assert.Len(t, versions.Versions, 1)
+	assert.Equal(t, []string{"The provider legacy/dummy has moved to vendor/dummy. Update the source."}, versions.Warnings)
+	assert.Equal(t, versions.Warnings, core.DeprecationsFromContext(deprecationCtx))
+
+	err = a.UploadProviderReleaseFiles(ctx, "legacy", "dummy", "terraform-provider-dummy_2.0.0_SHA256SUMS", strings.NewReader(sums))
+	assert.ErrorIs(t, err, provider.ErrPublishDisabled)
+
+	assert.NoError(t, a.DeleteAlias(ctx, alias.TypeProvider, "legacy/dummy"))
+	assert.ErrorIs(t, a.DeleteAlias(ctx, alias.TypeProvider, "legacy/dummy"), alias.ErrAliasNotFound)
+
+	// The cache is invalidated once the aliases change
+	_, err = a.ListProviderVersions(ctx, "legacy", "dummy")
+	assert.Error(t, err)
+}
+
+func TestNewAliasStorage(t *testing.T) {
+	t.Parallel()
+
+	s := newTestPluginStorage(t, t.TempDir())
+
+	_, err := NewAliasStorage(s, []alias.Alias{{Type: alias.TypeModule, From: "old/vpc", To: "new/vpc"}})
+	assert.ErrorIs(t, err, alias.ErrInvalidAlias)
+
+	_, err = NewAliasStorage(s, []alias.Alias{
+		{Type: alias.TypeNamespace, From: "a", To: "b"},
+		{Type: alias.TypeNamespace, From: "a", To: "c"},
+	})
+	assert.Error(t, err)
+
+	_, err = NewAliasStorage(s, []alias.Alias{
+		{Type: alias.TypeNamespace, From: "a", To: "b"},
+		{Type: alias.TypeNamespace, From: "b", To: "a"},
+	})
+	assert.ErrorIs(t, err, alias.ErrInvalidAlias)
+}
+
+func TestParseAliases(t *testing.T) {
+	t.Parallel()
+
+	file := filepath.Join(t.TempDir(), "aliases.hcl")
+	content := `
+alias "module" "old/vpc/aws" {
+  to          = "new/vpc/aws"
+  deprecation = "Update the source."
+}
+
+alias "namespace" "netwrok" {
+  to = "network"
+}
+`
+	assert.NoError(t, os.WriteFile(file, []byte(content), 0o600))
+
+	aliases, err := ParseAliases(file)
+	assert.NoError(t, err)
+	assert.Equal(t, []alias.Alias{
+		{Type: alias.TypeModule, From: "old/vpc/aws", To: "new/vpc/aws", Deprecation: "Update the source."},
+		{Type: alias.TypeNamespace, From: "netwrok", To: "network"},
+	}, aliases)
+}
+
+func TestDeleteModule(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	s := newTestPluginStorage(t, root)
+	ctx := context.Background()
+
+	assert.ErrorIs(t, s.DeleteModule(ctx, "network", "vpc", "aws", "1.0.0"), module.ErrModuleNotFound)
+
+	_, err := s.UploadModule(ctx, "network", "vpc", "aws", "1.0.0", strings.NewReader("archive"))
+	assert.NoError(t, err)
+	assert.NoError(t, s.DeleteModule(ctx, "network", "vpc", "aws", "1.0.0"))
+
+	_, err = s.GetModule(ctx, "network", "vpc", "aws", "1.0.0")
+	assert.ErrorIs(t, err, module.ErrModuleNotFound)
+	entries, err := os.ReadDir(filepath.Join(root, "modules/network/vpc/aws"))
+	if err == nil {
+		assert.Empty(t, entries)
+	}
+}
+
+func TestDeleteProviderRelease(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	s := newTestPluginStorage(t, root)
+	ctx := context.Background()
+
+	sums := fmt.Sprintf("%x  terraform-provider-dummy_1.0.0_linux_amd64.zip\n", sha256.Sum256([]byte("linux")))
+	files := map[string]string{
+		"terraform-provider-dummy_1.0.0_SHA256SUMS":      sums,
+		"terraform-provider-dummy_1.0.0_SHA256SUMS.sig":  "sig",
+		"terraform-provider-dummy_1.0.0_linux_amd64.zip": "linux",
+	}
+	for name, content := range files {
+		assert.NoError(t, s.UploadProviderReleaseFiles(ctx, "vendor", "dummy", name, strings.NewReader(content)))
+	}
+
+	assert.NoError(t, s.DeleteProviderRelease(ctx, "vendor", "dummy", "1.0.0"))
+	for name := range files {
+		_, err := os.Stat(filepath.Join(root, "providers/vendor/dummy", name))
+		assert.True(t, os.IsNotExist(err), name)
+	}
+
+	var providerErr *core.ProviderError
+	assert.ErrorAs(t, s.DeleteProviderRelease(ctx, "vendor", "dummy", "1.0.0"), &providerErr)
+}
@@ -11,12 +11,17 @@ import (
 	"path/filepath"
 	"time"
 
+	"github.com/boring-registry/boring-registry/pkg/alias"
 	"github.com/boring-registry/boring-registry/pkg/core"
 	"github.com/boring-registry/boring-registry/pkg/module"
+	"github.com/boring-registry/boring-registry/pkg/namespace"
+	"github.com/boring-registry/boring-registry/pkg/provider"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
 	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blob"
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/sas"
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/service"
@@ -81,6 +86,9 @@ func (s *AzureStorage) ListModuleVersions(ctx context.Context, namespace, name,
 			if err != nil {
 				return []core.Module{}, err
 			}
+			if obj.Properties != nil && obj.Properties.LastModified != nil {
+				m.PublishedAt = *obj.Properties.LastModified
+			}
 
 			modules = append(modules, *m)
 		}
@@ -89,6 +97,11 @@ func (s *AzureStorage) ListModuleVersions(ctx context.Context, namespace, name,
 	return modules, nil
 }
 
+// ListModules returns every version of all modules, restricted to the namespace if it isn't empty
+func (s *AzureStorage) ListModules(ctx context.Context, namespace string) ([]core.Module, error) {
+	return listModules(ctx, s, namespace)
+}
+
 // UploadModule uploads a module to the Azure Storage.
 
 func (s *AzureStorage) UploadModule(ctx context.Context, namespace, name, provider, version string, body io.Reader) (core.Module, error) {
@@ -114,13 +127,56 @@ func (s *AzureStorage) UploadModule(ctx context.Context, namespace, name, provid
 		return core.Module{}, fmt.Errorf("%w: %s", module.ErrModuleAlreadyExists, key)
 	}
 
-	if _, err := s.client.UploadStream(ctx, s.container, key, body, nil); err != nil {
+	m := core.Module{Namespace: namespace, Name: name, Provider: provider, Version: version}
+	if err := uploadModuleArchive(ctx, s, m, key, body); err != nil {
 		return core.Module{}, fmt.Errorf("%v: %w", module.ErrModuleUploadFailed, err)
 	}
 
 	return s.GetModule(ctx, namespace, name, provider, version)
 }
 
+// DownloadModule streams the module archive from the Azure Storage container. The caller has to close the returned io.ReadCloser
+func (s *AzureStorage) DownloadModule(ctx context.Context, namespace, name, provider, version string) (io.ReadCloser, error) {
+	key := modulePath(s.prefix, namespace, name, provider, version, s.moduleArchiveFormat)
+
+	exists, err := s.objectExists(ctx, key)
+	if err != nil {
+		return nil, err
+	} else if !exists {
+		return nil, module.ErrModuleNotFound
+	}
+
+	return s.reader(ctx, key)
+}
+
+// GetModuleDocs reads the docs that were extracted when the module version was uploaded
+func (s *AzureStorage) GetModuleDocs(ctx context.Context, namespace, name, provider, version string) (*module.Docs, error) {
+	return moduleDocs(ctx, s, namespace, name, provider, version)
+}
+
+// GetModuleInfo reads the info of the module spec file that was stored when the module version was uploaded
+func (s *AzureStorage) GetModuleInfo(ctx context.Context, namespace, name, provider, version string) (*module.Info, error) {
+	return moduleInfo(ctx, s, namespace, name, provider, version)
+}
+
+// GetModuleProvenance reads the provenance that was recorded when the module version was uploaded
+func (s *AzureStorage) GetModuleProvenance(ctx context.Context, namespace, name, provider, version string) (*core.Provenance, error) {
+	return moduleProvenance(ctx, s, namespace, name, provider, version)
+}
+
+// CopyModule copies the module version to another module, without transferring the archive through the registry if possible
+func (s *AzureStorage) CopyModule(ctx context.Context, src, dst core.Module) (core.Module, error) {
+	if err := copyModule(ctx, s, src, dst); err != nil {
+		return core.Module{}, err
+	}
+	return s.GetModule(ctx, dst.Namespace, dst.Name, dst.Provider, dst.Version)
+}
+
+// DeleteModule removes the archive of the module version together with its provenance, docs and info
+func (s *AzureStorage) DeleteModule(ctx context.Context, namespace, name, provider, version string) error {
+	return deleteModule(ctx, s, core.Module{Namespace: namespace, Name: name, Provider: provider, Version: version})
+}
+
 // GetProvider retrieves information about a provider from the Azure Storage.
 func (s *AzureStorage) getProvider(ctx context.Context, pt providerType, provider *core.Provider) (*core.Provider, error) {
 	var archivePath, shasumPath, shasumSigPath string
@@ -241,6 +297,11 @@ func (s *AzureStorage) ListProviderVersions(ctx context.Context, namespace, name
 	return collection.List(), nil
 }
 
+// ListProviders returns every version of all providers, restricted to the namespace if it isn't empty
+func (s *AzureStorage) ListProviders(ctx context.Context, namespace string) ([]core.ProviderVersion, error) {
+	return listProviders(ctx, s, namespace)
+}
+
 func (s *AzureStorage) ListMirroredProviders(ctx context.Context, provider *core.Provider) ([]*core.Provider, error) {
 	return s.listProviderVersions(ctx, mirrorProviderType, provider)
 }
@@ -260,7 +321,62 @@ func (s *AzureStorage) UploadProviderReleaseFiles(ctx context.Context, namespace
 
 	prefix := providerStoragePrefix(s.prefix, internalProviderType, "", namespace, name)
 	key := filepath.Join(prefix, filename)
-	return s.upload(ctx, key, file, false)
+	return uploadWithProvenance(ctx, s, key, file, false)
+}
+
+// GetProviderProvenance reads the provenance that was recorded when the files of the provider release were uploaded
+func (s *AzureStorage) GetProviderProvenance(ctx context.Context, namespace, name, version string) ([]core.Provenance, error) {
+	return providerProvenance(ctx, s, namespace, name, version)
+}
+
+// GetProviderRelease returns the files of the provider release, which are read once they are opened
+func (s *AzureStorage) GetProviderRelease(ctx context.Context, namespace, name, version string) (*provider.Release, error) {
+	return providerRelease(ctx, s, namespace, name, version)
+}
+
+// CopyProviderRelease copies all files of the provider release to another namespace
+func (s *AzureStorage) CopyProviderRelease(ctx context.Context, namespace, name, version, targetNamespace string) error {
+	return copyProviderRelease(ctx, s, namespace, name, version, targetNamespace)
+}
+
+// DeleteProviderRelease removes all files of the provider release
+func (s *AzureStorage) DeleteProviderRelease(ctx context.Context, namespace, name, version string) error {
+	return deleteProviderRelease(ctx, s, namespace, name, version)
+}
+
+// ListAliases returns the aliases managed through the API
+func (s *AzureStorage) ListAliases(ctx context.Context) ([]alias.Alias, error) {
+	return listAliases(ctx, s)
+}
+
+// AddAlias stores the alias together with the other aliases managed through the API
+func (s *AzureStorage) AddAlias(ctx context.Context, a alias.Alias) error {
+	return addAlias(ctx, s, a)
+}
+
+// DeleteAlias removes the alias from the aliases managed through the API
+func (s *AzureStorage) DeleteAlias(ctx context.Context, aliasType, from string) error {
+	return deleteAlias(ctx, s, aliasType, from)
+}
+
+// ListNamespaces returns the registered namespaces
+func (s *AzureStorage) ListNamespaces(ctx context.Context) ([]namespace.Namespace, error) {
+	return listNamespaces(ctx, s)
+}
+
+// AddNamespace registers the namespace together with the other registered namespaces
+func (s *AzureStorage) AddNamespace(ctx context.Context, n namespace.Namespace) error {
+	return addNamespace(ctx, s, n)
+}
+
+// UpdateNamespace replaces the registered namespace with the same name
+func (s *AzureStorage) UpdateNamespace(ctx context.Context, n namespace.Namespace) error {
+	return updateNamespace(ctx, s, n)
+}
+
+// DeleteNamespace removes the namespace from the registered namespaces
+func (s *AzureStorage) DeleteNamespace(ctx context.Context, name string) error {
+	return deleteNamespace(ctx, s, name)
 }
 
 func (s *AzureStorage) signingKeys(ctx context.Context, pt providerType, hostname, namespace string) (*core.SigningKeys, error) {
@@ -322,6 +438,18 @@ func (s *AzureStorage) UploadMirroredFile(ctx context.Context, provider *core.Pr
 	return s.upload(ctx, key, reader, true)
 }
 
+// copyObject copies the blob within the container.
+// The copy is synchronous, which is limited to blobs of up to 256 MiB, and reads the source through a presigned URL.
+func (s *AzureStorage) copyObject(ctx context.Context, src, dst string) error {
+	source, err := s.presignedURL(ctx, src)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.client.ServiceClient().NewContainerClient(s.container).NewBlobClient(dst).CopyFromURL(ctx, source, nil)
+	return err
+}
+
 func (s *AzureStorage) presignedURL(ctx context.Context, key string) (string, error) {
 	info := service.KeyInfo{
 		Start:  to.Ptr(time.Now().UTC().Format(sas.TimeFormat)),
@@ -349,6 +477,41 @@ func (s *AzureStorage) presignedURL(ctx context.Context, key string) (string, er
 	return url, nil
 }
 
+func (s *AzureStorage) listObjects(ctx context.Context, prefix string) ([]object, error) {
+	pager := s.client.NewListBlobsFlatPager(s.container, &azblob.ListBlobsFlatOptions{
+		Prefix: &prefix,
+	})
+
+	var objects []object
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, obj := range page.Segment.BlobItems {
+			o := object{
+				key: *obj.Name,
+			}
+			if obj.Properties != nil {
+				if obj.Properties.ContentLength != nil {
+					o.size = *obj.Properties.ContentLength
+				}
+				if obj.Properties.LastModified != nil {
+					o.lastModified = *obj.Properties.LastModified
+				}
+			}
+			objects = append(objects, o)
+		}
+	}
+
+	return objects, nil
+}
+
+func (s *AzureStorage) layout() (string, string) {
+	return s.prefix, s.moduleArchiveFormat
+}
+
 func (s *AzureStorage) objectExists(ctx context.Context, key string) (bool, error) {
 	o := s.client.ServiceClient().NewContainerClient(s.container).NewBlobClient(key)
 	_, err := o.GetProperties(ctx, nil)
@@ -372,25 +535,88 @@ func (s *AzureStorage) upload(ctx context.Context, key string, reader io.Reader,
 		}
 	}
 
-	if _, err := s.client.UploadStream(ctx, s.container, key, reader, nil); err != nil {
+	// UploadStream commits the blob only after the reader is drained, which allows us to store the Content-MD5 of the whole blob,
+	// so that clients downloading it can check it. Azure doesn't compare it with the content of block blobs, though,
+	// and only validates every block with its CRC64 checksum.
+	headers := &blob.HTTPHeaders{}
+	c := newChecksumReader(reader)
+	c.onEOF = func() {
+		headers.BlobContentMD5 = c.md5Sum()
+	}
+
+	options := &azblob.UploadStreamOptions{
+		TransactionalValidation: blob.TransferValidationTypeComputeCRC64(),
+		HTTPHeaders:             headers,
+	}
+	if _, err := s.client.UploadStream(ctx, s.container, key, c, options); err != nil {
 		return fmt.Errorf("failed to upload: %w", err)
 	}
 
+	// Azure has no checksum of the stored blob we could compare, so we have to read it back
+	return verifyUpload(ctx, s, key, c, func() error {
+		return verifySHA256(ctx, s, key, c.sha256Sum())
+	})
+}
+
+func (s *AzureStorage) deleteObject(ctx context.Context, key string) error {
+	if _, err := s.client.DeleteBlob(ctx, s.container, key, nil); err != nil {
+		return fmt.Errorf("failed to delete %s: %w", key, err)
+	}
 	return nil
 }
 
-func (s *AzureStorage) download(ctx context.Context, key string) ([]byte, error) {
+func (s *AzureStorage) reader(ctx context.Context, key string) (io.ReadCloser, error) {
 	r, err := s.client.DownloadStream(ctx, s.container, key, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to download %s: %w", key, err)
 	}
 
-	data, err := io.ReadAll(r.Body)
+	return r.Body, nil
+}
+
+// downloadVersion returns the content of the blob together with its ETag
+func (s *AzureStorage) downloadVersion(ctx context.Context, key string) ([]byte, string, error) {
+	r, err := s.client.DownloadStream(ctx, s.container, key, nil)
+	if bloberror.HasCode(err, bloberror.BlobNotFound) {
+		return nil, "", nil
+	} else if err != nil {
+		return nil, "", fmt.Errorf("failed to download %s: %w", key, err)
+	}
+	defer r.Body.Close()
+
+	b, err := io.ReadAll(r.Body)
+	if r.ETag == nil {
+		return b, "", err
+	}
+	return b, string(*r.ETag), err
+}
+
+// uploadVersion stores the blob only if it still has the ETag, or doesn't exist yet
+func (s *AzureStorage) uploadVersion(ctx context.Context, key string, b []byte, version *string) error {
+	conditions := &blob.ModifiedAccessConditions{IfNoneMatch: to.Ptr(azcore.ETagAny)}
+	if version != nil {
+		conditions = &blob.ModifiedAccessConditions{IfMatch: to.Ptr(azcore.ETag(*version))}
+	}
+
+	_, err := s.client.UploadBuffer(ctx, s.container, key, b, &azblob.UploadBufferOptions{
+		AccessConditions: &blob.AccessConditions{ModifiedAccessConditions: conditions},
+	})
+	if bloberror.HasCode(err, bloberror.ConditionNotMet, bloberror.BlobAlreadyExists) {
+		return fmt.Errorf("failed to upload %s: %w", key, errPreconditionFailed)
+	} else if err != nil {
+		return fmt.Errorf("failed to upload: %w", err)
+	}
+	return nil
+}
+
+func (s *AzureStorage) download(ctx context.Context, key string) ([]byte, error) {
+	r, err := s.reader(ctx, key)
 	if err != nil {
 		return nil, err
 	}
+	defer r.Close()
 
-	return data, nil
+	return io.ReadAll(r)
 }
 
 func (s *AzureStorage) GetDownloadUrl(ctx context.Context, url string) (string, error) {
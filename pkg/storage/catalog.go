@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/module"
+)
+
+// listModules returns every version of all modules, restricted to the namespace if it isn't empty.
+// The returned modules don't have a DownloadURL, as presigning every archive of the registry would be too expensive.
+func listModules(ctx context.Context, s objectStorage, namespace string) ([]core.Module, error) {
+	prefix, archiveFormat := s.layout()
+	base := path.Join(prefix, string(internalModuleType), namespace) + "/"
+
+	objects, err := s.listObjects(ctx, base)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", module.ErrModuleListFailed, err)
+	}
+
+	var modules []core.Module
+	for _, obj := range objects {
+		m, err := moduleFromObject(obj.key, archiveFormat)
+		if err != nil {
+			continue
+		}
+		m.PublishedAt = obj.lastModified
+		modules = append(modules, *m)
+	}
+	return modules, nil
+}
+
+// listProviders returns every version of all internal providers with their platforms, restricted to the namespace if it isn't empty
+func listProviders(ctx context.Context, s objectStorage, namespace string) ([]core.ProviderVersion, error) {
+	prefix, _ := s.layout()
+	base := path.Join(prefix, string(internalProviderType)) + "/"
+
+	objects, err := s.listObjects(ctx, path.Join(base, namespace)+"/")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list providers: %w", err)
+	}
+
+	collection := NewCollection()
+	for _, obj := range objects {
+		dir, file := path.Split(strings.TrimPrefix(obj.key, base))
+		p, err := core.NewProviderFromArchive(file)
+		if err != nil {
+			continue
+		}
+
+		// Providers are stored below <namespace>/<name>
+		parts := strings.Split(strings.Trim(dir, "/"), "/")
+		if len(parts) != 2 || parts[1] != p.Name {
+			continue
+		}
+		p.Namespace = parts[0]
+
+		collection.Add(&p)
+	}
+	return collection.List().Versions, nil
+}
@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListModules(t *testing.T) {
+	t.Parallel()
+
+	s := newTestPluginStorage(t, t.TempDir(), WithPluginStoragePrefix("registry"))
+	ctx := context.Background()
+
+	for _, m := range []core.Module{
+		{Namespace: "example", Name: "s3", Provider: "aws", Version: "1.0.0"},
+		{Namespace: "example", Name: "s3", Provider: "aws", Version: "1.1.0"},
+		{Namespace: "other", Name: "dns", Provider: "google", Version: "0.1.0"},
+	} {
+		_, err := s.UploadModule(ctx, m.Namespace, m.Name, m.Provider, m.Version, strings.NewReader("archive"))
+		assert.NoError(t, err)
+	}
+
+	modules, err := s.ListModules(ctx, "")
+	assert.NoError(t, err)
+	var ids []string
+	for _, m := range modules {
+		ids = append(ids, m.ID(true))
+		assert.False(t, m.PublishedAt.IsZero())
+	}
+	assert.ElementsMatch(t, []string{"example/s3/aws/1.0.0", "example/s3/aws/1.1.0", "other/dns/google/0.1.0"}, ids)
+
+	modules, err = s.ListModules(ctx, "other")
+	assert.NoError(t, err)
+	if assert.Len(t, modules, 1) {
+		assert.Equal(t, "other/dns/google/0.1.0", modules[0].ID(true))
+	}
+
+	// A namespace sharing the prefix must not show up
+	modules, err = s.ListModules(ctx, "ot")
+	assert.NoError(t, err)
+	assert.Empty(t, modules)
+}
+
+func TestListProviders(t *testing.T) {
+	t.Parallel()
+
+	s := newTestPluginStorage(t, t.TempDir())
+	ctx := context.Background()
+
+	for namespace, files := range map[string][]string{
+		"example": {
+			"terraform-provider-dummy_1.0.0_linux_amd64.zip",
+			"terraform-provider-dummy_1.0.0_darwin_arm64.zip",
+			"terraform-provider-dummy_1.0.0_SHA256SUMS",
+		},
+		"other": {
+			"terraform-provider-random_2.0.0_linux_amd64.zip",
+		},
+	} {
+		for _, f := range files {
+			name := strings.Split(strings.TrimPrefix(f, core.ProviderPrefix), "_")[0]
+			assert.NoError(t, s.UploadProviderReleaseFiles(ctx, namespace, name, f, strings.NewReader(f)))
+		}
+	}
+
+	providers, err := s.ListProviders(ctx, "")
+	assert.NoError(t, err)
+	assert.Len(t, providers, 2)
+
+	providers, err = s.ListProviders(ctx, "example")
+	assert.NoError(t, err)
+	if assert.Len(t, providers, 1) {
+		assert.Equal(t, "example", providers[0].Namespace)
+		assert.Equal(t, "dummy", providers[0].Name)
+		assert.Equal(t, "1.0.0", providers[0].Version)
+		assert.ElementsMatch(t, []core.Platform{{OS: "linux", Arch: "amd64"}, {OS: "darwin", Arch: "arm64"}}, providers[0].Platforms)
+	}
+}
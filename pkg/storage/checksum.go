@@ -0,0 +1,171 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+)
+
+// checksumReader computes the checksums used by the different storage backends while the content is streamed through it
+type checksumReader struct {
+	r      io.Reader
+	w      io.Writer
+	sha256 hash.Hash
+	md5    hash.Hash
+	crc32c hash.Hash32
+	size   int64
+
+	// onEOF is called once the underlying reader is drained
+	onEOF func()
+}
+
+func newChecksumReader(r io.Reader) *checksumReader {
+	c := &checksumReader{
+		r:      r,
+		sha256: sha256.New(),
+		md5:    md5.New(),
+		crc32c: crc32.New(crc32.MakeTable(crc32.Castagnoli)),
+	}
+	c.w = io.MultiWriter(c.sha256, c.md5, c.crc32c)
+	return c
+}
+
+func (c *checksumReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	if n > 0 {
+		_, _ = c.w.Write(p[:n]) // hash.Hash never returns an error
+		c.size += int64(n)
+	}
+	if errors.Is(err, io.EOF) && c.onEOF != nil {
+		c.onEOF()
+		c.onEOF = nil
+	}
+	return n, err
+}
+
+func (c *checksumReader) sha256Sum() []byte {
+	return c.sha256.Sum(nil)
+}
+
+func (c *checksumReader) md5Sum() []byte {
+	return c.md5.Sum(nil)
+}
+
+func (c *checksumReader) crc32cSum() uint32 {
+	return c.crc32c.Sum32()
+}
+
+// precomputeCRC32C computes the CRC32C checksum of seekable content and rewinds it afterward,
+// so that storage backends can send the checksum together with the content. It returns false for other readers.
+func precomputeCRC32C(r io.Reader) (uint32, bool, error) {
+	seeker, ok := r.(io.Seeker)
+	if !ok {
+		return 0, false, nil
+	}
+
+	start, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		// Not every io.Seeker supports seeking, e.g. os.Stdin
+		return 0, false, nil
+	}
+
+	h := crc32.New(crc32.MakeTable(crc32.Castagnoli))
+	if _, err := io.Copy(h, r); err != nil {
+		return 0, false, err
+	}
+	if _, err := seeker.Seek(start, io.SeekStart); err != nil {
+		return 0, false, err
+	}
+	return h.Sum32(), true, nil
+}
+
+func checksumMismatch(key, algorithm string, expected, actual []byte) error {
+	return fmt.Errorf("%w: %s of %s is %x instead of %x", core.ErrChecksumMismatch, algorithm, key, actual, expected)
+}
+
+// verifySHA256 reads the stored object back and compares its SHA-256 checksum with the expected one.
+// It is used by storage backends that don't provide a native way to verify the integrity of an upload.
+func verifySHA256(ctx context.Context, s objectStorage, key string, expected []byte) error {
+	r, err := s.reader(ctx, key)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	actual, err := core.Sha256Checksum(r)
+	if err != nil {
+		return fmt.Errorf("failed to compute checksum of %s: %w", key, err)
+	}
+
+	if !bytes.Equal(expected, actual) {
+		return checksumMismatch(key, "SHA-256", expected, actual)
+	}
+	return nil
+}
+
+// verifyProviderArchive cross-checks the SHA-256 checksum of a provider archive with its entry in the SHA256SUMS file of the release.
+// Other objects and archives without an uploaded SHA256SUMS file are not checked.
+func verifyProviderArchive(ctx context.Context, s objectStorage, key string, sum []byte) error {
+	if !strings.HasSuffix(key, core.ProviderExtension) {
+		return nil
+	}
+
+	p, err := core.NewProviderFromArchive(path.Base(key))
+	if err != nil {
+		return nil
+	}
+
+	shasumKey := path.Join(path.Dir(key), p.ShasumFileName())
+	exists, err := s.objectExists(ctx, shasumKey)
+	if err != nil {
+		return err
+	} else if !exists {
+		return nil
+	}
+
+	b, err := s.download(ctx, shasumKey)
+	if err != nil {
+		return err
+	}
+
+	sums, err := core.NewSha256Sums(p.ShasumFileName(), bytes.NewReader(b))
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", shasumKey, err)
+	}
+
+	expected, ok := sums.Entries[p.Filename]
+	if !ok {
+		return fmt.Errorf("%w: %s is not listed in %s", core.ErrChecksumMismatch, p.Filename, shasumKey)
+	}
+
+	if !bytes.Equal(expected, sum) {
+		return checksumMismatch(key, "SHA-256", expected, sum)
+	}
+	return nil
+}
+
+// verifyUpload runs the backend-specific verification of the stored object and cross-checks provider archives with
+// their SHA256SUMS file afterward. The object is deleted in case of a checksum mismatch, so corrupted content is never served.
+func verifyUpload(ctx context.Context, s objectStorage, key string, c *checksumReader, verify func() error) error {
+	err := verify()
+	if err == nil {
+		err = verifyProviderArchive(ctx, s, key, c.sha256Sum())
+	}
+
+	if errors.Is(err, core.ErrChecksumMismatch) {
+		if deleteErr := s.deleteObject(ctx, key); deleteErr != nil {
+			return errors.Join(err, fmt.Errorf("failed to delete %s: %w", key, deleteErr))
+		}
+	}
+	return err
+}
@@ -0,0 +1,122 @@
+package storage
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChecksumReader(t *testing.T) {
+	t.Parallel()
+
+	var eof bool
+	c := newChecksumReader(strings.NewReader("boring-registry"))
+	c.onEOF = func() { eof = true }
+
+	b := make([]byte, 4)
+	for {
+		if _, err := c.Read(b); err != nil {
+			break
+		}
+	}
+
+	sha256Sum := sha256.Sum256([]byte("boring-registry"))
+	md5Sum := md5.Sum([]byte("boring-registry"))
+	assert.True(t, eof)
+	assert.Equal(t, int64(15), c.size)
+	assert.Equal(t, sha256Sum[:], c.sha256Sum())
+	assert.Equal(t, md5Sum[:], c.md5Sum())
+	assert.Equal(t, crc32.Checksum([]byte("boring-registry"), crc32.MakeTable(crc32.Castagnoli)), c.crc32cSum())
+}
+
+func TestPrecomputeCRC32C(t *testing.T) {
+	t.Parallel()
+
+	r := strings.NewReader("boring-registry")
+	_, _ = r.Seek(7, io.SeekStart)
+	crc, ok, err := precomputeCRC32C(r)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, crc32.Checksum([]byte("registry"), crc32.MakeTable(crc32.Castagnoli)), crc)
+
+	// The content is rewound to where it was
+	b, _ := io.ReadAll(r)
+	assert.Equal(t, "registry", string(b))
+
+	_, ok, err = precomputeCRC32C(io.MultiReader(strings.NewReader("boring-registry")))
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestVerifyProviderArchive(t *testing.T) {
+	t.Parallel()
+
+	archive := "terraform-provider-random_2.0.0_linux_amd64.zip"
+	shasums := "terraform-provider-random_2.0.0_SHA256SUMS"
+	sum := sha256.Sum256([]byte("archive"))
+
+	testCases := []struct {
+		description string
+		shasums     string
+		content     string
+		wantErr     assert.ErrorAssertionFunc
+	}{
+		{
+			description: "no SHA256SUMS file uploaded",
+			content:     "archive",
+			wantErr:     assert.NoError,
+		},
+		{
+			description: "matching checksum",
+			shasums:     fmt.Sprintf("%x  %s\n", sum, archive),
+			content:     "archive",
+			wantErr:     assert.NoError,
+		},
+		{
+			description: "mismatching checksum",
+			shasums:     fmt.Sprintf("%x  %s\n", sum, archive),
+			content:     "corrupted",
+			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+				return assert.ErrorIs(t, err, core.ErrChecksumMismatch)
+			},
+		},
+		{
+			description: "archive missing in SHA256SUMS file",
+			shasums:     fmt.Sprintf("%x  %s\n", sum, "terraform-provider-random_2.0.0_darwin_arm64.zip"),
+			content:     "archive",
+			wantErr: func(t assert.TestingT, err error, i ...interface{}) bool {
+				return assert.ErrorIs(t, err, core.ErrChecksumMismatch)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			root := t.TempDir()
+			s := newTestPluginStorage(t, root)
+			ctx := context.Background()
+
+			if tc.shasums != "" {
+				assert.NoError(t, s.UploadProviderReleaseFiles(ctx, "hashicorp", "random", shasums, strings.NewReader(tc.shasums)))
+			}
+
+			err := s.UploadProviderReleaseFiles(ctx, "hashicorp", "random", archive, strings.NewReader(tc.content))
+			tc.wantErr(t, err)
+
+			// Objects failing the verification must not be kept
+			_, statErr := os.Stat(filepath.Join(root, "providers/hashicorp/random", archive))
+			assert.Equal(t, err == nil, statErr == nil)
+		})
+	}
+}
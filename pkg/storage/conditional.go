@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+)
+
+// errPreconditionFailed is returned by conditional writes if the object was changed after it was read
+var errPreconditionFailed = errors.New("the object was changed concurrently")
+
+// maxSidecarUpdates limits how often a change of a sidecar is retried when it was changed concurrently
+const maxSidecarUpdates = 10
+
+// conditionalStorage is implemented by storage backends supporting conditional writes,
+// which detect objects that were changed between reading and writing them.
+type conditionalStorage interface {
+	// downloadVersion returns the content of the object together with its version, like a generation or an ETag.
+	// The content is nil if the object doesn't exist, and the version is empty if the storage backend didn't return one.
+	downloadVersion(ctx context.Context, key string) ([]byte, string, error)
+
+	// uploadVersion stores the content only if the object still has the version, or doesn't exist yet for a nil version.
+	// errPreconditionFailed is returned otherwise.
+	uploadVersion(ctx context.Context, key string, b []byte, version *string) error
+}
+
+// sidecarLocks holds a mutex for every sidecar that was updated by this process
+var sidecarLocks sync.Map
+
+// updateSidecar reads the JSON sidecar, changes it with the update function and writes it back.
+// The sidecar is zero if it doesn't exist yet, and the update function is called again if the sidecar was changed concurrently.
+// Storage backends without conditional writes only guard against concurrent changes within this process.
+func updateSidecar[T any](ctx context.Context, s objectStorage, key string, update func(*T) error) error {
+	mu, _ := sidecarLocks.LoadOrStore(key, &sync.Mutex{})
+	mu.(*sync.Mutex).Lock()
+	defer mu.(*sync.Mutex).Unlock()
+
+	c, conditional := s.(conditionalStorage)
+	for i := 0; i < maxSidecarUpdates; i++ {
+		var (
+			b       []byte
+			version *string
+			err     error
+		)
+		if conditional {
+			var v string
+			b, v, err = c.downloadVersion(ctx, key)
+			if v != "" {
+				version = &v
+			}
+		} else {
+			b, err = downloadIfExists(ctx, s, key)
+		}
+		if err != nil {
+			return err
+		}
+
+		var v T
+		if b != nil {
+			if err := json.Unmarshal(b, &v); err != nil {
+				return fmt.Errorf("failed to decode sidecar %s: %w", key, err)
+			}
+		}
+		if err := update(&v); err != nil {
+			return err
+		}
+
+		updated, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+
+		// Without a version of an existing sidecar, we can only overwrite it
+		if !conditional || (b != nil && version == nil) {
+			return s.upload(ctx, key, bytes.NewReader(updated), true)
+		}
+
+		err = c.uploadVersion(ctx, key, updated, version)
+		if !errors.Is(err, errPreconditionFailed) {
+			return err
+		}
+		slog.Debug("sidecar was changed concurrently, retrying", slog.String("key", key))
+	}
+
+	return fmt.Errorf("failed to update sidecar %s: %w", key, errPreconditionFailed)
+}
+
+// downloadIfExists returns the content of the object, which is nil if the object doesn't exist
+func downloadIfExists(ctx context.Context, s objectStorage, key string) ([]byte, error) {
+	exists, err := s.objectExists(ctx, key)
+	if err != nil || !exists {
+		return nil, err
+	}
+	return s.download(ctx, key)
+}
@@ -0,0 +1,246 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/metadata"
+	"github.com/boring-registry/boring-registry/pkg/module"
+	"github.com/boring-registry/boring-registry/pkg/provider"
+)
+
+// objectCopier is implemented by storage backends that copy objects without transferring their content through the registry
+type objectCopier interface {
+	// copyObject copies the object within the storage backend and overwrites the destination
+	copyObject(ctx context.Context, src, dst string) error
+}
+
+// copyObject copies the object and fails if the destination exists already.
+// Storage backends without server-side copies stream the object through the registry, which verifies it like any other upload.
+func copyObject(ctx context.Context, s objectStorage, src, dst string) error {
+	exists, err := s.objectExists(ctx, dst)
+	if err != nil {
+		return err
+	} else if exists {
+		return fmt.Errorf("failed to copy to %s: %w", dst, core.ErrObjectAlreadyExists)
+	}
+
+	if copier, ok := s.(objectCopier); ok {
+		if err := copier.copyObject(ctx, src, dst); err != nil {
+			return fmt.Errorf("failed to copy %s to %s: %w", src, dst, err)
+		}
+		return nil
+	}
+
+	r, err := s.reader(ctx, src)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	return s.upload(ctx, dst, r, false)
+}
+
+// copyProvenance records the provenance of the copied artifact, which keeps the provenance of the original artifact as origin.
+// The checksum is taken from the original provenance, and only computed from the copy if the original artifact has none.
+// Failing to store it doesn't fail the copy, like for uploads.
+func copyProvenance(ctx context.Context, s objectStorage, src, dst, copiedFrom string) {
+	p := core.ProvenanceFromContext(ctx)
+	p.Artifact = path.Base(dst)
+	p.Uploader = metadata.UploaderFromContext(ctx)
+	p.UploadedAt = time.Now().UTC()
+	p.CopiedFrom = copiedFrom
+
+	origin := &core.Provenance{}
+	if err := readSidecar(ctx, s, src+provenanceExtension, core.ErrProvenanceNotFound, origin); err == nil {
+		p.Origin = origin
+		p.SHA256 = origin.SHA256
+	} else {
+		slog.Debug("failed to read provenance of copied artifact", slog.String("key", src), slog.String("err", err.Error()))
+	}
+
+	if p.SHA256 == "" {
+		sum, err := objectSHA256(ctx, s, dst)
+		if err != nil {
+			slog.Error("failed to store provenance", slog.String("key", dst), slog.String("err", err.Error()))
+			return
+		}
+		p.SHA256 = hex.EncodeToString(sum)
+	}
+
+	if err := writeSidecar(ctx, s, dst+provenanceExtension, p); err != nil {
+		slog.Error("failed to store provenance", slog.String("key", dst), slog.String("err", err.Error()))
+	}
+}
+
+func objectSHA256(ctx context.Context, s objectStorage, key string) ([]byte, error) {
+	r, err := s.reader(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+// copyModule copies the archive of the module version together with its docs and info to another module
+func copyModule(ctx context.Context, s objectStorage, src, dst core.Module) error {
+	prefix, archiveFormat := s.layout()
+	srcKey := modulePath(prefix, src.Namespace, src.Name, src.Provider, src.Version, archiveFormat)
+	dstKey := modulePath(prefix, dst.Namespace, dst.Name, dst.Provider, dst.Version, archiveFormat)
+
+	if exists, err := s.objectExists(ctx, srcKey); err != nil {
+		return err
+	} else if !exists {
+		return fmt.Errorf("%w: %s", module.ErrModuleNotFound, src.ID(true))
+	}
+	if exists, err := s.objectExists(ctx, dstKey); err != nil {
+		return err
+	} else if exists {
+		return fmt.Errorf("%w: %s", module.ErrModuleAlreadyExists, dst.ID(true))
+	}
+
+	if err := copyObject(ctx, s, srcKey, dstKey); err != nil {
+		return err
+	}
+	copyProvenance(ctx, s, srcKey, dstKey, src.ID(true))
+
+	// The sidecars can be extracted from the archive again, so failing to copy them doesn't fail the copy
+	for _, extension := range []string{moduleDocsExtension, moduleInfoExtension} {
+		key := modulePath(prefix, src.Namespace, src.Name, src.Provider, src.Version, extension)
+		if exists, err := s.objectExists(ctx, key); err != nil || !exists {
+			continue
+		}
+
+		b, err := s.download(ctx, key)
+		if err == nil {
+			err = s.upload(ctx, modulePath(prefix, dst.Namespace, dst.Name, dst.Provider, dst.Version, extension), bytes.NewReader(b), true)
+		}
+		if err != nil {
+			slog.Warn("failed to copy module sidecar", slog.String("module", dst.ID(true)), slog.String("extension", extension), slog.String("err", err.Error()))
+		}
+	}
+	return nil
+}
+
+// providerRelease returns the files of the provider release, which are read from the storage backend once they are opened
+func providerRelease(ctx context.Context, s objectStorage, namespace, name, version string) (*provider.Release, error) {
+	prefix, _ := s.layout()
+	base := providerStoragePrefix(prefix, internalProviderType, "", namespace, name) + "/"
+
+	objects, err := s.listObjects(ctx, base)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &core.Provider{Namespace: namespace, Name: name, Version: version}
+	release := &provider.Release{
+		Namespace: namespace,
+		Name:      name,
+		Version:   version,
+	}
+	for _, obj := range objects {
+		file := strings.TrimPrefix(obj.key, base)
+		if strings.Contains(file, "/") || strings.HasSuffix(file, provenanceExtension) || !strings.HasPrefix(file, fmt.Sprintf("%s%s_%s_", core.ProviderPrefix, name, version)) {
+			continue
+		}
+
+		key := obj.key
+		f := provider.ReleaseFile{
+			Name: file,
+			Open: func() (io.ReadCloser, error) {
+				return s.reader(ctx, key)
+			},
+		}
+		switch file {
+		case p.ShasumFileName():
+			release.SHA256Sums = f
+		case p.ShasumSignatureFileName():
+			release.Signature = f
+		default:
+			release.Files = append(release.Files, f)
+		}
+	}
+	sort.Slice(release.Files, func(i, j int) bool {
+		return release.Files[i].Name < release.Files[j].Name
+	})
+
+	if release.SHA256Sums.Open == nil || len(release.Platforms()) == 0 {
+		return nil, noMatchingProviderFound(p)
+	}
+	return release, nil
+}
+
+// copyProviderRelease copies all files of the provider release to another namespace.
+// The SHA256SUMS file and its signature are copied first, so that storage backends streaming the copy cross-check the archives with them.
+// Files that exist from a previous, interrupted copy are kept as long as they are identical, so that the copy can be retried.
+func copyProviderRelease(ctx context.Context, s objectStorage, namespace, name, version, targetNamespace string) error {
+	release, err := providerRelease(ctx, s, namespace, name, version)
+	if err != nil {
+		return err
+	}
+
+	prefix, _ := s.layout()
+	src := providerStoragePrefix(prefix, internalProviderType, "", namespace, name)
+	dst := providerStoragePrefix(prefix, internalProviderType, "", targetNamespace, name)
+	copiedFrom := fmt.Sprintf("%s/%s/%s", namespace, name, version)
+
+	files := append([]provider.ReleaseFile{release.SHA256Sums, release.Signature}, release.Files...)
+	for _, f := range files {
+		if f.Open == nil {
+			continue
+		}
+
+		srcKey, dstKey := path.Join(src, f.Name), path.Join(dst, f.Name)
+		err := copyObject(ctx, s, srcKey, dstKey)
+		if errors.Is(err, core.ErrObjectAlreadyExists) {
+			if err := compareObjects(ctx, s, srcKey, dstKey); err != nil {
+				return err
+			}
+			slog.Info("provider release file exists already", slog.String("name", f.Name))
+
+			// The previous copy might have been interrupted before the provenance was stored
+			if exists, err := s.objectExists(ctx, dstKey+provenanceExtension); err != nil || exists {
+				continue
+			}
+		} else if err != nil {
+			return err
+		}
+		copyProvenance(ctx, s, srcKey, dstKey, copiedFrom)
+	}
+
+	return nil
+}
+
+// compareObjects fails if the content of the objects differs.
+// The objects are compared by their SHA-256 checksum, so that large archives aren't held in memory.
+func compareObjects(ctx context.Context, s objectStorage, a, b string) error {
+	sumA, err := objectSHA256(ctx, s, a)
+	if err != nil {
+		return err
+	}
+	sumB, err := objectSHA256(ctx, s, b)
+	if err != nil {
+		return err
+	}
+
+	if !bytes.Equal(sumA, sumB) {
+		return fmt.Errorf("%w: %s with a different content", core.ErrObjectAlreadyExists, b)
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/metadata"
+	"github.com/boring-registry/boring-registry/pkg/module"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCopyModule(t *testing.T) {
+	t.Parallel()
+
+	s := newTestPluginStorage(t, t.TempDir())
+	ctx := metadata.WithUploader(core.WithProvenance(context.Background(), core.Provenance{GitCommit: "0123abc"}), "ci-bot")
+
+	src := core.Module{Namespace: "staging", Name: "vpc", Provider: "aws", Version: "1.0.0"}
+	dst := core.Module{Namespace: "prod", Name: "vpc", Provider: "aws", Version: "1.0.0"}
+
+	_, err := s.CopyModule(ctx, src, dst)
+	assert.ErrorIs(t, err, module.ErrModuleNotFound)
+
+	_, err = s.UploadModule(ctx, src.Namespace, src.Name, src.Provider, src.Version, strings.NewReader("archive"))
+	assert.NoError(t, err)
+	docsKey := modulePath("", src.Namespace, src.Name, src.Provider, src.Version, moduleDocsExtension)
+	assert.NoError(t, s.upload(ctx, docsKey, strings.NewReader(`{"inputs":[]}`), true))
+
+	promoteCtx := metadata.WithUploader(context.Background(), "release-bot")
+	m, err := s.CopyModule(promoteCtx, src, dst)
+	assert.NoError(t, err)
+	assert.Equal(t, "prod", m.Namespace)
+
+	archive, err := s.DownloadModule(ctx, dst.Namespace, dst.Name, dst.Provider, dst.Version)
+	assert.NoError(t, err)
+	content, err := io.ReadAll(archive)
+	assert.NoError(t, err)
+	assert.NoError(t, archive.Close())
+	assert.Equal(t, "archive", string(content))
+
+	docs, err := s.download(ctx, modulePath("", dst.Namespace, dst.Name, dst.Provider, dst.Version, moduleDocsExtension))
+	assert.NoError(t, err)
+	assert.Equal(t, `{"inputs":[]}`, string(docs))
+
+	p, err := s.GetModuleProvenance(ctx, dst.Namespace, dst.Name, dst.Provider, dst.Version)
+	assert.NoError(t, err)
+	assert.Equal(t, "prod-vpc-aws-1.0.0.tar.gz", p.Artifact)
+	assert.Equal(t, "release-bot", p.Uploader)
+	assert.Equal(t, "staging/vpc/aws/1.0.0", p.CopiedFrom)
+	assert.Equal(t, fmt.Sprintf("%x", sha256.Sum256([]byte("archive"))), p.SHA256)
+	if assert.NotNil(t, p.Origin) {
+		assert.Equal(t, "ci-bot", p.Origin.Uploader)
+		assert.Equal(t, "0123abc", p.Origin.GitCommit)
+	}
+
+	_, err = s.CopyModule(ctx, src, dst)
+	assert.ErrorIs(t, err, module.ErrModuleAlreadyExists)
+}
+
+func TestCopyProviderRelease(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	s := newTestPluginStorage(t, root)
+	ctx := metadata.WithUploader(context.Background(), "ci-bot")
+
+	sums := fmt.Sprintf("%x  terraform-provider-dummy_1.0.0_linux_amd64.zip\n%x  terraform-provider-dummy_1.0.0_darwin_arm64.zip\n",
+		sha256.Sum256([]byte("linux")), sha256.Sum256([]byte("darwin")))
+	files := map[string]string{
+		"terraform-provider-dummy_1.0.0_SHA256SUMS":       sums,
+		"terraform-provider-dummy_1.0.0_SHA256SUMS.sig":   "sig",
+		"terraform-provider-dummy_1.0.0_linux_amd64.zip":  "linux",
+		"terraform-provider-dummy_1.0.0_darwin_arm64.zip": "darwin",
+	}
+	for name, content := range files {
+		assert.NoError(t, s.UploadProviderReleaseFiles(ctx, "staging", "dummy", name, strings.NewReader(content)))
+	}
+
+	_, err := s.GetProviderRelease(ctx, "staging", "dummy", "2.0.0")
+	var providerErr *core.ProviderError
+	assert.ErrorAs(t, err, &providerErr)
+
+	release, err := s.GetProviderRelease(ctx, "staging", "dummy", "1.0.0")
+	assert.NoError(t, err)
+	assert.Equal(t, "terraform-provider-dummy_1.0.0_SHA256SUMS.sig", release.Signature.Name)
+	assert.Len(t, release.Files, 2)
+
+	// An identical SHA256SUMS file and archive from an interrupted copy are kept
+	assert.NoError(t, s.UploadProviderReleaseFiles(ctx, "prod", "dummy", "terraform-provider-dummy_1.0.0_SHA256SUMS", strings.NewReader(sums)))
+	assert.NoError(t, s.UploadProviderReleaseFiles(ctx, "prod", "dummy", "terraform-provider-dummy_1.0.0_linux_amd64.zip", strings.NewReader("linux")))
+
+	assert.NoError(t, s.CopyProviderRelease(ctx, "staging", "dummy", "1.0.0", "prod"))
+	for name, content := range files {
+		b, err := os.ReadFile(filepath.Join(root, "providers/prod/dummy", name))
+		assert.NoError(t, err)
+		assert.Equal(t, content, string(b))
+	}
+
+	provenance, err := s.GetProviderProvenance(ctx, "prod", "dummy", "1.0.0")
+	assert.NoError(t, err)
+	if assert.Len(t, provenance, 4) {
+		assert.Empty(t, provenance[0].CopiedFrom, "the existing SHA256SUMS file wasn't copied")
+		assert.Equal(t, "terraform-provider-dummy_1.0.0_darwin_arm64.zip", provenance[2].Artifact)
+		assert.Equal(t, "staging/dummy/1.0.0", provenance[2].CopiedFrom)
+		assert.Equal(t, fmt.Sprintf("%x", sha256.Sum256([]byte("darwin"))), provenance[2].SHA256)
+		assert.NotNil(t, provenance[2].Origin)
+		assert.Empty(t, provenance[3].CopiedFrom, "the existing archive wasn't copied")
+	}
+
+	// Copying again is a no-op, unless a file differs
+	assert.NoError(t, s.CopyProviderRelease(ctx, "staging", "dummy", "1.0.0", "prod"))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "providers/prod/dummy/terraform-provider-dummy_1.0.0_darwin_arm64.zip"), []byte("other"), 0o644))
+	err = s.CopyProviderRelease(ctx, "staging", "dummy", "1.0.0", "prod")
+	assert.ErrorIs(t, err, core.ErrObjectAlreadyExists)
+}
@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/module"
+)
+
+// deleteModule removes the archive of the module version together with its provenance, docs and info.
+// The archive is removed last, so that an interrupted deletion can be repeated.
+func deleteModule(ctx context.Context, s objectStorage, m core.Module) error {
+	prefix, archiveFormat := s.layout()
+	key := modulePath(prefix, m.Namespace, m.Name, m.Provider, m.Version, archiveFormat)
+
+	if exists, err := s.objectExists(ctx, key); err != nil {
+		return err
+	} else if !exists {
+		return fmt.Errorf("%w: %s", module.ErrModuleNotFound, m.ID(true))
+	}
+
+	keys := []string{
+		key + provenanceExtension,
+		modulePath(prefix, m.Namespace, m.Name, m.Provider, m.Version, moduleDocsExtension),
+		modulePath(prefix, m.Namespace, m.Name, m.Provider, m.Version, moduleInfoExtension),
+		key,
+	}
+	return deleteObjects(ctx, s, keys...)
+}
+
+// deleteProviderRelease removes the archives of the provider release with their provenance, and the SHA256SUMS file and its signature last
+func deleteProviderRelease(ctx context.Context, s objectStorage, namespace, name, version string) error {
+	release, err := providerRelease(ctx, s, namespace, name, version)
+	if err != nil {
+		return err
+	}
+
+	prefix, _ := s.layout()
+	base := providerStoragePrefix(prefix, internalProviderType, "", namespace, name)
+
+	var keys []string
+	for _, f := range release.Files {
+		keys = append(keys, path.Join(base, f.Name)+provenanceExtension, path.Join(base, f.Name))
+	}
+	if release.Signature.Open != nil {
+		keys = append(keys, path.Join(base, release.Signature.Name)+provenanceExtension, path.Join(base, release.Signature.Name))
+	}
+	keys = append(keys, path.Join(base, release.SHA256Sums.Name)+provenanceExtension, path.Join(base, release.SHA256Sums.Name))
+	return deleteObjects(ctx, s, keys...)
+}
+
+// deleteObjects removes the objects in order and skips objects that don't exist
+func deleteObjects(ctx context.Context, s objectStorage, keys ...string) error {
+	for _, key := range keys {
+		exists, err := s.objectExists(ctx, key)
+		if err != nil {
+			return err
+		} else if !exists {
+			continue
+		}
+
+		if err := s.deleteObject(ctx, key); err != nil {
+			return fmt.Errorf("failed to delete %s: %w", key, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/module"
+)
+
+const (
+	// moduleDocsExtension is the file extension of the docs sidecar, which is stored next to the module archive
+	moduleDocsExtension = "docs.json"
+
+	// moduleInfoExtension is the file extension of the sidecar holding the info of the module spec file
+	moduleInfoExtension = "info.json"
+)
+
+// extracted is the result of reading the module archive while it's uploaded
+type extracted struct {
+	docs *module.Docs
+	info *module.Info
+}
+
+// uploadModuleArchive uploads the module archive and stores its provenance and the docs and info extracted from it in sidecars next to it.
+// They are extracted while the archive is streamed to the storage backend, so the archive is only read once.
+// Failing to extract or store them doesn't fail the upload, as the docs can still be extracted from the archive later on.
+func uploadModuleArchive(ctx context.Context, s objectStorage, m core.Module, key string, body io.Reader) error {
+	pr, pw := io.Pipe()
+	done := make(chan extracted, 1)
+	go func() {
+		docs, info, err := module.Extract(pr)
+		if err != nil {
+			slog.Debug("failed to extract module docs", slog.String("module", m.ID(true)), slog.String("err", err.Error()))
+		}
+
+		// Consume the remaining data, so the upload never blocks
+		_, _ = io.Copy(io.Discard, pr)
+		done <- extracted{docs: docs, info: info}
+	}()
+
+	h := sha256.New()
+	err := s.upload(ctx, key, io.TeeReader(body, io.MultiWriter(pw, h)), true)
+	pw.CloseWithError(err)
+	result := <-done
+	if err != nil {
+		return err
+	}
+
+	storeProvenance(ctx, s, key, h.Sum(nil))
+
+	if result.docs != nil {
+		storeSidecar(ctx, s, m, moduleDocsExtension, result.docs)
+	}
+	if result.info != nil {
+		storeSidecar(ctx, s, m, moduleInfoExtension, result.info)
+	}
+	return nil
+}
+
+// storeSidecar stores v as JSON next to the module archive and only logs failures
+func storeSidecar(ctx context.Context, s objectStorage, m core.Module, extension string, v interface{}) {
+	prefix, _ := s.layout()
+	if err := writeSidecar(ctx, s, modulePath(prefix, m.Namespace, m.Name, m.Provider, m.Version, extension), v); err != nil {
+		slog.Warn("failed to store module sidecar", slog.String("module", m.ID(true)), slog.String("extension", extension), slog.String("err", err.Error()))
+	}
+}
+
+// writeSidecar encodes v as JSON and overwrites the sidecar
+func writeSidecar(ctx context.Context, s objectStorage, key string, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return s.upload(ctx, key, bytes.NewReader(b), true)
+}
+
+// moduleDocs reads the docs sidecar of the module version
+func moduleDocs(ctx context.Context, s objectStorage, namespace, name, provider, version string) (*module.Docs, error) {
+	docs := &module.Docs{}
+	if err := readSidecar(ctx, s, modulePathFor(s, namespace, name, provider, version, moduleDocsExtension), module.ErrDocsNotFound, docs); err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
+
+// moduleInfo reads the info sidecar of the module version
+func moduleInfo(ctx context.Context, s objectStorage, namespace, name, provider, version string) (*module.Info, error) {
+	info := &module.Info{}
+	if err := readSidecar(ctx, s, modulePathFor(s, namespace, name, provider, version, moduleInfoExtension), module.ErrInfoNotFound, info); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+func modulePathFor(s objectStorage, namespace, name, provider, version, extension string) string {
+	prefix, _ := s.layout()
+	return modulePath(prefix, namespace, name, provider, version, extension)
+}
+
+// readSidecar decodes the sidecar into v and returns the notFound error if it doesn't exist
+func readSidecar(ctx context.Context, s objectStorage, key string, notFound error, v interface{}) error {
+	exists, err := s.objectExists(ctx, key)
+	if err != nil {
+		return err
+	} else if !exists {
+		return fmt.Errorf("%w: %s", notFound, key)
+	}
+
+	b, err := s.download(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("failed to decode sidecar %s: %w", key, err)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/boring-registry/boring-registry/pkg/module"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestModuleDocs(t *testing.T) {
+	t.Parallel()
+
+	s := newTestPluginStorage(t, t.TempDir())
+	ctx := context.Background()
+
+	archive := new(bytes.Buffer)
+	gw := gzip.NewWriter(archive)
+	tw := tar.NewWriter(gw)
+	files := map[string]string{
+		"README.md": "# S3",
+		"main.tf":   `variable "bucket" {}`,
+		module.SpecFileName: `
+metadata {
+  namespace = "example"
+  name      = "s3"
+  provider  = "aws"
+  version   = "1.0.0"
+}
+
+info {
+  team = "storage"
+}
+`,
+	}
+	for name, content := range files {
+		assert.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content))}))
+		_, err := tw.Write([]byte(content))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, tw.Close())
+	assert.NoError(t, gw.Close())
+
+	_, err := s.UploadModule(ctx, "example", "s3", "aws", "1.0.0", archive)
+	assert.NoError(t, err)
+
+	docs, err := s.GetModuleDocs(ctx, "example", "s3", "aws", "1.0.0")
+	assert.NoError(t, err)
+	assert.Equal(t, "# S3", docs.Root.Readme)
+	assert.Equal(t, []module.Input{{Name: "bucket", Required: true}}, docs.Root.Inputs)
+
+	info, err := s.GetModuleInfo(ctx, "example", "s3", "aws", "1.0.0")
+	assert.NoError(t, err)
+	assert.Equal(t, &module.Info{Team: "storage"}, info)
+
+	// The upload doesn't fail if the docs can't be extracted
+	_, err = s.UploadModule(ctx, "example", "s3", "aws", "1.1.0", strings.NewReader("archive"))
+	assert.NoError(t, err)
+
+	_, err = s.GetModuleDocs(ctx, "example", "s3", "aws", "1.1.0")
+	assert.ErrorIs(t, err, module.ErrDocsNotFound)
+
+	_, err = s.GetModuleInfo(ctx, "example", "s3", "aws", "1.1.0")
+	assert.ErrorIs(t, err, module.ErrInfoNotFound)
+
+	// The sidecars aren't listed as module versions
+	modules, err := s.ListModuleVersions(ctx, "example", "s3", "aws")
+	assert.NoError(t, err)
+	assert.Len(t, modules, 2)
+}
@@ -0,0 +1,244 @@
+package driver
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// Client implements the Driver interface by talking to a driver over the driver protocol
+type Client struct {
+	client  *http.Client
+	baseURL *url.URL
+	token   string
+}
+
+// Stat returns the metadata of an object
+func (c *Client) Stat(ctx context.Context, key string) (*Object, error) {
+	resp, err := c.do(ctx, http.MethodHead, c.ObjectURL(key).String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp, http.StatusOK); err != nil {
+		return nil, fmt.Errorf("failed to stat %s: %w", key, err)
+	}
+
+	obj := &Object{
+		Key:  key,
+		Size: resp.ContentLength,
+	}
+	if lastModified := resp.Header.Get("Last-Modified"); lastModified != "" {
+		obj.LastModified, _ = http.ParseTime(lastModified)
+	}
+	return obj, nil
+}
+
+// Get returns the content of an object. The caller has to close the returned io.ReadCloser
+func (c *Client) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	resp, err := c.do(ctx, http.MethodGet, c.ObjectURL(key).String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkResponse(resp, http.StatusOK); err != nil {
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to get %s: %w", key, err)
+	}
+	return resp.Body, nil
+}
+
+// Put creates or replaces an object.
+// The SHA-256 checksum of the body is sent as trailer, so that the driver rejects objects that were corrupted on the way.
+func (c *Client) Put(ctx context.Context, key string, body io.Reader) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, c.ObjectURL(key).String(), nil)
+	if err != nil {
+		return err
+	}
+
+	// The trailer is only sent with a chunked body, and its value has to be set before the body returns io.EOF
+	req.Trailer = http.Header{checksumTrailer: nil}
+	req.ContentLength = -1
+	req.Body = io.NopCloser(&checksumReader{r: body, sum: sha256.New(), onEOF: func(sum []byte) {
+		req.Trailer.Set(checksumTrailer, hex.EncodeToString(sum))
+	}})
+
+	resp, err := c.send(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp, http.StatusCreated); err != nil {
+		return fmt.Errorf("failed to put %s: %w", key, err)
+	}
+	return nil
+}
+
+// Delete removes an object
+func (c *Client) Delete(ctx context.Context, key string) error {
+	resp, err := c.do(ctx, http.MethodDelete, c.ObjectURL(key).String(), nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp, http.StatusNoContent); err != nil {
+		return fmt.Errorf("failed to delete %s: %w", key, err)
+	}
+	return nil
+}
+
+// List returns all objects whose keys start with the prefix
+func (c *Client) List(ctx context.Context, prefix string) ([]Object, error) {
+	u := c.baseURL.JoinPath("v1", "objects")
+	u.RawQuery = url.Values{"prefix": []string{prefix}}.Encode()
+
+	resp, err := c.do(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp, http.StatusOK); err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", prefix, err)
+	}
+
+	var list listResponse
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return nil, fmt.Errorf("failed to decode list response: %w", err)
+	}
+	return list.Objects, nil
+}
+
+// ObjectURL returns the URL under which the driver serves the object
+func (c *Client) ObjectURL(key string) *url.URL {
+	return c.baseURL.JoinPath("v1", "objects", key)
+}
+
+// BaseURL returns the URL of the driver
+func (c *Client) BaseURL() *url.URL {
+	return c.baseURL
+}
+
+// AuthorizeRequest adds the token to the request, if it targets the driver
+func (c *Client) AuthorizeRequest(req *http.Request) {
+	if req.URL.Host != c.baseURL.Host || c.token == "" {
+		return
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.token))
+}
+
+func (c *Client) do(ctx context.Context, method, u string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	return c.send(req)
+}
+
+func (c *Client) send(req *http.Request) (*http.Response, error) {
+	c.AuthorizeRequest(req)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach storage driver: %w", err)
+	}
+	return resp, nil
+}
+
+// checkResponse maps unexpected status codes back to the errors of the Driver interface
+func checkResponse(resp *http.Response, expected int) error {
+	if resp.StatusCode == expected {
+		return nil
+	}
+
+	var errResp errorResponse
+	_ = json.NewDecoder(resp.Body).Decode(&errResp)
+
+	var err error
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		err = ErrNotFound
+	case http.StatusBadRequest:
+		err = ErrInvalidKey
+	case http.StatusUnprocessableEntity:
+		err = ErrChecksumMismatch
+	default:
+		err = errors.New("unexpected status code " + strconv.Itoa(resp.StatusCode))
+	}
+
+	if errResp.Error != "" && errResp.Error != err.Error() {
+		return fmt.Errorf("%w: %s", err, errResp.Error)
+	}
+	return err
+}
+
+// checksumReader computes the checksum of the content streamed through it and hands it to onEOF
+type checksumReader struct {
+	r     io.Reader
+	sum   hash.Hash
+	onEOF func(sum []byte)
+}
+
+func (c *checksumReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.sum.Write(p[:n])
+	if errors.Is(err, io.EOF) && c.onEOF != nil {
+		c.onEOF(c.sum.Sum(nil))
+		c.onEOF = nil
+	}
+	return n, err
+}
+
+// ClientOption provides additional options for the Client
+type ClientOption func(*Client)
+
+// WithClientToken configures the token the Client authenticates with
+func WithClientToken(token string) ClientOption {
+	return func(c *Client) {
+		c.token = token
+	}
+}
+
+// WithClientHTTPClient configures the HTTP client used to talk to the driver
+func WithClientHTTPClient(client *http.Client) ClientOption {
+	return func(c *Client) {
+		c.client = client
+	}
+}
+
+// NewClient returns a Client for the driver listening on baseURL
+func NewClient(baseURL string, options ...ClientOption) (*Client, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid driver url: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("driver url %s must use the http or https scheme", baseURL)
+	}
+	if u.Path == "" {
+		// Joined paths have to be absolute for signed URLs to match after proxying
+		u.Path = "/"
+	}
+
+	c := &Client{
+		client:  http.DefaultClient,
+		baseURL: u,
+	}
+
+	for _, option := range options {
+		option(c)
+	}
+
+	return c, nil
+}
@@ -0,0 +1,79 @@
+// Package driver defines the protocol between the boring-registry and external storage drivers.
+//
+// A driver is a separate process exposing a small blob interface over HTTP.
+// The boring-registry either launches the driver process itself, similar to how Terraform runs providers,
+// or connects to an already running driver by its URL.
+//
+// The protocol consists of the following endpoints, which all require the shared token as bearer token:
+//
+//	HEAD /v1/objects/{key}          returns the object metadata in the Content-Length and Last-Modified headers
+//	GET  /v1/objects/{key}          returns the object content
+//	PUT  /v1/objects/{key}          creates or replaces the object with the request body
+//	                                and removes it again if the body doesn't match the X-Checksum-Sha256 trailer
+//	DELETE /v1/objects/{key}        removes the object
+//	GET  /v1/objects?prefix={p}     returns all objects with the given key prefix as JSON
+//
+// Errors are returned as JSON object with an "error" attribute and a matching status code.
+// A missing object results in 404 Not Found, and a checksum mismatch in 422 Unprocessable Entity.
+package driver
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+// ProtocolVersion is the version of the driver protocol implemented by this package
+const ProtocolVersion = 1
+
+var (
+	// ErrNotFound is returned by a Driver if the object doesn't exist
+	ErrNotFound = errors.New("object not found")
+
+	// ErrInvalidKey is returned by a Driver if the object key is not acceptable
+	ErrInvalidKey = errors.New("invalid object key")
+
+	// ErrChecksumMismatch is returned if the uploaded object doesn't match the checksum sent by the client
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+
+	// ErrTokenRequired is returned by Serve if the driver would listen on a non-loopback address without a token
+	ErrTokenRequired = errors.New("a token is required to listen on a non-loopback address")
+)
+
+// checksumTrailer carries the hex-encoded SHA-256 checksum of the body of PUT requests
+const checksumTrailer = "X-Checksum-Sha256"
+
+// Object describes a single object in the storage of a driver
+type Object struct {
+	Key          string    `json:"key"`
+	Size         int64     `json:"size"`
+	LastModified time.Time `json:"last_modified"`
+}
+
+// Driver is the blob interface every storage driver has to implement.
+// Keys are slash-separated paths without a leading slash.
+type Driver interface {
+	// Stat returns the metadata of an object or ErrNotFound
+	Stat(ctx context.Context, key string) (*Object, error)
+
+	// Get returns the content of an object or ErrNotFound
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+
+	// Put creates or replaces an object
+	Put(ctx context.Context, key string, body io.Reader) error
+
+	// Delete removes an object or returns ErrNotFound
+	Delete(ctx context.Context, key string) error
+
+	// List returns all objects whose keys start with the prefix
+	List(ctx context.Context, prefix string) ([]Object, error)
+}
+
+type listResponse struct {
+	Objects []Object `json:"objects"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
@@ -0,0 +1,257 @@
+package driver
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestMain lets the test binary act as driver executable for TestLaunch
+func TestMain(m *testing.M) {
+	if root := os.Getenv("BORING_REGISTRY_DRIVER_TEST_ROOT"); root != "" {
+		d, err := NewFilesystemDriver(root)
+		if err == nil {
+			err = Serve(context.Background(), d)
+		}
+		if err != nil {
+			_, _ = io.WriteString(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+func newTestClient(t *testing.T, token string) *Client {
+	t.Helper()
+	root := t.TempDir()
+	d, err := NewFilesystemDriver(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(NewHandler(d, "secret"))
+	t.Cleanup(ts.Close)
+
+	c, err := NewClient(ts.URL, WithClientToken(token), WithClientHTTPClient(ts.Client()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestClient(t *testing.T) {
+	t.Parallel()
+
+	c := newTestClient(t, "secret")
+	ctx := context.Background()
+
+	_, err := c.Stat(ctx, "modules/example/archive.tar.gz")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = c.Get(ctx, "modules/example/archive.tar.gz")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	for _, key := range []string{"modules/example/archive.tar.gz", "modules/example/other.tar.gz", "modules/example-extra/archive.tar.gz", "providers/example/signing-keys.json"} {
+		assert.NoError(t, c.Put(ctx, key, strings.NewReader("content")))
+	}
+
+	obj, err := c.Stat(ctx, "modules/example/archive.tar.gz")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len("content")), obj.Size)
+	assert.False(t, obj.LastModified.IsZero())
+
+	body, err := c.Get(ctx, "modules/example/archive.tar.gz")
+	assert.NoError(t, err)
+	content, err := io.ReadAll(body)
+	assert.NoError(t, err)
+	assert.NoError(t, body.Close())
+	assert.Equal(t, "content", string(content))
+
+	testCases := []struct {
+		prefix string
+		keys   []string
+	}{
+		{
+			prefix: "modules/example/",
+			keys:   []string{"modules/example/archive.tar.gz", "modules/example/other.tar.gz"},
+		},
+		{
+			prefix: "modules/example",
+			keys:   []string{"modules/example/archive.tar.gz", "modules/example/other.tar.gz", "modules/example-extra/archive.tar.gz"},
+		},
+		{
+			prefix: "",
+			keys:   []string{"modules/example/archive.tar.gz", "modules/example/other.tar.gz", "modules/example-extra/archive.tar.gz", "providers/example/signing-keys.json"},
+		},
+		{
+			prefix: "missing/",
+		},
+	}
+	for _, tc := range testCases {
+		objects, err := c.List(ctx, tc.prefix)
+		assert.NoError(t, err)
+		var keys []string
+		for _, o := range objects {
+			keys = append(keys, o.Key)
+		}
+		assert.ElementsMatch(t, tc.keys, keys, tc.prefix)
+	}
+
+	assert.NoError(t, c.Delete(ctx, "modules/example/other.tar.gz"))
+	_, err = c.Stat(ctx, "modules/example/other.tar.gz")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.ErrorIs(t, c.Delete(ctx, "modules/example/other.tar.gz"), ErrNotFound)
+}
+
+func TestFilesystemDriver_InvalidKey(t *testing.T) {
+	t.Parallel()
+
+	d, err := NewFilesystemDriver(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range []string{"", "../outside", "modules/../../outside", "/etc/passwd"} {
+		err := d.Put(context.Background(), key, strings.NewReader("content"))
+		assert.ErrorIs(t, err, ErrInvalidKey, key)
+		_, err = d.Stat(context.Background(), key)
+		assert.ErrorIs(t, err, ErrInvalidKey, key)
+	}
+}
+
+func TestClient_ChecksumMismatch(t *testing.T) {
+	t.Parallel()
+
+	c := newTestClient(t, "secret")
+	ctx := context.Background()
+	key := "modules/example/archive.tar.gz"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, c.ObjectURL(key).String(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Trailer = http.Header{checksumTrailer: []string{"0000"}}
+	req.ContentLength = -1
+	req.Body = io.NopCloser(strings.NewReader("content"))
+
+	resp, err := c.send(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	assert.ErrorIs(t, checkResponse(resp, http.StatusCreated), ErrChecksumMismatch)
+
+	// The corrupted object is removed again
+	_, err = c.Stat(ctx, key)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestClient_Unauthorized(t *testing.T) {
+	t.Parallel()
+
+	c := newTestClient(t, "wrong")
+	err := c.Put(context.Background(), "modules/example/archive.tar.gz", strings.NewReader("content"))
+	assert.ErrorContains(t, err, "unauthorized")
+}
+
+func TestParseHandshake(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name    string
+		line    string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "valid handshake",
+			line: "BORING_REGISTRY_DRIVER|1|http://127.0.0.1:1234\n",
+			want: "http://127.0.0.1:1234",
+		},
+		{
+			name:    "unsupported protocol version",
+			line:    "BORING_REGISTRY_DRIVER|2|http://127.0.0.1:1234",
+			wantErr: true,
+		},
+		{
+			name:    "unrelated output",
+			line:    "starting driver",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseHandshake(tc.line)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestLaunch(t *testing.T) {
+	executable, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("BORING_REGISTRY_DRIVER_TEST_ROOT", t.TempDir())
+
+	p, err := Launch(context.Background(), executable)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	assert.NoError(t, p.Put(ctx, "modules/example/archive.tar.gz", strings.NewReader("content")))
+	objects, err := p.List(ctx, "modules/")
+	assert.NoError(t, err)
+	assert.Len(t, objects, 1)
+
+	assert.NoError(t, p.Close())
+}
+
+func TestServe_TokenRequired(t *testing.T) {
+	d, err := NewFilesystemDriver(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tc := []struct {
+		name    string
+		address string
+		token   string
+		err     error
+	}{
+		{name: "all interfaces", address: ":0", err: ErrTokenRequired},
+		{name: "unspecified address", address: "0.0.0.0:0", err: ErrTokenRequired},
+		{name: "all interfaces with token", address: ":0", token: "secret"},
+		{name: "loopback", address: "127.0.0.1:0"},
+		{name: "localhost", address: "localhost:0"},
+		{name: "ipv6 loopback", address: "[::1]:0"},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			// The driver shuts down immediately, as the context is cancelled already
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			err := Serve(ctx, d, WithListenAddress(c.address), WithToken(c.token))
+			if c.err != nil {
+				assert.ErrorIs(t, err, c.err)
+			} else if err != nil {
+				assert.NotErrorIs(t, err, ErrTokenRequired)
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package driver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// temporaryFilePrefix is used for files that are still being written
+const temporaryFilePrefix = ".boring-registry-"
+
+// FilesystemDriver is the reference Driver implementation, which stores objects as files below a root directory
+type FilesystemDriver struct {
+	root string
+}
+
+// Stat returns the metadata of the file
+func (d *FilesystemDriver) Stat(_ context.Context, key string) (*Object, error) {
+	p, err := d.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(p)
+	if errors.Is(err, fs.ErrNotExist) || (err == nil && info.IsDir()) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &Object{
+		Key:          key,
+		Size:         info.Size(),
+		LastModified: info.ModTime(),
+	}, nil
+}
+
+// Get opens the file
+func (d *FilesystemDriver) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	if _, err := d.Stat(ctx, key); err != nil {
+		return nil, err
+	}
+
+	p, err := d.path(key)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(p)
+}
+
+// Put writes the body to a temporary file first, which is then renamed to the final file.
+// Concurrent readers therefore never observe partially written files.
+func (d *FilesystemDriver) Put(_ context.Context, key string, body io.Reader) error {
+	p, err := d.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(p), temporaryFilePrefix+"*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := io.Copy(f, body); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write %s: %w", key, err)
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), p)
+}
+
+// Delete removes the file
+func (d *FilesystemDriver) Delete(ctx context.Context, key string) error {
+	if _, err := d.Stat(ctx, key); err != nil {
+		return err
+	}
+
+	p, err := d.path(key)
+	if err != nil {
+		return err
+	}
+	return os.Remove(p)
+}
+
+// List walks the deepest directory contained in the prefix and returns all files matching the prefix
+func (d *FilesystemDriver) List(_ context.Context, prefix string) ([]Object, error) {
+	start := d.root
+	if i := strings.LastIndex(prefix, "/"); i > 0 {
+		var err error
+		if start, err = d.path(prefix[:i]); err != nil {
+			return nil, err
+		}
+	}
+
+	var objects []Object
+	err := filepath.WalkDir(start, func(p string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), temporaryFilePrefix) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(d.root, p)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		objects = append(objects, Object{
+			Key:          key,
+			Size:         info.Size(),
+			LastModified: info.ModTime(),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return objects, nil
+}
+
+// path maps the key to a file below the root directory and rejects keys escaping it
+func (d *FilesystemDriver) path(key string) (string, error) {
+	if key == "" || path.IsAbs(key) || !filepath.IsLocal(filepath.FromSlash(key)) {
+		return "", fmt.Errorf("%w: %s", ErrInvalidKey, key)
+	}
+	return filepath.Join(d.root, filepath.FromSlash(key)), nil
+}
+
+// NewFilesystemDriver returns a FilesystemDriver storing objects below root. The directory is created if necessary
+func NewFilesystemDriver(root string) (*FilesystemDriver, error) {
+	if root == "" {
+		return nil, errors.New("root directory is empty")
+	}
+
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(abs, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create root directory: %w", err)
+	}
+
+	return &FilesystemDriver{root: abs}, nil
+}
@@ -0,0 +1,146 @@
+package driver
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+type handler struct {
+	driver Driver
+	token  string
+}
+
+// NewHandler returns an http.Handler serving the driver protocol for the Driver.
+// Requests have to present the token as bearer token, unless the token is empty.
+func NewHandler(d Driver, token string) http.Handler {
+	h := &handler{
+		driver: d,
+		token:  token,
+	}
+
+	r := mux.NewRouter().StrictSlash(true)
+	r.Use(h.authenticate)
+	r.Methods("GET").Path("/v1/objects").HandlerFunc(h.list)
+	r.Methods("HEAD").Path("/v1/objects/{key:.+}").HandlerFunc(h.stat)
+	r.Methods("GET").Path("/v1/objects/{key:.+}").HandlerFunc(h.get)
+	r.Methods("PUT").Path("/v1/objects/{key:.+}").HandlerFunc(h.put)
+	r.Methods("DELETE").Path("/v1/objects/{key:.+}").HandlerFunc(h.delete)
+
+	return r
+}
+
+func (h *handler) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if h.token != "" {
+			token, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(token), []byte(h.token)) != 1 {
+				writeError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (h *handler) stat(w http.ResponseWriter, r *http.Request) {
+	obj, err := h.driver.Stat(r.Context(), mux.Vars(r)["key"])
+	if err != nil {
+		// HEAD responses can't have a body, so only the status code is relevant
+		w.WriteHeader(statusCode(err))
+		return
+	}
+
+	w.Header().Set("Content-Length", strconv.FormatInt(obj.Size, 10))
+	w.Header().Set("Last-Modified", obj.LastModified.UTC().Format(http.TimeFormat))
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *handler) get(w http.ResponseWriter, r *http.Request) {
+	key := mux.Vars(r)["key"]
+	body, err := h.driver.Get(r.Context(), key)
+	if err != nil {
+		writeError(w, statusCode(err), err)
+		return
+	}
+	defer body.Close()
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, body); err != nil {
+		slog.Error("failed to write object", slog.String("key", key), slog.String("err", err.Error()))
+	}
+}
+
+// put verifies the body against the checksum trailer once the driver stored it, as the trailer is only available afterward
+func (h *handler) put(w http.ResponseWriter, r *http.Request) {
+	key := mux.Vars(r)["key"]
+	sum := sha256.New()
+	if err := h.driver.Put(r.Context(), key, io.TeeReader(r.Body, sum)); err != nil {
+		writeError(w, statusCode(err), err)
+		return
+	}
+
+	expected := r.Trailer.Get(checksumTrailer)
+	if actual := hex.EncodeToString(sum.Sum(nil)); expected != "" && expected != actual {
+		err := fmt.Errorf("%w: SHA-256 of %s is %s instead of %s", ErrChecksumMismatch, key, actual, expected)
+		if deleteErr := h.driver.Delete(r.Context(), key); deleteErr != nil {
+			err = errors.Join(err, fmt.Errorf("failed to delete %s: %w", key, deleteErr))
+		}
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (h *handler) delete(w http.ResponseWriter, r *http.Request) {
+	if err := h.driver.Delete(r.Context(), mux.Vars(r)["key"]); err != nil {
+		writeError(w, statusCode(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *handler) list(w http.ResponseWriter, r *http.Request) {
+	objects, err := h.driver.List(r.Context(), r.URL.Query().Get("prefix"))
+	if err != nil {
+		writeError(w, statusCode(err), err)
+		return
+	}
+
+	if objects == nil {
+		objects = []Object{}
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	_ = json.NewEncoder(w).Encode(listResponse{Objects: objects})
+}
+
+func statusCode(err error) int {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrInvalidKey):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrChecksumMismatch):
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(errorResponse{Error: err.Error()})
+}
@@ -0,0 +1,262 @@
+package driver
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// TokenEnv is the environment variable through which a launched driver receives the token it has to verify.
+	// Its presence signals the driver that it was launched by the boring-registry.
+	TokenEnv = "BORING_REGISTRY_DRIVER_TOKEN"
+
+	// handshakePrefix starts the line a launched driver prints to stdout once it's ready to serve
+	handshakePrefix = "BORING_REGISTRY_DRIVER"
+
+	handshakeTimeout = 30 * time.Second
+)
+
+// Plugin is a driver process launched by the boring-registry
+type Plugin struct {
+	*Client
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// Close stops the driver process.
+// Closing stdin asks the driver to shut down gracefully, it's killed if it doesn't exit in time.
+func (p *Plugin) Close() error {
+	p.closeOnce.Do(func() {
+		_ = p.stdin.Close()
+
+		done := make(chan error, 1)
+		go func() {
+			done <- p.cmd.Wait()
+		}()
+
+		select {
+		case p.closeErr = <-done:
+		case <-time.After(10 * time.Second):
+			_ = p.cmd.Process.Kill()
+			p.closeErr = <-done
+		}
+	})
+	return p.closeErr
+}
+
+// Launch starts the driver executable and waits for its handshake.
+// The driver is stopped once the context is done, or when the boring-registry exits and its stdin is closed.
+func Launch(ctx context.Context, command string, args ...string) (*Plugin, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	token := hex.EncodeToString(b)
+
+	cmd := exec.Command(command, args...)
+	cmd.Env = append(os.Environ(), fmt.Sprintf("%s=%s", TokenEnv, token))
+	cmd.Stderr = os.Stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start storage driver: %w", err)
+	}
+	p := &Plugin{
+		cmd:   cmd,
+		stdin: stdin,
+	}
+
+	lines := make(chan string, 1)
+	go func() {
+		scanner := bufio.NewScanner(stdout)
+		if scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		close(lines)
+		// Anything the driver prints afterward is discarded, so it can't block on a full pipe
+		_, _ = io.Copy(io.Discard, stdout)
+	}()
+
+	var line string
+	select {
+	case l, ok := <-lines:
+		if !ok {
+			_ = p.Close()
+			return nil, errors.New("storage driver exited before completing the handshake")
+		}
+		line = l
+	case <-time.After(handshakeTimeout):
+		_ = p.Close()
+		return nil, errors.New("timed out waiting for the storage driver handshake")
+	case <-ctx.Done():
+		_ = p.Close()
+		return nil, ctx.Err()
+	}
+
+	address, err := parseHandshake(line)
+	if err != nil {
+		_ = p.Close()
+		return nil, err
+	}
+
+	p.Client, err = NewClient(address, WithClientToken(token))
+	if err != nil {
+		_ = p.Close()
+		return nil, err
+	}
+
+	go func() {
+		<-ctx.Done()
+		_ = p.Close()
+	}()
+
+	slog.Info("launched storage driver", slog.String("command", command), slog.String("address", address))
+	return p, nil
+}
+
+// parseHandshake parses the line in the format BORING_REGISTRY_DRIVER|<protocol version>|<url>
+func parseHandshake(line string) (string, error) {
+	parts := strings.Split(strings.TrimSpace(line), "|")
+	if len(parts) != 3 || parts[0] != handshakePrefix {
+		return "", fmt.Errorf("invalid storage driver handshake: %q", line)
+	}
+
+	version, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return "", fmt.Errorf("invalid storage driver protocol version: %q", parts[1])
+	}
+	if version != ProtocolVersion {
+		return "", fmt.Errorf("storage driver speaks protocol version %d, but version %d is required", version, ProtocolVersion)
+	}
+
+	return parts[2], nil
+}
+
+// DefaultListenAddress is the address the driver listens on if it wasn't launched by the boring-registry
+const DefaultListenAddress = "127.0.0.1:5602"
+
+// ServeOption provides additional options for Serve
+type ServeOption func(*serveConfig)
+
+type serveConfig struct {
+	listenAddress string
+	token         string
+}
+
+// WithListenAddress configures the address the driver listens on, if it wasn't launched by the boring-registry
+func WithListenAddress(address string) ServeOption {
+	return func(c *serveConfig) {
+		c.listenAddress = address
+	}
+}
+
+// WithToken configures the token the driver verifies, if it wasn't launched by the boring-registry
+func WithToken(token string) ServeOption {
+	return func(c *serveConfig) {
+		c.token = token
+	}
+}
+
+// Serve serves the Driver until the context is done.
+//
+// If the driver was launched by the boring-registry, it listens on a random port on the loopback interface
+// and announces it through the handshake on stdout. It then shuts down once stdin is closed.
+// Otherwise, it listens on the configured address, which requires a token unless it's a loopback address.
+func Serve(ctx context.Context, d Driver, options ...ServeOption) error {
+	config := &serveConfig{
+		listenAddress: DefaultListenAddress,
+	}
+	for _, option := range options {
+		option(config)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	launched := false
+	if token, ok := os.LookupEnv(TokenEnv); ok {
+		launched = true
+		config.listenAddress = "127.0.0.1:0"
+		config.token = token
+	}
+
+	if !launched && config.token == "" && !isLoopback(config.listenAddress) {
+		return fmt.Errorf("%w: %s", ErrTokenRequired, config.listenAddress)
+	}
+
+	listener, err := net.Listen("tcp", config.listenAddress)
+	if err != nil {
+		return err
+	}
+
+	if launched {
+		go func() {
+			// The boring-registry never writes to stdin, it's only closed on exit
+			_, _ = io.Copy(io.Discard, os.Stdin)
+			cancel()
+		}()
+
+		if _, err := fmt.Fprintf(os.Stdout, "%s|%d|http://%s\n", handshakePrefix, ProtocolVersion, listener.Addr()); err != nil {
+			return err
+		}
+	} else {
+		slog.Info("starting storage driver", slog.String("listen", listener.Addr().String()))
+	}
+
+	server := &http.Server{
+		Handler:           NewHandler(d, config.token),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- server.Serve(listener)
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer shutdownCancel()
+		return server.Shutdown(shutdownCtx)
+	}
+}
+
+// isLoopback returns whether the address only accepts connections from the same host
+func isLoopback(address string) bool {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return false
+	} else if host == "localhost" {
+		return true
+	}
+
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -3,21 +3,28 @@ package storage
 import (
 	"bytes"
 	"context"
+	"encoding/binary"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"path"
 	"path/filepath"
+	"strconv"
 	"time"
 
+	"github.com/boring-registry/boring-registry/pkg/alias"
 	"github.com/boring-registry/boring-registry/pkg/core"
 	"github.com/boring-registry/boring-registry/pkg/module"
+	"github.com/boring-registry/boring-registry/pkg/namespace"
+	"github.com/boring-registry/boring-registry/pkg/provider"
 
 	credentials "cloud.google.com/go/iam/credentials/apiv1"
 	"cloud.google.com/go/iam/credentials/apiv1/credentialspb"
 	"cloud.google.com/go/storage"
 	"golang.org/x/oauth2/google"
+	"google.golang.org/api/googleapi"
 	"google.golang.org/api/iterator"
 )
 
@@ -76,11 +83,17 @@ func (s *GCSStorage) ListModuleVersions(ctx context.Context, namespace, name, pr
 			// TODO: we're skipping possible failures silently
 			continue
 		}
+		m.PublishedAt = attrs.Created
 		modules = append(modules, *m)
 	}
 	return modules, nil
 }
 
+// ListModules returns every version of all modules, restricted to the namespace if it isn't empty
+func (s *GCSStorage) ListModules(ctx context.Context, namespace string) ([]core.Module, error) {
+	return listModules(ctx, s, namespace)
+}
+
 func (s *GCSStorage) UploadModule(ctx context.Context, namespace, name, provider, version string, body io.Reader) (core.Module, error) {
 	if namespace == "" {
 		return core.Module{}, errors.New("namespace not defined")
@@ -103,17 +116,56 @@ func (s *GCSStorage) UploadModule(ctx context.Context, namespace, name, provider
 		return core.Module{}, fmt.Errorf("%w: %s", module.ErrModuleAlreadyExists, key)
 	}
 
-	wc := s.sc.Bucket(s.bucket).Object(key).NewWriter(ctx)
-	if _, err := io.Copy(wc, body); err != nil {
-		return core.Module{}, fmt.Errorf("%v: %w", module.ErrModuleUploadFailed, err)
-	}
-	if err := wc.Close(); err != nil {
+	m := core.Module{Namespace: namespace, Name: name, Provider: provider, Version: version}
+	if err := uploadModuleArchive(ctx, s, m, key, body); err != nil {
 		return core.Module{}, fmt.Errorf("%v: %w", module.ErrModuleUploadFailed, err)
 	}
 
 	return s.GetModule(ctx, namespace, name, provider, version)
 }
 
+// DownloadModule streams the module archive from the GCS bucket. The caller has to close the returned io.ReadCloser
+func (s *GCSStorage) DownloadModule(ctx context.Context, namespace, name, provider, version string) (io.ReadCloser, error) {
+	key := modulePath(s.bucketPrefix, namespace, name, provider, version, s.moduleArchiveFormat)
+
+	exists, err := s.objectExists(ctx, key)
+	if err != nil {
+		return nil, err
+	} else if !exists {
+		return nil, module.ErrModuleNotFound
+	}
+
+	return s.reader(ctx, key)
+}
+
+// GetModuleDocs reads the docs that were extracted when the module version was uploaded
+func (s *GCSStorage) GetModuleDocs(ctx context.Context, namespace, name, provider, version string) (*module.Docs, error) {
+	return moduleDocs(ctx, s, namespace, name, provider, version)
+}
+
+// GetModuleInfo reads the info of the module spec file that was stored when the module version was uploaded
+func (s *GCSStorage) GetModuleInfo(ctx context.Context, namespace, name, provider, version string) (*module.Info, error) {
+	return moduleInfo(ctx, s, namespace, name, provider, version)
+}
+
+// GetModuleProvenance reads the provenance that was recorded when the module version was uploaded
+func (s *GCSStorage) GetModuleProvenance(ctx context.Context, namespace, name, provider, version string) (*core.Provenance, error) {
+	return moduleProvenance(ctx, s, namespace, name, provider, version)
+}
+
+// CopyModule copies the module version to another module, without transferring the archive through the registry if possible
+func (s *GCSStorage) CopyModule(ctx context.Context, src, dst core.Module) (core.Module, error) {
+	if err := copyModule(ctx, s, src, dst); err != nil {
+		return core.Module{}, err
+	}
+	return s.GetModule(ctx, dst.Namespace, dst.Name, dst.Provider, dst.Version)
+}
+
+// DeleteModule removes the archive of the module version together with its provenance, docs and info
+func (s *GCSStorage) DeleteModule(ctx context.Context, namespace, name, provider, version string) error {
+	return deleteModule(ctx, s, core.Module{Namespace: namespace, Name: name, Provider: provider, Version: version})
+}
+
 // GetProvider implements provider.Storage
 func (s *GCSStorage) getProvider(ctx context.Context, pt providerType, provider *core.Provider) (*core.Provider, error) {
 	var archivePath, shasumPath, shasumSigPath string
@@ -248,6 +300,11 @@ func (s *GCSStorage) ListProviderVersions(ctx context.Context, namespace, name s
 	return collection.List(), nil
 }
 
+// ListProviders returns every version of all providers, restricted to the namespace if it isn't empty
+func (s *GCSStorage) ListProviders(ctx context.Context, namespace string) ([]core.ProviderVersion, error) {
+	return listProviders(ctx, s, namespace)
+}
+
 func (s *GCSStorage) ListMirroredProviders(ctx context.Context, provider *core.Provider) ([]*core.Provider, error) {
 	return s.listProviderVersions(ctx, mirrorProviderType, provider)
 }
@@ -267,7 +324,62 @@ func (s *GCSStorage) UploadProviderReleaseFiles(ctx context.Context, namespace,
 
 	prefix := providerStoragePrefix(s.bucketPrefix, internalProviderType, "", namespace, name)
 	key := filepath.Join(prefix, filename)
-	return s.upload(ctx, key, file, false)
+	return uploadWithProvenance(ctx, s, key, file, false)
+}
+
+// GetProviderProvenance reads the provenance that was recorded when the files of the provider release were uploaded
+func (s *GCSStorage) GetProviderProvenance(ctx context.Context, namespace, name, version string) ([]core.Provenance, error) {
+	return providerProvenance(ctx, s, namespace, name, version)
+}
+
+// GetProviderRelease returns the files of the provider release, which are read once they are opened
+func (s *GCSStorage) GetProviderRelease(ctx context.Context, namespace, name, version string) (*provider.Release, error) {
+	return providerRelease(ctx, s, namespace, name, version)
+}
+
+// CopyProviderRelease copies all files of the provider release to another namespace
+func (s *GCSStorage) CopyProviderRelease(ctx context.Context, namespace, name, version, targetNamespace string) error {
+	return copyProviderRelease(ctx, s, namespace, name, version, targetNamespace)
+}
+
+// DeleteProviderRelease removes all files of the provider release
+func (s *GCSStorage) DeleteProviderRelease(ctx context.Context, namespace, name, version string) error {
+	return deleteProviderRelease(ctx, s, namespace, name, version)
+}
+
+// ListAliases returns the aliases managed through the API
+func (s *GCSStorage) ListAliases(ctx context.Context) ([]alias.Alias, error) {
+	return listAliases(ctx, s)
+}
+
+// AddAlias stores the alias together with the other aliases managed through the API
+func (s *GCSStorage) AddAlias(ctx context.Context, a alias.Alias) error {
+	return addAlias(ctx, s, a)
+}
+
+// DeleteAlias removes the alias from the aliases managed through the API
+func (s *GCSStorage) DeleteAlias(ctx context.Context, aliasType, from string) error {
+	return deleteAlias(ctx, s, aliasType, from)
+}
+
+// ListNamespaces returns the registered namespaces
+func (s *GCSStorage) ListNamespaces(ctx context.Context) ([]namespace.Namespace, error) {
+	return listNamespaces(ctx, s)
+}
+
+// AddNamespace registers the namespace together with the other registered namespaces
+func (s *GCSStorage) AddNamespace(ctx context.Context, n namespace.Namespace) error {
+	return addNamespace(ctx, s, n)
+}
+
+// UpdateNamespace replaces the registered namespace with the same name
+func (s *GCSStorage) UpdateNamespace(ctx context.Context, n namespace.Namespace) error {
+	return updateNamespace(ctx, s, n)
+}
+
+// DeleteNamespace removes the namespace from the registered namespaces
+func (s *GCSStorage) DeleteNamespace(ctx context.Context, name string) error {
+	return deleteNamespace(ctx, s, name)
 }
 
 func (s *GCSStorage) UploadMirroredFile(ctx context.Context, provider *core.Provider, fileName string, reader io.Reader) error {
@@ -338,32 +450,115 @@ func (s *GCSStorage) upload(ctx context.Context, key string, reader io.Reader, o
 		}
 	}
 
-	wc := s.sc.Bucket(s.bucket).Object(key).NewWriter(ctx)
-	if _, err := io.Copy(wc, reader); err != nil {
+	// Cancelling the context of the writer discards the object, so nothing is stored if the upload fails halfway
+	writeCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	wc := s.sc.Bucket(s.bucket).Object(key).NewWriter(writeCtx)
+
+	// GCS rejects the upload if the content doesn't match the CRC32C checksum, which is only known up front for seekable content like files
+	if crc, ok, err := precomputeCRC32C(reader); err != nil {
+		return fmt.Errorf("failed to compute checksum of %s: %w", key, err)
+	} else if ok {
+		wc.CRC32C, wc.SendCRC32C = crc, true
+	}
+
+	c := newChecksumReader(reader)
+	if _, err := io.Copy(wc, c); err != nil {
+		cancel()
+		_ = wc.Close()
 		return fmt.Errorf("failed to upload object: %w", err)
 	}
 	if err := wc.Close(); err != nil {
 		return fmt.Errorf("failed to upload object: %w", err)
 	}
 
+	return verifyUpload(ctx, s, key, c, func() error {
+		return verifyGCSChecksums(key, c, wc.Attrs())
+	})
+}
+
+// verifyGCSChecksums compares the CRC32C and MD5 checksums computed by GCS with the checksums computed during the upload.
+// GCS doesn't compute an MD5 checksum for composite objects.
+func verifyGCSChecksums(key string, c *checksumReader, attrs *storage.ObjectAttrs) error {
+	if expected := c.crc32cSum(); attrs.CRC32C != expected {
+		return checksumMismatch(key, "CRC32C", binary.BigEndian.AppendUint32(nil, expected), binary.BigEndian.AppendUint32(nil, attrs.CRC32C))
+	}
+	if expected := c.md5Sum(); len(attrs.MD5) > 0 && !bytes.Equal(attrs.MD5, expected) {
+		return checksumMismatch(key, "MD5", expected, attrs.MD5)
+	}
 	return nil
 }
 
-func (s *GCSStorage) download(ctx context.Context, key string) ([]byte, error) {
+func (s *GCSStorage) deleteObject(ctx context.Context, key string) error {
+	if err := s.sc.Bucket(s.bucket).Object(key).Delete(ctx); err != nil {
+		return fmt.Errorf("failed to delete %s: %w", key, err)
+	}
+	return nil
+}
+
+// copyObject copies the object within the bucket
+func (s *GCSStorage) copyObject(ctx context.Context, src, dst string) error {
+	bucket := s.sc.Bucket(s.bucket)
+	_, err := bucket.Object(dst).CopierFrom(bucket.Object(src)).Run(ctx)
+	return err
+}
+
+func (s *GCSStorage) reader(ctx context.Context, key string) (io.ReadCloser, error) {
+	return s.sc.Bucket(s.bucket).Object(key).NewReader(ctx)
+}
+
+// downloadVersion returns the content of the object together with its generation
+func (s *GCSStorage) downloadVersion(ctx context.Context, key string) ([]byte, string, error) {
 	r, err := s.sc.Bucket(s.bucket).Object(key).NewReader(ctx)
-	if err != nil {
-		return nil, err
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return nil, "", nil
+	} else if err != nil {
+		return nil, "", fmt.Errorf("failed to download %s: %w", key, err)
 	}
-	defer func(r *storage.Reader) {
-		_ = r.Close()
-	}(r)
+	defer r.Close()
 
-	data, err := io.ReadAll(r)
+	b, err := io.ReadAll(r)
+	return b, strconv.FormatInt(r.Attrs.Generation, 10), err
+}
+
+// uploadVersion stores the object only if it still has the generation, or doesn't exist yet
+func (s *GCSStorage) uploadVersion(ctx context.Context, key string, b []byte, version *string) error {
+	conditions := storage.Conditions{DoesNotExist: true}
+	if version != nil {
+		generation, err := strconv.ParseInt(*version, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid generation %s of %s: %w", *version, key, err)
+		}
+		conditions = storage.Conditions{GenerationMatch: generation}
+	}
+
+	writeCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	wc := s.sc.Bucket(s.bucket).Object(key).If(conditions).NewWriter(writeCtx)
+	if _, err := wc.Write(b); err != nil {
+		cancel()
+		_ = wc.Close()
+		return fmt.Errorf("failed to upload object: %w", err)
+	}
+
+	err := wc.Close()
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) && apiErr.Code == http.StatusPreconditionFailed {
+		return fmt.Errorf("failed to upload %s: %w", key, errPreconditionFailed)
+	} else if err != nil {
+		return fmt.Errorf("failed to upload object: %w", err)
+	}
+	return nil
+}
+
+func (s *GCSStorage) download(ctx context.Context, key string) ([]byte, error) {
+	r, err := s.reader(ctx, key)
 	if err != nil {
 		return nil, err
 	}
+	defer r.Close()
 
-	return data, nil
+	return io.ReadAll(r)
 }
 
 // https://github.com/GoogleCloudPlatform/golang-samples/blob/73d60a5de091dcdda5e4f753b594ef18eee67906/storage/objects/generate_v4_get_object_signed_url.go#L28
@@ -424,6 +619,32 @@ func (s *GCSStorage) presignedURL(ctx context.Context, object string) (string, e
 	return url, nil
 }
 
+func (s *GCSStorage) listObjects(ctx context.Context, prefix string) ([]object, error) {
+	var objects []object
+	it := s.sc.Bucket(s.bucket).Objects(ctx, &storage.Query{Prefix: prefix})
+	for {
+		attrs, err := it.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		objects = append(objects, object{
+			key:          attrs.Name,
+			size:         attrs.Size,
+			lastModified: attrs.Updated,
+		})
+	}
+
+	return objects, nil
+}
+
+func (s *GCSStorage) layout() (string, string) {
+	return s.bucketPrefix, s.moduleArchiveFormat
+}
+
 func (s *GCSStorage) objectExists(ctx context.Context, key string) (bool, error) {
 	o := s.sc.Bucket(s.bucket).Object(key)
 	_, err := o.Attrs(ctx)
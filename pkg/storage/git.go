@@ -0,0 +1,464 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/metadata"
+	"github.com/boring-registry/boring-registry/pkg/module"
+	"github.com/boring-registry/boring-registry/pkg/proxy"
+
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/hcl/v2/hclsimple"
+)
+
+// gitUploader is recorded as the uploader of the archives built from git tags
+const gitUploader = "git"
+
+// gitTagVersion matches the version of a tag once the tag prefix was removed
+var gitTagVersion = regexp.MustCompile(`^v?([0-9]+\.[0-9]+\.[0-9]+(?:-[0-9A-Za-z.-]+)?)$`)
+
+// GitModule maps a module to a directory of a git repository, whose tags are the versions of the module
+type GitModule struct {
+	Namespace string `hcl:"namespace,label"`
+	Name      string `hcl:"name,label"`
+	Provider  string `hcl:"provider,label"`
+
+	// Repository is the URL or path that git clones the repository from
+	Repository string `hcl:"repository"`
+
+	// Path is the directory of the module within the repository, which is the root of the repository if empty
+	Path string `hcl:"path,optional"`
+
+	// TagPrefix selects the tags of the module in repositories with several modules, e.g. "vpc/" for tags like vpc/v1.2.0
+	TagPrefix string `hcl:"tag_prefix,optional"`
+
+	// Archive serves an archive built from the tag instead of the git:: address of the tag
+	Archive bool `hcl:"archive,optional"`
+}
+
+func (m GitModule) id() string {
+	return fmt.Sprintf("%s/%s/%s", m.Namespace, m.Name, m.Provider)
+}
+
+// address returns the address of the module directory at the tag in the format of the go-getter library used by Terraform
+func (m GitModule) address(tag string) string {
+	address := strings.TrimPrefix(m.Repository, "git::")
+	if m.Path != "" {
+		address += "//" + m.Path
+	}
+	return fmt.Sprintf("git::%s?ref=%s", address, tag)
+}
+
+// ParseGitModules reads the git modules from an HCL file like:
+//
+//	module "example" "vpc" "aws" {
+//	  repository = "https://git.example.com/infrastructure/modules.git"
+//	  path       = "vpc"
+//	  tag_prefix = "vpc/"
+//	}
+func ParseGitModules(file string) ([]GitModule, error) {
+	config := struct {
+		Modules []GitModule `hcl:"module,block"`
+	}{}
+	if err := hclsimple.DecodeFile(file, nil, &config); err != nil {
+		return nil, err
+	}
+	return config.Modules, nil
+}
+
+// gitVersion is a tag of a git module
+type gitVersion struct {
+	tag     string
+	commit  string
+	version *version.Version
+}
+
+type gitVersions struct {
+	versions []gitVersion
+	listedAt time.Time
+}
+
+// GitStorage decorates a Storage and serves the modules mapped to git repositories from their tags instead of published archives.
+// The versions of a git module are listed from its tags, and a version is downloaded from either the git:: address of the tag
+// or an archive, which is built from the tag once and cached in the decorated Storage.
+// The archive is also built for git:: addresses when the docs of a version are requested.
+// All other modules, providers and mirrored providers are served by the decorated Storage.
+type GitStorage struct {
+	Storage
+	modules map[string]GitModule
+
+	tagsExpiry time.Duration
+	mu         sync.Mutex
+	versions   map[string]gitVersions
+	builds     map[string]*sync.Mutex
+}
+
+// GetModule returns the module version of the tag
+func (s *GitStorage) GetModule(ctx context.Context, namespace, name, provider, version string) (core.Module, error) {
+	m, ok := s.lookup(namespace, name, provider)
+	if !ok {
+		return s.Storage.GetModule(ctx, namespace, name, provider, version)
+	}
+
+	v, err := s.version(ctx, m, version)
+	if err != nil {
+		return core.Module{}, err
+	}
+
+	if m.Archive {
+		if err := s.buildArchive(ctx, m, v); err != nil {
+			return core.Module{}, err
+		}
+		return s.Storage.GetModule(ctx, namespace, name, provider, version)
+	}
+
+	return core.Module{
+		Namespace:   namespace,
+		Name:        name,
+		Provider:    provider,
+		Version:     version,
+		DownloadURL: m.address(v.tag),
+	}, nil
+}
+
+// ListModuleVersions returns a module version for every tag of the module
+func (s *GitStorage) ListModuleVersions(ctx context.Context, namespace, name, provider string) ([]core.Module, error) {
+	m, ok := s.lookup(namespace, name, provider)
+	if !ok {
+		return s.Storage.ListModuleVersions(ctx, namespace, name, provider)
+	}
+
+	versions, err := s.listVersions(ctx, m)
+	if err != nil {
+		return nil, err
+	}
+	return gitModuleVersions(m, versions), nil
+}
+
+// ListModules returns the modules of the decorated Storage and the tags of all git modules in the namespace.
+// Archives cached for git modules are not listed twice, and git modules whose tags can't be listed are skipped.
+func (s *GitStorage) ListModules(ctx context.Context, namespace string) ([]core.Module, error) {
+	stored, err := s.Storage.ListModules(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	var modules []core.Module
+	for _, m := range stored {
+		if _, ok := s.lookup(m.Namespace, m.Name, m.Provider); !ok {
+			modules = append(modules, m)
+		}
+	}
+
+	for _, m := range s.modules {
+		if namespace != "" && m.Namespace != namespace {
+			continue
+		}
+
+		versions, err := s.listVersions(ctx, m)
+		if err != nil {
+			slog.Warn("failed to list the tags of a git module", slog.String("module", m.id()), slog.String("err", err.Error()))
+			continue
+		}
+		modules = append(modules, gitModuleVersions(m, versions)...)
+	}
+	return modules, nil
+}
+
+// UploadModule rejects uploads of git modules, as their versions are the tags of the repository
+func (s *GitStorage) UploadModule(ctx context.Context, namespace, name, provider, version string, body io.Reader) (core.Module, error) {
+	if m, ok := s.lookup(namespace, name, provider); ok {
+		return core.Module{}, fmt.Errorf("%w: %s is served from the tags of %s", module.ErrPublishDisabled, m.id(), m.Repository)
+	}
+	return s.Storage.UploadModule(ctx, namespace, name, provider, version, body)
+}
+
+// CopyModule copies the module version, which is archived first if it's served from git.
+// Modules served from git can't be the destination, as they are only published by pushing tags.
+func (s *GitStorage) CopyModule(ctx context.Context, src, dst core.Module) (core.Module, error) {
+	if m, ok := s.lookup(dst.Namespace, dst.Name, dst.Provider); ok {
+		return core.Module{}, fmt.Errorf("%w: %s is served from the tags of %s", module.ErrPublishDisabled, m.id(), m.Repository)
+	}
+	if err := s.ensureArchive(ctx, src.Namespace, src.Name, src.Provider, src.Version); err != nil {
+		return core.Module{}, err
+	}
+	return s.Storage.CopyModule(ctx, src, dst)
+}
+
+// DownloadModule streams the archive built from the tag
+func (s *GitStorage) DownloadModule(ctx context.Context, namespace, name, provider, version string) (io.ReadCloser, error) {
+	if err := s.ensureArchive(ctx, namespace, name, provider, version); err != nil {
+		return nil, err
+	}
+	return s.Storage.DownloadModule(ctx, namespace, name, provider, version)
+}
+
+// GetModuleDocs returns the docs extracted from the archive built from the tag
+func (s *GitStorage) GetModuleDocs(ctx context.Context, namespace, name, provider, version string) (*module.Docs, error) {
+	if err := s.ensureArchive(ctx, namespace, name, provider, version); err != nil {
+		return nil, err
+	}
+	return s.Storage.GetModuleDocs(ctx, namespace, name, provider, version)
+}
+
+// GetModuleInfo returns the info of the module spec file in the archive built from the tag
+func (s *GitStorage) GetModuleInfo(ctx context.Context, namespace, name, provider, version string) (*module.Info, error) {
+	if err := s.ensureArchive(ctx, namespace, name, provider, version); err != nil {
+		return nil, err
+	}
+	return s.Storage.GetModuleInfo(ctx, namespace, name, provider, version)
+}
+
+// GetModuleProvenance returns the provenance of the archive built from the tag, which records the tag and its commit
+func (s *GitStorage) GetModuleProvenance(ctx context.Context, namespace, name, provider, version string) (*core.Provenance, error) {
+	if err := s.ensureArchive(ctx, namespace, name, provider, version); err != nil {
+		return nil, err
+	}
+	return s.Storage.GetModuleProvenance(ctx, namespace, name, provider, version)
+}
+
+// AuthorizeRequest forwards the credentials of the decorated Storage to the download proxy
+func (s *GitStorage) AuthorizeRequest(req *http.Request) {
+	if authorizer, ok := s.Storage.(proxy.RequestAuthorizer); ok {
+		authorizer.AuthorizeRequest(req)
+	}
+}
+
+func (s *GitStorage) lookup(namespace, name, provider string) (GitModule, bool) {
+	m, ok := s.modules[fmt.Sprintf("%s/%s/%s", namespace, name, provider)]
+	return m, ok
+}
+
+// ensureArchive builds the archive of the tag if the module is a git module
+func (s *GitStorage) ensureArchive(ctx context.Context, namespace, name, provider, version string) error {
+	m, ok := s.lookup(namespace, name, provider)
+	if !ok {
+		return nil
+	}
+
+	v, err := s.version(ctx, m, version)
+	if err != nil {
+		return err
+	}
+	return s.buildArchive(ctx, m, v)
+}
+
+// version returns the tag of the version, or an ErrModuleNotFound error if there is no such tag
+func (s *GitStorage) version(ctx context.Context, m GitModule, version string) (gitVersion, error) {
+	versions, err := s.listVersions(ctx, m)
+	if err != nil {
+		return gitVersion{}, err
+	}
+
+	for _, v := range versions {
+		if v.version.Original() == version {
+			return v, nil
+		}
+	}
+	return gitVersion{}, fmt.Errorf("%w: %s has no tag for version %s", module.ErrModuleNotFound, m.id(), version)
+}
+
+// listVersions returns the tags of the module sorted by their version.
+// The tags are listed again once they are older than the tags expiry.
+func (s *GitStorage) listVersions(ctx context.Context, m GitModule) ([]gitVersion, error) {
+	s.mu.Lock()
+	cached, ok := s.versions[m.id()]
+	s.mu.Unlock()
+	if ok && time.Since(cached.listedAt) < s.tagsExpiry {
+		return cached.versions, nil
+	}
+
+	out, err := runGit(ctx, "", "ls-remote", "--tags", m.Repository)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to list the tags of %s: %v", module.ErrModuleListFailed, m.Repository, err)
+	}
+
+	versions := parseGitTags(m, out)
+	s.mu.Lock()
+	s.versions[m.id()] = gitVersions{versions: versions, listedAt: time.Now()}
+	s.mu.Unlock()
+	return versions, nil
+}
+
+// parseGitTags returns the tags of the module in the output of git ls-remote.
+// Annotated tags are listed twice, and the commit of the dereferenced tag ending in ^{} is used.
+func parseGitTags(m GitModule, out string) []gitVersion {
+	tags := map[string]gitVersion{}
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 || !strings.HasPrefix(fields[1], "refs/tags/") {
+			continue
+		}
+
+		tag := strings.TrimPrefix(fields[1], "refs/tags/")
+		peeled := strings.HasSuffix(tag, "^{}")
+		tag = strings.TrimSuffix(tag, "^{}")
+		if _, ok := tags[tag]; ok && !peeled {
+			continue
+		}
+
+		if !strings.HasPrefix(tag, m.TagPrefix) {
+			continue
+		}
+		match := gitTagVersion.FindStringSubmatch(strings.TrimPrefix(tag, m.TagPrefix))
+		if match == nil {
+			continue
+		}
+		v, err := version.NewSemver(match[1])
+		if err != nil {
+			continue
+		}
+		tags[tag] = gitVersion{tag: tag, commit: fields[0], version: v}
+	}
+
+	versions := make([]gitVersion, 0, len(tags))
+	for _, v := range tags {
+		versions = append(versions, v)
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].version.LessThan(versions[j].version)
+	})
+	return versions
+}
+
+func gitModuleVersions(m GitModule, versions []gitVersion) []core.Module {
+	modules := make([]core.Module, 0, len(versions))
+	for _, v := range versions {
+		modules = append(modules, core.Module{
+			Namespace: m.Namespace,
+			Name:      m.Name,
+			Provider:  m.Provider,
+			Version:   v.version.Original(),
+		})
+	}
+	return modules
+}
+
+// buildArchive archives the module directory of the tag and uploads it to the decorated Storage, unless it was built before.
+// Tags are expected to be immutable like published versions, so a moved tag isn't built again.
+func (s *GitStorage) buildArchive(ctx context.Context, m GitModule, v gitVersion) error {
+	id := fmt.Sprintf("%s/%s", m.id(), v.version.Original())
+
+	// Concurrent requests for the same version wait for a single build
+	s.mu.Lock()
+	build, ok := s.builds[id]
+	if !ok {
+		build = &sync.Mutex{}
+		s.builds[id] = build
+	}
+	s.mu.Unlock()
+	build.Lock()
+	defer build.Unlock()
+
+	_, err := s.Storage.GetModule(ctx, m.Namespace, m.Name, m.Provider, v.version.Original())
+	if err == nil {
+		return nil
+	} else if !errors.Is(err, module.ErrModuleNotFound) {
+		return err
+	}
+
+	tmp, err := os.MkdirTemp("", "boring-registry-git-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	if _, err := runGit(ctx, "", "clone", "--quiet", "--depth", "1", "--branch", v.tag, m.Repository, tmp); err != nil {
+		return fmt.Errorf("failed to clone %s at %s: %w", m.Repository, v.tag, err)
+	}
+
+	dir := filepath.Join(tmp, filepath.FromSlash(path.Clean("/"+m.Path)))
+	if _, err := os.Stat(dir); err != nil {
+		return fmt.Errorf("%w: %s doesn't contain %s", module.ErrModuleNotFound, v.tag, m.Path)
+	}
+
+	ctx = metadata.WithUploader(ctx, gitUploader)
+	ctx = core.WithProvenance(ctx, core.Provenance{
+		GitCommit: v.commit,
+		GitRef:    "refs/tags/" + v.tag,
+	})
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(module.WriteArchive(dir, pw))
+	}()
+	defer pr.Close()
+
+	_, err = s.Storage.UploadModule(ctx, m.Namespace, m.Name, m.Provider, v.version.Original(), pr)
+	if err != nil && !errors.Is(err, module.ErrModuleAlreadyExists) {
+		return fmt.Errorf("failed to store the archive of %s: %w", v.tag, err)
+	}
+
+	slog.Info("built module archive from git tag", slog.String("module", id), slog.String("tag", v.tag), slog.String("commit", v.commit))
+	return nil
+}
+
+// runGit runs git in the directory, which is the working directory if it's empty, and returns its output
+func runGit(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+
+	stderr := new(bytes.Buffer)
+	cmd.Stderr = stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return string(out), nil
+}
+
+// GitStorageOption configures a GitStorage
+type GitStorageOption func(*GitStorage)
+
+// WithGitStorageTagsExpiry sets how long the tags of a repository are cached before they are listed again
+func WithGitStorageTagsExpiry(d time.Duration) GitStorageOption {
+	return func(s *GitStorage) {
+		s.tagsExpiry = d
+	}
+}
+
+// NewGitStorage returns a Storage serving the git modules from the tags of their repositories
+func NewGitStorage(s Storage, modules []GitModule, options ...GitStorageOption) (*GitStorage, error) {
+	g := &GitStorage{
+		Storage:    s,
+		modules:    make(map[string]GitModule),
+		tagsExpiry: time.Minute,
+		versions:   make(map[string]gitVersions),
+		builds:     make(map[string]*sync.Mutex),
+	}
+
+	for _, m := range modules {
+		if m.Namespace == "" || m.Name == "" || m.Provider == "" || m.Repository == "" {
+			return nil, fmt.Errorf("git module %s requires a namespace, name, provider and repository", m.id())
+		}
+		if p := path.Clean(m.Path); m.Path != "" && (path.IsAbs(p) || p == ".." || strings.HasPrefix(p, "../")) {
+			return nil, fmt.Errorf("path %s of git module %s is outside of the repository", m.Path, m.id())
+		}
+		if _, ok := g.modules[m.id()]; ok {
+			return nil, fmt.Errorf("git module %s is configured twice", m.id())
+		}
+		g.modules[m.id()] = m
+	}
+
+	for _, option := range options {
+		option(g)
+	}
+
+	return g, nil
+}
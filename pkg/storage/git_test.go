@@ -0,0 +1,172 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/module"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestGitRepository returns a bare repository with the modules vpc and dns.
+// vpc is tagged with vpc/v1.0.0 and the annotated tag vpc/v1.1.0, and dns with v0.1.0.
+func newTestGitRepository(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	work := filepath.Join(dir, "work")
+	bare := filepath.Join(dir, "modules.git")
+	run := func(dir string, args ...string) {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %v: %s", args[0], err, out)
+		}
+	}
+	write := func(name, content string) {
+		p := filepath.Join(work, name)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(p), 0755))
+		assert.NoError(t, os.WriteFile(p, []byte(content), 0644))
+	}
+
+	assert.NoError(t, os.MkdirAll(work, 0755))
+	run(work, "init", "--quiet")
+	write("vpc/main.tf", `variable "cidr" {}`)
+	write("vpc/README.md", "# VPC")
+	write("dns/main.tf", `variable "zone" {}`)
+	run(work, "add", "-A")
+	run(work, "commit", "--quiet", "-m", "initial")
+	run(work, "tag", "vpc/v1.0.0")
+	run(work, "tag", "v0.1.0")
+	write("vpc/outputs.tf", `output "id" { value = "" }`)
+	run(work, "add", "-A")
+	run(work, "commit", "--quiet", "-m", "outputs")
+	run(work, "tag", "-a", "-m", "release", "vpc/v1.1.0")
+	run(work, "tag", "vpc/latest")
+	run(dir, "clone", "--quiet", "--bare", work, bare)
+	return bare
+}
+
+func TestGitStorage(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	repository := newTestGitRepository(t)
+	backend := newTestPluginStorage(t, t.TempDir())
+	_, err := backend.UploadModule(ctx, "example", "app", "aws", "1.0.0", strings.NewReader("archive"))
+	assert.NoError(t, err)
+
+	s, err := NewGitStorage(backend, []GitModule{
+		{Namespace: "example", Name: "vpc", Provider: "aws", Repository: repository, Path: "vpc", TagPrefix: "vpc/"},
+		{Namespace: "example", Name: "dns", Provider: "aws", Repository: repository, Path: "dns", Archive: true},
+	})
+	assert.NoError(t, err)
+
+	versions, err := s.ListModuleVersions(ctx, "example", "vpc", "aws")
+	assert.NoError(t, err)
+	assert.Equal(t, []core.Module{
+		{Namespace: "example", Name: "vpc", Provider: "aws", Version: "1.0.0"},
+		{Namespace: "example", Name: "vpc", Provider: "aws", Version: "1.1.0"},
+	}, versions)
+
+	m, err := s.GetModule(ctx, "example", "vpc", "aws", "1.1.0")
+	assert.NoError(t, err)
+	assert.Equal(t, "git::"+repository+"//vpc?ref=vpc/v1.1.0", m.DownloadURL)
+
+	_, err = s.GetModule(ctx, "example", "vpc", "aws", "2.0.0")
+	assert.ErrorIs(t, err, module.ErrModuleNotFound)
+
+	docs, err := s.GetModuleDocs(ctx, "example", "vpc", "aws", "1.1.0")
+	assert.NoError(t, err)
+	assert.Equal(t, "# VPC", docs.Root.Readme)
+
+	// The archive of a tag is built once and cached in the decorated storage
+	m, err = s.GetModule(ctx, "example", "dns", "aws", "0.1.0")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, m.DownloadURL)
+	assert.False(t, strings.HasPrefix(m.DownloadURL, "git::"))
+	_, err = backend.GetModule(ctx, "example", "dns", "aws", "0.1.0")
+	assert.NoError(t, err)
+
+	out, err := exec.Command("git", "-C", repository, "rev-parse", "vpc/v1.1.0^{commit}").Output()
+	assert.NoError(t, err)
+	p, err := s.GetModuleProvenance(ctx, "example", "vpc", "aws", "1.1.0")
+	assert.NoError(t, err)
+	assert.Equal(t, strings.TrimSpace(string(out)), p.GitCommit, "annotated tags record the tagged commit")
+	assert.Equal(t, "refs/tags/vpc/v1.1.0", p.GitRef)
+	assert.Equal(t, "git", p.Uploader)
+
+	_, err = s.UploadModule(ctx, "example", "vpc", "aws", "2.0.0", strings.NewReader("archive"))
+	assert.ErrorIs(t, err, module.ErrPublishDisabled)
+
+	modules, err := s.ListModules(ctx, "example")
+	assert.NoError(t, err)
+	var ids []string
+	for _, m := range modules {
+		ids = append(ids, m.ID(true))
+	}
+	assert.ElementsMatch(t, []string{"example/app/aws/1.0.0", "example/vpc/aws/1.0.0", "example/vpc/aws/1.1.0", "example/dns/aws/0.1.0"}, ids)
+}
+
+func TestNewGitStorage(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name    string
+		modules []GitModule
+	}{
+		{
+			name:    "missing repository",
+			modules: []GitModule{{Namespace: "example", Name: "vpc", Provider: "aws"}},
+		},
+		{
+			name:    "path outside of the repository",
+			modules: []GitModule{{Namespace: "example", Name: "vpc", Provider: "aws", Repository: "/srv/git/vpc.git", Path: "../vpc"}},
+		},
+		{
+			name: "duplicate module",
+			modules: []GitModule{
+				{Namespace: "example", Name: "vpc", Provider: "aws", Repository: "/srv/git/vpc.git"},
+				{Namespace: "example", Name: "vpc", Provider: "aws", Repository: "/srv/git/network.git"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewGitStorage(nil, tc.modules)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestParseGitModules(t *testing.T) {
+	t.Parallel()
+
+	file := filepath.Join(t.TempDir(), "git.hcl")
+	assert.NoError(t, os.WriteFile(file, []byte(`
+module "example" "vpc" "aws" {
+  repository = "https://git.example.com/modules.git"
+  path       = "vpc"
+  tag_prefix = "vpc/"
+}
+
+module "example" "dns" "aws" {
+  repository = "https://git.example.com/dns.git"
+  archive    = true
+}
+`), 0644))
+
+	modules, err := ParseGitModules(file)
+	assert.NoError(t, err)
+	assert.Equal(t, []GitModule{
+		{Namespace: "example", Name: "vpc", Provider: "aws", Repository: "https://git.example.com/modules.git", Path: "vpc", TagPrefix: "vpc/"},
+		{Namespace: "example", Name: "dns", Provider: "aws", Repository: "https://git.example.com/dns.git", Archive: true},
+	}, modules)
+}
@@ -0,0 +1,636 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"log/slog"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/metadata"
+	"github.com/boring-registry/boring-registry/pkg/proxy"
+)
+
+// MetadataStorage decorates a Storage and records every upload in a metadata.Store.
+// Once the store was rebuilt from the storage backend, version listings are answered by the store
+// instead of listing the objects of the storage backend. Listings the store has no records for are answered by the
+// storage backend, so that modules and providers uploaded without going through the server are found before the next rebuild.
+type MetadataStorage struct {
+	Storage
+	objects objectStorage
+	store   metadata.Store
+	synced  atomic.Bool
+
+	// rebuildMu serializes rebuilds
+	rebuildMu sync.Mutex
+
+	// mu guards the changes of the store made while a rebuild is running,
+	// which are merged into the rebuilt records, as the storage backend might have been listed before the change
+	mu         sync.Mutex
+	rebuilding bool
+	puts       map[string]metadata.Record
+	deletes    map[string]bool
+}
+
+// UploadModule uploads the module and records it in the store
+func (s *MetadataStorage) UploadModule(ctx context.Context, namespace, name, provider, version string, body io.Reader) (core.Module, error) {
+	r := newRecordingReader(body)
+	m, err := s.Storage.UploadModule(ctx, namespace, name, provider, version, r)
+	if err != nil {
+		return m, err
+	}
+
+	prefix, archiveFormat := s.objects.layout()
+	s.record(ctx, r.record(metadata.Record{
+		Key:       modulePath(prefix, namespace, name, provider, version, archiveFormat),
+		Kind:      metadata.KindModule,
+		Namespace: namespace,
+		Name:      name,
+		Provider:  provider,
+		Version:   version,
+		Uploader:  metadata.UploaderFromContext(ctx),
+	}))
+
+	return m, nil
+}
+
+// UploadProviderReleaseFiles uploads the file and records it in the store, if it's a provider archive
+func (s *MetadataStorage) UploadProviderReleaseFiles(ctx context.Context, namespace, name, filename string, file io.Reader) error {
+	p, err := core.NewProviderFromArchive(filename)
+	if err != nil {
+		// SHA256SUMS and signature files aren't recorded
+		return s.Storage.UploadProviderReleaseFiles(ctx, namespace, name, filename, file)
+	}
+
+	r := newRecordingReader(file)
+	if err := s.Storage.UploadProviderReleaseFiles(ctx, namespace, name, filename, r); err != nil {
+		return err
+	}
+
+	prefix, _ := s.objects.layout()
+	s.record(ctx, r.record(metadata.Record{
+		Key:       path.Join(providerStoragePrefix(prefix, internalProviderType, "", namespace, name), filename),
+		Kind:      metadata.KindProvider,
+		Namespace: namespace,
+		Name:      name,
+		Version:   p.Version,
+		Platforms: []core.Platform{{OS: p.OS, Arch: p.Arch}},
+		Uploader:  metadata.UploaderFromContext(ctx),
+	}))
+
+	return nil
+}
+
+// CopyModule copies the module version and records the copy in the store
+func (s *MetadataStorage) CopyModule(ctx context.Context, src, dst core.Module) (core.Module, error) {
+	m, err := s.Storage.CopyModule(ctx, src, dst)
+	if err != nil {
+		return m, err
+	}
+
+	prefix, archiveFormat := s.objects.layout()
+	s.recordCopy(ctx, metadata.Record{
+		Key:       modulePath(prefix, dst.Namespace, dst.Name, dst.Provider, dst.Version, archiveFormat),
+		Kind:      metadata.KindModule,
+		Namespace: dst.Namespace,
+		Name:      dst.Name,
+		Provider:  dst.Provider,
+		Version:   dst.Version,
+	})
+
+	return m, nil
+}
+
+// CopyProviderRelease copies the provider release and records the copied archives in the store
+func (s *MetadataStorage) CopyProviderRelease(ctx context.Context, namespace, name, version, targetNamespace string) error {
+	release, err := s.Storage.GetProviderRelease(ctx, namespace, name, version)
+	if err != nil {
+		return err
+	}
+	if err := s.Storage.CopyProviderRelease(ctx, namespace, name, version, targetNamespace); err != nil {
+		return err
+	}
+
+	prefix, _ := s.objects.layout()
+	for _, f := range release.Files {
+		p, err := core.NewProviderFromArchive(f.Name)
+		if err != nil {
+			continue
+		}
+
+		s.recordCopy(ctx, metadata.Record{
+			Key:       path.Join(providerStoragePrefix(prefix, internalProviderType, "", targetNamespace, name), f.Name),
+			Kind:      metadata.KindProvider,
+			Namespace: targetNamespace,
+			Name:      name,
+			Version:   version,
+			Platforms: []core.Platform{{OS: p.OS, Arch: p.Arch}},
+		})
+	}
+
+	return nil
+}
+
+// DeleteModule removes the module version and its record from the store
+func (s *MetadataStorage) DeleteModule(ctx context.Context, namespace, name, provider, version string) error {
+	if err := s.Storage.DeleteModule(ctx, namespace, name, provider, version); err != nil {
+		return err
+	}
+
+	prefix, archiveFormat := s.objects.layout()
+	s.forget(ctx, modulePath(prefix, namespace, name, provider, version, archiveFormat))
+	return nil
+}
+
+// DeleteProviderRelease removes the provider release and the records of its archives from the store
+func (s *MetadataStorage) DeleteProviderRelease(ctx context.Context, namespace, name, version string) error {
+	release, err := s.Storage.GetProviderRelease(ctx, namespace, name, version)
+	if err != nil {
+		return err
+	}
+	if err := s.Storage.DeleteProviderRelease(ctx, namespace, name, version); err != nil {
+		return err
+	}
+
+	prefix, _ := s.objects.layout()
+	keys := make([]string, 0, len(release.Files))
+	for _, f := range release.Files {
+		keys = append(keys, path.Join(providerStoragePrefix(prefix, internalProviderType, "", namespace, name), f.Name))
+	}
+	s.forget(ctx, keys...)
+	return nil
+}
+
+// UploadMirroredFile uploads the file and records it in the store, if it's a provider archive
+func (s *MetadataStorage) UploadMirroredFile(ctx context.Context, provider *core.Provider, fileName string, reader io.Reader) error {
+	p, err := core.NewProviderFromArchive(fileName)
+	if err != nil {
+		return s.Storage.UploadMirroredFile(ctx, provider, fileName, reader)
+	}
+
+	r := newRecordingReader(reader)
+	if err := s.Storage.UploadMirroredFile(ctx, provider, fileName, r); err != nil {
+		return err
+	}
+
+	prefix, _ := s.objects.layout()
+	s.record(ctx, r.record(metadata.Record{
+		Key:       path.Join(providerStoragePrefix(prefix, mirrorProviderType, provider.Hostname, provider.Namespace, provider.Name), fileName),
+		Kind:      metadata.KindMirroredProvider,
+		Hostname:  provider.Hostname,
+		Namespace: provider.Namespace,
+		Name:      provider.Name,
+		Version:   p.Version,
+		Platforms: []core.Platform{{OS: p.OS, Arch: p.Arch}},
+		Uploader:  metadata.UploaderFromContext(ctx),
+	}))
+
+	return nil
+}
+
+// ListModuleVersions returns the module versions from the store.
+// The returned modules don't have a DownloadURL, as it's not part of the module versions response.
+func (s *MetadataStorage) ListModuleVersions(ctx context.Context, namespace, name, provider string) ([]core.Module, error) {
+	if !s.synced.Load() {
+		return s.Storage.ListModuleVersions(ctx, namespace, name, provider)
+	}
+
+	records, err := s.store.Query(ctx, metadata.Filter{
+		Kind:      metadata.KindModule,
+		Namespace: namespace,
+		Name:      name,
+		Provider:  provider,
+	})
+	if err != nil {
+		return nil, err
+	} else if len(records) == 0 {
+		return s.Storage.ListModuleVersions(ctx, namespace, name, provider)
+	}
+
+	var modules []core.Module
+	for _, r := range records {
+		modules = append(modules, core.Module{
+			Namespace:   r.Namespace,
+			Name:        r.Name,
+			Provider:    r.Provider,
+			Version:     r.Version,
+			PublishedAt: r.UploadedAt,
+		})
+	}
+	return modules, nil
+}
+
+// ListProviderVersions returns the provider versions and their platforms from the store
+func (s *MetadataStorage) ListProviderVersions(ctx context.Context, namespace, name string) (*core.ProviderVersions, error) {
+	if !s.synced.Load() {
+		return s.Storage.ListProviderVersions(ctx, namespace, name)
+	}
+
+	records, err := s.store.Query(ctx, metadata.Filter{
+		Kind:      metadata.KindProvider,
+		Namespace: namespace,
+		Name:      name,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return s.Storage.ListProviderVersions(ctx, namespace, name)
+	}
+
+	collection := NewCollection()
+	for _, r := range records {
+		for _, platform := range r.Platforms {
+			collection.Add(&core.Provider{
+				Namespace: r.Namespace,
+				Name:      r.Name,
+				Version:   r.Version,
+				OS:        platform.OS,
+				Arch:      platform.Arch,
+			})
+		}
+	}
+	return collection.List(), nil
+}
+
+// ListModules returns the module versions of all modules from the store
+func (s *MetadataStorage) ListModules(ctx context.Context, namespace string) ([]core.Module, error) {
+	if !s.synced.Load() {
+		return s.Storage.ListModules(ctx, namespace)
+	}
+
+	records, err := s.store.Query(ctx, metadata.Filter{
+		Kind:      metadata.KindModule,
+		Namespace: namespace,
+	})
+	if err != nil {
+		return nil, err
+	} else if len(records) == 0 {
+		return s.Storage.ListModules(ctx, namespace)
+	}
+
+	var modules []core.Module
+	for _, r := range records {
+		modules = append(modules, core.Module{
+			Namespace:   r.Namespace,
+			Name:        r.Name,
+			Provider:    r.Provider,
+			Version:     r.Version,
+			PublishedAt: r.UploadedAt,
+		})
+	}
+	return modules, nil
+}
+
+// ListProviders returns the provider versions of all providers and their platforms from the store
+func (s *MetadataStorage) ListProviders(ctx context.Context, namespace string) ([]core.ProviderVersion, error) {
+	if !s.synced.Load() {
+		return s.Storage.ListProviders(ctx, namespace)
+	}
+
+	records, err := s.store.Query(ctx, metadata.Filter{
+		Kind:      metadata.KindProvider,
+		Namespace: namespace,
+	})
+	if err != nil {
+		return nil, err
+	} else if len(records) == 0 {
+		return s.Storage.ListProviders(ctx, namespace)
+	}
+
+	collection := NewCollection()
+	for _, r := range records {
+		for _, platform := range r.Platforms {
+			collection.Add(&core.Provider{
+				Namespace: r.Namespace,
+				Name:      r.Name,
+				Version:   r.Version,
+				OS:        platform.OS,
+				Arch:      platform.Arch,
+			})
+		}
+	}
+	return collection.List().Versions, nil
+}
+
+// AuthorizeRequest forwards to the decorated Storage, if it implements proxy.RequestAuthorizer
+func (s *MetadataStorage) AuthorizeRequest(req *http.Request) {
+	if authorizer, ok := s.Storage.(proxy.RequestAuthorizer); ok {
+		authorizer.AuthorizeRequest(req)
+	}
+}
+
+// Rebuild replaces the content of the store with the modules and providers found in the storage backend.
+// Uploaders and checksums of existing records are retained, and new records take them from the provenance stored next to the artifact.
+// Records put or deleted while the storage backend is listed are merged into the rebuilt records, so that they aren't lost.
+func (s *MetadataStorage) Rebuild(ctx context.Context) error {
+	s.rebuildMu.Lock()
+	defer s.rebuildMu.Unlock()
+
+	s.mu.Lock()
+	s.rebuilding = true
+	s.puts = make(map[string]metadata.Record)
+	s.deletes = make(map[string]bool)
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.rebuilding = false
+		s.puts, s.deletes = nil, nil
+		s.mu.Unlock()
+	}()
+
+	existing, err := s.store.Query(ctx, metadata.Filter{})
+	if err != nil {
+		return err
+	}
+	previous := make(map[string]metadata.Record, len(existing))
+	for _, r := range existing {
+		previous[r.Key] = r
+	}
+
+	prefix, archiveFormat := s.objects.layout()
+
+	var records []metadata.Record
+	provenance := make(map[string]bool)
+	objects, err := s.objects.listObjects(ctx, path.Join(prefix, string(internalModuleType))+"/")
+	if err != nil {
+		return fmt.Errorf("failed to list modules: %w", err)
+	}
+	for _, obj := range objects {
+		if strings.HasSuffix(obj.key, provenanceExtension) {
+			provenance[obj.key] = true
+			continue
+		}
+
+		m, err := moduleFromObject(obj.key, archiveFormat)
+		if err != nil {
+			continue
+		}
+		records = append(records, metadata.Record{
+			Key:        obj.key,
+			Kind:       metadata.KindModule,
+			Namespace:  m.Namespace,
+			Name:       m.Name,
+			Provider:   m.Provider,
+			Version:    m.Version,
+			Size:       obj.size,
+			UploadedAt: obj.lastModified,
+		})
+	}
+
+	for kind, pt := range map[metadata.Kind]providerType{metadata.KindProvider: internalProviderType, metadata.KindMirroredProvider: mirrorProviderType} {
+		providerRecords, err := s.providerRecords(ctx, kind, pt, prefix, provenance)
+		if err != nil {
+			return err
+		}
+		records = append(records, providerRecords...)
+	}
+
+	for i, r := range records {
+		if p, ok := previous[r.Key]; ok && p.Size == r.Size {
+			records[i].Uploader = p.Uploader
+			if records[i].SHA256 == "" {
+				records[i].SHA256 = p.SHA256
+			}
+		} else if provenance[r.Key+provenanceExtension] {
+			s.applyProvenance(ctx, &records[i])
+		}
+	}
+
+	// Changes are blocked until the store is replaced, so that none is made between merging and replacing
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	merged := make([]metadata.Record, 0, len(records)+len(s.puts))
+	for _, r := range records {
+		if _, ok := s.puts[r.Key]; !ok && !s.deletes[r.Key] {
+			merged = append(merged, r)
+		}
+	}
+	for _, r := range s.puts {
+		merged = append(merged, r)
+	}
+
+	if err := s.store.Replace(ctx, merged); err != nil {
+		return err
+	}
+	s.synced.Store(true)
+	return nil
+}
+
+// applyProvenance sets the uploader and the checksum of the record from the provenance of the artifact
+func (s *MetadataStorage) applyProvenance(ctx context.Context, r *metadata.Record) {
+	b, err := s.objects.download(ctx, r.Key+provenanceExtension)
+	if err != nil {
+		slog.Debug("failed to download provenance", slog.String("key", r.Key), slog.String("err", err.Error()))
+		return
+	}
+
+	var p core.Provenance
+	if err := json.Unmarshal(b, &p); err != nil {
+		slog.Debug("failed to decode provenance", slog.String("key", r.Key), slog.String("err", err.Error()))
+		return
+	}
+
+	r.Uploader = p.Uploader
+	if r.SHA256 == "" {
+		r.SHA256 = p.SHA256
+	}
+}
+
+// providerRecords lists the provider archives and reads their checksums from the SHA256SUMS files.
+// The keys of provenance sidecars are added to provenance.
+func (s *MetadataStorage) providerRecords(ctx context.Context, kind metadata.Kind, pt providerType, prefix string, provenance map[string]bool) ([]metadata.Record, error) {
+	base := path.Join(prefix, string(pt)) + "/"
+	objects, err := s.objects.listObjects(ctx, base)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list providers: %w", err)
+	}
+
+	shasums := make(map[string][]byte)
+	var records []metadata.Record
+	for _, obj := range objects {
+		if strings.HasSuffix(obj.key, provenanceExtension) {
+			provenance[obj.key] = true
+			continue
+		}
+
+		dir, file := path.Split(strings.TrimPrefix(obj.key, base))
+		p, err := core.NewProviderFromArchive(file)
+		if err != nil {
+			continue
+		}
+
+		// Internal providers are stored below <namespace>/<name>, mirrored ones below <hostname>/<namespace>/<name>
+		parts := strings.Split(strings.Trim(dir, "/"), "/")
+		r := metadata.Record{
+			Key:        obj.key,
+			Kind:       kind,
+			Name:       p.Name,
+			Version:    p.Version,
+			Platforms:  []core.Platform{{OS: p.OS, Arch: p.Arch}},
+			Size:       obj.size,
+			UploadedAt: obj.lastModified,
+		}
+		if pt == internalProviderType && len(parts) == 2 {
+			r.Namespace = parts[0]
+		} else if pt == mirrorProviderType && len(parts) == 3 {
+			r.Hostname, r.Namespace = parts[0], parts[1]
+		} else {
+			continue
+		}
+
+		shasumKey := path.Join(path.Dir(obj.key), p.ShasumFileName())
+		if _, ok := shasums[shasumKey]; !ok {
+			b, err := s.objects.download(ctx, shasumKey)
+			if err != nil {
+				slog.Debug("failed to download SHA256SUMS", slog.String("key", shasumKey), slog.String("err", err.Error()))
+			}
+			shasums[shasumKey] = b
+		}
+		if b := shasums[shasumKey]; b != nil {
+			r.SHA256, _ = readSHASums(bytes.NewReader(b), file)
+		}
+
+		records = append(records, r)
+	}
+
+	return records, nil
+}
+
+// Sync rebuilds the store immediately and then periodically until the context is done.
+// A zero interval disables the periodic rebuild.
+func (s *MetadataStorage) Sync(ctx context.Context, interval time.Duration) {
+	rebuild := func() {
+		begin := time.Now()
+		if err := s.Rebuild(ctx); err != nil {
+			slog.Error("failed to rebuild metadata store", slog.String("err", err.Error()))
+			return
+		}
+		slog.Info("rebuilt metadata store", slog.String("took", time.Since(begin).String()))
+	}
+
+	rebuild()
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			rebuild()
+		}
+	}
+}
+
+func (s *MetadataStorage) record(ctx context.Context, r metadata.Record) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.rebuilding {
+		s.puts[r.Key] = r
+		delete(s.deletes, r.Key)
+	}
+
+	// The upload succeeded already, a stale store is fixed by the next rebuild
+	if err := s.store.Put(ctx, r); err != nil {
+		slog.Error("failed to record upload", slog.String("key", r.Key), slog.String("err", err.Error()))
+	}
+}
+
+// forget removes the records of deleted objects
+func (s *MetadataStorage) forget(ctx context.Context, keys ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.rebuilding {
+		for _, key := range keys {
+			s.deletes[key] = true
+			delete(s.puts, key)
+		}
+	}
+
+	// The deletion succeeded already, a stale store is fixed by the next rebuild
+	if err := s.store.Delete(ctx, keys...); err != nil {
+		slog.Error("failed to delete records", slog.Any("keys", keys), slog.String("err", err.Error()))
+	}
+}
+
+// recordCopy records an object that was copied within the storage backend, whose size and checksum are looked up instead of computed
+func (s *MetadataStorage) recordCopy(ctx context.Context, r metadata.Record) {
+	r.Uploader = metadata.UploaderFromContext(ctx)
+	r.UploadedAt = time.Now().UTC()
+
+	if objects, err := s.objects.listObjects(ctx, path.Dir(r.Key)+"/"); err == nil {
+		for _, obj := range objects {
+			if obj.key == r.Key {
+				r.Size = obj.size
+			}
+		}
+	}
+
+	p := &core.Provenance{}
+	if err := readSidecar(ctx, s.objects, r.Key+provenanceExtension, core.ErrProvenanceNotFound, p); err == nil {
+		r.SHA256 = p.SHA256
+	}
+
+	s.record(ctx, r)
+}
+
+// recordingReader computes the size and the SHA256 checksum of everything read through it
+type recordingReader struct {
+	reader io.Reader
+	hash   hash.Hash
+	size   int64
+}
+
+func newRecordingReader(r io.Reader) *recordingReader {
+	return &recordingReader{
+		reader: r,
+		hash:   sha256.New(),
+	}
+}
+
+func (r *recordingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.hash.Write(p[:n])
+	r.size += int64(n)
+	return n, err
+}
+
+func (r *recordingReader) record(record metadata.Record) metadata.Record {
+	record.Size = r.size
+	record.SHA256 = hex.EncodeToString(r.hash.Sum(nil))
+	record.UploadedAt = time.Now().UTC()
+	return record
+}
+
+// NewMetadataStorage returns a Storage recording uploads in the store.
+// Only the storage backends of this package can be decorated, as rebuilding the store requires listing all objects.
+func NewMetadataStorage(s Storage, store metadata.Store) (*MetadataStorage, error) {
+	objects, ok := s.(objectStorage)
+	if !ok {
+		return nil, fmt.Errorf("storage %T doesn't support the metadata store", s)
+	}
+
+	return &MetadataStorage{
+		Storage: s,
+		objects: objects,
+		store:   store,
+	}, nil
+}
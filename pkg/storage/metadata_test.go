@@ -0,0 +1,146 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/metadata"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetadataStorage(t *testing.T) {
+	t.Parallel()
+
+	ctx := metadata.WithUploader(context.Background(), "ci")
+	backend := newTestPluginStorage(t, t.TempDir(), WithPluginStoragePrefix("registry"))
+	store, err := metadata.NewFileStore(filepath.Join(t.TempDir(), "metadata.json"))
+	assert.NoError(t, err)
+	s, err := NewMetadataStorage(backend, store)
+	assert.NoError(t, err)
+
+	_, err = s.UploadModule(ctx, "example", "vpc", "aws", "1.0.0", strings.NewReader("archive"))
+	assert.NoError(t, err)
+	files := map[string]string{
+		"terraform-provider-dummy_1.0.0_linux_amd64.zip":  "linux",
+		"terraform-provider-dummy_1.0.0_darwin_arm64.zip": "darwin",
+		"terraform-provider-dummy_1.0.0_SHA256SUMS": "caf90169eefa5f807d577486b9f795ab86ae2983c5c20806cff959117e90af18  terraform-provider-dummy_1.0.0_linux_amd64.zip\n" +
+			"26ce1a1580f693873b6268fef54c5f0d0607f2896cad02ce2894c0c899a11575  terraform-provider-dummy_1.0.0_darwin_arm64.zip",
+	}
+	for name, content := range files {
+		assert.NoError(t, s.UploadProviderReleaseFiles(ctx, "example", "dummy", name, strings.NewReader(content)))
+	}
+
+	records, err := store.Query(ctx, metadata.Filter{Kind: metadata.KindModule})
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+	checksum := sha256.Sum256([]byte("archive"))
+	assert.Equal(t, "registry/modules/example/vpc/aws/example-vpc-aws-1.0.0.tar.gz", records[0].Key)
+	assert.Equal(t, hex.EncodeToString(checksum[:]), records[0].SHA256)
+	assert.Equal(t, int64(len("archive")), records[0].Size)
+	assert.Equal(t, "ci", records[0].Uploader)
+
+	records, err = store.Query(ctx, metadata.Filter{Kind: metadata.KindProvider})
+	assert.NoError(t, err)
+	assert.Len(t, records, 2, "SHA256SUMS files aren't recorded")
+
+	// An upload that bypassed the decorator is only picked up by the rebuild
+	_, err = backend.UploadModule(metadata.WithUploader(ctx, "cli"), "example", "vpc", "aws", "2.0.0", strings.NewReader("archive"))
+	assert.NoError(t, err)
+
+	assert.NoError(t, s.Rebuild(ctx))
+
+	// Modules the store has no records for are listed from the storage backend
+	_, err = backend.UploadModule(ctx, "example", "subnet", "aws", "1.0.0", strings.NewReader("archive"))
+	assert.NoError(t, err)
+	modules, err := s.ListModuleVersions(ctx, "example", "subnet", "aws")
+	assert.NoError(t, err)
+	assert.Len(t, modules, 1)
+
+	records, err = store.Query(ctx, metadata.Filter{Version: "2.0.0", Kind: metadata.KindModule})
+	assert.NoError(t, err)
+	assert.Equal(t, "cli", records[0].Uploader, "the uploader is taken from the provenance")
+	assert.Equal(t, hex.EncodeToString(checksum[:]), records[0].SHA256, "the checksum is taken from the provenance")
+
+	modules, err = s.ListModuleVersions(ctx, "example", "vpc", "aws")
+	assert.NoError(t, err)
+	var versions []string
+	for _, m := range modules {
+		versions = append(versions, m.Version)
+	}
+	assert.ElementsMatch(t, []string{"1.0.0", "2.0.0"}, versions)
+
+	records, err = store.Query(ctx, metadata.Filter{Version: "1.0.0", Kind: metadata.KindModule})
+	assert.NoError(t, err)
+	assert.Equal(t, "ci", records[0].Uploader, "the uploader is retained")
+	assert.Equal(t, hex.EncodeToString(checksum[:]), records[0].SHA256, "the checksum is retained")
+
+	records, err = store.Query(ctx, metadata.Filter{Kind: metadata.KindProvider, Namespace: "example", Name: "dummy"})
+	assert.NoError(t, err)
+	for _, r := range records {
+		if r.Platforms[0].OS == "linux" {
+			assert.Equal(t, "caf90169eefa5f807d577486b9f795ab86ae2983c5c20806cff959117e90af18", r.SHA256)
+		}
+	}
+
+	providers, err := s.ListProviderVersions(ctx, "example", "dummy")
+	assert.NoError(t, err)
+	assert.Len(t, providers.Versions, 1)
+	assert.ElementsMatch(t, []core.Platform{{OS: "linux", Arch: "amd64"}, {OS: "darwin", Arch: "arm64"}}, providers.Versions[0].Platforms)
+
+	_, err = s.ListProviderVersions(ctx, "example", "missing")
+	var providerErr *core.ProviderError
+	assert.ErrorAs(t, err, &providerErr)
+}
+
+// listingHookStorage calls afterList once after the storage backend was listed, to change the storage backend during a rebuild
+type listingHookStorage struct {
+	*PluginStorage
+	afterList func()
+}
+
+func (s *listingHookStorage) listObjects(ctx context.Context, prefix string) ([]object, error) {
+	objects, err := s.PluginStorage.listObjects(ctx, prefix)
+	if s.afterList != nil {
+		afterList := s.afterList
+		s.afterList = nil
+		afterList()
+	}
+	return objects, err
+}
+
+func TestMetadataStorage_ChangesDuringRebuild(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	backend := &listingHookStorage{PluginStorage: newTestPluginStorage(t, t.TempDir())}
+	store, err := metadata.NewFileStore(filepath.Join(t.TempDir(), "metadata.json"))
+	assert.NoError(t, err)
+	s, err := NewMetadataStorage(backend, store)
+	assert.NoError(t, err)
+
+	_, err = s.UploadModule(ctx, "example", "vpc", "aws", "1.0.0", strings.NewReader("archive"))
+	assert.NoError(t, err)
+	assert.NoError(t, s.Rebuild(ctx))
+
+	// The changes aren't part of the listing, but mustn't be lost by replacing the store
+	backend.afterList = func() {
+		_, err := s.UploadModule(ctx, "example", "vpc", "aws", "2.0.0", strings.NewReader("archive"))
+		assert.NoError(t, err)
+		assert.NoError(t, s.DeleteModule(ctx, "example", "vpc", "aws", "1.0.0"))
+	}
+	assert.NoError(t, s.Rebuild(ctx))
+
+	modules, err := s.ListModuleVersions(ctx, "example", "vpc", "aws")
+	assert.NoError(t, err)
+	var versions []string
+	for _, m := range modules {
+		versions = append(versions, m.Version)
+	}
+	assert.Equal(t, []string{"2.0.0"}, versions)
+}
@@ -0,0 +1,226 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/module"
+	"github.com/boring-registry/boring-registry/pkg/namespace"
+	"github.com/boring-registry/boring-registry/pkg/provider"
+	"github.com/boring-registry/boring-registry/pkg/proxy"
+)
+
+// storedNamespaces is the content of the object holding the registered namespaces
+type storedNamespaces struct {
+	Namespaces []namespace.Namespace `json:"namespaces"`
+}
+
+// listNamespaces returns the registered namespaces, which are empty if none were registered yet
+func listNamespaces(ctx context.Context, s objectStorage) ([]namespace.Namespace, error) {
+	prefix, _ := s.layout()
+
+	var stored storedNamespaces
+	err := readSidecar(ctx, s, namespacesPath(prefix), namespace.ErrNamespaceNotFound, &stored)
+	if err != nil && !errors.Is(err, namespace.ErrNamespaceNotFound) {
+		return nil, err
+	}
+	return stored.Namespaces, nil
+}
+
+// addNamespace adds the namespace to the registered namespaces
+func addNamespace(ctx context.Context, s objectStorage, n namespace.Namespace) error {
+	prefix, _ := s.layout()
+	return updateSidecar(ctx, s, namespacesPath(prefix), func(stored *storedNamespaces) error {
+		if _, ok := namespace.Namespaces(stored.Namespaces).Lookup(n.Name); ok {
+			return fmt.Errorf("%w: %s", namespace.ErrNamespaceAlreadyExists, n.Name)
+		}
+		stored.Namespaces = append(stored.Namespaces, n)
+		return nil
+	})
+}
+
+// updateNamespace replaces the registered namespace with the same name
+func updateNamespace(ctx context.Context, s objectStorage, n namespace.Namespace) error {
+	prefix, _ := s.layout()
+	return updateSidecar(ctx, s, namespacesPath(prefix), func(stored *storedNamespaces) error {
+		for i := range stored.Namespaces {
+			if stored.Namespaces[i].Name == n.Name {
+				stored.Namespaces[i] = n
+				return nil
+			}
+		}
+		return fmt.Errorf("%w: %s", namespace.ErrNamespaceNotFound, n.Name)
+	})
+}
+
+// deleteNamespace removes the namespace from the registered namespaces
+func deleteNamespace(ctx context.Context, s objectStorage, name string) error {
+	prefix, _ := s.layout()
+	return updateSidecar(ctx, s, namespacesPath(prefix), func(stored *storedNamespaces) error {
+		remaining := make([]namespace.Namespace, 0, len(stored.Namespaces))
+		for _, n := range stored.Namespaces {
+			if n.Name != name {
+				remaining = append(remaining, n)
+			}
+		}
+		if len(remaining) == len(stored.Namespaces) {
+			return fmt.Errorf("%w: %s", namespace.ErrNamespaceNotFound, name)
+		}
+		stored.Namespaces = remaining
+		return nil
+	})
+}
+
+// NamespaceStorage decorates a Storage and checks publishing against the registered namespaces.
+// Modules and providers can't be published to or deleted from frozen namespaces, or with names that don't match the name patterns of their namespace.
+// Namespaces that aren't registered are rejected once any namespace is registered, so that existing registries keep working until they register namespaces.
+type NamespaceStorage struct {
+	Storage
+	required bool
+
+	expiry     time.Duration
+	mu         sync.Mutex
+	namespaces namespace.Namespaces
+	listedAt   time.Time
+}
+
+// AddNamespace registers the namespace, which is checked by the next upload
+func (s *NamespaceStorage) AddNamespace(ctx context.Context, n namespace.Namespace) error {
+	defer s.invalidate()
+	return s.Storage.AddNamespace(ctx, n)
+}
+
+// UpdateNamespace replaces the registered namespace, which is checked by the next upload
+func (s *NamespaceStorage) UpdateNamespace(ctx context.Context, n namespace.Namespace) error {
+	defer s.invalidate()
+	return s.Storage.UpdateNamespace(ctx, n)
+}
+
+// DeleteNamespace removes the namespace from the registry, which is checked by the next upload
+func (s *NamespaceStorage) DeleteNamespace(ctx context.Context, name string) error {
+	defer s.invalidate()
+	return s.Storage.DeleteNamespace(ctx, name)
+}
+
+// UploadModule rejects uploads the namespace of the module doesn't allow
+func (s *NamespaceStorage) UploadModule(ctx context.Context, namespace, name, provider, version string, body io.Reader) (core.Module, error) {
+	if err := s.Check(ctx, namespace, name); err != nil {
+		return core.Module{}, fmt.Errorf("%w: %v", module.ErrPublishDisabled, err)
+	}
+	return s.Storage.UploadModule(ctx, namespace, name, provider, version, body)
+}
+
+// CopyModule rejects copies the target namespace doesn't allow
+func (s *NamespaceStorage) CopyModule(ctx context.Context, src, dst core.Module) (core.Module, error) {
+	if err := s.Check(ctx, dst.Namespace, dst.Name); err != nil {
+		return core.Module{}, fmt.Errorf("%w: %v", module.ErrPublishDisabled, err)
+	}
+	return s.Storage.CopyModule(ctx, src, dst)
+}
+
+// DeleteModule rejects deleting modules of namespaces that don't allow changes, like moving them out of frozen namespaces
+func (s *NamespaceStorage) DeleteModule(ctx context.Context, namespace, name, provider, version string) error {
+	if err := s.Check(ctx, namespace, name); err != nil {
+		return fmt.Errorf("%w: %v", module.ErrPublishDisabled, err)
+	}
+	return s.Storage.DeleteModule(ctx, namespace, name, provider, version)
+}
+
+// UploadProviderReleaseFiles rejects uploads the namespace of the provider doesn't allow
+func (s *NamespaceStorage) UploadProviderReleaseFiles(ctx context.Context, namespace, name, filename string, file io.Reader) error {
+	if err := s.Check(ctx, namespace, name); err != nil {
+		return fmt.Errorf("%w: %v", provider.ErrPublishDisabled, err)
+	}
+	return s.Storage.UploadProviderReleaseFiles(ctx, namespace, name, filename, file)
+}
+
+// CopyProviderRelease rejects copies the target namespace doesn't allow
+func (s *NamespaceStorage) CopyProviderRelease(ctx context.Context, namespace, name, version, targetNamespace string) error {
+	if err := s.Check(ctx, targetNamespace, name); err != nil {
+		return fmt.Errorf("%w: %v", provider.ErrPublishDisabled, err)
+	}
+	return s.Storage.CopyProviderRelease(ctx, namespace, name, version, targetNamespace)
+}
+
+// DeleteProviderRelease rejects deleting provider releases of namespaces that don't allow changes, like moving them out of frozen namespaces
+func (s *NamespaceStorage) DeleteProviderRelease(ctx context.Context, namespace, name, version string) error {
+	if err := s.Check(ctx, namespace, name); err != nil {
+		return fmt.Errorf("%w: %v", provider.ErrPublishDisabled, err)
+	}
+	return s.Storage.DeleteProviderRelease(ctx, namespace, name, version)
+}
+
+// AuthorizeRequest forwards the credentials of the decorated Storage to the download proxy
+func (s *NamespaceStorage) AuthorizeRequest(req *http.Request) {
+	if authorizer, ok := s.Storage.(proxy.RequestAuthorizer); ok {
+		authorizer.AuthorizeRequest(req)
+	}
+}
+
+// Check returns an error if the namespace doesn't allow changing the module or provider with the name.
+// The registered namespaces are read again once they are older than the expiry,
+// and changes are rejected if the namespaces can't be read, as they can't be checked.
+func (s *NamespaceStorage) Check(ctx context.Context, namespace, name string) error {
+	s.mu.Lock()
+	namespaces, listedAt := s.namespaces, s.listedAt
+	s.mu.Unlock()
+
+	if listedAt.IsZero() || time.Since(listedAt) >= s.expiry {
+		listed, err := s.Storage.ListNamespaces(ctx)
+		if err != nil {
+			slog.Warn("failed to list namespaces", slog.String("err", err.Error()))
+			return fmt.Errorf("failed to list namespaces: %w", err)
+		}
+		namespaces = listed
+
+		s.mu.Lock()
+		s.namespaces, s.listedAt = namespaces, time.Now()
+		s.mu.Unlock()
+	}
+
+	return namespaces.CheckPublish(namespace, name, s.required)
+}
+
+func (s *NamespaceStorage) invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.listedAt = time.Time{}
+}
+
+// NamespaceStorageOption configures a NamespaceStorage
+type NamespaceStorageOption func(*NamespaceStorage)
+
+// WithNamespacesRequired rejects publishing to namespaces that aren't registered, even if no namespace is registered yet
+func WithNamespacesRequired(required bool) NamespaceStorageOption {
+	return func(s *NamespaceStorage) {
+		s.required = required
+	}
+}
+
+// WithNamespaceStorageExpiry configures how long the registered namespaces are cached before they are read again
+func WithNamespaceStorageExpiry(d time.Duration) NamespaceStorageOption {
+	return func(s *NamespaceStorage) {
+		s.expiry = d
+	}
+}
+
+// NewNamespaceStorage returns a NamespaceStorage decorating the Storage
+func NewNamespaceStorage(s Storage, options ...NamespaceStorageOption) *NamespaceStorage {
+	n := &NamespaceStorage{
+		Storage: s,
+		expiry:  time.Minute,
+	}
+
+	for _, option := range options {
+		option(n)
+	}
+
+	return n
+}
@@ -0,0 +1,93 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/module"
+	"github.com/boring-registry/boring-registry/pkg/namespace"
+	"github.com/boring-registry/boring-registry/pkg/provider"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNamespaces(t *testing.T) {
+	t.Parallel()
+
+	s := newTestPluginStorage(t, t.TempDir())
+	ctx := context.Background()
+
+	namespaces, err := s.ListNamespaces(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, namespaces)
+
+	assert.NoError(t, s.AddNamespace(ctx, namespace.Namespace{Name: "network", Owners: []string{"team-network"}}))
+	assert.NoError(t, s.AddNamespace(ctx, namespace.Namespace{Name: "legacy"}))
+	assert.ErrorIs(t, s.AddNamespace(ctx, namespace.Namespace{Name: "network"}), namespace.ErrNamespaceAlreadyExists)
+
+	assert.NoError(t, s.UpdateNamespace(ctx, namespace.Namespace{Name: "legacy", Frozen: true}))
+	assert.ErrorIs(t, s.UpdateNamespace(ctx, namespace.Namespace{Name: "netwrok"}), namespace.ErrNamespaceNotFound)
+
+	namespaces, err = s.ListNamespaces(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []namespace.Namespace{
+		{Name: "network", Owners: []string{"team-network"}},
+		{Name: "legacy", Frozen: true},
+	}, namespaces)
+
+	assert.NoError(t, s.DeleteNamespace(ctx, "legacy"))
+	assert.ErrorIs(t, s.DeleteNamespace(ctx, "legacy"), namespace.ErrNamespaceNotFound)
+}
+
+func TestNamespaceStorage(t *testing.T) {
+	t.Parallel()
+
+	s := newTestPluginStorage(t, t.TempDir())
+	ctx := context.Background()
+
+	// Without registered namespaces, unregistered ones are only rejected if they are required
+	n := NewNamespaceStorage(s)
+	_, err := n.UploadModule(ctx, "other", "vpc", "aws", "1.0.0", strings.NewReader("archive"))
+	assert.NoError(t, err)
+	required := NewNamespaceStorage(s, WithNamespacesRequired(true))
+	_, err = required.UploadModule(ctx, "other", "vpc", "aws", "2.0.0", strings.NewReader("archive"))
+	assert.ErrorIs(t, err, module.ErrPublishDisabled)
+
+	assert.NoError(t, n.AddNamespace(ctx, namespace.Namespace{Name: "network", NamePatterns: []string{"net-.*"}}))
+	assert.NoError(t, n.AddNamespace(ctx, namespace.Namespace{Name: "other"}))
+	_, err = n.UploadModule(ctx, "network", "net-vpc", "aws", "1.0.0", strings.NewReader("archive"))
+	assert.NoError(t, err)
+	_, err = n.UploadModule(ctx, "network", "vpc", "aws", "1.0.0", strings.NewReader("archive"))
+	assert.ErrorIs(t, err, module.ErrPublishDisabled)
+
+	// Once namespaces are registered, the others are rejected
+	_, err = n.UploadModule(ctx, "netwrok", "net-vpc", "aws", "1.0.0", strings.NewReader("archive"))
+	assert.ErrorIs(t, err, module.ErrPublishDisabled)
+
+	// Changes of the namespaces through the decorator are checked by the next upload
+	assert.NoError(t, n.UpdateNamespace(ctx, namespace.Namespace{Name: "network", Frozen: true}))
+	_, err = n.UploadModule(ctx, "network", "net-vpc", "aws", "2.0.0", strings.NewReader("archive"))
+	assert.ErrorIs(t, err, module.ErrPublishDisabled)
+
+	src := core.Module{Namespace: "other", Name: "vpc", Provider: "aws", Version: "1.0.0"}
+	_, err = n.CopyModule(ctx, src, core.Module{Namespace: "network", Name: "vpc", Provider: "aws", Version: "1.0.0"})
+	assert.ErrorIs(t, err, module.ErrPublishDisabled)
+
+	sums := fmt.Sprintf("%x  terraform-provider-dummy_1.0.0_linux_amd64.zip\n", sha256.Sum256([]byte("linux")))
+	err = n.UploadProviderReleaseFiles(ctx, "network", "dummy", "terraform-provider-dummy_1.0.0_SHA256SUMS", strings.NewReader(sums))
+	assert.ErrorIs(t, err, provider.ErrPublishDisabled)
+	assert.NoError(t, n.UploadProviderReleaseFiles(ctx, "other", "dummy", "terraform-provider-dummy_1.0.0_SHA256SUMS", strings.NewReader(sums)))
+	assert.ErrorIs(t, n.CopyProviderRelease(ctx, "other", "dummy", "1.0.0", "network"), provider.ErrPublishDisabled)
+
+	// Nothing can be moved out of frozen namespaces, as the old versions can't be deleted
+	assert.ErrorIs(t, n.DeleteModule(ctx, "network", "net-vpc", "aws", "1.0.0"), module.ErrPublishDisabled)
+	_, err = s.GetModule(ctx, "network", "net-vpc", "aws", "1.0.0")
+	assert.NoError(t, err)
+	assert.NoError(t, s.UploadProviderReleaseFiles(ctx, "network", "dummy", "terraform-provider-dummy_1.0.0_SHA256SUMS", strings.NewReader(sums)))
+	assert.ErrorIs(t, n.DeleteProviderRelease(ctx, "network", "dummy", "1.0.0"), provider.ErrPublishDisabled)
+	assert.NoError(t, n.DeleteModule(ctx, "other", "vpc", "aws", "1.0.0"))
+}
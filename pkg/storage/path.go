@@ -83,6 +83,16 @@ func signingKeysPath(prefix string, pt providerType, hostname, namespace string)
 	)
 }
 
+// aliasesPath returns the path of the aliases managed through the API, which are stored in a single object
+func aliasesPath(prefix string) string {
+	return path.Join(prefix, "aliases.json")
+}
+
+// namespacesPath returns the path of the registered namespaces, which are stored in a single object
+func namespacesPath(prefix string) string {
+	return path.Join(prefix, "namespaces.json")
+}
+
 func readSHASums(r io.Reader, name string) (string, error) {
 	scanner := bufio.NewScanner(r)
 
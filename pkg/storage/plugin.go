@@ -0,0 +1,568 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/boring-registry/boring-registry/pkg/alias"
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/module"
+	"github.com/boring-registry/boring-registry/pkg/namespace"
+	"github.com/boring-registry/boring-registry/pkg/provider"
+	"github.com/boring-registry/boring-registry/pkg/storage/driver"
+)
+
+// PluginStorage is a Storage implementation backed by an external storage driver speaking the driver protocol.
+// Downloads are served through signed URLs pointing at the driver, which requires the download proxy.
+// PluginStorage implements module.Storage, provider.Storage, and mirror.Storage
+type PluginStorage struct {
+	driver              *driver.Client
+	prefix              string
+	moduleArchiveFormat string
+	signingKey          []byte
+	signedURLExpiry     time.Duration
+	signer              *urlSigner
+}
+
+// GetModule retrieves information about a module from the storage driver.
+func (s *PluginStorage) GetModule(ctx context.Context, namespace, name, provider, version string) (core.Module, error) {
+	key := modulePath(s.prefix, namespace, name, provider, version, s.moduleArchiveFormat)
+
+	exists, err := s.objectExists(ctx, key)
+	if err != nil {
+		return core.Module{}, err
+	} else if !exists {
+		return core.Module{}, module.ErrModuleNotFound
+	}
+
+	return core.Module{
+		Namespace:   namespace,
+		Name:        name,
+		Provider:    provider,
+		Version:     version,
+		DownloadURL: s.presignedURL(key),
+	}, nil
+}
+
+func (s *PluginStorage) ListModuleVersions(ctx context.Context, namespace, name, provider string) ([]core.Module, error) {
+	objects, err := s.list(ctx, modulePathPrefix(s.prefix, namespace, name, provider))
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", module.ErrModuleListFailed, err)
+	}
+
+	var modules []core.Module
+	for _, obj := range objects {
+		m, err := moduleFromObject(obj.Key, s.moduleArchiveFormat)
+		if err != nil {
+			continue
+		}
+
+		m.DownloadURL = s.presignedURL(obj.Key)
+		m.PublishedAt = obj.LastModified
+		modules = append(modules, *m)
+	}
+
+	return modules, nil
+}
+
+// ListModules returns every version of all modules, restricted to the namespace if it isn't empty
+func (s *PluginStorage) ListModules(ctx context.Context, namespace string) ([]core.Module, error) {
+	return listModules(ctx, s, namespace)
+}
+
+// UploadModule uploads a module to the storage driver.
+func (s *PluginStorage) UploadModule(ctx context.Context, namespace, name, provider, version string, body io.Reader) (core.Module, error) {
+	if namespace == "" {
+		return core.Module{}, errors.New("namespace not defined")
+	}
+
+	if name == "" {
+		return core.Module{}, errors.New("name not defined")
+	}
+
+	if provider == "" {
+		return core.Module{}, errors.New("provider not defined")
+	}
+
+	if version == "" {
+		return core.Module{}, errors.New("version not defined")
+	}
+
+	key := modulePath(s.prefix, namespace, name, provider, version, s.moduleArchiveFormat)
+
+	if _, err := s.GetModule(ctx, namespace, name, provider, version); err == nil {
+		return core.Module{}, fmt.Errorf("%w: %s", module.ErrModuleAlreadyExists, key)
+	}
+
+	m := core.Module{Namespace: namespace, Name: name, Provider: provider, Version: version}
+	if err := uploadModuleArchive(ctx, s, m, key, body); err != nil {
+		return core.Module{}, fmt.Errorf("%v: %w", module.ErrModuleUploadFailed, err)
+	}
+
+	return s.GetModule(ctx, namespace, name, provider, version)
+}
+
+// DownloadModule streams the module archive from the storage driver. The caller has to close the returned io.ReadCloser
+func (s *PluginStorage) DownloadModule(ctx context.Context, namespace, name, provider, version string) (io.ReadCloser, error) {
+	key := modulePath(s.prefix, namespace, name, provider, version, s.moduleArchiveFormat)
+
+	exists, err := s.objectExists(ctx, key)
+	if err != nil {
+		return nil, err
+	} else if !exists {
+		return nil, module.ErrModuleNotFound
+	}
+
+	return s.reader(ctx, key)
+}
+
+// GetModuleDocs reads the docs that were extracted when the module version was uploaded
+func (s *PluginStorage) GetModuleDocs(ctx context.Context, namespace, name, provider, version string) (*module.Docs, error) {
+	return moduleDocs(ctx, s, namespace, name, provider, version)
+}
+
+// GetModuleInfo reads the info of the module spec file that was stored when the module version was uploaded
+func (s *PluginStorage) GetModuleInfo(ctx context.Context, namespace, name, provider, version string) (*module.Info, error) {
+	return moduleInfo(ctx, s, namespace, name, provider, version)
+}
+
+// GetModuleProvenance reads the provenance that was recorded when the module version was uploaded
+func (s *PluginStorage) GetModuleProvenance(ctx context.Context, namespace, name, provider, version string) (*core.Provenance, error) {
+	return moduleProvenance(ctx, s, namespace, name, provider, version)
+}
+
+// CopyModule copies the module version to another module, without transferring the archive through the registry if possible
+func (s *PluginStorage) CopyModule(ctx context.Context, src, dst core.Module) (core.Module, error) {
+	if err := copyModule(ctx, s, src, dst); err != nil {
+		return core.Module{}, err
+	}
+	return s.GetModule(ctx, dst.Namespace, dst.Name, dst.Provider, dst.Version)
+}
+
+// DeleteModule removes the archive of the module version together with its provenance, docs and info
+func (s *PluginStorage) DeleteModule(ctx context.Context, namespace, name, provider, version string) error {
+	return deleteModule(ctx, s, core.Module{Namespace: namespace, Name: name, Provider: provider, Version: version})
+}
+
+// getProvider retrieves information about a provider from the storage driver.
+func (s *PluginStorage) getProvider(ctx context.Context, pt providerType, provider *core.Provider) (*core.Provider, error) {
+	var archivePath, shasumPath, shasumSigPath string
+	if pt == internalProviderType {
+		archivePath, shasumPath, shasumSigPath = internalProviderPath(s.prefix, provider.Namespace, provider.Name, provider.Version, provider.OS, provider.Arch)
+	} else if pt == mirrorProviderType {
+		archivePath, shasumPath, shasumSigPath = mirrorProviderPath(s.prefix, provider.Hostname, provider.Namespace, provider.Name, provider.Version, provider.OS, provider.Arch)
+	}
+
+	if exists, err := s.objectExists(ctx, archivePath); err != nil {
+		return nil, err
+	} else if !exists {
+		return nil, noMatchingProviderFound(provider)
+	}
+
+	provider.DownloadURL = s.presignedURL(archivePath)
+	provider.SHASumsURL = s.presignedURL(shasumPath)
+	provider.SHASumsSignatureURL = s.presignedURL(shasumSigPath)
+
+	shasumBytes, err := s.download(ctx, shasumPath)
+	if err != nil {
+		return nil, err
+	}
+
+	provider.Shasum, err = readSHASums(bytes.NewReader(shasumBytes), path.Base(archivePath))
+	if err != nil {
+		return nil, err
+	}
+
+	var signingKeys *core.SigningKeys
+	if pt == internalProviderType {
+		signingKeys, err = s.SigningKeys(ctx, provider.Namespace)
+	} else if pt == mirrorProviderType {
+		signingKeys, err = s.MirroredSigningKeys(ctx, provider.Hostname, provider.Namespace)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	provider.Filename = path.Base(archivePath)
+	provider.SigningKeys = *signingKeys
+	return provider, nil
+}
+
+func (s *PluginStorage) GetProvider(ctx context.Context, namespace, name, version, os, arch string) (*core.Provider, error) {
+	return s.getProvider(ctx, internalProviderType, &core.Provider{
+		Namespace: namespace,
+		Name:      name,
+		Version:   version,
+		OS:        os,
+		Arch:      arch,
+	})
+}
+
+func (s *PluginStorage) GetMirroredProvider(ctx context.Context, provider *core.Provider) (*core.Provider, error) {
+	return s.getProvider(ctx, mirrorProviderType, provider)
+}
+
+func (s *PluginStorage) listProviderVersions(ctx context.Context, pt providerType, provider *core.Provider) ([]*core.Provider, error) {
+	prefix := providerStoragePrefix(s.prefix, pt, provider.Hostname, provider.Namespace, provider.Name)
+	objects, err := s.list(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	var providers []*core.Provider
+	for _, obj := range objects {
+		p, err := core.NewProviderFromArchive(path.Base(obj.Key))
+		if err != nil {
+			continue
+		}
+
+		if provider.Version != "" && provider.Version != p.Version {
+			// The provider version doesn't match the requested version
+			continue
+		}
+
+		p.Hostname = provider.Hostname
+		p.Namespace = provider.Namespace
+		p.DownloadURL = s.presignedURL(obj.Key)
+
+		providers = append(providers, &p)
+	}
+
+	if len(providers) == 0 {
+		return nil, noMatchingProviderFound(provider)
+	}
+
+	return providers, nil
+}
+
+func (s *PluginStorage) ListProviderVersions(ctx context.Context, namespace, name string) (*core.ProviderVersions, error) {
+	providers, err := s.listProviderVersions(ctx, internalProviderType, &core.Provider{Namespace: namespace, Name: name})
+	if err != nil {
+		return nil, err
+	}
+
+	collection := NewCollection()
+	for _, p := range providers {
+		collection.Add(p)
+	}
+	return collection.List(), nil
+}
+
+// ListProviders returns every version of all providers, restricted to the namespace if it isn't empty
+func (s *PluginStorage) ListProviders(ctx context.Context, namespace string) ([]core.ProviderVersion, error) {
+	return listProviders(ctx, s, namespace)
+}
+
+func (s *PluginStorage) ListMirroredProviders(ctx context.Context, provider *core.Provider) ([]*core.Provider, error) {
+	return s.listProviderVersions(ctx, mirrorProviderType, provider)
+}
+
+func (s *PluginStorage) UploadProviderReleaseFiles(ctx context.Context, namespace, name, filename string, file io.Reader) error {
+	if namespace == "" {
+		return fmt.Errorf("namespace argument is empty")
+	}
+
+	if name == "" {
+		return fmt.Errorf("name argument is empty")
+	}
+
+	if filename == "" {
+		return fmt.Errorf("filename argument is empty")
+	}
+
+	prefix := providerStoragePrefix(s.prefix, internalProviderType, "", namespace, name)
+	key := path.Join(prefix, filename)
+	return uploadWithProvenance(ctx, s, key, file, false)
+}
+
+// GetProviderProvenance reads the provenance that was recorded when the files of the provider release were uploaded
+func (s *PluginStorage) GetProviderProvenance(ctx context.Context, namespace, name, version string) ([]core.Provenance, error) {
+	return providerProvenance(ctx, s, namespace, name, version)
+}
+
+// GetProviderRelease returns the files of the provider release, which are read once they are opened
+func (s *PluginStorage) GetProviderRelease(ctx context.Context, namespace, name, version string) (*provider.Release, error) {
+	return providerRelease(ctx, s, namespace, name, version)
+}
+
+// CopyProviderRelease copies all files of the provider release to another namespace
+func (s *PluginStorage) CopyProviderRelease(ctx context.Context, namespace, name, version, targetNamespace string) error {
+	return copyProviderRelease(ctx, s, namespace, name, version, targetNamespace)
+}
+
+// DeleteProviderRelease removes all files of the provider release
+func (s *PluginStorage) DeleteProviderRelease(ctx context.Context, namespace, name, version string) error {
+	return deleteProviderRelease(ctx, s, namespace, name, version)
+}
+
+// ListAliases returns the aliases managed through the API
+func (s *PluginStorage) ListAliases(ctx context.Context) ([]alias.Alias, error) {
+	return listAliases(ctx, s)
+}
+
+// AddAlias stores the alias together with the other aliases managed through the API
+func (s *PluginStorage) AddAlias(ctx context.Context, a alias.Alias) error {
+	return addAlias(ctx, s, a)
+}
+
+// DeleteAlias removes the alias from the aliases managed through the API
+func (s *PluginStorage) DeleteAlias(ctx context.Context, aliasType, from string) error {
+	return deleteAlias(ctx, s, aliasType, from)
+}
+
+// ListNamespaces returns the registered namespaces
+func (s *PluginStorage) ListNamespaces(ctx context.Context) ([]namespace.Namespace, error) {
+	return listNamespaces(ctx, s)
+}
+
+// AddNamespace registers the namespace together with the other registered namespaces
+func (s *PluginStorage) AddNamespace(ctx context.Context, n namespace.Namespace) error {
+	return addNamespace(ctx, s, n)
+}
+
+// UpdateNamespace replaces the registered namespace with the same name
+func (s *PluginStorage) UpdateNamespace(ctx context.Context, n namespace.Namespace) error {
+	return updateNamespace(ctx, s, n)
+}
+
+// DeleteNamespace removes the namespace from the registered namespaces
+func (s *PluginStorage) DeleteNamespace(ctx context.Context, name string) error {
+	return deleteNamespace(ctx, s, name)
+}
+
+func (s *PluginStorage) signingKeys(ctx context.Context, pt providerType, hostname, namespace string) (*core.SigningKeys, error) {
+	if namespace == "" {
+		return nil, fmt.Errorf("namespace argument is empty")
+	}
+	key := signingKeysPath(s.prefix, pt, hostname, namespace)
+	exists, err := s.objectExists(ctx, key)
+	if err != nil {
+		return nil, err
+	} else if !exists {
+		return nil, core.ErrObjectNotFound
+	}
+
+	signingKeysRaw, err := s.download(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download signing_keys.json for namespace %s: %w", namespace, err)
+	}
+
+	return unmarshalSigningKeys(signingKeysRaw)
+}
+
+// SigningKeys downloads the JSON placed in the namespace in the storage driver and unmarshals it into a core.SigningKeys
+func (s *PluginStorage) SigningKeys(ctx context.Context, namespace string) (*core.SigningKeys, error) {
+	return s.signingKeys(ctx, internalProviderType, "", namespace)
+}
+
+func (s *PluginStorage) MirroredSigningKeys(ctx context.Context, hostname, namespace string) (*core.SigningKeys, error) {
+	return s.signingKeys(ctx, mirrorProviderType, hostname, namespace)
+}
+
+func (s *PluginStorage) uploadSigningKeys(ctx context.Context, pt providerType, hostname, namespace string, signingKeys *core.SigningKeys) error {
+	b, err := json.Marshal(signingKeys)
+	if err != nil {
+		return err
+	}
+	key := signingKeysPath(s.prefix, pt, hostname, namespace)
+	return s.upload(ctx, key, bytes.NewReader(b), true)
+}
+
+func (s *PluginStorage) UploadMirroredSigningKeys(ctx context.Context, hostname, namespace string, signingKeys *core.SigningKeys) error {
+	return s.uploadSigningKeys(ctx, mirrorProviderType, hostname, namespace, signingKeys)
+}
+
+func (s *PluginStorage) MirroredSha256Sum(ctx context.Context, provider *core.Provider) (*core.Sha256Sums, error) {
+	prefix := providerStoragePrefix(s.prefix, mirrorProviderType, provider.Hostname, provider.Namespace, provider.Name)
+	key := path.Join(prefix, provider.ShasumFileName())
+	shaSumBytes, err := s.download(ctx, key)
+	if err != nil {
+		return nil, errors.New("failed to download SHA256SUMS")
+	}
+
+	return core.NewSha256Sums(provider.ShasumFileName(), bytes.NewReader(shaSumBytes))
+}
+
+func (s *PluginStorage) UploadMirroredFile(ctx context.Context, provider *core.Provider, fileName string, reader io.Reader) error {
+	prefix := providerStoragePrefix(s.prefix, mirrorProviderType, provider.Hostname, provider.Namespace, provider.Name)
+	key := path.Join(prefix, fileName)
+	return s.upload(ctx, key, reader, true)
+}
+
+// GetDownloadUrl verifies the signature of a URL that was handed out by presignedURL and returns the absolute URL of the driver.
+func (s *PluginStorage) GetDownloadUrl(ctx context.Context, rawURL string) (string, error) {
+	base := s.driver.BaseURL()
+	u, err := url.Parse(fmt.Sprintf("%s://%s/%s", base.Scheme, base.Host, strings.TrimPrefix(rawURL, "/")))
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.signer.verify(u); err != nil {
+		return "", err
+	}
+
+	return u.String(), nil
+}
+
+// AuthorizeRequest adds the driver token to requests targeting the driver.
+// It implements proxy.RequestAuthorizer
+func (s *PluginStorage) AuthorizeRequest(req *http.Request) {
+	s.driver.AuthorizeRequest(req)
+}
+
+// presignedURL returns the URL of the object in the driver signed by the urlSigner
+func (s *PluginStorage) presignedURL(key string) string {
+	return s.signer.sign(s.driver.ObjectURL(key))
+}
+
+func (s *PluginStorage) objectExists(ctx context.Context, key string) (bool, error) {
+	if _, err := s.driver.Stat(ctx, key); errors.Is(err, driver.ErrNotFound) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *PluginStorage) upload(ctx context.Context, key string, reader io.Reader, overwrite bool) error {
+	// If we don't want to overwrite, check if the object exists
+	if !overwrite {
+		exists, err := s.objectExists(ctx, key)
+		if err != nil {
+			return err
+		} else if exists {
+			return fmt.Errorf("failed to upload key %s: %w", key, core.ErrObjectAlreadyExists)
+		}
+	}
+
+	// The driver verifies the object against the SHA-256 checksum sent by the client, and removes it again on a mismatch
+	c := newChecksumReader(reader)
+	if err := s.driver.Put(ctx, key, c); errors.Is(err, driver.ErrChecksumMismatch) {
+		return fmt.Errorf("%w: %v", core.ErrChecksumMismatch, err)
+	} else if err != nil {
+		return fmt.Errorf("failed to upload: %w", err)
+	}
+
+	return verifyUpload(ctx, s, key, c, func() error {
+		return nil
+	})
+}
+
+func (s *PluginStorage) deleteObject(ctx context.Context, key string) error {
+	if err := s.driver.Delete(ctx, key); err != nil {
+		return fmt.Errorf("failed to delete %s: %w", key, err)
+	}
+	return nil
+}
+
+func (s *PluginStorage) reader(ctx context.Context, key string) (io.ReadCloser, error) {
+	r, err := s.driver.Get(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", key, err)
+	}
+
+	return r, nil
+}
+
+func (s *PluginStorage) download(ctx context.Context, key string) ([]byte, error) {
+	r, err := s.reader(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return io.ReadAll(r)
+}
+
+// list returns all objects below the prefix
+func (s *PluginStorage) list(ctx context.Context, prefix string) ([]driver.Object, error) {
+	return s.driver.List(ctx, strings.TrimSuffix(prefix, "/")+"/")
+}
+
+func (s *PluginStorage) listObjects(ctx context.Context, prefix string) ([]object, error) {
+	driverObjects, err := s.driver.List(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	objects := make([]object, 0, len(driverObjects))
+	for _, o := range driverObjects {
+		objects = append(objects, object{
+			key:          o.Key,
+			size:         o.Size,
+			lastModified: o.LastModified,
+		})
+	}
+	return objects, nil
+}
+
+func (s *PluginStorage) layout() (string, string) {
+	return s.prefix, s.moduleArchiveFormat
+}
+
+// PluginStorageOption provides additional options for the PluginStorage.
+type PluginStorageOption func(*PluginStorage)
+
+// WithPluginStoragePrefix configures the plugin storage to work under a given prefix.
+func WithPluginStoragePrefix(prefix string) PluginStorageOption {
+	return func(s *PluginStorage) {
+		s.prefix = prefix
+	}
+}
+
+// WithPluginStorageArchiveFormat configures the module archive format (zip, tar, tgz, etc.)
+func WithPluginStorageArchiveFormat(archiveFormat string) PluginStorageOption {
+	return func(s *PluginStorage) {
+		if archiveFormat != "" {
+			s.moduleArchiveFormat = archiveFormat
+		}
+	}
+}
+
+// WithPluginStorageSigningKey configures the key that download URLs are signed with.
+// All replicas of the boring-registry have to share the same key.
+func WithPluginStorageSigningKey(key string) PluginStorageOption {
+	return func(s *PluginStorage) {
+		if key != "" {
+			s.signingKey = []byte(key)
+		}
+	}
+}
+
+// WithPluginStorageSignedUrlExpiry configures the duration until the signed url expires
+func WithPluginStorageSignedUrlExpiry(t time.Duration) PluginStorageOption {
+	return func(s *PluginStorage) {
+		s.signedURLExpiry = t
+	}
+}
+
+// NewPluginStorage returns a fully initialized storage backed by the driver client.
+// The client either belongs to a driver launched with driver.Launch or to a driver running elsewhere.
+func NewPluginStorage(client *driver.Client, options ...PluginStorageOption) (Storage, error) {
+	s := &PluginStorage{
+		driver:              client,
+		moduleArchiveFormat: DefaultModuleArchiveFormat,
+		signedURLExpiry:     5 * time.Minute,
+	}
+
+	for _, option := range options {
+		option(s)
+	}
+
+	var err error
+	s.signer, err = newURLSigner(s.signingKey, s.signedURLExpiry)
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
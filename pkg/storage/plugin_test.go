@@ -0,0 +1,163 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/module"
+	"github.com/boring-registry/boring-registry/pkg/storage/driver"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestPluginStorage(t *testing.T, root string, options ...PluginStorageOption) *PluginStorage {
+	t.Helper()
+	d, err := driver.NewFilesystemDriver(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(driver.NewHandler(d, "secret"))
+	t.Cleanup(ts.Close)
+
+	client, err := driver.NewClient(ts.URL, driver.WithClientToken("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewPluginStorage(client, options...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s.(*PluginStorage)
+}
+
+func TestPluginStorage_Modules(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	s := newTestPluginStorage(t, root, WithPluginStoragePrefix("registry"))
+	ctx := context.Background()
+
+	_, err := s.GetModule(ctx, "example", "s3", "aws", "1.0.0")
+	assert.ErrorIs(t, err, module.ErrModuleNotFound)
+
+	for _, version := range []string{"1.0.0", "1.1.0"} {
+		m, err := s.UploadModule(ctx, "example", "s3", "aws", version, strings.NewReader("archive-"+version))
+		assert.NoError(t, err)
+		assert.Equal(t, version, m.Version)
+		assert.Contains(t, m.DownloadURL, "/v1/objects/registry/modules/example/s3/aws/example-s3-aws-"+version+".tar.gz?")
+	}
+	content, err := os.ReadFile(filepath.Join(root, "registry/modules/example/s3/aws/example-s3-aws-1.0.0.tar.gz"))
+	assert.NoError(t, err)
+	assert.Equal(t, "archive-1.0.0", string(content))
+
+	_, err = s.UploadModule(ctx, "example", "s3", "aws", "1.0.0", strings.NewReader("overwrite"))
+	assert.ErrorIs(t, err, module.ErrModuleAlreadyExists)
+
+	archive, err := s.DownloadModule(ctx, "example", "s3", "aws", "1.1.0")
+	assert.NoError(t, err)
+	content, err = io.ReadAll(archive)
+	assert.NoError(t, err)
+	assert.NoError(t, archive.Close())
+	assert.Equal(t, "archive-1.1.0", string(content))
+
+	_, err = s.DownloadModule(ctx, "example", "s3", "aws", "2.0.0")
+	assert.ErrorIs(t, err, module.ErrModuleNotFound)
+
+	// A module with a name sharing the prefix must not show up
+	_, err = s.UploadModule(ctx, "example", "s3", "aws-extra", "1.0.0", strings.NewReader("archive"))
+	assert.NoError(t, err)
+
+	modules, err := s.ListModuleVersions(ctx, "example", "s3", "aws")
+	assert.NoError(t, err)
+	var versions []string
+	for _, m := range modules {
+		versions = append(versions, m.Version)
+	}
+	assert.ElementsMatch(t, []string{"1.0.0", "1.1.0"}, versions)
+
+	modules, err = s.ListModuleVersions(ctx, "example", "missing", "aws")
+	assert.NoError(t, err)
+	assert.Empty(t, modules)
+}
+
+func TestPluginStorage_Providers(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	s := newTestPluginStorage(t, root)
+	ctx := context.Background()
+
+	files := map[string]string{
+		"terraform-provider-dummy_1.0.0_linux_amd64.zip": "zip",
+		"terraform-provider-dummy_1.0.0_SHA256SUMS":      "4a70fe9aa6436e02c2dea340fbd1e352e4ef2d8ce6ca52ad25d4b95471fc8bf2  terraform-provider-dummy_1.0.0_linux_amd64.zip",
+		"terraform-provider-dummy_1.0.0_SHA256SUMS.sig":  "sig",
+	}
+	for name, content := range files {
+		assert.NoError(t, s.UploadProviderReleaseFiles(ctx, "example", "dummy", name, strings.NewReader(content)))
+	}
+	err := s.UploadProviderReleaseFiles(ctx, "example", "dummy", "terraform-provider-dummy_1.0.0_SHA256SUMS", strings.NewReader(""))
+	assert.ErrorIs(t, err, core.ErrObjectAlreadyExists)
+
+	_, err = s.GetProvider(ctx, "example", "dummy", "1.0.0", "linux", "amd64")
+	assert.ErrorIs(t, err, core.ErrObjectNotFound, "signing keys are missing")
+
+	signingKeys := `{"gpg_public_keys":[{"key_id":"47422B4AA9FA381B","ascii_armor":"test"}]}`
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "providers/example/signing-keys.json"), []byte(signingKeys), 0o644))
+
+	p, err := s.GetProvider(ctx, "example", "dummy", "1.0.0", "linux", "amd64")
+	assert.NoError(t, err)
+	assert.Equal(t, "4a70fe9aa6436e02c2dea340fbd1e352e4ef2d8ce6ca52ad25d4b95471fc8bf2", p.Shasum)
+	assert.Equal(t, "47422B4AA9FA381B", p.SigningKeys.GPGPublicKeys[0].KeyID)
+
+	versions, err := s.ListProviderVersions(ctx, "example", "dummy")
+	assert.NoError(t, err)
+	assert.Equal(t, &core.ProviderVersions{
+		Versions: []core.ProviderVersion{
+			{
+				Namespace: "example",
+				Name:      "dummy",
+				Version:   "1.0.0",
+				Platforms: []core.Platform{{OS: "linux", Arch: "amd64"}},
+			},
+		},
+	}, versions)
+}
+
+func TestPluginStorage_GetDownloadUrl(t *testing.T) {
+	t.Parallel()
+
+	s := newTestPluginStorage(t, t.TempDir())
+	ctx := context.Background()
+
+	m, err := s.UploadModule(ctx, "example", "s3", "aws", "1.0.0", strings.NewReader("archive"))
+	assert.NoError(t, err)
+
+	// The proxy receives everything after the host of the download URL
+	u, err := url.Parse(m.DownloadURL)
+	assert.NoError(t, err)
+	downloadURL, err := s.GetDownloadUrl(ctx, strings.TrimPrefix(u.RequestURI(), "/"))
+	assert.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodGet, downloadURL, nil)
+	assert.NoError(t, err)
+	s.AuthorizeRequest(req)
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "archive", string(body))
+
+	// Paths that weren't signed by the storage are rejected
+	_, err = s.GetDownloadUrl(ctx, "v1/objects/providers/example/signing-keys.json")
+	assert.Error(t, err)
+}
@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/metadata"
+)
+
+// provenanceExtension is appended to the key of an artifact to store its provenance next to it
+const provenanceExtension = ".provenance.json"
+
+// uploadWithProvenance uploads the artifact and records its provenance in a sidecar next to it
+func uploadWithProvenance(ctx context.Context, s objectStorage, key string, body io.Reader, overwrite bool) error {
+	h := sha256.New()
+	if err := s.upload(ctx, key, io.TeeReader(body, h), overwrite); err != nil {
+		return err
+	}
+
+	storeProvenance(ctx, s, key, h.Sum(nil))
+	return nil
+}
+
+// storeProvenance combines the provenance reported by the client with the uploader and the checksum of the artifact.
+// Failing to store it doesn't fail the upload, as the artifact can't be uploaded again once it exists.
+func storeProvenance(ctx context.Context, s objectStorage, key string, sum []byte) {
+	p := core.ProvenanceFromContext(ctx)
+	p.Artifact = path.Base(key)
+	p.SHA256 = hex.EncodeToString(sum)
+	p.Uploader = metadata.UploaderFromContext(ctx)
+	p.UploadedAt = time.Now().UTC()
+
+	if err := writeSidecar(ctx, s, key+provenanceExtension, p); err != nil {
+		slog.Error("failed to store provenance", slog.String("key", key), slog.String("err", err.Error()))
+	}
+}
+
+// moduleProvenance reads the provenance of the module archive
+func moduleProvenance(ctx context.Context, s objectStorage, namespace, name, provider, version string) (*core.Provenance, error) {
+	prefix, archiveFormat := s.layout()
+	key := modulePath(prefix, namespace, name, provider, version, archiveFormat) + provenanceExtension
+
+	p := &core.Provenance{}
+	if err := readSidecar(ctx, s, key, core.ErrProvenanceNotFound, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// providerProvenance reads the provenance of every file of the provider release, sorted by the file name
+func providerProvenance(ctx context.Context, s objectStorage, namespace, name, version string) ([]core.Provenance, error) {
+	prefix, _ := s.layout()
+	base := providerStoragePrefix(prefix, internalProviderType, "", namespace, name) + "/"
+
+	objects, err := s.listObjects(ctx, base)
+	if err != nil {
+		return nil, err
+	}
+
+	release := fmt.Sprintf("%s%s_%s_", core.ProviderPrefix, name, version)
+	var result []core.Provenance
+	for _, obj := range objects {
+		file := strings.TrimPrefix(obj.key, base)
+		if !strings.HasSuffix(file, provenanceExtension) || !strings.HasPrefix(file, release) {
+			continue
+		}
+
+		var p core.Provenance
+		if err := readSidecar(ctx, s, obj.key, core.ErrProvenanceNotFound, &p); err != nil {
+			return nil, err
+		}
+		result = append(result, p)
+	}
+
+	if len(result) == 0 {
+		return nil, fmt.Errorf("%w: %s", core.ErrProvenanceNotFound, strings.TrimSuffix(release, "_"))
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Artifact < result[j].Artifact
+	})
+	return result, nil
+}
@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/metadata"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestModuleProvenance(t *testing.T) {
+	t.Parallel()
+
+	s := newTestPluginStorage(t, t.TempDir())
+	ctx := metadata.WithUploader(core.WithProvenance(context.Background(), core.Provenance{
+		ClientVersion: "1.0.0",
+		GitCommit:     "0123abc",
+		GitRef:        "main",
+		CIRunURL:      "https://ci.example.com/runs/1",
+	}), "ci-bot")
+
+	_, err := s.GetModuleProvenance(ctx, "example", "s3", "aws", "1.0.0")
+	assert.ErrorIs(t, err, core.ErrProvenanceNotFound)
+
+	_, err = s.UploadModule(ctx, "example", "s3", "aws", "1.0.0", strings.NewReader("archive"))
+	assert.NoError(t, err)
+
+	p, err := s.GetModuleProvenance(ctx, "example", "s3", "aws", "1.0.0")
+	assert.NoError(t, err)
+	assert.Equal(t, "example-s3-aws-1.0.0.tar.gz", p.Artifact)
+	assert.Equal(t, fmt.Sprintf("%x", sha256.Sum256([]byte("archive"))), p.SHA256)
+	assert.Equal(t, "ci-bot", p.Uploader)
+	assert.Equal(t, "0123abc", p.GitCommit)
+	assert.Equal(t, "main", p.GitRef)
+	assert.Equal(t, "https://ci.example.com/runs/1", p.CIRunURL)
+	assert.Equal(t, "1.0.0", p.ClientVersion)
+	assert.False(t, p.UploadedAt.IsZero())
+
+	// The sidecar isn't listed as module version
+	modules, err := s.ListModuleVersions(ctx, "example", "s3", "aws")
+	assert.NoError(t, err)
+	assert.Len(t, modules, 1)
+}
+
+func TestProviderProvenance(t *testing.T) {
+	t.Parallel()
+
+	s := newTestPluginStorage(t, t.TempDir())
+	ctx := metadata.WithUploader(context.Background(), "ci-bot")
+
+	files := map[string]string{
+		"terraform-provider-dummy_1.0.0_linux_amd64.zip":  "linux",
+		"terraform-provider-dummy_1.0.0_darwin_arm64.zip": "darwin",
+		"terraform-provider-dummy_1.1.0_linux_amd64.zip":  "linux",
+	}
+	for name, content := range files {
+		assert.NoError(t, s.UploadProviderReleaseFiles(ctx, "hashicorp", "dummy", name, strings.NewReader(content)))
+	}
+
+	provenance, err := s.GetProviderProvenance(ctx, "hashicorp", "dummy", "1.0.0")
+	assert.NoError(t, err)
+	if assert.Len(t, provenance, 2) {
+		assert.Equal(t, "terraform-provider-dummy_1.0.0_darwin_arm64.zip", provenance[0].Artifact)
+		assert.Equal(t, fmt.Sprintf("%x", sha256.Sum256([]byte("darwin"))), provenance[0].SHA256)
+		assert.Equal(t, "terraform-provider-dummy_1.0.0_linux_amd64.zip", provenance[1].Artifact)
+		assert.Equal(t, "ci-bot", provenance[1].Uploader)
+	}
+
+	_, err = s.GetProviderProvenance(ctx, "hashicorp", "dummy", "2.0.0")
+	assert.ErrorIs(t, err, core.ErrProvenanceNotFound)
+
+	// The sidecars aren't listed as provider platforms
+	versions, err := s.ListProviderVersions(ctx, "hashicorp", "dummy")
+	assert.NoError(t, err)
+	assert.Len(t, versions.Versions, 2)
+}
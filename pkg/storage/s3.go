@@ -3,17 +3,23 @@ package storage
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"path"
 	"path/filepath"
+	"strings"
 	"time"
 
+	"github.com/boring-registry/boring-registry/pkg/alias"
 	"github.com/boring-registry/boring-registry/pkg/core"
 	"github.com/boring-registry/boring-registry/pkg/module"
+	"github.com/boring-registry/boring-registry/pkg/namespace"
+	"github.com/boring-registry/boring-registry/pkg/provider"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	signer "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
@@ -21,6 +27,8 @@ import (
 	"github.com/aws/aws-sdk-go-v2/config"
 	s3manager "github.com/aws/aws-sdk-go-v2/feature/s3/manager"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
 )
 
 // s3ClientAPI is used to mock the AWS APIs
@@ -29,6 +37,7 @@ type s3ClientAPI interface {
 	HeadObject(ctx context.Context, params *s3.HeadObjectInput, optFns ...func(*s3.Options)) (*s3.HeadObjectOutput, error)
 	ListObjectsV2(ctx context.Context, input *s3.ListObjectsV2Input, f ...func(*s3.Options)) (*s3.ListObjectsV2Output, error)
 	CopyObject(ctx context.Context, params *s3.CopyObjectInput, optFns ...func(*s3.Options)) (*s3.CopyObjectOutput, error)
+	DeleteObject(ctx context.Context, params *s3.DeleteObjectInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectOutput, error)
 }
 
 // s3UploaderAPI is used to mock the AWS APIs
@@ -40,7 +49,7 @@ type s3UploaderAPI interface {
 // s3DownloaderAPI is used to mock the AWS APIs
 // See https://aws.github.io/aws-sdk-go-v2/docs/unit-testing/
 type s3DownloaderAPI interface {
-	Download(ctx context.Context, w io.WriterAt, input *s3.GetObjectInput, options ...func(api *s3manager.Downloader)) (n int64, err error)
+	GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
 }
 
 // s3PresignClientAPI is used to mock the AWS APIs
@@ -111,6 +120,10 @@ func (s *S3Storage) ListModuleVersions(ctx context.Context, namespace, name, pro
 				continue
 			}
 
+			if obj.LastModified != nil {
+				m.PublishedAt = *obj.LastModified
+			}
+
 			// The download URL is probably not necessary for ListModules
 			m.DownloadURL, err = s.presignedURL(ctx, modulePath(s.bucketPrefix, m.Namespace, m.Name, m.Provider, m.Version, s.moduleArchiveFormat))
 			if err != nil {
@@ -124,6 +137,11 @@ func (s *S3Storage) ListModuleVersions(ctx context.Context, namespace, name, pro
 	return modules, nil
 }
 
+// ListModules returns every version of all modules, restricted to the namespace if it isn't empty
+func (s *S3Storage) ListModules(ctx context.Context, namespace string) ([]core.Module, error) {
+	return listModules(ctx, s, namespace)
+}
+
 // UploadModule uploads a module to the S3 storage.
 func (s *S3Storage) UploadModule(ctx context.Context, namespace, name, provider, version string, body io.Reader) (core.Module, error) {
 	if namespace == "" {
@@ -148,19 +166,56 @@ func (s *S3Storage) UploadModule(ctx context.Context, namespace, name, provider,
 		return core.Module{}, fmt.Errorf("%w: %s", module.ErrModuleAlreadyExists, key)
 	}
 
-	input := &s3.PutObjectInput{
-		Bucket: aws.String(s.bucket),
-		Key:    aws.String(key),
-		Body:   body,
-	}
-
-	if _, err := s.uploader.Upload(ctx, input); err != nil {
+	m := core.Module{Namespace: namespace, Name: name, Provider: provider, Version: version}
+	if err := uploadModuleArchive(ctx, s, m, key, body); err != nil {
 		return core.Module{}, fmt.Errorf("%v: %w", module.ErrModuleUploadFailed, err)
 	}
 
 	return s.GetModule(ctx, namespace, name, provider, version)
 }
 
+// DownloadModule streams the module archive from the S3 bucket. The caller has to close the returned io.ReadCloser
+func (s *S3Storage) DownloadModule(ctx context.Context, namespace, name, provider, version string) (io.ReadCloser, error) {
+	key := modulePath(s.bucketPrefix, namespace, name, provider, version, s.moduleArchiveFormat)
+
+	exists, err := s.objectExists(ctx, key)
+	if err != nil {
+		return nil, err
+	} else if !exists {
+		return nil, module.ErrModuleNotFound
+	}
+
+	return s.reader(ctx, key)
+}
+
+// GetModuleDocs reads the docs that were extracted when the module version was uploaded
+func (s *S3Storage) GetModuleDocs(ctx context.Context, namespace, name, provider, version string) (*module.Docs, error) {
+	return moduleDocs(ctx, s, namespace, name, provider, version)
+}
+
+// GetModuleInfo reads the info of the module spec file that was stored when the module version was uploaded
+func (s *S3Storage) GetModuleInfo(ctx context.Context, namespace, name, provider, version string) (*module.Info, error) {
+	return moduleInfo(ctx, s, namespace, name, provider, version)
+}
+
+// GetModuleProvenance reads the provenance that was recorded when the module version was uploaded
+func (s *S3Storage) GetModuleProvenance(ctx context.Context, namespace, name, provider, version string) (*core.Provenance, error) {
+	return moduleProvenance(ctx, s, namespace, name, provider, version)
+}
+
+// CopyModule copies the module version to another module, without transferring the archive through the registry if possible
+func (s *S3Storage) CopyModule(ctx context.Context, src, dst core.Module) (core.Module, error) {
+	if err := copyModule(ctx, s, src, dst); err != nil {
+		return core.Module{}, err
+	}
+	return s.GetModule(ctx, dst.Namespace, dst.Name, dst.Provider, dst.Version)
+}
+
+// DeleteModule removes the archive of the module version together with its provenance, docs and info
+func (s *S3Storage) DeleteModule(ctx context.Context, namespace, name, provider, version string) error {
+	return deleteModule(ctx, s, core.Module{Namespace: namespace, Name: name, Provider: provider, Version: version})
+}
+
 // GetProvider retrieves information about a provider from the S3 storage.
 func (s *S3Storage) getProvider(ctx context.Context, pt providerType, provider *core.Provider) (*core.Provider, error) {
 	var archivePath, shasumPath, shasumSigPath string
@@ -290,6 +345,11 @@ func (s *S3Storage) ListProviderVersions(ctx context.Context, namespace, name st
 	return collection.List(), nil
 }
 
+// ListProviders returns every version of all providers, restricted to the namespace if it isn't empty
+func (s *S3Storage) ListProviders(ctx context.Context, namespace string) ([]core.ProviderVersion, error) {
+	return listProviders(ctx, s, namespace)
+}
+
 func (s *S3Storage) ListMirroredProviders(ctx context.Context, provider *core.Provider) ([]*core.Provider, error) {
 	return s.listProviderVersions(ctx, mirrorProviderType, provider)
 }
@@ -309,7 +369,62 @@ func (s *S3Storage) UploadProviderReleaseFiles(ctx context.Context, namespace, n
 
 	prefix := providerStoragePrefix(s.bucketPrefix, internalProviderType, "", namespace, name)
 	key := filepath.Join(prefix, filename)
-	return s.upload(ctx, key, file, false)
+	return uploadWithProvenance(ctx, s, key, file, false)
+}
+
+// GetProviderProvenance reads the provenance that was recorded when the files of the provider release were uploaded
+func (s *S3Storage) GetProviderProvenance(ctx context.Context, namespace, name, version string) ([]core.Provenance, error) {
+	return providerProvenance(ctx, s, namespace, name, version)
+}
+
+// GetProviderRelease returns the files of the provider release, which are read once they are opened
+func (s *S3Storage) GetProviderRelease(ctx context.Context, namespace, name, version string) (*provider.Release, error) {
+	return providerRelease(ctx, s, namespace, name, version)
+}
+
+// CopyProviderRelease copies all files of the provider release to another namespace
+func (s *S3Storage) CopyProviderRelease(ctx context.Context, namespace, name, version, targetNamespace string) error {
+	return copyProviderRelease(ctx, s, namespace, name, version, targetNamespace)
+}
+
+// DeleteProviderRelease removes all files of the provider release
+func (s *S3Storage) DeleteProviderRelease(ctx context.Context, namespace, name, version string) error {
+	return deleteProviderRelease(ctx, s, namespace, name, version)
+}
+
+// ListAliases returns the aliases managed through the API
+func (s *S3Storage) ListAliases(ctx context.Context) ([]alias.Alias, error) {
+	return listAliases(ctx, s)
+}
+
+// AddAlias stores the alias together with the other aliases managed through the API
+func (s *S3Storage) AddAlias(ctx context.Context, a alias.Alias) error {
+	return addAlias(ctx, s, a)
+}
+
+// DeleteAlias removes the alias from the aliases managed through the API
+func (s *S3Storage) DeleteAlias(ctx context.Context, aliasType, from string) error {
+	return deleteAlias(ctx, s, aliasType, from)
+}
+
+// ListNamespaces returns the registered namespaces
+func (s *S3Storage) ListNamespaces(ctx context.Context) ([]namespace.Namespace, error) {
+	return listNamespaces(ctx, s)
+}
+
+// AddNamespace registers the namespace together with the other registered namespaces
+func (s *S3Storage) AddNamespace(ctx context.Context, n namespace.Namespace) error {
+	return addNamespace(ctx, s, n)
+}
+
+// UpdateNamespace replaces the registered namespace with the same name
+func (s *S3Storage) UpdateNamespace(ctx context.Context, n namespace.Namespace) error {
+	return updateNamespace(ctx, s, n)
+}
+
+// DeleteNamespace removes the namespace from the registered namespaces
+func (s *S3Storage) DeleteNamespace(ctx context.Context, name string) error {
+	return deleteNamespace(ctx, s, name)
 }
 
 func (s *S3Storage) signingKeys(ctx context.Context, pt providerType, hostname, namespace string) (*core.SigningKeys, error) {
@@ -411,32 +526,181 @@ func (s *S3Storage) upload(ctx context.Context, key string, reader io.Reader, ov
 		}
 	}
 
+	c := newChecksumReader(reader)
 	input := &s3.PutObjectInput{
-		Bucket: aws.String(s.bucket),
-		Key:    aws.String(key),
-		Body:   reader,
+		Bucket:            aws.String(s.bucket),
+		Key:               aws.String(key),
+		Body:              c,
+		ChecksumAlgorithm: types.ChecksumAlgorithmSha256,
 	}
 
-	if _, err := s.uploader.Upload(ctx, input); err != nil {
+	output, err := s.uploader.Upload(ctx, input)
+	if err != nil {
 		return fmt.Errorf("failed to upload: %w", err)
 	}
 
+	return verifyUpload(ctx, s, key, c, func() error {
+		return s.verifyChecksum(ctx, key, c, output.ChecksumSHA256)
+	})
+}
+
+// verifyChecksum compares the x-amz-checksum-sha256 returned by S3 with the checksum computed during the upload
+func (s *S3Storage) verifyChecksum(ctx context.Context, key string, c *checksumReader, checksum *string) error {
+	if checksum == nil {
+		// The S3-compatible storage doesn't support additional checksums, so we have to read the object back
+		return verifySHA256(ctx, s, key, c.sha256Sum())
+	}
+
+	// Multipart uploads return a checksum of the checksums of all parts in the form of <checksum>-<parts>.
+	// S3 already verified the SHA-256 of every part, so we only make sure the object is complete.
+	if strings.Contains(*checksum, "-") {
+		out, err := s.client.HeadObject(ctx, &s3.HeadObjectInput{
+			Bucket: aws.String(s.bucket),
+			Key:    aws.String(key),
+		})
+		if err != nil {
+			return err
+		}
+		if size := aws.ToInt64(out.ContentLength); size != c.size {
+			return fmt.Errorf("%w: %s has a size of %d bytes instead of %d bytes", core.ErrChecksumMismatch, key, size, c.size)
+		}
+		return nil
+	}
+
+	actual, err := base64.StdEncoding.DecodeString(*checksum)
+	if err != nil {
+		return fmt.Errorf("failed to decode checksum of %s: %w", key, err)
+	}
+	if expected := c.sha256Sum(); !bytes.Equal(expected, actual) {
+		return checksumMismatch(key, "SHA-256", expected, actual)
+	}
 	return nil
 }
 
+func (s *S3Storage) deleteObject(ctx context.Context, key string) error {
+	input := &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	}
+
+	if _, err := s.client.DeleteObject(ctx, input); err != nil {
+		return fmt.Errorf("failed to delete %s: %w", key, err)
+	}
+	return nil
+}
+
+// copyObject copies the object within the bucket, which is limited to objects of up to 5 GB
+func (s *S3Storage) copyObject(ctx context.Context, src, dst string) error {
+	input := &s3.CopyObjectInput{
+		Bucket:            aws.String(s.bucket),
+		Key:               aws.String(dst),
+		CopySource:        aws.String(url.PathEscape(s.bucket) + "/" + (&url.URL{Path: src}).EscapedPath()),
+		ChecksumAlgorithm: types.ChecksumAlgorithmSha256,
+	}
+
+	_, err := s.client.CopyObject(ctx, input)
+	return err
+}
+
+func (s *S3Storage) reader(ctx context.Context, key string) (io.ReadCloser, error) {
+	output, err := s.downloader.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", key, err)
+	}
+
+	return output.Body, nil
+}
+
 func (s *S3Storage) download(ctx context.Context, key string) ([]byte, error) {
-	buf := s3manager.NewWriteAtBuffer([]byte{})
+	r, err := s.reader(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
 
-	input := &s3.GetObjectInput{
+	return io.ReadAll(r)
+}
+
+// downloadVersion returns the content of the object together with its ETag
+func (s *S3Storage) downloadVersion(ctx context.Context, key string) ([]byte, string, error) {
+	output, err := s.downloader.GetObject(ctx, &s3.GetObjectInput{
 		Bucket: aws.String(s.bucket),
 		Key:    aws.String(key),
+	})
+	if err != nil {
+		var responseError *awshttp.ResponseError
+		if errors.As(err, &responseError) && responseError.ResponseError.HTTPStatusCode() == http.StatusNotFound {
+			return nil, "", nil
+		}
+		return nil, "", fmt.Errorf("failed to download %s: %w", key, err)
 	}
+	defer output.Body.Close()
 
-	if _, err := s.downloader.Download(ctx, buf, input); err != nil {
-		return nil, fmt.Errorf("failed to download %s: %w", key, err)
+	b, err := io.ReadAll(output.Body)
+	return b, aws.ToString(output.ETag), err
+}
+
+// uploadVersion stores the object with an If-Match or If-None-Match header.
+// S3-compatible storages that don't support conditional writes ignore the header and overwrite the object.
+func (s *S3Storage) uploadVersion(ctx context.Context, key string, b []byte, version *string) error {
+	condition := smithyhttp.SetHeaderValue("If-None-Match", "*")
+	if version != nil {
+		condition = smithyhttp.SetHeaderValue("If-Match", *version)
+	}
+
+	input := &s3.PutObjectInput{
+		Bucket:            aws.String(s.bucket),
+		Key:               aws.String(key),
+		Body:              bytes.NewReader(b),
+		ChecksumAlgorithm: types.ChecksumAlgorithmSha256,
 	}
+	_, err := s.uploader.Upload(ctx, input, func(u *s3manager.Uploader) {
+		u.ClientOptions = append(u.ClientOptions, s3.WithAPIOptions(condition))
+	})
+
+	var responseError *awshttp.ResponseError
+	if errors.As(err, &responseError) {
+		switch responseError.ResponseError.HTTPStatusCode() {
+		case http.StatusPreconditionFailed, http.StatusConflict:
+			return fmt.Errorf("failed to upload %s: %w", key, errPreconditionFailed)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to upload: %w", err)
+	}
+	return nil
+}
+
+func (s *S3Storage) listObjects(ctx context.Context, prefix string) ([]object, error) {
+	paginator := s3.NewListObjectsV2Paginator(s.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucket),
+		Prefix: aws.String(prefix),
+	})
+
+	var objects []object
+	for paginator.HasMorePages() {
+		resp, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to page next page: %w", err)
+		}
+
+		for _, obj := range resp.Contents {
+			objects = append(objects, object{
+				key:          aws.ToString(obj.Key),
+				size:         aws.ToInt64(obj.Size),
+				lastModified: aws.ToTime(obj.LastModified),
+			})
+		}
+	}
+
+	return objects, nil
+}
 
-	return buf.Bytes(), nil
+func (s *S3Storage) layout() (string, string) {
+	return s.bucketPrefix, s.moduleArchiveFormat
 }
 
 func (s *S3Storage) GetDownloadUrl(ctx context.Context, url string) (string, error) {
@@ -524,7 +788,7 @@ func NewS3Storage(ctx context.Context, bucket string, options ...S3StorageOption
 	s.client = client
 	s.presignClient = s3.NewPresignClient(client)
 	s.uploader = s3manager.NewUploader(client)
-	s.downloader = s3manager.NewDownloader(client)
+	s.downloader = client
 
 	if s.bucketRegion == "" {
 		region, err := s3manager.GetBucketRegion(ctx, client, s.bucket)
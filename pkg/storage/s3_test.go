@@ -3,6 +3,8 @@ package storage
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -14,6 +16,7 @@ import (
 
 	"github.com/boring-registry/boring-registry/pkg/core"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	signer "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
 	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
 	s3manager "github.com/aws/aws-sdk-go-v2/feature/s3/manager"
@@ -24,6 +27,7 @@ import (
 
 type mockS3Client struct {
 	headObject func(ctx context.Context, params *s3.HeadObjectInput, optFns ...func(*s3.Options)) (*s3.HeadObjectOutput, error)
+	deleted    []string
 }
 
 func (m *mockS3Client) HeadObject(ctx context.Context, params *s3.HeadObjectInput, optFns ...func(*s3.Options)) (*s3.HeadObjectOutput, error) {
@@ -38,9 +42,20 @@ func (m *mockS3Client) CopyObject(ctx context.Context, params *s3.CopyObjectInpu
 	panic("not yet implemented, as we don't have tests using it")
 }
 
+func (m *mockS3Client) DeleteObject(ctx context.Context, params *s3.DeleteObjectInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectOutput, error) {
+	m.deleted = append(m.deleted, *params.Key)
+	return &s3.DeleteObjectOutput{}, nil
+}
+
 type mockS3Uploader struct {
 	b   *bytes.Buffer
 	err error
+
+	// objects holds the content of every uploaded object by its key
+	objects map[string]string
+
+	// checksum overrides the SHA-256 checksum returned for the uploaded object
+	checksum *string
 }
 
 func (m *mockS3Uploader) Upload(ctx context.Context, input *s3.PutObjectInput, opts ...func(*s3manager.Uploader)) (*s3manager.UploadOutput, error) {
@@ -48,8 +63,20 @@ func (m *mockS3Uploader) Upload(ctx context.Context, input *s3.PutObjectInput, o
 	if _, err := io.Copy(m.b, input.Body); err != nil {
 		return nil, err
 	}
+	if m.err != nil {
+		return nil, m.err
+	}
+	if m.objects == nil {
+		m.objects = make(map[string]string)
+	}
+	m.objects[aws.ToString(input.Key)] = m.b.String()
 
-	return nil, m.err
+	checksum := m.checksum
+	if checksum == nil {
+		sum := sha256.Sum256(m.b.Bytes())
+		checksum = aws.String(base64.StdEncoding.EncodeToString(sum[:]))
+	}
+	return &s3manager.UploadOutput{ChecksumSHA256: checksum}, nil
 }
 
 type mockS3Downloader struct {
@@ -58,11 +85,10 @@ type mockS3Downloader struct {
 	error bool
 }
 
-// Not 100% sure if that works correctly for large byte arrays
 // Implements the s3DownloaderAPI interface
-func (m *mockS3Downloader) Download(ctx context.Context, w io.WriterAt, input *s3.GetObjectInput, options ...func(api *s3manager.Downloader)) (n int64, err error) {
+func (m *mockS3Downloader) GetObject(ctx context.Context, input *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
 	if m.error {
-		return 0, errors.New("mocked error")
+		return nil, errors.New("mocked error")
 	}
 
 	data, exists := m.data[*input.Key]
@@ -70,18 +96,9 @@ func (m *mockS3Downloader) Download(ctx context.Context, w io.WriterAt, input *s
 		panic(fmt.Sprintf("key %s does not exist in mocked payload map", *input.Key))
 	}
 
-	var off int64 = 0
-	for {
-		written, err := w.WriteAt(data, off)
-		if err != nil {
-			return 0, err
-		}
-		off += int64(written)
-		if off == int64(len(m.data[*input.Key])) {
-			break
-		}
-	}
-	return 0, nil
+	return &s3.GetObjectOutput{
+		Body: io.NopCloser(bytes.NewReader(data)),
+	}, nil
 }
 
 type mockS3PresignClient struct{}
@@ -117,6 +134,7 @@ func TestS3Storage_UploadProviderReleaseFiles(t *testing.T) {
 		name        string
 		filename    string
 		content     string
+		checksum    *string
 		client      s3ClientAPI
 		wantErr     assertion.ErrorAssertionFunc
 	}{
@@ -145,11 +163,25 @@ func TestS3Storage_UploadProviderReleaseFiles(t *testing.T) {
 				return !assertion.NoError(t, err)
 			},
 		},
+		{
+			description: "checksum mismatch",
+			namespace:   "hashicorp",
+			name:        "random",
+			filename:    "terraform-provider-random_2.0.0_linux_amd64.zip",
+			content:     "test",
+			checksum:    aws.String(base64.StdEncoding.EncodeToString(make([]byte, sha256.Size))),
+			client: &mockS3Client{
+				headObject: headNonExistingObject,
+			},
+			wantErr: func(t assertion.TestingT, err error, i ...interface{}) bool {
+				return assertion.ErrorIs(t, err, core.ErrChecksumMismatch)
+			},
+		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.description, func(t *testing.T) {
-			u := &mockS3Uploader{}
+			u := &mockS3Uploader{checksum: tc.checksum}
 			s := S3Storage{
 				client:   tc.client,
 				uploader: u,
@@ -160,7 +192,9 @@ func TestS3Storage_UploadProviderReleaseFiles(t *testing.T) {
 				return
 			}
 
-			assertion.Equal(t, tc.content, u.b.String())
+			key := providerStoragePrefix("", internalProviderType, "", tc.namespace, tc.name) + "/" + tc.filename
+			assertion.Equal(t, tc.content, u.objects[key])
+			assertion.Contains(t, u.objects[key+provenanceExtension], `"sha256":"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`)
 		})
 	}
 }
@@ -1,12 +1,17 @@
 package storage
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"time"
 
+	"github.com/boring-registry/boring-registry/pkg/alias"
 	"github.com/boring-registry/boring-registry/pkg/core"
 	"github.com/boring-registry/boring-registry/pkg/mirror"
 	"github.com/boring-registry/boring-registry/pkg/module"
+	"github.com/boring-registry/boring-registry/pkg/namespace"
 	"github.com/boring-registry/boring-registry/pkg/provider"
 	"github.com/boring-registry/boring-registry/pkg/proxy"
 )
@@ -20,6 +25,39 @@ type Storage interface {
 	module.Storage
 	mirror.Storage
 	proxy.Storage
+	alias.Storage
+	namespace.Storage
+}
+
+// object is a single object of a storage backend
+type object struct {
+	key          string
+	size         int64
+	lastModified time.Time
+}
+
+// objectStorage provides the low-level primitives every storage backend of this package implements
+type objectStorage interface {
+	// listObjects returns all objects whose keys start with the prefix, including objects in nested directories
+	listObjects(ctx context.Context, prefix string) ([]object, error)
+
+	// objectExists returns whether an object with the key exists
+	objectExists(ctx context.Context, key string) (bool, error)
+
+	// deleteObject removes the object from the storage backend
+	deleteObject(ctx context.Context, key string) error
+
+	// reader streams the content of the object. The caller has to close the returned io.ReadCloser
+	reader(ctx context.Context, key string) (io.ReadCloser, error)
+
+	// download returns the content of the object, which is only meant for small objects
+	download(ctx context.Context, key string) ([]byte, error)
+
+	// upload stores the content of the reader, and fails if the object exists already unless overwrite is set
+	upload(ctx context.Context, key string, reader io.Reader, overwrite bool) error
+
+	// layout returns the prefix and the module archive format the storage backend was configured with
+	layout() (prefix string, moduleArchiveFormat string)
 }
 
 // unmarshalSigningKeys tries to unmarshal the byte-array into core.SigningKeys, and if that fails into core.GPGPublicKey.
@@ -0,0 +1,962 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/boring-registry/boring-registry/pkg/alias"
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/module"
+	"github.com/boring-registry/boring-registry/pkg/namespace"
+	"github.com/boring-registry/boring-registry/pkg/provider"
+)
+
+// WebDAVStorage is a Storage implementation backed by a generic HTTP server that supports PUT, GET, HEAD and PROPFIND.
+// This covers Artifactory generic repositories, Nexus raw repositories, and plain WebDAV servers.
+// WebDAVStorage implements module.Storage, provider.Storage, and mirror.Storage
+type WebDAVStorage struct {
+	client              *http.Client
+	baseURL             *url.URL
+	prefix              string
+	username            string
+	password            string
+	token               string
+	createCollections   bool
+	moduleArchiveFormat string
+	signingKey          []byte
+	signedURLExpiry     time.Duration
+	signer              *urlSigner
+}
+
+// webdavObject is a single entry of a PROPFIND response
+type webdavObject struct {
+	key          string
+	isCollection bool
+	lastModified time.Time
+	size         int64
+}
+
+// GetModule retrieves information about a module from the WebDAV storage.
+func (s *WebDAVStorage) GetModule(ctx context.Context, namespace, name, provider, version string) (core.Module, error) {
+	key := modulePath(s.prefix, namespace, name, provider, version, s.moduleArchiveFormat)
+
+	exists, err := s.objectExists(ctx, key)
+	if err != nil {
+		return core.Module{}, err
+	} else if !exists {
+		return core.Module{}, module.ErrModuleNotFound
+	}
+
+	return core.Module{
+		Namespace:   namespace,
+		Name:        name,
+		Provider:    provider,
+		Version:     version,
+		DownloadURL: s.presignedURL(key),
+	}, nil
+}
+
+func (s *WebDAVStorage) ListModuleVersions(ctx context.Context, namespace, name, provider string) ([]core.Module, error) {
+	objects, err := s.list(ctx, modulePathPrefix(s.prefix, namespace, name, provider))
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", module.ErrModuleListFailed, err)
+	}
+
+	var modules []core.Module
+	for _, obj := range objects {
+		if obj.isCollection {
+			continue
+		}
+
+		m, err := moduleFromObject(obj.key, s.moduleArchiveFormat)
+		if err != nil {
+			continue
+		}
+
+		m.DownloadURL = s.presignedURL(obj.key)
+		m.PublishedAt = obj.lastModified
+		modules = append(modules, *m)
+	}
+
+	return modules, nil
+}
+
+// ListModules returns every version of all modules, restricted to the namespace if it isn't empty
+func (s *WebDAVStorage) ListModules(ctx context.Context, namespace string) ([]core.Module, error) {
+	return listModules(ctx, s, namespace)
+}
+
+// UploadModule uploads a module to the WebDAV storage.
+func (s *WebDAVStorage) UploadModule(ctx context.Context, namespace, name, provider, version string, body io.Reader) (core.Module, error) {
+	if namespace == "" {
+		return core.Module{}, errors.New("namespace not defined")
+	}
+
+	if name == "" {
+		return core.Module{}, errors.New("name not defined")
+	}
+
+	if provider == "" {
+		return core.Module{}, errors.New("provider not defined")
+	}
+
+	if version == "" {
+		return core.Module{}, errors.New("version not defined")
+	}
+
+	key := modulePath(s.prefix, namespace, name, provider, version, s.moduleArchiveFormat)
+
+	if _, err := s.GetModule(ctx, namespace, name, provider, version); err == nil {
+		return core.Module{}, fmt.Errorf("%w: %s", module.ErrModuleAlreadyExists, key)
+	}
+
+	m := core.Module{Namespace: namespace, Name: name, Provider: provider, Version: version}
+	if err := uploadModuleArchive(ctx, s, m, key, body); err != nil {
+		return core.Module{}, fmt.Errorf("%v: %w", module.ErrModuleUploadFailed, err)
+	}
+
+	return s.GetModule(ctx, namespace, name, provider, version)
+}
+
+// DownloadModule streams the module archive from the WebDAV server. The caller has to close the returned io.ReadCloser
+func (s *WebDAVStorage) DownloadModule(ctx context.Context, namespace, name, provider, version string) (io.ReadCloser, error) {
+	key := modulePath(s.prefix, namespace, name, provider, version, s.moduleArchiveFormat)
+
+	exists, err := s.objectExists(ctx, key)
+	if err != nil {
+		return nil, err
+	} else if !exists {
+		return nil, module.ErrModuleNotFound
+	}
+
+	return s.reader(ctx, key)
+}
+
+// GetModuleDocs reads the docs that were extracted when the module version was uploaded
+func (s *WebDAVStorage) GetModuleDocs(ctx context.Context, namespace, name, provider, version string) (*module.Docs, error) {
+	return moduleDocs(ctx, s, namespace, name, provider, version)
+}
+
+// GetModuleInfo reads the info of the module spec file that was stored when the module version was uploaded
+func (s *WebDAVStorage) GetModuleInfo(ctx context.Context, namespace, name, provider, version string) (*module.Info, error) {
+	return moduleInfo(ctx, s, namespace, name, provider, version)
+}
+
+// GetModuleProvenance reads the provenance that was recorded when the module version was uploaded
+func (s *WebDAVStorage) GetModuleProvenance(ctx context.Context, namespace, name, provider, version string) (*core.Provenance, error) {
+	return moduleProvenance(ctx, s, namespace, name, provider, version)
+}
+
+// CopyModule copies the module version to another module, without transferring the archive through the registry if possible
+func (s *WebDAVStorage) CopyModule(ctx context.Context, src, dst core.Module) (core.Module, error) {
+	if err := copyModule(ctx, s, src, dst); err != nil {
+		return core.Module{}, err
+	}
+	return s.GetModule(ctx, dst.Namespace, dst.Name, dst.Provider, dst.Version)
+}
+
+// DeleteModule removes the archive of the module version together with its provenance, docs and info
+func (s *WebDAVStorage) DeleteModule(ctx context.Context, namespace, name, provider, version string) error {
+	return deleteModule(ctx, s, core.Module{Namespace: namespace, Name: name, Provider: provider, Version: version})
+}
+
+// getProvider retrieves information about a provider from the WebDAV storage.
+func (s *WebDAVStorage) getProvider(ctx context.Context, pt providerType, provider *core.Provider) (*core.Provider, error) {
+	var archivePath, shasumPath, shasumSigPath string
+	if pt == internalProviderType {
+		archivePath, shasumPath, shasumSigPath = internalProviderPath(s.prefix, provider.Namespace, provider.Name, provider.Version, provider.OS, provider.Arch)
+	} else if pt == mirrorProviderType {
+		archivePath, shasumPath, shasumSigPath = mirrorProviderPath(s.prefix, provider.Hostname, provider.Namespace, provider.Name, provider.Version, provider.OS, provider.Arch)
+	}
+
+	if exists, err := s.objectExists(ctx, archivePath); err != nil {
+		return nil, err
+	} else if !exists {
+		return nil, noMatchingProviderFound(provider)
+	}
+
+	provider.DownloadURL = s.presignedURL(archivePath)
+	provider.SHASumsURL = s.presignedURL(shasumPath)
+	provider.SHASumsSignatureURL = s.presignedURL(shasumSigPath)
+
+	shasumBytes, err := s.download(ctx, shasumPath)
+	if err != nil {
+		return nil, err
+	}
+
+	provider.Shasum, err = readSHASums(bytes.NewReader(shasumBytes), path.Base(archivePath))
+	if err != nil {
+		return nil, err
+	}
+
+	var signingKeys *core.SigningKeys
+	if pt == internalProviderType {
+		signingKeys, err = s.SigningKeys(ctx, provider.Namespace)
+	} else if pt == mirrorProviderType {
+		signingKeys, err = s.MirroredSigningKeys(ctx, provider.Hostname, provider.Namespace)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	provider.Filename = path.Base(archivePath)
+	provider.SigningKeys = *signingKeys
+	return provider, nil
+}
+
+func (s *WebDAVStorage) GetProvider(ctx context.Context, namespace, name, version, os, arch string) (*core.Provider, error) {
+	return s.getProvider(ctx, internalProviderType, &core.Provider{
+		Namespace: namespace,
+		Name:      name,
+		Version:   version,
+		OS:        os,
+		Arch:      arch,
+	})
+}
+
+func (s *WebDAVStorage) GetMirroredProvider(ctx context.Context, provider *core.Provider) (*core.Provider, error) {
+	return s.getProvider(ctx, mirrorProviderType, provider)
+}
+
+func (s *WebDAVStorage) listProviderVersions(ctx context.Context, pt providerType, provider *core.Provider) ([]*core.Provider, error) {
+	prefix := providerStoragePrefix(s.prefix, pt, provider.Hostname, provider.Namespace, provider.Name)
+	objects, err := s.list(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	var providers []*core.Provider
+	for _, obj := range objects {
+		if obj.isCollection {
+			continue
+		}
+
+		p, err := core.NewProviderFromArchive(path.Base(obj.key))
+		if err != nil {
+			continue
+		}
+
+		if provider.Version != "" && provider.Version != p.Version {
+			// The provider version doesn't match the requested version
+			continue
+		}
+
+		p.Hostname = provider.Hostname
+		p.Namespace = provider.Namespace
+		p.DownloadURL = s.presignedURL(obj.key)
+
+		providers = append(providers, &p)
+	}
+
+	if len(providers) == 0 {
+		return nil, noMatchingProviderFound(provider)
+	}
+
+	return providers, nil
+}
+
+func (s *WebDAVStorage) ListProviderVersions(ctx context.Context, namespace, name string) (*core.ProviderVersions, error) {
+	providers, err := s.listProviderVersions(ctx, internalProviderType, &core.Provider{Namespace: namespace, Name: name})
+	if err != nil {
+		return nil, err
+	}
+
+	collection := NewCollection()
+	for _, p := range providers {
+		collection.Add(p)
+	}
+	return collection.List(), nil
+}
+
+// ListProviders returns every version of all providers, restricted to the namespace if it isn't empty
+func (s *WebDAVStorage) ListProviders(ctx context.Context, namespace string) ([]core.ProviderVersion, error) {
+	return listProviders(ctx, s, namespace)
+}
+
+func (s *WebDAVStorage) ListMirroredProviders(ctx context.Context, provider *core.Provider) ([]*core.Provider, error) {
+	return s.listProviderVersions(ctx, mirrorProviderType, provider)
+}
+
+func (s *WebDAVStorage) UploadProviderReleaseFiles(ctx context.Context, namespace, name, filename string, file io.Reader) error {
+	if namespace == "" {
+		return fmt.Errorf("namespace argument is empty")
+	}
+
+	if name == "" {
+		return fmt.Errorf("name argument is empty")
+	}
+
+	if filename == "" {
+		return fmt.Errorf("filename argument is empty")
+	}
+
+	prefix := providerStoragePrefix(s.prefix, internalProviderType, "", namespace, name)
+	key := path.Join(prefix, filename)
+	return uploadWithProvenance(ctx, s, key, file, false)
+}
+
+// GetProviderProvenance reads the provenance that was recorded when the files of the provider release were uploaded
+func (s *WebDAVStorage) GetProviderProvenance(ctx context.Context, namespace, name, version string) ([]core.Provenance, error) {
+	return providerProvenance(ctx, s, namespace, name, version)
+}
+
+// GetProviderRelease returns the files of the provider release, which are read once they are opened
+func (s *WebDAVStorage) GetProviderRelease(ctx context.Context, namespace, name, version string) (*provider.Release, error) {
+	return providerRelease(ctx, s, namespace, name, version)
+}
+
+// CopyProviderRelease copies all files of the provider release to another namespace
+func (s *WebDAVStorage) CopyProviderRelease(ctx context.Context, namespace, name, version, targetNamespace string) error {
+	return copyProviderRelease(ctx, s, namespace, name, version, targetNamespace)
+}
+
+// DeleteProviderRelease removes all files of the provider release
+func (s *WebDAVStorage) DeleteProviderRelease(ctx context.Context, namespace, name, version string) error {
+	return deleteProviderRelease(ctx, s, namespace, name, version)
+}
+
+// ListAliases returns the aliases managed through the API
+func (s *WebDAVStorage) ListAliases(ctx context.Context) ([]alias.Alias, error) {
+	return listAliases(ctx, s)
+}
+
+// AddAlias stores the alias together with the other aliases managed through the API
+func (s *WebDAVStorage) AddAlias(ctx context.Context, a alias.Alias) error {
+	return addAlias(ctx, s, a)
+}
+
+// DeleteAlias removes the alias from the aliases managed through the API
+func (s *WebDAVStorage) DeleteAlias(ctx context.Context, aliasType, from string) error {
+	return deleteAlias(ctx, s, aliasType, from)
+}
+
+// ListNamespaces returns the registered namespaces
+func (s *WebDAVStorage) ListNamespaces(ctx context.Context) ([]namespace.Namespace, error) {
+	return listNamespaces(ctx, s)
+}
+
+// AddNamespace registers the namespace together with the other registered namespaces
+func (s *WebDAVStorage) AddNamespace(ctx context.Context, n namespace.Namespace) error {
+	return addNamespace(ctx, s, n)
+}
+
+// UpdateNamespace replaces the registered namespace with the same name
+func (s *WebDAVStorage) UpdateNamespace(ctx context.Context, n namespace.Namespace) error {
+	return updateNamespace(ctx, s, n)
+}
+
+// DeleteNamespace removes the namespace from the registered namespaces
+func (s *WebDAVStorage) DeleteNamespace(ctx context.Context, name string) error {
+	return deleteNamespace(ctx, s, name)
+}
+
+func (s *WebDAVStorage) signingKeys(ctx context.Context, pt providerType, hostname, namespace string) (*core.SigningKeys, error) {
+	if namespace == "" {
+		return nil, fmt.Errorf("namespace argument is empty")
+	}
+	key := signingKeysPath(s.prefix, pt, hostname, namespace)
+	exists, err := s.objectExists(ctx, key)
+	if err != nil {
+		return nil, err
+	} else if !exists {
+		return nil, core.ErrObjectNotFound
+	}
+
+	signingKeysRaw, err := s.download(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download signing_keys.json for namespace %s: %w", namespace, err)
+	}
+
+	return unmarshalSigningKeys(signingKeysRaw)
+}
+
+// SigningKeys downloads the JSON placed in the namespace on the WebDAV server and unmarshals it into a core.SigningKeys
+func (s *WebDAVStorage) SigningKeys(ctx context.Context, namespace string) (*core.SigningKeys, error) {
+	return s.signingKeys(ctx, internalProviderType, "", namespace)
+}
+
+func (s *WebDAVStorage) MirroredSigningKeys(ctx context.Context, hostname, namespace string) (*core.SigningKeys, error) {
+	return s.signingKeys(ctx, mirrorProviderType, hostname, namespace)
+}
+
+func (s *WebDAVStorage) uploadSigningKeys(ctx context.Context, pt providerType, hostname, namespace string, signingKeys *core.SigningKeys) error {
+	b, err := json.Marshal(signingKeys)
+	if err != nil {
+		return err
+	}
+	key := signingKeysPath(s.prefix, pt, hostname, namespace)
+	return s.upload(ctx, key, bytes.NewReader(b), true)
+}
+
+func (s *WebDAVStorage) UploadMirroredSigningKeys(ctx context.Context, hostname, namespace string, signingKeys *core.SigningKeys) error {
+	return s.uploadSigningKeys(ctx, mirrorProviderType, hostname, namespace, signingKeys)
+}
+
+func (s *WebDAVStorage) MirroredSha256Sum(ctx context.Context, provider *core.Provider) (*core.Sha256Sums, error) {
+	prefix := providerStoragePrefix(s.prefix, mirrorProviderType, provider.Hostname, provider.Namespace, provider.Name)
+	key := path.Join(prefix, provider.ShasumFileName())
+	shaSumBytes, err := s.download(ctx, key)
+	if err != nil {
+		return nil, errors.New("failed to download SHA256SUMS")
+	}
+
+	return core.NewSha256Sums(provider.ShasumFileName(), bytes.NewReader(shaSumBytes))
+}
+
+func (s *WebDAVStorage) UploadMirroredFile(ctx context.Context, provider *core.Provider, fileName string, reader io.Reader) error {
+	prefix := providerStoragePrefix(s.prefix, mirrorProviderType, provider.Hostname, provider.Namespace, provider.Name)
+	key := path.Join(prefix, fileName)
+	return s.upload(ctx, key, reader, true)
+}
+
+// GetDownloadUrl verifies the signature of a URL that was handed out by presignedURL and returns the absolute URL.
+// The proxy only forwards requests with credentials for URLs that were signed by this storage.
+func (s *WebDAVStorage) GetDownloadUrl(ctx context.Context, rawURL string) (string, error) {
+	u, err := url.Parse(fmt.Sprintf("%s://%s/%s", s.baseURL.Scheme, s.baseURL.Host, strings.TrimPrefix(rawURL, "/")))
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.signer.verify(u); err != nil {
+		return "", err
+	}
+
+	return u.String(), nil
+}
+
+// AuthorizeRequest adds the configured credentials to requests targeting the WebDAV server.
+// It implements proxy.RequestAuthorizer
+func (s *WebDAVStorage) AuthorizeRequest(req *http.Request) {
+	if req.URL.Host != s.baseURL.Host {
+		return
+	}
+	s.authorize(req)
+}
+
+func (s *WebDAVStorage) authorize(req *http.Request) {
+	if s.token != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", s.token))
+	} else if s.username != "" {
+		req.SetBasicAuth(s.username, s.password)
+	}
+}
+
+// objectURL returns the absolute URL of an object key
+func (s *WebDAVStorage) objectURL(key string) *url.URL {
+	return s.baseURL.JoinPath(key)
+}
+
+// presignedURL returns the URL of the object signed by the urlSigner
+func (s *WebDAVStorage) presignedURL(key string) string {
+	return s.signer.sign(s.objectURL(key))
+}
+
+func (s *WebDAVStorage) newRequest(ctx context.Context, method, key string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, s.objectURL(key).String(), body)
+	if err != nil {
+		return nil, err
+	}
+	s.authorize(req)
+	return req, nil
+}
+
+func (s *WebDAVStorage) objectExists(ctx context.Context, key string) (bool, error) {
+	req, err := s.newRequest(ctx, http.MethodHead, key, nil)
+	if err != nil {
+		return false, err
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, fmt.Errorf("unexpected status code %d for HEAD %s", resp.StatusCode, key)
+	}
+}
+
+func (s *WebDAVStorage) upload(ctx context.Context, key string, reader io.Reader, overwrite bool) error {
+	// If we don't want to overwrite, check if the object exists
+	if !overwrite {
+		exists, err := s.objectExists(ctx, key)
+		if err != nil {
+			return err
+		} else if exists {
+			return fmt.Errorf("failed to upload key %s: %w", key, core.ErrObjectAlreadyExists)
+		}
+	}
+
+	if s.createCollections {
+		if err := s.mkcol(ctx, path.Dir(key)); err != nil {
+			return fmt.Errorf("failed to create collection for %s: %w", key, err)
+		}
+	}
+
+	c := newChecksumReader(reader)
+	req, err := s.newRequest(ctx, http.MethodPut, key, c)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to upload: %w", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("failed to upload: unexpected status code %d for PUT %s", resp.StatusCode, key)
+	}
+
+	return verifyUpload(ctx, s, key, c, func() error {
+		return s.verify(ctx, key, c, resp.Header)
+	})
+}
+
+// verify compares the checksum reported by the WebDAV server with the checksums computed during the upload.
+// WebDAV has no common integrity checks, so the checksum is taken from the response to the PUT request or from a HEAD request.
+// Only the size of the object is compared if the server doesn't report any checksum.
+func (s *WebDAVStorage) verify(ctx context.Context, key string, c *checksumReader, header http.Header) error {
+	if ok, err := verifyWebDAVChecksums(key, c, header); ok || err != nil {
+		return err
+	}
+
+	req, err := s.newRequest(ctx, http.MethodHead, key, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to verify %s: %w", key, err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to verify %s: unexpected status code %d for HEAD", key, resp.StatusCode)
+	}
+
+	if ok, err := verifyWebDAVChecksums(key, c, resp.Header); ok || err != nil {
+		return err
+	}
+	if resp.ContentLength >= 0 && resp.ContentLength != c.size {
+		return fmt.Errorf("%w: %s has a size of %d bytes instead of %d bytes", core.ErrChecksumMismatch, key, resp.ContentLength, c.size)
+	}
+	return nil
+}
+
+// verifyWebDAVChecksums compares the checksums in the headers of a WebDAV response and returns whether any was found.
+// Artifactory reports X-Checksum-Sha256, Nextcloud and ownCloud report OC-Checksum, and some servers use the MD5 checksum as ETag.
+func verifyWebDAVChecksums(key string, c *checksumReader, header http.Header) (bool, error) {
+	if sum := header.Get("X-Checksum-Sha256"); sum != "" {
+		return true, compareHexChecksum(key, "SHA-256", c.sha256Sum(), sum)
+	}
+
+	// OC-Checksum contains one or more space-separated checksums like SHA1:<hex> MD5:<hex>
+	for _, field := range strings.Fields(header.Get("OC-Checksum")) {
+		algorithm, sum, _ := strings.Cut(field, ":")
+		switch strings.ToUpper(algorithm) {
+		case "SHA256":
+			return true, compareHexChecksum(key, "SHA-256", c.sha256Sum(), sum)
+		case "MD5":
+			return true, compareHexChecksum(key, "MD5", c.md5Sum(), sum)
+		}
+	}
+
+	if etag := strings.Trim(strings.TrimPrefix(header.Get("ETag"), "W/"), `"`); md5ETagRegex.MatchString(etag) {
+		return true, compareHexChecksum(key, "MD5", c.md5Sum(), etag)
+	}
+	return false, nil
+}
+
+// md5ETagRegex matches ETags that are MD5 checksums
+var md5ETagRegex = regexp.MustCompile(`^[0-9a-fA-F]{32}$`)
+
+func compareHexChecksum(key, algorithm string, expected []byte, actual string) error {
+	b, err := hex.DecodeString(actual)
+	if err != nil {
+		return fmt.Errorf("%w: %s of %s is %q, which isn't hex-encoded", core.ErrChecksumMismatch, algorithm, key, actual)
+	}
+	if !bytes.Equal(expected, b) {
+		return checksumMismatch(key, algorithm, expected, b)
+	}
+	return nil
+}
+
+func (s *WebDAVStorage) deleteObject(ctx context.Context, key string) error {
+	req, err := s.newRequest(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to delete %s: %w", key, err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("failed to delete %s: unexpected status code %d", key, resp.StatusCode)
+	}
+	return nil
+}
+
+// mkcol creates the collection and all of its parents, as WebDAV servers don't create intermediate collections on PUT
+func (s *WebDAVStorage) mkcol(ctx context.Context, key string) error {
+	var current string
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." {
+			continue
+		}
+		current = path.Join(current, segment)
+
+		req, err := s.newRequest(ctx, "MKCOL", current+"/", nil)
+		if err != nil {
+			return err
+		}
+		resp, err := s.client.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+
+		// 405 Method Not Allowed is returned by WebDAV servers if the collection exists already
+		if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusMethodNotAllowed {
+			return fmt.Errorf("unexpected status code %d for MKCOL %s", resp.StatusCode, current)
+		}
+	}
+
+	return nil
+}
+
+func (s *WebDAVStorage) reader(ctx context.Context, key string) (io.ReadCloser, error) {
+	req, err := s.newRequest(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", key, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to download %s: unexpected status code %d", key, resp.StatusCode)
+	}
+
+	return resp.Body, nil
+}
+
+// downloadVersion returns the content of the object together with its ETag.
+// Weak ETags are ignored, as they can't be used for conditional writes.
+func (s *WebDAVStorage) downloadVersion(ctx context.Context, key string) ([]byte, string, error) {
+	req, err := s.newRequest(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, "", err
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to download %s: %w", key, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, "", nil
+	default:
+		return nil, "", fmt.Errorf("failed to download %s: unexpected status code %d", key, resp.StatusCode)
+	}
+
+	b, err := io.ReadAll(resp.Body)
+	if etag := resp.Header.Get("ETag"); !strings.HasPrefix(etag, "W/") {
+		return b, etag, err
+	}
+	return b, "", err
+}
+
+// uploadVersion stores the object with an If-Match or If-None-Match header
+func (s *WebDAVStorage) uploadVersion(ctx context.Context, key string, b []byte, version *string) error {
+	if s.createCollections {
+		if err := s.mkcol(ctx, path.Dir(key)); err != nil {
+			return fmt.Errorf("failed to create collection for %s: %w", key, err)
+		}
+	}
+
+	req, err := s.newRequest(ctx, http.MethodPut, key, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	if version != nil {
+		req.Header.Set("If-Match", *version)
+	} else {
+		req.Header.Set("If-None-Match", "*")
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to upload: %w", err)
+	}
+	resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated, http.StatusNoContent:
+		return nil
+	case http.StatusPreconditionFailed:
+		return fmt.Errorf("failed to upload %s: %w", key, errPreconditionFailed)
+	default:
+		return fmt.Errorf("failed to upload: unexpected status code %d for PUT %s", resp.StatusCode, key)
+	}
+}
+
+func (s *WebDAVStorage) download(ctx context.Context, key string) ([]byte, error) {
+	r, err := s.reader(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return io.ReadAll(r)
+}
+
+type propfindResponse struct {
+	Responses []struct {
+		Href     string `xml:"href"`
+		Propstat []struct {
+			Prop struct {
+				ResourceType struct {
+					Collection *struct{} `xml:"collection"`
+				} `xml:"resourcetype"`
+				ContentLength string `xml:"getcontentlength"`
+				LastModified  string `xml:"getlastmodified"`
+			} `xml:"prop"`
+			Status string `xml:"status"`
+		} `xml:"propstat"`
+	} `xml:"response"`
+}
+
+const propfindBody = `<?xml version="1.0" encoding="utf-8"?>
+<D:propfind xmlns:D="DAV:">
+  <D:prop>
+    <D:resourcetype/>
+    <D:getcontentlength/>
+    <D:getlastmodified/>
+  </D:prop>
+</D:propfind>`
+
+// list returns the direct children of a collection.
+// A non-existing collection is treated like an empty one.
+func (s *WebDAVStorage) list(ctx context.Context, prefix string) ([]webdavObject, error) {
+	req, err := s.newRequest(ctx, "PROPFIND", strings.TrimSuffix(prefix, "/")+"/", strings.NewReader(propfindBody))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Depth", "1")
+	req.Header.Set("Content-Type", "application/xml; charset=utf-8")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	} else if resp.StatusCode != http.StatusMultiStatus {
+		return nil, fmt.Errorf("unexpected status code %d for PROPFIND %s", resp.StatusCode, prefix)
+	}
+
+	var multistatus propfindResponse
+	if err := xml.NewDecoder(resp.Body).Decode(&multistatus); err != nil {
+		return nil, fmt.Errorf("failed to decode PROPFIND response: %w", err)
+	}
+
+	basePath := strings.TrimSuffix(s.baseURL.Path, "/")
+	self := path.Clean(path.Join(basePath, prefix))
+
+	var objects []webdavObject
+	for _, r := range multistatus.Responses {
+		href, err := url.Parse(r.Href)
+		if err != nil {
+			return nil, fmt.Errorf("invalid href %s in PROPFIND response: %w", r.Href, err)
+		}
+		p := path.Clean(href.Path)
+		if p == self {
+			// The collection itself is part of the response
+			continue
+		}
+
+		obj := webdavObject{
+			key: strings.TrimPrefix(strings.TrimPrefix(p, basePath), "/"),
+		}
+		for _, propstat := range r.Propstat {
+			if !strings.Contains(propstat.Status, "200") {
+				continue
+			}
+			prop := propstat.Prop
+			obj.isCollection = prop.ResourceType.Collection != nil
+			if prop.ContentLength != "" {
+				obj.size, _ = strconv.ParseInt(prop.ContentLength, 10, 64)
+			}
+			if prop.LastModified != "" {
+				obj.lastModified, _ = http.ParseTime(prop.LastModified)
+			}
+		}
+		objects = append(objects, obj)
+	}
+
+	return objects, nil
+}
+
+// listObjects walks the collections below the prefix, as PROPFIND with infinite depth is disabled on most servers
+func (s *WebDAVStorage) listObjects(ctx context.Context, prefix string) ([]object, error) {
+	var objects []object
+	collections := []string{strings.TrimSuffix(prefix, "/")}
+	for len(collections) > 0 {
+		current := collections[0]
+		collections = collections[1:]
+
+		children, err := s.list(ctx, current)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, child := range children {
+			if child.isCollection {
+				collections = append(collections, child.key)
+				continue
+			}
+			objects = append(objects, object{
+				key:          child.key,
+				size:         child.size,
+				lastModified: child.lastModified,
+			})
+		}
+	}
+
+	return objects, nil
+}
+
+func (s *WebDAVStorage) layout() (string, string) {
+	return s.prefix, s.moduleArchiveFormat
+}
+
+// WebDAVStorageOption provides additional options for the WebDAVStorage.
+type WebDAVStorageOption func(*WebDAVStorage)
+
+// WithWebDAVStoragePrefix configures the WebDAV storage to work under a given prefix.
+func WithWebDAVStoragePrefix(prefix string) WebDAVStorageOption {
+	return func(s *WebDAVStorage) {
+		s.prefix = prefix
+	}
+}
+
+// WithWebDAVStorageBasicAuth configures the credentials for HTTP basic authentication.
+func WithWebDAVStorageBasicAuth(username, password string) WebDAVStorageOption {
+	return func(s *WebDAVStorage) {
+		s.username = username
+		s.password = password
+	}
+}
+
+// WithWebDAVStorageBearerToken configures a token for bearer authentication. It takes precedence over basic auth.
+func WithWebDAVStorageBearerToken(token string) WebDAVStorageOption {
+	return func(s *WebDAVStorage) {
+		s.token = token
+	}
+}
+
+// WithWebDAVStorageCreateCollections configures whether parent collections are created with MKCOL before uploading.
+// This is required by plain WebDAV servers, while Artifactory and Nexus create them implicitly.
+func WithWebDAVStorageCreateCollections(create bool) WebDAVStorageOption {
+	return func(s *WebDAVStorage) {
+		s.createCollections = create
+	}
+}
+
+// WithWebDAVStorageArchiveFormat configures the module archive format (zip, tar, tgz, etc.)
+func WithWebDAVStorageArchiveFormat(archiveFormat string) WebDAVStorageOption {
+	return func(s *WebDAVStorage) {
+		if archiveFormat != "" {
+			s.moduleArchiveFormat = archiveFormat
+		}
+	}
+}
+
+// WithWebDAVStorageSigningKey configures the key that download URLs are signed with.
+// All replicas of the boring-registry have to share the same key.
+func WithWebDAVStorageSigningKey(key string) WebDAVStorageOption {
+	return func(s *WebDAVStorage) {
+		if key != "" {
+			s.signingKey = []byte(key)
+		}
+	}
+}
+
+// WithWebDAVStorageSignedUrlExpiry configures the duration until the signed url expires
+func WithWebDAVStorageSignedUrlExpiry(t time.Duration) WebDAVStorageOption {
+	return func(s *WebDAVStorage) {
+		s.signedURLExpiry = t
+	}
+}
+
+// WithWebDAVStorageHTTPClient configures the HTTP client used to talk to the WebDAV server.
+func WithWebDAVStorageHTTPClient(client *http.Client) WebDAVStorageOption {
+	return func(s *WebDAVStorage) {
+		s.client = client
+	}
+}
+
+// NewWebDAVStorage returns a fully initialized WebDAV storage.
+func NewWebDAVStorage(baseURL string, options ...WebDAVStorageOption) (Storage, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base url: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("base url %s must use the http or https scheme", baseURL)
+	}
+	if u.Path == "" {
+		// Joined paths have to be absolute for signed URLs to match after proxying
+		u.Path = "/"
+	}
+
+	s := &WebDAVStorage{
+		client:              http.DefaultClient,
+		baseURL:             u,
+		moduleArchiveFormat: DefaultModuleArchiveFormat,
+		signedURLExpiry:     5 * time.Minute,
+	}
+
+	for _, option := range options {
+		option(s)
+	}
+
+	s.signer, err = newURLSigner(s.signingKey, s.signedURLExpiry)
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
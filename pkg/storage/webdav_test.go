@@ -0,0 +1,468 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/module"
+	"github.com/boring-registry/boring-registry/pkg/namespace"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeWebDAVServer is a minimal in-memory WebDAV server implementing the subset of methods used by WebDAVStorage
+type fakeWebDAVServer struct {
+	mu          sync.Mutex
+	objects     map[string][]byte
+	collections map[string]bool
+
+	// strict requires parent collections to exist for PUT requests, like plain WebDAV servers do
+	strict        bool
+	authorization string
+
+	// checksumHeader is the header the server reports the checksum of the stored object in, if it isn't empty
+	checksumHeader string
+
+	// corrupt modifies the content of PUT requests before it is stored
+	corrupt func([]byte) []byte
+
+	// afterGet is called after GET requests, e.g. to change objects like a concurrent client
+	afterGet func(objects map[string][]byte)
+}
+
+func newFakeWebDAVServer() *fakeWebDAVServer {
+	return &fakeWebDAVServer{
+		objects:     map[string][]byte{},
+		collections: map[string]bool{"/": true},
+	}
+}
+
+func (f *fakeWebDAVServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if f.authorization != "" && r.Header.Get("Authorization") != f.authorization {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	p := path.Clean(r.URL.Path)
+	switch r.Method {
+	case http.MethodPut:
+		if f.strict && !f.collections[path.Dir(p)] {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		existing, exists := f.objects[p]
+		if match := r.Header.Get("If-Match"); match != "" && (!exists || match != fmt.Sprintf(`"%x"`, md5.Sum(existing))) {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		if r.Header.Get("If-None-Match") == "*" && exists {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		b, _ := io.ReadAll(r.Body)
+		if f.corrupt != nil {
+			b = f.corrupt(b)
+		}
+		f.objects[p] = b
+		f.setChecksumHeader(w, b)
+		w.WriteHeader(http.StatusCreated)
+	case http.MethodGet, http.MethodHead:
+		b, ok := f.objects[p]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		f.setChecksumHeader(w, b)
+		w.Header().Set("Content-Length", fmt.Sprintf("%d", len(b)))
+		w.WriteHeader(http.StatusOK)
+		if r.Method == http.MethodGet {
+			_, _ = w.Write(b)
+			if f.afterGet != nil {
+				f.afterGet(f.objects)
+			}
+		}
+	case http.MethodDelete:
+		if _, ok := f.objects[p]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		delete(f.objects, p)
+		w.WriteHeader(http.StatusNoContent)
+	case "MKCOL":
+		if f.collections[p] {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		f.collections[p] = true
+		w.WriteHeader(http.StatusCreated)
+	case "PROPFIND":
+		f.propfind(w, p)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (f *fakeWebDAVServer) setChecksumHeader(w http.ResponseWriter, b []byte) {
+	switch f.checksumHeader {
+	case "X-Checksum-Sha256":
+		w.Header().Set(f.checksumHeader, fmt.Sprintf("%x", sha256.Sum256(b)))
+	case "OC-Checksum":
+		w.Header().Set(f.checksumHeader, fmt.Sprintf("SHA1:%x MD5:%x", sha1.Sum(b), md5.Sum(b)))
+	case "ETag":
+		w.Header().Set(f.checksumHeader, fmt.Sprintf(`"%x"`, md5.Sum(b)))
+	}
+}
+
+func (f *fakeWebDAVServer) propfind(w http.ResponseWriter, dir string) {
+	children := map[string]bool{}
+	for key := range f.objects {
+		if !strings.HasPrefix(key, dir+"/") {
+			continue
+		}
+		rest := strings.TrimPrefix(key, dir+"/")
+		if i := strings.Index(rest, "/"); i >= 0 {
+			children[path.Join(dir, rest[:i])] = true
+		} else {
+			children[key] = false
+		}
+	}
+	if len(children) == 0 && !f.collections[dir] {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	keys := make([]string, 0, len(children))
+	for k := range children {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	sb.WriteString(`<?xml version="1.0" encoding="utf-8"?><D:multistatus xmlns:D="DAV:">`)
+	sb.WriteString(fmt.Sprintf(`<D:response><D:href>%s/</D:href><D:propstat><D:prop><D:resourcetype><D:collection/></D:resourcetype></D:prop><D:status>HTTP/1.1 200 OK</D:status></D:propstat></D:response>`, dir))
+	for _, k := range keys {
+		if children[k] {
+			sb.WriteString(fmt.Sprintf(`<D:response><D:href>%s/</D:href><D:propstat><D:prop><D:resourcetype><D:collection/></D:resourcetype></D:prop><D:status>HTTP/1.1 200 OK</D:status></D:propstat></D:response>`, (&url.URL{Path: k}).EscapedPath()))
+			continue
+		}
+		sb.WriteString(fmt.Sprintf(`<D:response><D:href>%s</D:href><D:propstat><D:prop><D:resourcetype/><D:getcontentlength>%d</D:getcontentlength><D:getlastmodified>%s</D:getlastmodified></D:prop><D:status>HTTP/1.1 200 OK</D:status></D:propstat></D:response>`, (&url.URL{Path: k}).EscapedPath(), len(f.objects[k]), time.Now().UTC().Format(http.TimeFormat)))
+	}
+	sb.WriteString(`</D:multistatus>`)
+
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(http.StatusMultiStatus)
+	_, _ = w.Write([]byte(sb.String()))
+}
+
+func newTestWebDAVStorage(t *testing.T, server *fakeWebDAVServer, options ...WebDAVStorageOption) *WebDAVStorage {
+	t.Helper()
+	ts := httptest.NewServer(server)
+	t.Cleanup(ts.Close)
+
+	s, err := NewWebDAVStorage(ts.URL+"/artifactory/generic-local", options...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s.(*WebDAVStorage)
+}
+
+func TestWebDAVStorage_Modules(t *testing.T) {
+	t.Parallel()
+
+	server := newFakeWebDAVServer()
+	s := newTestWebDAVStorage(t, server, WithWebDAVStoragePrefix("registry"))
+	ctx := context.Background()
+
+	_, err := s.GetModule(ctx, "example", "s3", "aws", "1.0.0")
+	assert.ErrorIs(t, err, module.ErrModuleNotFound)
+
+	for _, version := range []string{"1.0.0", "1.1.0"} {
+		m, err := s.UploadModule(ctx, "example", "s3", "aws", version, strings.NewReader("archive-"+version))
+		assert.NoError(t, err)
+		assert.Equal(t, version, m.Version)
+		assert.Contains(t, m.DownloadURL, fmt.Sprintf("/artifactory/generic-local/registry/modules/example/s3/aws/example-s3-aws-%s.tar.gz?", version))
+	}
+	assert.Equal(t, []byte("archive-1.0.0"), server.objects["/artifactory/generic-local/registry/modules/example/s3/aws/example-s3-aws-1.0.0.tar.gz"])
+
+	_, err = s.UploadModule(ctx, "example", "s3", "aws", "1.0.0", strings.NewReader("overwrite"))
+	assert.ErrorIs(t, err, module.ErrModuleAlreadyExists)
+
+	archive, err := s.DownloadModule(ctx, "example", "s3", "aws", "1.1.0")
+	assert.NoError(t, err)
+	content, err := io.ReadAll(archive)
+	assert.NoError(t, err)
+	assert.NoError(t, archive.Close())
+	assert.Equal(t, "archive-1.1.0", string(content))
+
+	_, err = s.DownloadModule(ctx, "example", "s3", "aws", "2.0.0")
+	assert.ErrorIs(t, err, module.ErrModuleNotFound)
+
+	modules, err := s.ListModuleVersions(ctx, "example", "s3", "aws")
+	assert.NoError(t, err)
+	var versions []string
+	for _, m := range modules {
+		versions = append(versions, m.Version)
+	}
+	assert.ElementsMatch(t, []string{"1.0.0", "1.1.0"}, versions)
+
+	modules, err = s.ListModuleVersions(ctx, "example", "missing", "aws")
+	assert.NoError(t, err)
+	assert.Empty(t, modules)
+}
+
+func TestWebDAVStorage_Providers(t *testing.T) {
+	t.Parallel()
+
+	server := newFakeWebDAVServer()
+	s := newTestWebDAVStorage(t, server)
+	ctx := context.Background()
+
+	files := map[string]string{
+		"terraform-provider-dummy_1.0.0_linux_amd64.zip": "zip",
+		"terraform-provider-dummy_1.0.0_SHA256SUMS":      "4a70fe9aa6436e02c2dea340fbd1e352e4ef2d8ce6ca52ad25d4b95471fc8bf2  terraform-provider-dummy_1.0.0_linux_amd64.zip",
+		"terraform-provider-dummy_1.0.0_SHA256SUMS.sig":  "sig",
+	}
+	for name, content := range files {
+		assert.NoError(t, s.UploadProviderReleaseFiles(ctx, "example", "dummy", name, strings.NewReader(content)))
+	}
+	err := s.UploadProviderReleaseFiles(ctx, "example", "dummy", "terraform-provider-dummy_1.0.0_SHA256SUMS", strings.NewReader(""))
+	assert.ErrorIs(t, err, core.ErrObjectAlreadyExists)
+
+	_, err = s.GetProvider(ctx, "example", "dummy", "1.0.0", "linux", "amd64")
+	assert.ErrorIs(t, err, core.ErrObjectNotFound, "signing keys are missing")
+
+	server.objects["/artifactory/generic-local/providers/example/signing-keys.json"] = []byte(`{"gpg_public_keys":[{"key_id":"47422B4AA9FA381B","ascii_armor":"test"}]}`)
+	p, err := s.GetProvider(ctx, "example", "dummy", "1.0.0", "linux", "amd64")
+	assert.NoError(t, err)
+	assert.Equal(t, "4a70fe9aa6436e02c2dea340fbd1e352e4ef2d8ce6ca52ad25d4b95471fc8bf2", p.Shasum)
+	assert.Equal(t, "terraform-provider-dummy_1.0.0_linux_amd64.zip", p.Filename)
+	assert.Equal(t, "47422B4AA9FA381B", p.SigningKeys.GPGPublicKeys[0].KeyID)
+
+	versions, err := s.ListProviderVersions(ctx, "example", "dummy")
+	assert.NoError(t, err)
+	assert.Equal(t, &core.ProviderVersions{
+		Versions: []core.ProviderVersion{
+			{
+				Namespace: "example",
+				Name:      "dummy",
+				Version:   "1.0.0",
+				Platforms: []core.Platform{{OS: "linux", Arch: "amd64"}},
+			},
+		},
+	}, versions)
+}
+
+func TestWebDAVStorage_Authentication(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		authorization string
+		options       []WebDAVStorageOption
+		wantErr       bool
+	}{
+		{
+			name:          "basic auth",
+			authorization: "Basic dXNlcjpwYXNz",
+			options:       []WebDAVStorageOption{WithWebDAVStorageBasicAuth("user", "pass")},
+		},
+		{
+			name:          "bearer token takes precedence",
+			authorization: "Bearer token",
+			options:       []WebDAVStorageOption{WithWebDAVStorageBasicAuth("user", "pass"), WithWebDAVStorageBearerToken("token")},
+		},
+		{
+			name:          "missing credentials",
+			authorization: "Bearer token",
+			wantErr:       true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			server := newFakeWebDAVServer()
+			server.authorization = tc.authorization
+			s := newTestWebDAVStorage(t, server, tc.options...)
+
+			_, err := s.GetModule(context.Background(), "example", "s3", "aws", "1.0.0")
+			if tc.wantErr {
+				assert.NotErrorIs(t, err, module.ErrModuleNotFound)
+				assert.Error(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, module.ErrModuleNotFound)
+		})
+	}
+}
+
+func TestWebDAVStorage_CreateCollections(t *testing.T) {
+	t.Parallel()
+
+	server := newFakeWebDAVServer()
+	server.strict = true
+	ctx := context.Background()
+
+	s := newTestWebDAVStorage(t, server)
+	_, err := s.UploadModule(ctx, "example", "s3", "aws", "1.0.0", strings.NewReader("archive"))
+	assert.ErrorContains(t, err, "409")
+
+	s = newTestWebDAVStorage(t, server, WithWebDAVStorageCreateCollections(true))
+	_, err = s.UploadModule(ctx, "example", "s3", "aws", "1.0.0", strings.NewReader("archive"))
+	assert.NoError(t, err)
+	assert.True(t, server.collections["/artifactory/generic-local/modules/example/s3/aws"])
+}
+
+func TestWebDAVStorage_GetDownloadUrl(t *testing.T) {
+	t.Parallel()
+
+	server := newFakeWebDAVServer()
+	s := newTestWebDAVStorage(t, server, WithWebDAVStorageSigningKey("secret"), WithWebDAVStorageBearerToken("token"))
+	ctx := context.Background()
+
+	m, err := s.UploadModule(ctx, "example", "s3", "aws", "1.0.0", strings.NewReader("archive"))
+	assert.NoError(t, err)
+
+	// The proxy strips the scheme and host of the download URL
+	u, err := url.Parse(m.DownloadURL)
+	assert.NoError(t, err)
+	proxied := strings.TrimPrefix(u.RequestURI(), "/")
+
+	downloadURL, err := s.GetDownloadUrl(ctx, proxied)
+	assert.NoError(t, err)
+	assert.Equal(t, m.DownloadURL, downloadURL)
+
+	req, err := http.NewRequest(http.MethodGet, downloadURL, nil)
+	assert.NoError(t, err)
+	s.AuthorizeRequest(req)
+	assert.Equal(t, "Bearer token", req.Header.Get("Authorization"))
+
+	foreign, err := http.NewRequest(http.MethodGet, "https://example.com/file", nil)
+	assert.NoError(t, err)
+	s.AuthorizeRequest(foreign)
+	assert.Empty(t, foreign.Header.Get("Authorization"))
+
+	_, err = s.GetDownloadUrl(ctx, strings.Replace(proxied, "1.0.0", "2.0.0", 1))
+	assert.Error(t, err, "tampered path")
+
+	expired := s.objectURL(modulePath("", "example", "s3", "aws", "1.0.0", DefaultModuleArchiveFormat))
+	expires := time.Now().Add(-time.Minute).Unix()
+	expired.RawQuery = url.Values{
+		signedURLExpiresParam:   []string{fmt.Sprintf("%d", expires)},
+		signedURLSignatureParam: []string{fmt.Sprintf("%x", s.signer.signature(expired.EscapedPath(), expires))},
+	}.Encode()
+	_, err = s.GetDownloadUrl(ctx, strings.TrimPrefix(expired.RequestURI(), "/"))
+	assert.Error(t, err, "expired url")
+
+	other := newTestWebDAVStorage(t, server, WithWebDAVStorageSigningKey("other"))
+	_, err = other.GetDownloadUrl(ctx, proxied)
+	assert.Error(t, err, "different signing key")
+}
+
+func TestWebDAVStorage_download(t *testing.T) {
+	t.Parallel()
+
+	server := newFakeWebDAVServer()
+	s := newTestWebDAVStorage(t, server)
+
+	_, err := s.download(context.Background(), "missing")
+	assert.Error(t, err)
+
+	server.objects["/artifactory/generic-local/existing"] = []byte("content")
+	b, err := s.download(context.Background(), "existing")
+	assert.NoError(t, err)
+	assert.True(t, bytes.Equal([]byte("content"), b))
+}
+
+func TestWebDAVStorage_VerifyUpload(t *testing.T) {
+	t.Parallel()
+
+	flip := func(b []byte) []byte {
+		return bytes.ToUpper(b)
+	}
+	truncate := func(b []byte) []byte {
+		return b[:len(b)-1]
+	}
+
+	testCases := []struct {
+		name           string
+		checksumHeader string
+		corrupt        func([]byte) []byte
+		expectedError  error
+	}{
+		{name: "SHA-256 checksum", checksumHeader: "X-Checksum-Sha256"},
+		{name: "corrupted SHA-256 checksum", checksumHeader: "X-Checksum-Sha256", corrupt: flip, expectedError: core.ErrChecksumMismatch},
+		{name: "OC-Checksum", checksumHeader: "OC-Checksum"},
+		{name: "corrupted OC-Checksum", checksumHeader: "OC-Checksum", corrupt: flip, expectedError: core.ErrChecksumMismatch},
+		{name: "MD5 ETag", checksumHeader: "ETag"},
+		{name: "corrupted MD5 ETag", checksumHeader: "ETag", corrupt: flip, expectedError: core.ErrChecksumMismatch},
+		{name: "size"},
+		{name: "corrupted size", corrupt: truncate, expectedError: core.ErrChecksumMismatch},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			server := newFakeWebDAVServer()
+			server.checksumHeader = tc.checksumHeader
+			server.corrupt = tc.corrupt
+			s := newTestWebDAVStorage(t, server)
+
+			err := s.upload(context.Background(), "modules/example/archive.tar.gz", strings.NewReader("archive"), false)
+			if tc.expectedError == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, tc.expectedError)
+
+			// Corrupted objects are deleted again
+			server.mu.Lock()
+			defer server.mu.Unlock()
+			assert.Empty(t, server.objects)
+		})
+	}
+}
+
+func TestWebDAVStorage_ConcurrentNamespaceChanges(t *testing.T) {
+	t.Parallel()
+
+	server := newFakeWebDAVServer()
+	server.checksumHeader = "ETag"
+	s := newTestWebDAVStorage(t, server)
+	ctx := context.Background()
+	assert.NoError(t, s.AddNamespace(ctx, namespace.Namespace{Name: "network"}))
+
+	// Another client registers a namespace after the namespaces were read for the next change
+	server.afterGet = func(objects map[string][]byte) {
+		server.afterGet = nil
+		for key := range objects {
+			if path.Base(key) == "namespaces.json" {
+				objects[key] = []byte(`{"namespaces":[{"name":"network"},{"name":"legacy"}]}`)
+			}
+		}
+	}
+	assert.NoError(t, s.AddNamespace(ctx, namespace.Namespace{Name: "storage"}))
+
+	namespaces, err := s.ListNamespaces(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []namespace.Namespace{{Name: "network"}, {Name: "legacy"}, {Name: "storage"}}, namespaces)
+}
@@ -0,0 +1,118 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/boring-registry/boring-registry/pkg/catalog"
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/provider"
+
+	"github.com/go-kit/kit/endpoint"
+)
+
+type namespacesRequest struct {
+	page core.Page
+	url  *url.URL
+}
+
+type namespacesResponse struct {
+	Meta       core.PageMeta
+	Namespaces []catalog.Namespace
+}
+
+func namespacesEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(namespacesRequest)
+
+		res, meta, err := svc.ListNamespaces(ctx, req.page)
+		if err != nil {
+			return nil, err
+		}
+
+		return namespacesResponse{
+			Meta:       meta.WithURLs(req.url),
+			Namespaces: res,
+		}, nil
+	}
+}
+
+type namespaceRequest struct {
+	namespace string
+}
+
+func namespaceEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(namespaceRequest)
+
+		return svc.GetNamespace(ctx, req.namespace)
+	}
+}
+
+// moduleRequest requests the latest version of the module if the version is empty
+type moduleRequest struct {
+	host      string
+	namespace string
+	name      string
+	provider  string
+	version   string
+}
+
+type moduleResponse struct {
+	Module
+
+	// Snippet is the module block that uses the module version
+	Snippet string
+}
+
+func moduleEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(moduleRequest)
+
+		res, err := svc.GetModule(ctx, req.namespace, req.name, req.provider, req.version)
+		if err != nil {
+			return nil, err
+		}
+
+		return moduleResponse{
+			Module: res,
+			Snippet: fmt.Sprintf("module %q {\n  source  = \"%s/%s/%s/%s\"\n  version = %q\n}",
+				res.Name, req.host, res.Namespace, res.Name, res.Provider, res.Version,
+			),
+		}, nil
+	}
+}
+
+// providerRequest requests the latest version of the provider if the version is empty
+type providerRequest struct {
+	host      string
+	namespace string
+	name      string
+	version   string
+}
+
+type providerResponse struct {
+	provider.Details
+
+	// Snippet is the required_providers block that uses the provider version
+	Snippet string
+}
+
+func providerEndpoint(svc Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(providerRequest)
+
+		res, err := svc.GetProvider(ctx, req.namespace, req.name, req.version)
+		if err != nil {
+			return nil, err
+		}
+
+		return providerResponse{
+			Details: res,
+			Snippet: fmt.Sprintf("terraform {\n  required_providers {\n    %s = {\n      source  = \"%s/%s/%s\"\n      version = %q\n    }\n  }\n}",
+				res.Name, req.host, res.Namespace, res.Name, res.Version,
+			),
+		}, nil
+	}
+}
@@ -0,0 +1,7 @@
+package ui
+
+import "errors"
+
+var (
+	ErrNamespaceNotFound = errors.New("failed to locate namespace")
+)
@@ -0,0 +1,113 @@
+// Package ui serves an embedded web interface for browsing the namespaces, modules and providers of the registry.
+// It only reads through the module, provider and catalog services, so it shows the same data as the API.
+package ui
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/boring-registry/boring-registry/pkg/catalog"
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/module"
+	"github.com/boring-registry/boring-registry/pkg/provider"
+)
+
+// Service collects the data shown by the pages of the web interface
+type Service interface {
+	ListNamespaces(ctx context.Context, page core.Page) ([]catalog.Namespace, core.PageMeta, error)
+
+	// GetNamespace returns the latest version of every module and provider of the namespace
+	GetNamespace(ctx context.Context, namespace string) (Namespace, error)
+
+	// GetModule returns a module version together with its docs, or the latest version if the version is empty
+	GetModule(ctx context.Context, namespace, name, provider, version string) (Module, error)
+
+	// GetProvider returns a provider version, or the latest version if the version is empty
+	GetProvider(ctx context.Context, namespace, name, version string) (provider.Details, error)
+}
+
+// Namespace lists the modules and providers of a namespace
+type Namespace struct {
+	Name      string
+	Modules   []module.Details
+	Providers []core.ProviderVersion
+}
+
+// Module is a module version with its docs, which are nil if they couldn't be extracted
+type Module struct {
+	module.Details
+	Docs *module.Docs
+}
+
+type service struct {
+	modules    module.Service
+	providers  provider.Service
+	namespaces catalog.Service
+}
+
+// NewService returns a fully initialized Service.
+func NewService(modules module.Service, providers provider.Service, namespaces catalog.Service) Service {
+	return &service{
+		modules:    modules,
+		providers:  providers,
+		namespaces: namespaces,
+	}
+}
+
+func (s *service) ListNamespaces(ctx context.Context, page core.Page) ([]catalog.Namespace, core.PageMeta, error) {
+	return s.namespaces.ListNamespaces(ctx, page)
+}
+
+func (s *service) GetNamespace(ctx context.Context, namespace string) (Namespace, error) {
+	ns := Namespace{Name: namespace}
+
+	for page := (core.Page{Limit: core.MaxPageLimit}); ; {
+		modules, meta, err := s.modules.ListModules(ctx, module.ListFilter{Namespace: namespace}, page)
+		if err != nil {
+			return Namespace{}, err
+		}
+		ns.Modules = append(ns.Modules, modules...)
+
+		if meta.NextOffset == nil {
+			break
+		}
+		page.Offset = *meta.NextOffset
+	}
+
+	for page := (core.Page{Limit: core.MaxPageLimit}); ; {
+		providers, meta, err := s.providers.ListProviders(ctx, provider.ListFilter{Namespace: namespace}, page)
+		if err != nil {
+			return Namespace{}, err
+		}
+		ns.Providers = append(ns.Providers, providers...)
+
+		if meta.NextOffset == nil {
+			break
+		}
+		page.Offset = *meta.NextOffset
+	}
+
+	if len(ns.Modules) == 0 && len(ns.Providers) == 0 {
+		return Namespace{}, fmt.Errorf("%w: %s", ErrNamespaceNotFound, namespace)
+	}
+	return ns, nil
+}
+
+func (s *service) GetModule(ctx context.Context, namespace, name, provider, version string) (Module, error) {
+	details, err := s.modules.GetModuleDetails(ctx, namespace, name, provider, version)
+	if err != nil {
+		return Module{}, err
+	}
+
+	// The module page is still useful without the docs, the error is already logged by the module service
+	docs, _ := s.modules.GetModuleDocs(ctx, namespace, name, provider, details.Version)
+
+	return Module{
+		Details: details,
+		Docs:    docs,
+	}, nil
+}
+
+func (s *service) GetProvider(ctx context.Context, namespace, name, version string) (provider.Details, error) {
+	return s.providers.GetProviderDetails(ctx, namespace, name, version)
+}
@@ -0,0 +1,13 @@
+// Copies the snippet next to the button to the clipboard
+document.addEventListener("click", (event) => {
+  const button = event.target.closest(".snippet .copy");
+  if (!button) {
+    return;
+  }
+
+  const code = button.parentElement.querySelector("code");
+  navigator.clipboard.writeText(code.textContent).then(() => {
+    button.textContent = "Copied";
+    setTimeout(() => { button.textContent = "Copy"; }, 2000);
+  });
+});
@@ -0,0 +1,58 @@
+package ui
+
+import (
+	"embed"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+)
+
+var (
+	//go:embed templates
+	templateFS embed.FS
+
+	//go:embed static
+	staticFS embed.FS
+
+	pages = parsePages(
+		"namespaces.html",
+		"namespace.html",
+		"module.html",
+		"provider.html",
+		"login.html",
+		"error.html",
+	)
+)
+
+// page is rendered by the layout, which embeds the content template of the page
+type page struct {
+	// Base is the path the web interface is served at, which prefixes all links
+	Base    string
+	Content interface{}
+}
+
+// parsePages parses every page together with the layout, as each page defines the same blocks
+func parsePages(names ...string) map[string]*template.Template {
+	pages := make(map[string]*template.Template, len(names))
+	for _, name := range names {
+		pages[name] = template.Must(template.ParseFS(templateFS, "templates/layout.html", "templates/"+name))
+	}
+	return pages
+}
+
+func render(w io.Writer, name string, p page) error {
+	t, ok := pages[name]
+	if !ok {
+		return fmt.Errorf("unknown page %s", name)
+	}
+	return t.ExecuteTemplate(w, "layout.html", p)
+}
+
+func static() fs.FS {
+	sub, err := fs.Sub(staticFS, "static")
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}
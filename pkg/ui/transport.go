@@ -0,0 +1,297 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/module"
+	o11y "github.com/boring-registry/boring-registry/pkg/observability"
+	"github.com/boring-registry/boring-registry/pkg/provider"
+
+	"github.com/go-kit/kit/auth/jwt"
+	"github.com/go-kit/kit/endpoint"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+)
+
+type muxVar string
+
+const (
+	varNamespace muxVar = "namespace"
+	varName      muxVar = "name"
+	varProvider  muxVar = "provider"
+	varVersion   muxVar = "version"
+
+	// basePathContextKey holds the path the web interface is served at
+	basePathContextKey muxVar = "basePath"
+)
+
+// TokenCookieName is the cookie that holds the API token of the browser session
+const TokenCookieName = "boring-registry-token"
+
+// MakeHandler returns a fully initialized http.Handler.
+// Browsers can't send the API token in the Authorization header, so it's read from a cookie that is set by the login page.
+func MakeHandler(svc Service, auth endpoint.Middleware, instrumentation o11y.Middleware, options ...httptransport.ServerOption) http.Handler {
+	r := mux.NewRouter().StrictSlash(true)
+
+	options = append(
+		options,
+		httptransport.ServerBefore(extractBasePath),
+		httptransport.ServerBefore(jwt.HTTPToContext()),
+		httptransport.ServerBefore(tokenCookieToContext),
+	)
+
+	r.Methods("GET").PathPrefix(`/static/`).Handler(
+		http.StripPrefix("/static/", http.FileServer(http.FS(static()))),
+	)
+
+	r.Methods("GET").Path(`/login`).Handler(instrumentation.WrapHandler(http.HandlerFunc(loginPage)))
+	r.Methods("POST").Path(`/login`).Handler(instrumentation.WrapHandler(http.HandlerFunc(login)))
+	r.Methods("POST").Path(`/logout`).Handler(instrumentation.WrapHandler(http.HandlerFunc(logout)))
+
+	r.Methods("GET").Path(`/`).Handler(
+		instrumentation.WrapHandler(
+			httptransport.NewServer(
+				auth(namespacesEndpoint(svc)),
+				decodeNamespacesRequest,
+				encodePage("namespaces.html"),
+				options...,
+			),
+		),
+	)
+
+	r.Methods("GET").Path(`/namespaces/{namespace}`).Handler(
+		instrumentation.WrapHandler(
+			httptransport.NewServer(
+				auth(namespaceEndpoint(svc)),
+				decodeNamespaceRequest,
+				encodePage("namespace.html"),
+				append(
+					options,
+					httptransport.ServerBefore(extractMuxVars(varNamespace)),
+				)...,
+			),
+		),
+	)
+
+	for _, path := range []string{`/modules/{namespace}/{name}/{provider}`, `/modules/{namespace}/{name}/{provider}/{version}`} {
+		r.Methods("GET").Path(path).Handler(
+			instrumentation.WrapHandler(
+				httptransport.NewServer(
+					auth(moduleEndpoint(svc)),
+					decodeModuleRequest,
+					encodePage("module.html"),
+					append(
+						options,
+						httptransport.ServerBefore(extractMuxVars(varNamespace, varName, varProvider, varVersion)),
+					)...,
+				),
+			),
+		)
+	}
+
+	for _, path := range []string{`/providers/{namespace}/{name}`, `/providers/{namespace}/{name}/{version}`} {
+		r.Methods("GET").Path(path).Handler(
+			instrumentation.WrapHandler(
+				httptransport.NewServer(
+					auth(providerEndpoint(svc)),
+					decodeProviderRequest,
+					encodePage("provider.html"),
+					append(
+						options,
+						httptransport.ServerBefore(extractMuxVars(varNamespace, varName, varVersion)),
+					)...,
+				),
+			),
+		)
+	}
+
+	return r
+}
+
+func decodeNamespacesRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	page, err := core.ParsePage(r.URL.Query())
+	if err != nil {
+		return nil, err
+	}
+
+	// The URL of the request is used for the links to the next and previous page, so the path must not be stripped
+	u, err := url.ParseRequestURI(r.RequestURI)
+	if err != nil {
+		u = r.URL
+	}
+
+	return namespacesRequest{
+		page: page,
+		url:  u,
+	}, nil
+}
+
+func decodeNamespaceRequest(ctx context.Context, _ *http.Request) (interface{}, error) {
+	namespace, ok := ctx.Value(varNamespace).(string)
+	if !ok {
+		return nil, fmt.Errorf("%w: namespace", core.ErrVarMissing)
+	}
+
+	return namespaceRequest{
+		namespace: namespace,
+	}, nil
+}
+
+func decodeModuleRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	req := moduleRequest{host: r.Host}
+	for v, target := range map[muxVar]*string{varNamespace: &req.namespace, varName: &req.name, varProvider: &req.provider} {
+		value, ok := ctx.Value(v).(string)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", core.ErrVarMissing, v)
+		}
+		*target = value
+	}
+
+	// The version is missing when the latest version is requested
+	req.version, _ = ctx.Value(varVersion).(string)
+	return req, nil
+}
+
+func decodeProviderRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	req := providerRequest{host: r.Host}
+	for v, target := range map[muxVar]*string{varNamespace: &req.namespace, varName: &req.name} {
+		value, ok := ctx.Value(v).(string)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", core.ErrVarMissing, v)
+		}
+		*target = value
+	}
+
+	// The version is missing when the latest version is requested
+	req.version, _ = ctx.Value(varVersion).(string)
+	return req, nil
+}
+
+func encodePage(name string) httptransport.EncodeResponseFunc {
+	return func(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		return render(w, name, page{Base: basePath(ctx), Content: response})
+	}
+}
+
+// errorResponse is shown on the error page, the login link is shown if the request wasn't authorized
+type errorResponse struct {
+	Status       int
+	Message      string
+	Unauthorized bool
+}
+
+// ErrorEncoder renders the error page with the HTTP status code of the error
+func ErrorEncoder(ctx context.Context, err error, w http.ResponseWriter) {
+	var providerError *core.ProviderError
+	status := core.GenericError(err)
+	if errors.Is(err, module.ErrModuleNotFound) || errors.Is(err, provider.ErrProviderNotFound) || errors.Is(err, ErrNamespaceNotFound) {
+		status = http.StatusNotFound
+	} else if errors.As(err, &providerError) {
+		status = providerError.StatusCode
+	}
+
+	message := http.StatusText(status)
+	if status < http.StatusInternalServerError {
+		message = err.Error()
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	if err := render(w, "error.html", page{Base: basePath(ctx), Content: errorResponse{
+		Status:       status,
+		Message:      message,
+		Unauthorized: status == http.StatusUnauthorized,
+	}}); err != nil {
+		slog.Error("failed to render error page", slog.String("err", err.Error()))
+	}
+}
+
+func loginPage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := render(w, "login.html", page{Base: basePath(extractBasePath(r.Context(), r))}); err != nil {
+		slog.Error("failed to render login page", slog.String("err", err.Error()))
+	}
+}
+
+// login stores the API token in a cookie that is only sent to the web interface.
+// The token is verified by the auth providers on every following request, just like tokens sent to the API.
+func login(w http.ResponseWriter, r *http.Request) {
+	base := basePath(extractBasePath(r.Context(), r))
+
+	token := strings.TrimSpace(r.PostFormValue("token"))
+	if token == "" {
+		http.Redirect(w, r, base+"/login", http.StatusSeeOther)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     TokenCookieName,
+		Value:    token,
+		Path:     base + "/",
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteStrictMode,
+	})
+	http.Redirect(w, r, base+"/", http.StatusSeeOther)
+}
+
+func logout(w http.ResponseWriter, r *http.Request) {
+	base := basePath(extractBasePath(r.Context(), r))
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     TokenCookieName,
+		Path:     base + "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteStrictMode,
+	})
+	http.Redirect(w, r, base+"/login", http.StatusSeeOther)
+}
+
+// extractBasePath derives the path the web interface is served at from the path prefix stripped off the request
+func extractBasePath(ctx context.Context, r *http.Request) context.Context {
+	base := ""
+	if u, err := url.ParseRequestURI(r.RequestURI); err == nil {
+		base = strings.TrimSuffix(u.EscapedPath(), r.URL.EscapedPath())
+	}
+	return context.WithValue(ctx, basePathContextKey, base)
+}
+
+func basePath(ctx context.Context) string {
+	base, _ := ctx.Value(basePathContextKey).(string)
+	return base
+}
+
+// tokenCookieToContext uses the token of the cookie, unless the request contains an Authorization header
+func tokenCookieToContext(ctx context.Context, r *http.Request) context.Context {
+	if _, ok := ctx.Value(jwt.JWTContextKey).(string); ok {
+		return ctx
+	}
+
+	cookie, err := r.Cookie(TokenCookieName)
+	if err != nil || cookie.Value == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, jwt.JWTContextKey, cookie.Value)
+}
+
+func extractMuxVars(keys ...muxVar) httptransport.RequestFunc {
+	return func(ctx context.Context, r *http.Request) context.Context {
+		for _, k := range keys {
+			if v, ok := mux.Vars(r)[string(k)]; ok {
+				ctx = context.WithValue(ctx, k, v)
+			}
+		}
+
+		return ctx
+	}
+}
@@ -0,0 +1,199 @@
+package ui
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/boring-registry/boring-registry/pkg/auth"
+	"github.com/boring-registry/boring-registry/pkg/catalog"
+	"github.com/boring-registry/boring-registry/pkg/core"
+	"github.com/boring-registry/boring-registry/pkg/module"
+	"github.com/boring-registry/boring-registry/pkg/namespace"
+	"github.com/boring-registry/boring-registry/pkg/provider"
+
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/stretchr/testify/assert"
+)
+
+type noopInstrumentation struct{}
+
+func (noopInstrumentation) WrapHandler(handler http.Handler) http.HandlerFunc {
+	return handler.ServeHTTP
+}
+
+// testStorage stores modules in memory and serves a fixed list of providers
+type testStorage struct {
+	*module.InmemStorage
+	provider.Storage
+	providers []core.ProviderVersion
+}
+
+func (s *testStorage) ListProviders(_ context.Context, namespace string) ([]core.ProviderVersion, error) {
+	var providers []core.ProviderVersion
+	for _, p := range s.providers {
+		if namespace == "" || p.Namespace == namespace {
+			providers = append(providers, p)
+		}
+	}
+	return providers, nil
+}
+
+func (s *testStorage) ListNamespaces(context.Context) ([]namespace.Namespace, error) {
+	return nil, nil
+}
+
+func (s *testStorage) ListProviderVersions(_ context.Context, namespace, name string) (*core.ProviderVersions, error) {
+	versions := &core.ProviderVersions{}
+	for _, p := range s.providers {
+		if p.Namespace == namespace && p.Name == name {
+			versions.Versions = append(versions.Versions, p)
+		}
+	}
+	if len(versions.Versions) == 0 {
+		return nil, provider.ErrProviderNotFound
+	}
+	return versions, nil
+}
+
+func testArchive(t *testing.T, files map[string]string) *bytes.Buffer {
+	t.Helper()
+
+	buf := new(bytes.Buffer)
+	gw := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gw)
+	for name, content := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content))}); err != nil {
+			t.Fatal(err)
+		}
+		_, _ = tw.Write([]byte(content))
+	}
+	_ = tw.Close()
+	_ = gw.Close()
+	return buf
+}
+
+func TestMakeHandler(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	modules := module.NewInmemStorage().(*module.InmemStorage)
+	_, err := modules.UploadModule(ctx, "example", "vpc", "aws", "1.0.0", testArchive(t, map[string]string{
+		"main.tf":   `variable "cidr" {}`,
+		"README.md": "# VPC <script>",
+	}))
+	assert.NoError(t, err)
+
+	s := &testStorage{
+		InmemStorage: modules,
+		providers: []core.ProviderVersion{
+			{Namespace: "example", Name: "dummy", Version: "0.1.0", Platforms: []core.Platform{{OS: "linux", Arch: "amd64"}}},
+		},
+	}
+	proxy := core.NewProxyUrlService(false, "/proxy")
+	handler := MakeHandler(
+		NewService(module.NewService(s, proxy), provider.NewService(s, proxy), catalog.NewService(s)),
+		auth.Middleware(auth.NewStaticProvider("secret")),
+		noopInstrumentation{},
+		httptransport.ServerErrorEncoder(ErrorEncoder),
+	)
+
+	testCases := []struct {
+		name           string
+		path           string
+		token          string
+		expectedStatus int
+		expectedBody   []string
+	}{
+		{
+			name:           "missing token",
+			path:           "/",
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   []string{`href="/ui/login"`},
+		},
+		{
+			name:           "invalid token",
+			path:           "/",
+			token:          "invalid",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "namespaces",
+			path:           "/",
+			token:          "secret",
+			expectedStatus: http.StatusOK,
+			expectedBody:   []string{`href="/ui/namespaces/example"`},
+		},
+		{
+			name:           "namespace",
+			path:           "/namespaces/example",
+			token:          "secret",
+			expectedStatus: http.StatusOK,
+			expectedBody:   []string{`href="/ui/modules/example/vpc/aws"`, `href="/ui/providers/example/dummy"`},
+		},
+		{
+			name:           "missing namespace",
+			path:           "/namespaces/other",
+			token:          "secret",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "module with readme",
+			path:           "/modules/example/vpc/aws",
+			token:          "secret",
+			expectedStatus: http.StatusOK,
+			expectedBody:   []string{`source  = &#34;registry.example.com/example/vpc/aws&#34;`, `# VPC &lt;script&gt;`, `<code>cidr</code>`},
+		},
+		{
+			name:           "missing module version",
+			path:           "/modules/example/vpc/aws/2.0.0",
+			token:          "secret",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "provider",
+			path:           "/providers/example/dummy/0.1.0",
+			token:          "secret",
+			expectedStatus: http.StatusOK,
+			expectedBody:   []string{`source  = &#34;registry.example.com/example/dummy&#34;`, `linux_amd64`},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/ui"+tc.path, nil)
+			req.Host = "registry.example.com"
+			if tc.token != "" {
+				req.AddCookie(&http.Cookie{Name: TokenCookieName, Value: tc.token})
+			}
+
+			rec := httptest.NewRecorder()
+			http.StripPrefix("/ui", handler).ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedStatus, rec.Code, rec.Body.String())
+			for _, expected := range tc.expectedBody {
+				assert.Contains(t, rec.Body.String(), expected)
+			}
+		})
+	}
+
+	t.Run("login", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/ui/login", bytes.NewBufferString("token=secret"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		rec := httptest.NewRecorder()
+		http.StripPrefix("/ui", handler).ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusSeeOther, rec.Code)
+		assert.Equal(t, "/ui/", rec.Header().Get("Location"))
+
+		cookies := rec.Result().Cookies()
+		if assert.Len(t, cookies, 1) {
+			assert.Equal(t, "secret", cookies[0].Value)
+			assert.Equal(t, "/ui/", cookies[0].Path)
+			assert.True(t, cookies[0].HttpOnly)
+		}
+	})
+}